@@ -0,0 +1,92 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+)
+
+// Prefix отличает API ключ от токена сессии в заголовке authorization.
+const Prefix = "usk_"
+
+// lastUsedGranularity — точность api_keys.last_used_at.
+const lastUsedGranularity = time.Minute
+
+var ErrInvalid = errors.New("api key is invalid, expired or revoked")
+
+// Generate выпускает ключ "usk_<id>_<секрет>". id открыт (по нему ключ
+// ищется и отзывается), секрет хранится только хэшем.
+func Generate() (id, secret, token string) {
+	id = strings.ToLower(rand.Text()[:12])
+	secret = rand.Text()
+	return id, secret, Prefix + id + "_" + secret
+}
+
+// Parse разбирает ключ на id и секрет.
+func Parse(token string) (id, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(token, Prefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	return id, secret, ok && id != "" && secret != ""
+}
+
+func IsKey(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// HashSecret — sha256: секрет случайный и длинный, медленный хэш не нужен.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+type Authenticator interface {
+	// Authenticate проверяет ключ и отмечает его использование.
+	Authenticate(ctx context.Context, token string) (*model.APIKey, error)
+}
+
+type authenticator struct {
+	keys repository.APIKeyRepository
+}
+
+func NewAuthenticator(keys repository.APIKeyRepository) Authenticator {
+	return &authenticator{keys: keys}
+}
+
+func (a *authenticator) Authenticate(ctx context.Context, token string) (*model.APIKey, error) {
+	id, secret, ok := Parse(token)
+	if !ok {
+		return nil, ErrInvalid
+	}
+	key, err := a.keys.Get(ctx, id)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(HashSecret(secret))) != 1 || !key.Active(time.Now()) {
+		return nil, ErrInvalid
+	}
+
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) >= lastUsedGranularity {
+		// отметка не должна задерживать запрос
+		go func() {
+			if err := a.keys.Touch(context.WithoutCancel(ctx), key.ID, lastUsedGranularity); err != nil {
+				logger.Errorf("apikey - Authenticate: Failed to record use of key %s: %v", key.ID, err)
+			}
+		}()
+	}
+	return key, nil
+}
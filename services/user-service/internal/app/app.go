@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apikey"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/idempotency"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/middleware"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/service"
+	"github.com/DmitriiPro/user-service/internal/session"
+	"github.com/DmitriiPro/user-service/internal/signing"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"golang.org/x/sync/errgroup"
+)
+
+// tenantCacheTTL — сколько помнится, существует ли тенант: новый тенант
+// становится доступен не позже чем через это время.
+const tenantCacheTTL = time.Minute
+
+// closer — остановка компонента, запущенного в Run.
+type closer struct {
+	name  string
+	close func(ctx context.Context) error
+}
+
+type App struct {
+	cfg     *config.Config
+	watcher *config.Watcher
+	closers []closer
+
+	rateLimiter *middleware.RateLimiter
+	idempotency idempotency.Store
+	sessions    session.Store
+	tenants     *tenant.Directory
+	apiKeys     apikey.Authenticator
+	avatars     service.AvatarService
+	oidc        service.OIDCService
+	// signingKeys — ключи подписи токенов; nil без signing_keys.encryption_key.
+	signingKeys *signing.KeyManager
+	// gatewayToken подтверждает gRPC серверу, что вызов пришёл из
+	// встроенного HTTP gateway; генерируется заново при каждом запуске.
+	gatewayToken string
+}
+
+// New создаёт приложение с конфигурацией, актуальной на момент старта;
+// изменения из watcher применяются к компонентам на лету.
+func New(watcher *config.Watcher) *App {
+	return &App{cfg: watcher.Current(), watcher: watcher}
+}
+
+// Run запускает компоненты в порядке зависимостей и блокируется до отмены ctx
+// или до первой фатальной ошибки любого из серверов. Перед возвратом все
+// запущенные компоненты останавливаются в обратном порядке.
+func (a *App) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	g, gctx := errgroup.WithContext(ctx)
+
+	if err := a.start(gctx, g); err != nil {
+		cancel()
+		a.shutdown()
+		_ = g.Wait()
+		return err
+	}
+
+	g.Go(func() error {
+		<-gctx.Done()
+		logger.Infof("Shutting down servers...")
+		return a.shutdown()
+	})
+
+	return g.Wait()
+}
+
+func (a *App) start(ctx context.Context, g *errgroup.Group) error {
+	dbConn, err := a.startPostgres(ctx, g)
+	if err != nil {
+		return err
+	}
+	err = service.ApplyEmailNormalization(ctx, repository.NewEmailNormalizationRepository(dbConn),
+		service.EmailNormalizer{ProviderRules: a.cfg.Email.ProviderRules})
+	if err != nil {
+		return fmt.Errorf("failed to apply email normalization: %w", err)
+	}
+
+	redisClient, err := a.startRedis(ctx)
+	if err != nil {
+		return err
+	}
+
+	a.rateLimiter = a.newRateLimiter(ctx, g, redisClient)
+	a.gatewayToken = rand.Text()
+	a.idempotency = idempotency.NewRedisStore(redisClient)
+	a.sessions = session.NewRedisStore(redisClient)
+	a.tenants = tenant.NewDirectory(repository.NewTenantRepository(dbConn), tenantCacheTTL)
+
+	handler, err := a.newHandler(dbConn, redisClient)
+	if err != nil {
+		return err
+	}
+
+	if err := a.startSigningKeys(ctx, g); err != nil {
+		return err
+	}
+
+	if err := a.startGRPC(ctx, g, handler); err != nil {
+		return err
+	}
+
+	conn, err := a.dialGateway(ctx, g)
+	if err != nil {
+		return err
+	}
+
+	if err := a.startSwagger(ctx, g); err != nil {
+		return err
+	}
+
+	return a.startHTTP(ctx, g, conn)
+}
+
+// onShutdown регистрирует остановку компонента; вызывается сразу после его запуска.
+func (a *App) onShutdown(name string, fn func(ctx context.Context) error) {
+	a.closers = append(a.closers, closer{name: name, close: fn})
+}
+
+func (a *App) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		c := a.closers[i]
+		if err := c.close(ctx); err != nil {
+			logger.Errorf("%s shutdown error: %v", c.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			continue
+		}
+		logger.Infof("%s stopped", c.name)
+	}
+	a.closers = nil
+
+	return errors.Join(errs...)
+}
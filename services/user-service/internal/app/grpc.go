@@ -0,0 +1,271 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apikey"
+	"github.com/DmitriiPro/user-service/internal/blob"
+	"github.com/DmitriiPro/user-service/internal/cache"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/handler"
+	"github.com/DmitriiPro/user-service/internal/lockout"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/magiclink"
+	"github.com/DmitriiPro/user-service/internal/mail"
+	"github.com/DmitriiPro/user-service/internal/mfa"
+	"github.com/DmitriiPro/user-service/internal/middleware"
+	"github.com/DmitriiPro/user-service/internal/notify"
+	"github.com/DmitriiPro/user-service/internal/oidc"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/service"
+	"github.com/DmitriiPro/user-service/internal/signing"
+	"github.com/DmitriiPro/user-service/internal/social"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+)
+
+func (a *App) newHandler(dbConn *sql.DB, redisClient *redis.Client) (*handler.UserHandler, error) {
+	userCache := cache.NewRedis(redisClient, a.cfg.Cache.UserTTL)
+	a.watcher.Subscribe(func(r config.Reloadable) {
+		userCache.SetTTL(r.CacheTTL)
+	})
+
+	repo := repository.NewUserRepository(dbConn)
+	svc := service.NewUserService(repo, userCache, service.EmailNormalizer{ProviderRules: a.cfg.Email.ProviderRules},
+		a.cfg.Admin.UserIDs)
+
+	blobStore, err := blob.New(a.cfg.Blob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blob store: %w", err)
+	}
+	a.avatars = service.NewAvatarService(svc, blobStore, a.cfg.Avatar.MaxPixels, a.cfg.Admin.UserIDs)
+
+	search := service.NewSearchService(repo, a.cfg.Search, a.cfg.Admin.UserIDs)
+
+	notifier, err := notify.New(a.cfg.Notify)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notifier: %w", err)
+	}
+	var totpCipher *mfa.Cipher
+	if a.cfg.TwoFactor.EncryptionKey != "" {
+		if totpCipher, err = mfa.NewCipher(a.cfg.TwoFactor.EncryptionKey); err != nil {
+			return nil, fmt.Errorf("failed to create TOTP cipher: %w", err)
+		}
+	}
+	guard := lockout.NewRedisGuard(redisClient, a.cfg.Lockout)
+	totpRepo := repository.NewTOTPRepository(dbConn)
+	twoFactor := service.NewTwoFactorService(repo, totpRepo, totpCipher, guard, a.cfg.TwoFactor)
+
+	introspectionCache := cache.NewRedis(redisClient, a.cfg.Cache.IntrospectionTTL)
+	loginEvents := repository.NewLoginEventRepository(dbConn)
+	authSvc := service.NewAuthService(repo, loginEvents, a.sessions, guard, notifier,
+		service.EmailNormalizer{ProviderRules: a.cfg.Email.ProviderRules}, a.cfg.Sessions,
+		twoFactor, mfa.NewRedisChallengeStore(redisClient), a.cfg.TwoFactor, a.cfg.Admin.UserIDs, introspectionCache)
+
+	mailer, err := mail.New(a.cfg.Mail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mail sender: %w", err)
+	}
+	magicLinks := service.NewMagicLinkService(repo, loginEvents, magiclink.NewRedisStore(redisClient), mailer, authSvc,
+		service.EmailNormalizer{ProviderRules: a.cfg.Email.ProviderRules}, a.cfg.MagicLink)
+
+	if a.cfg.SigningKeys.EncryptionKey != "" {
+		keyCipher, err := mfa.NewCipher(a.cfg.SigningKeys.EncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create signing key cipher: %w", err)
+		}
+		a.signingKeys = signing.NewKeyManager(repository.NewSigningKeyRepository(dbConn), keyCipher, a.cfg.SigningKeys)
+	}
+	a.oidc = service.NewOIDCService(repo, repository.NewOIDCClientRepository(dbConn), a.sessions,
+		oidc.NewRedisCodeStore(redisClient), a.signingKeys, a.cfg.OIDC, a.cfg.Admin.UserIDs)
+
+	identities := repository.NewFederatedIdentityRepository(dbConn)
+	socialLogin := service.NewSocialLoginService(social.New(a.cfg.Social), social.NewRedisStateStore(redisClient),
+		identities, repo, authSvc,
+		service.EmailNormalizer{ProviderRules: a.cfg.Email.ProviderRules}, a.cfg.Social)
+
+	apiKeyRepo := repository.NewAPIKeyRepository(dbConn)
+	a.apiKeys = apikey.NewAuthenticator(apiKeyRepo)
+	apiKeys := service.NewAPIKeyService(apiKeyRepo, userServiceMethods(), a.cfg.Admin.UserIDs, introspectionCache)
+	introspection := service.NewIntrospectionService(a.sessions, a.apiKeys, a.oidc, introspectionCache, a.cfg.Admin.UserIDs)
+
+	orgRepo := repository.NewOrganizationRepository(dbConn)
+	organizations := service.NewOrganizationService(orgRepo, repo, a.cfg.Admin.UserIDs)
+	invitations := service.NewInvitationService(repository.NewInvitationRepository(dbConn), orgRepo, repo, svc, mailer,
+		service.EmailNormalizer{ProviderRules: a.cfg.Email.ProviderRules}, a.cfg.Organizations, a.cfg.Admin.UserIDs)
+	var erasureHashKey []byte
+	if a.cfg.Privacy.ErasureHashKey != "" {
+		if erasureHashKey, err = base64.StdEncoding.DecodeString(a.cfg.Privacy.ErasureHashKey); err != nil {
+			return nil, fmt.Errorf("failed to decode privacy.erasure_hash_key: %w", err)
+		}
+	}
+	privacy := service.NewPrivacyService(repo, loginEvents, identities, totpRepo, orgRepo,
+		repository.NewErasureRepository(dbConn), a.sessions, guard, blobStore, userCache, introspectionCache,
+		erasureHashKey, a.cfg.Admin.UserIDs)
+
+	return handler.NewUserHandler(svc, a.avatars, search, authSvc, twoFactor, magicLinks, a.oidc, socialLogin,
+		apiKeys, introspection, organizations, invitations, privacy, a.cfg.Avatar.MaxBytes), nil
+}
+
+// userServiceMethods — имена методов UserService для scopes API ключей.
+func userServiceMethods() []string {
+	desc := userv1.UserService_ServiceDesc
+	var methods []string
+	for _, m := range desc.Methods {
+		methods = append(methods, m.MethodName)
+	}
+	for _, s := range desc.Streams {
+		methods = append(methods, s.StreamName)
+	}
+	return methods
+}
+
+func (a *App) startGRPC(ctx context.Context, g *errgroup.Group, h *handler.UserHandler) error {
+	tlsConfig, err := a.grpcServerTLS(ctx, g)
+	if err != nil {
+		return fmt.Errorf("failed to configure gRPC TLS: %w", err)
+	}
+
+	// Слушаем порт до запуска Serve: соединения принимаются сразу
+	grpcLis, err := net.Listen("tcp", a.cfg.GRPC.Addr())
+	if err != nil {
+		return fmt.Errorf("failed to listen starting gRPC server: %w", err)
+	}
+
+	// *серверные keepalive параметры
+	kaep := keepalive.EnforcementPolicy{
+		MinTime:             a.cfg.GRPC.KeepaliveMinTime,
+		PermitWithoutStream: true,
+	}
+
+	kasp := keepalive.ServerParameters{
+		Time:    a.cfg.GRPC.KeepaliveTime,
+		Timeout: a.cfg.GRPC.KeepaliveTimeout,
+	}
+
+	// общие для unary и потоковых методов
+	common := []grpc.UnaryServerInterceptor{
+		middleware.RecoveryInterceptor(),
+		middleware.ClientInfoInterceptor(a.gatewayToken),
+		middleware.LocaleInterceptor(),
+		middleware.PeerIdentityInterceptor(a.cfg.GRPC.TLS.AllowedIdentities),
+		middleware.AuthInterceptor(a.sessions, a.apiKeys),
+		a.rateLimiter.UnaryInterceptor(),
+		middleware.TenantInterceptor(a.tenants),
+	}
+	var stream []grpc.StreamServerInterceptor
+	for _, i := range common {
+		stream = append(stream, middleware.StreamInterceptor(i))
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(append(common,
+			middleware.IdempotencyInterceptor(a.idempotency, a.cfg.Idempotency),
+		)...),
+		grpc.ChainStreamInterceptor(stream...),
+		grpc.KeepaliveEnforcementPolicy(kaep),
+		grpc.KeepaliveParams(kasp),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	s := grpc.NewServer(opts...)
+	userv1.RegisterUserServiceServer(s, h)
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+	healthServer.SetServingStatus(userv1.UserService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	g.Go(func() error {
+		logger.Infof("gRPC server started on %s (TLS: %v)", a.cfg.GRPC.Addr(), tlsConfig != nil)
+		if err := s.Serve(grpcLis); err != nil {
+			return fmt.Errorf("failed to serve gRPC server: %w", err)
+		}
+		return nil
+	})
+
+	a.onShutdown("gRPC server", func(ctx context.Context) error {
+		healthServer.Shutdown()
+
+		stopped := make(chan struct{})
+		go func() {
+			s.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			s.Stop()
+			return ctx.Err()
+		}
+	})
+
+	return nil
+}
+
+// dialGateway создаёт клиентское соединение для HTTP gateway и ждёт, пока
+// gRPC сервер не ответит SERVING на health check.
+func (a *App) dialGateway(ctx context.Context, g *errgroup.Group) (*grpc.ClientConn, error) {
+	creds, err := a.gatewayCredentials(ctx, g)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure gateway TLS: %w", err)
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUnaryInterceptor(middleware.GatewayTokenInterceptor(a.gatewayToken)),
+		grpc.WithStreamInterceptor(middleware.GatewayTokenStreamInterceptor(a.gatewayToken)),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                60 * time.Second,
+			Timeout:             20 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	}
+
+	conn, err := grpc.NewClient(a.cfg.GRPC.Addr(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+	a.onShutdown("gRPC gateway connection", func(context.Context) error {
+		return conn.Close()
+	})
+
+	if err := waitServing(ctx, conn, a.cfg.GRPC.ReadinessTimeout); err != nil {
+		return nil, err
+	}
+	logger.Infof("✅ gRPC connection established, state: %v", conn.GetState())
+
+	return conn, nil
+}
+
+func waitServing(ctx context.Context, conn *grpc.ClientConn, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx,
+		&healthpb.HealthCheckRequest{Service: userv1.UserService_ServiceDesc.ServiceName},
+		grpc.WaitForReady(true),
+	)
+	if err != nil {
+		return fmt.Errorf("gRPC server is not ready: %w", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("gRPC server is not ready: status %v", resp.GetStatus())
+	}
+
+	return nil
+}
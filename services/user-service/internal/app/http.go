@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/handler"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/middleware"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/justinas/alice"
+	httpSwagger "github.com/swaggo/http-swagger"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+)
+
+func (a *App) startHTTP(ctx context.Context, g *errgroup.Group, conn *grpc.ClientConn) error {
+	mux := runtime.NewServeMux(
+		runtime.WithMetadata(middleware.GatewayMetadata),
+		runtime.WithIncomingHeaderMatcher(middleware.GatewayHeaderMatcher(runtime.DefaultHeaderMatcher)),
+		runtime.WithOutgoingHeaderMatcher(middleware.GatewayOutgoingHeaderMatcher),
+		runtime.WithErrorHandler(middleware.GatewayErrorHandler),
+	)
+
+	//! ===== Swagger JSON endpoint =====
+	mux.HandlePath("GET", "/swagger.json", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		http.ServeFile(w, r, a.cfg.Swagger.SpecFile)
+	})
+
+	//! ===== JWKS: открытые ключи подписи токенов =====
+	if a.signingKeys != nil {
+		if err := mux.HandlePath("GET", "/.well-known/jwks.json", handler.JWKSHTTP(a.signingKeys)); err != nil {
+			return fmt.Errorf("failed to register JWKS endpoint: %w", err)
+		}
+	}
+
+	client := userv1.NewUserServiceClient(conn)
+	err := userv1.RegisterUserServiceHandlerClient(ctx, mux, client)
+	if err != nil {
+		return fmt.Errorf("failed to register gRPC gateway: %w", err)
+	}
+
+	//! ===== Аватары: multipart загрузка и отдача файлов =====
+	if err := mux.HandlePath("POST", "/v1/users/{id}/avatar", handler.AvatarUploadHTTP(mux, client, a.cfg.Avatar.MaxBytes)); err != nil {
+		return fmt.Errorf("failed to register avatar upload: %w", err)
+	}
+	for _, method := range []string{"GET", "HEAD"} {
+		if err := mux.HandlePath(method, "/v1/users/{id}/avatar", handler.AvatarServeHTTP(mux, a.avatars)); err != nil {
+			return fmt.Errorf("failed to register avatar download: %w", err)
+		}
+	}
+	if a.cfg.OIDC.Enabled {
+		if err := a.registerOIDC(mux); err != nil {
+			return fmt.Errorf("failed to register OIDC endpoints: %w", err)
+		}
+	}
+	logger.Infof("✅ HTTP Gateway successfully connected to gRPC")
+
+	cors := middleware.NewCORS(a.cfg.CORS.AllowedOrigins)
+	a.watcher.Subscribe(func(r config.Reloadable) {
+		cors.SetAllowedOrigins(r.CORSOrigins)
+	})
+
+	var trustedProxies []netip.Prefix
+	for _, cidr := range a.cfg.HTTP.TrustedProxies {
+		// формат проверен при валидации конфигурации
+		trustedProxies = append(trustedProxies, netip.MustParsePrefix(cidr))
+	}
+
+	// Создаем цепочку middleware
+	chain := alice.New(
+		cors.Middleware,                   // CORS
+		middleware.RequestIDMiddleware,    // X-Request-Id
+		middleware.HTTPRecoveryMiddleware, // Восстановление после паники
+		middleware.LoggingMiddleware,      // Логирование
+		middleware.ClientIPMiddleware(trustedProxies),
+		a.rateLimiter.Middleware, // Ограничение частоты запросов
+		middleware.TenantMiddleware(a.tenants),
+	).Then(mux)
+
+	httpServer := &http.Server{
+		Handler:      chain,
+		ReadTimeout:  a.cfg.HTTP.ReadTimeout,
+		WriteTimeout: a.cfg.HTTP.WriteTimeout,
+		IdleTimeout:  a.cfg.HTTP.IdleTimeout,
+	}
+
+	httpServer.TLSConfig, err = a.httpServerTLS(ctx, g)
+	if err != nil {
+		return fmt.Errorf("failed to configure HTTP TLS: %w", err)
+	}
+
+	return a.serveHTTP(g, "HTTP Gateway", a.cfg.HTTP.Addr, httpServer)
+}
+
+func (a *App) startSwagger(ctx context.Context, g *errgroup.Group) error {
+	if !a.cfg.Swagger.Enabled {
+		return nil
+	}
+
+	swaggerServer := &http.Server{
+		Handler: httpSwagger.Handler(
+			httpSwagger.URL(a.cfg.Swagger.SpecURL),
+		),
+		ReadTimeout:  a.cfg.HTTP.ReadTimeout,
+		WriteTimeout: a.cfg.HTTP.WriteTimeout,
+		IdleTimeout:  a.cfg.HTTP.IdleTimeout,
+	}
+
+	// Swagger UI отдаётся по тому же протоколу, что и gateway
+	var err error
+	swaggerServer.TLSConfig, err = a.httpServerTLS(ctx, g)
+	if err != nil {
+		return fmt.Errorf("failed to configure Swagger TLS: %w", err)
+	}
+
+	return a.serveHTTP(g, "Swagger UI", a.cfg.Swagger.Addr, swaggerServer)
+}
+
+// serveHTTP занимает порт синхронно, чтобы ошибка bind вернулась из Run,
+// а не терялась в горутине.
+func (a *App) serveHTTP(g *errgroup.Group, name, addr string, srv *http.Server) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen %s on %s: %w", name, addr, err)
+	}
+
+	g.Go(func() error {
+		logger.Infof("%s started on %s (TLS: %v)", name, addr, srv.TLSConfig != nil)
+
+		serve := srv.Serve
+		if srv.TLSConfig != nil {
+			// сертификат берётся из TLSConfig.GetCertificate
+			serve = func(l net.Listener) error { return srv.ServeTLS(l, "", "") }
+		}
+		if err := serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to serve %s: %w", name, err)
+		}
+		return nil
+	})
+
+	a.onShutdown(name, srv.Shutdown)
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/DmitriiPro/user-service/internal/handler"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func (a *App) registerOIDC(mux *runtime.ServeMux) error {
+	routes := []struct {
+		method, path string
+		h            runtime.HandlerFunc
+	}{
+		{"GET", "/.well-known/openid-configuration", handler.OIDCDiscoveryHTTP(a.oidc)},
+		{"GET", "/oauth2/authorize", handler.OIDCAuthorizeHTTP(a.oidc, a.cfg.OIDC)},
+		{"POST", "/oauth2/authorize", handler.OIDCAuthorizeHTTP(a.oidc, a.cfg.OIDC)},
+		{"POST", "/oauth2/token", handler.OIDCTokenHTTP(a.oidc)},
+		{"GET", "/oauth2/userinfo", handler.OIDCUserInfoHTTP(a.oidc)},
+		{"POST", "/oauth2/userinfo", handler.OIDCUserInfoHTTP(a.oidc)},
+	}
+	for _, r := range routes {
+		if err := mux.HandlePath(r.method, r.path, r.h); err != nil {
+			return fmt.Errorf("%s %s: %w", r.method, r.path, err)
+		}
+	}
+	return nil
+}
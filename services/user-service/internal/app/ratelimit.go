@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/middleware"
+	"github.com/DmitriiPro/user-service/internal/ratelimit"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
+)
+
+func (a *App) newRateLimiter(ctx context.Context, g *errgroup.Group, redisClient *redis.Client) *middleware.RateLimiter {
+	memory := ratelimit.NewMemory()
+	g.Go(func() error {
+		memory.Cleanup(ctx, time.Minute)
+		return nil
+	})
+
+	var limiter ratelimit.Limiter = memory
+	if a.cfg.RateLimit.Backend == "redis" {
+		limiter = ratelimit.NewFallback(ratelimit.NewRedis(redisClient), memory)
+	}
+
+	rl := middleware.NewRateLimiter(limiter, a.cfg.RateLimit)
+	a.watcher.Subscribe(func(r config.Reloadable) {
+		rl.SetConfig(r.RateLimit)
+	})
+	return rl
+}
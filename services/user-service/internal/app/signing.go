@@ -0,0 +1,29 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// signingKeysRefreshInterval — как часто реплика проверяет ротацию ключей
+// и перечитывает ключи, созданные другими репликами.
+const signingKeysRefreshInterval = time.Minute
+
+// startSigningKeys загружает (или создаёт) ключи подписи токенов до
+// запуска серверов и дальше обновляет их в фоне.
+func (a *App) startSigningKeys(ctx context.Context, g *errgroup.Group) error {
+	if a.signingKeys == nil {
+		return nil
+	}
+	if err := a.signingKeys.Refresh(ctx); err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	g.Go(func() error {
+		a.signingKeys.Run(ctx, signingKeysRefreshInterval)
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/DmitriiPro/user-service/internal/cache"
+	"github.com/DmitriiPro/user-service/internal/db"
+	"github.com/DmitriiPro/user-service/internal/secrets"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
+)
+
+func (a *App) startPostgres(ctx context.Context, g *errgroup.Group) (*sql.DB, error) {
+	provider, err := secrets.New(a.cfg.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := a.cfg.Postgres.DSN
+	if a.cfg.Postgres.DSNSecret != "" {
+		dsn, err = provider.GetSecret(ctx, a.cfg.Postgres.DSNSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load postgres DSN secret: %w", err)
+		}
+	}
+
+	// Применяем миграции
+	if err := db.RunMigrations(a.cfg.Postgres.MigrationsDir, dsn); err != nil {
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	connector := db.NewConnector(dsn)
+	dbConn, err := db.NewPostgres(ctx, a.cfg.Postgres, connector)
+	if err != nil {
+		return nil, err
+	}
+	a.onShutdown("PostgreSQL", func(context.Context) error {
+		return dbConn.Close()
+	})
+
+	// Ротация учётных данных: новые соединения пула открываются с новым DSN
+	if a.cfg.Postgres.DSNSecret != "" && a.cfg.Secrets.RefreshInterval > 0 {
+		g.Go(func() error {
+			secrets.Watch(ctx, provider, a.cfg.Postgres.DSNSecret, dsn, a.cfg.Secrets.RefreshInterval, connector.SetDSN)
+			return nil
+		})
+	}
+
+	return dbConn, nil
+}
+
+func (a *App) startRedis(ctx context.Context) (*redis.Client, error) {
+	redisClient, err := cache.NewRedisClient(ctx, a.cfg.Redis)
+	if err != nil {
+		return nil, err
+	}
+	a.onShutdown("Redis", func(context.Context) error {
+		return redisClient.Close()
+	})
+
+	return redisClient, nil
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/tlsutil"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type fileWatcher interface {
+	Watch(ctx context.Context, interval time.Duration)
+}
+
+// watchFiles перечитывает сертификаты до остановки приложения.
+func (a *App) watchFiles(ctx context.Context, g *errgroup.Group, w fileWatcher) {
+	g.Go(func() error {
+		w.Watch(ctx, a.cfg.TLSReloadInterval)
+		return nil
+	})
+}
+
+// grpcServerTLS возвращает nil, если TLS для gRPC выключен.
+func (a *App) grpcServerTLS(ctx context.Context, g *errgroup.Group) (*tls.Config, error) {
+	tlsCfg := a.cfg.GRPC.TLS
+	if !tlsCfg.Enabled {
+		return nil, nil
+	}
+
+	cert, err := tlsutil.NewCertReloader(tlsCfg.CertFile, tlsCfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	a.watchFiles(ctx, g, cert)
+
+	var clientCAs *tlsutil.CAReloader
+	if tlsCfg.ClientCAFile != "" {
+		clientCAs, err = tlsutil.NewCAReloader(tlsCfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		a.watchFiles(ctx, g, clientCAs)
+	}
+
+	// значение уже проверено при валидации конфигурации
+	clientAuth, _ := tlsutil.ParseClientAuth(tlsCfg.ClientAuth)
+	return tlsutil.ServerConfig(cert, clientCAs, clientAuth), nil
+}
+
+// gatewayCredentials — транспорт для соединения HTTP gateway с gRPC
+// сервером; при mTLS gateway предъявляет собственный клиентский сертификат.
+func (a *App) gatewayCredentials(ctx context.Context, g *errgroup.Group) (credentials.TransportCredentials, error) {
+	if !a.cfg.GRPC.TLS.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	clientCfg := a.cfg.GRPC.ClientTLS
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: clientCfg.ServerName,
+	}
+	if clientCfg.CAFile != "" {
+		pool, err := tlsutil.LoadCertPool(clientCfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if clientCfg.CertFile != "" {
+		cert, err := tlsutil.NewCertReloader(clientCfg.CertFile, clientCfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		a.watchFiles(ctx, g, cert)
+		tlsConfig.GetClientCertificate = cert.GetClientCertificate
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// httpServerTLS возвращает nil, если HTTPS выключен.
+func (a *App) httpServerTLS(ctx context.Context, g *errgroup.Group) (*tls.Config, error) {
+	if !a.cfg.HTTP.TLS.Enabled {
+		return nil, nil
+	}
+
+	cert, err := tlsutil.NewCertReloader(a.cfg.HTTP.TLS.CertFile, a.cfg.HTTP.TLS.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	a.watchFiles(ctx, g, cert)
+
+	return tlsutil.ServerConfig(cert, nil, tls.NoClientCert), nil
+}
@@ -0,0 +1,150 @@
+package apperr
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Domain — значение ErrorInfo.domain для всех ошибок сервиса.
+const Domain = "user-service"
+
+// Стабильные коды причин (ErrorInfo.reason). Клиенты могут на них
+// опираться, поэтому существующие значения не переименовываются.
+const (
+	ReasonValidationFailed       = "VALIDATION_FAILED"
+	ReasonUserNotFound           = "USER_NOT_FOUND"
+	ReasonUserAlreadyExists      = "USER_ALREADY_EXISTS"
+	ReasonAvatarNotFound         = "AVATAR_NOT_FOUND"
+	ReasonSearchThrottled        = "SEARCH_THROTTLED"
+	ReasonSearchTimeout          = "SEARCH_TIMEOUT"
+	ReasonInvalidCredentials     = "INVALID_CREDENTIALS"
+	ReasonUnauthenticated        = "UNAUTHENTICATED"
+	ReasonPermissionDenied       = "PERMISSION_DENIED"
+	ReasonSessionNotFound        = "SESSION_NOT_FOUND"
+	ReasonLoginThrottled         = "LOGIN_THROTTLED"
+	ReasonLoginLocked            = "LOGIN_LOCKED"
+	ReasonInvalidSecondFactor    = "INVALID_SECOND_FACTOR"
+	ReasonMFAChallengeExpired    = "MFA_CHALLENGE_EXPIRED"
+	ReasonTOTPAlreadyEnabled     = "TOTP_ALREADY_ENABLED"
+	ReasonTOTPNotEnabled         = "TOTP_NOT_ENABLED"
+	ReasonTwoFactorUnavailable   = "TWO_FACTOR_UNAVAILABLE"
+	ReasonMagicLinkInvalid       = "MAGIC_LINK_INVALID"
+	ReasonMagicLinkDisabled      = "MAGIC_LINK_DISABLED"
+	ReasonOIDCClientNotFound     = "OIDC_CLIENT_NOT_FOUND"
+	ReasonSocialProviderNotFound = "SOCIAL_PROVIDER_NOT_FOUND"
+	ReasonSocialLoginFailed      = "SOCIAL_LOGIN_FAILED"
+	ReasonSocialEmailUnverified  = "SOCIAL_EMAIL_UNVERIFIED"
+	ReasonAPIKeyNotFound         = "API_KEY_NOT_FOUND"
+	ReasonTenantNotFound         = "TENANT_NOT_FOUND"
+	ReasonTenantMismatch         = "TENANT_MISMATCH"
+	ReasonOrganizationNotFound   = "ORGANIZATION_NOT_FOUND"
+	ReasonMemberNotFound         = "MEMBER_NOT_FOUND"
+	ReasonLastOwner              = "LAST_OWNER"
+	ReasonInvitationNotFound     = "INVITATION_NOT_FOUND"
+	ReasonRateLimited            = "RATE_LIMITED"
+	ReasonIdempotencyKeyReused   = "IDEMPOTENCY_KEY_REUSED"
+	ReasonIdempotencyInProgress  = "IDEMPOTENCY_REQUEST_IN_PROGRESS"
+	ReasonCanceled               = "REQUEST_CANCELED"
+	ReasonInternal               = "INTERNAL"
+)
+
+// New возвращает gRPC ошибку с ErrorInfo и дополнительными деталями.
+func New(code codes.Code, reason, msg string, metadata map[string]string, details ...protoadapt.MessageV1) error {
+	st := status.New(code, msg)
+	details = append([]protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   Domain,
+		Metadata: metadata,
+	}}, details...)
+
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// Internal скрывает от клиента текст внутренней ошибки.
+func Internal() error {
+	return New(codes.Internal, ReasonInternal, "internal server error", nil)
+}
+
+// fieldError — ошибка валидации, сгенерированная protoc-gen-validate.
+type fieldError interface {
+	Field() string
+	Reason() string
+}
+
+// Validation превращает ошибку ValidateAll() в InvalidArgument с
+// google.rpc.BadRequest, где перечислены все нарушенные поля.
+func Validation(err error) error {
+	var errs []error
+	var multi interface{ AllErrors() []error }
+	if errors.As(err, &multi) {
+		errs = multi.AllErrors()
+	} else {
+		errs = []error{err}
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(errs))
+	for _, e := range errs {
+		var fe fieldError
+		if errors.As(e, &fe) {
+			violations = append(violations, FieldViolation(fieldPath(fe.Field()), fe.Reason()))
+			continue
+		}
+		violations = append(violations, FieldViolation("", e.Error()))
+	}
+
+	return InvalidFields(violations...)
+}
+
+// FieldViolation описывает нарушение для одного поля запроса.
+func FieldViolation(field, description string) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{Field: field, Description: description}
+}
+
+// InvalidFields — InvalidArgument с перечнем нарушений.
+func InvalidFields(violations ...*errdetails.BadRequest_FieldViolation) error {
+	return New(codes.InvalidArgument, ReasonValidationFailed, "request validation failed", nil,
+		&errdetails.BadRequest{FieldViolations: violations})
+}
+
+// ErrorInfo возвращает ErrorInfo из деталей статуса, если он есть.
+func ErrorInfo(st *status.Status) *errdetails.ErrorInfo {
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	return nil
+}
+
+// fieldPath переводит имя поля из protoc-gen-validate (CamelCase) в имя
+// поля proto/JSON запроса: "Email" → "email", "CreatedAt" → "created_at".
+// Ключи и индексы в квадратных скобках ("Metadata[UserTier]") — данные
+// клиента и остаются как есть.
+func fieldPath(name string) string {
+	var b strings.Builder
+	inKey := false
+	for i, r := range name {
+		switch {
+		case r == '[':
+			inKey = true
+		case r == ']':
+			inKey = false
+		case !inKey && unicode.IsUpper(r):
+			if i > 0 && name[i-1] != '.' {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package apperr
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFieldPath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Email", "email"},
+		{"DisplayName", "display_name"},
+		{"PageSize", "page_size"},
+		{"Info.UserId", "info.user_id"},
+		{"Addresses[0].StreetName", "addresses[0].street_name"},
+		// ключ map — данные клиента, его не трогаем
+		{"Metadata[UserTier]", "metadata[UserTier]"},
+		{"Metadata[bad key]", "metadata[bad key]"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := fieldPath(tt.in); got != tt.want {
+			t.Errorf("fieldPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidation(t *testing.T) {
+	long := strings.Repeat("x", 65)
+	tests := []struct {
+		name       string
+		req        interface{ ValidateAll() error }
+		wantFields []string
+	}{
+		{"one field", &userv1.SearchUsersRequest{Query: "ab", PageSize: 500}, []string{"page_size"}},
+		{"all fields are reported", &userv1.SearchUsersRequest{Query: "a", PageSize: -1}, []string{"query", "page_size"}},
+		{"optional and map fields", &userv1.UpdateProfileRequest{Id: 1, DisplayName: &long,
+			Metadata: map[string]string{"Bad Key": "v"}}, []string{"display_name", "metadata[Bad Key]"}},
+		{"create user", &userv1.CreateUserRequest{Email: "not-an-email", Password: "short"}, []string{"email", "password"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validateErr := tt.req.ValidateAll()
+			if validateErr == nil {
+				t.Fatal("request is valid")
+			}
+			st := status.Convert(Validation(validateErr))
+			if st.Code() != codes.InvalidArgument {
+				t.Fatalf("code = %v, want InvalidArgument", st.Code())
+			}
+			if info := ErrorInfo(st); info.GetReason() != ReasonValidationFailed || info.GetDomain() != Domain {
+				t.Fatalf("ErrorInfo = %v", info)
+			}
+			var fields []string
+			for _, d := range st.Details() {
+				if br, ok := d.(*errdetails.BadRequest); ok {
+					for _, v := range br.GetFieldViolations() {
+						if v.GetDescription() == "" {
+							t.Errorf("violation of %s has no description", v.GetField())
+						}
+						fields = append(fields, v.GetField())
+					}
+				}
+			}
+			slices.Sort(fields)
+			slices.Sort(tt.wantFields)
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Fatalf("violations = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+// Ошибка не из protoc-gen-validate попадает в BadRequest без поля.
+func TestValidationPlainError(t *testing.T) {
+	st := status.Convert(Validation(errors.New("bad request")))
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			v := br.GetFieldViolations()
+			if len(v) != 1 || v[0].GetField() != "" || v[0].GetDescription() != "bad request" {
+				t.Fatalf("violations = %v", v)
+			}
+			return
+		}
+	}
+	t.Fatal("no BadRequest details")
+}
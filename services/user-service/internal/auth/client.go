@@ -0,0 +1,22 @@
+package auth
+
+import "context"
+
+// ClientInfo — сведения о конечном клиенте запроса. Для запросов через
+// HTTP gateway это адрес и User-Agent исходного HTTP клиента.
+type ClientInfo struct {
+	IP         string
+	UserAgent  string
+	ViaGateway bool
+}
+
+type clientInfoKey struct{}
+
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
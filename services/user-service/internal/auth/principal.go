@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+)
+
+type PrincipalType string
+
+const (
+	// PrincipalService — другой сервис, опознанный по клиентскому сертификату (mTLS).
+	PrincipalService PrincipalType = "service"
+	// PrincipalUser — пользователь, предъявивший токен сессии.
+	PrincipalUser PrincipalType = "user"
+	// PrincipalAPIKey — машинный клиент с API ключом; методы вне scopes
+	// ключа отсекает AuthInterceptor.
+	PrincipalAPIKey PrincipalType = "api_key"
+)
+
+// Principal — тот, от чьего имени выполняется запрос.
+type Principal struct {
+	Type PrincipalType
+	ID   string
+	// SessionID — сессия, по которой опознан пользователь (PrincipalUser).
+	SessionID string
+	// TenantID — тенант пользователя (PrincipalUser) или API ключа
+	// (PrincipalAPIKey); только сервисы выбирают тенант заголовком.
+	TenantID string
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// ServiceIdentity возвращает идентичность сервиса из SAN сертификата:
+// URI (например SPIFFE ID) приоритетнее DNS имени.
+func ServiceIdentity(cert *x509.Certificate) (string, bool) {
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String(), true
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0], true
+	}
+	return "", false
+}
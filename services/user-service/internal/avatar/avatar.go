@@ -0,0 +1,107 @@
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // декодер GIF для image.Decode
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"slices"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // декодер WebP для image.Decode
+)
+
+// Sizes — стороны квадратных миниатюр в пикселях; DefaultSize отдаётся,
+// если размер не указан.
+var Sizes = []int{64, 256, 512}
+
+const DefaultSize = 256
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type, use JPEG, PNG, GIF or WebP")
+	ErrTooLarge        = errors.New("image dimensions are too large")
+)
+
+var supportedTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// Thumbnail — миниатюра одного из Sizes.
+type Thumbnail struct {
+	Size        int
+	Data        []byte
+	ContentType string
+}
+
+// Process проверяет изображение и строит миниатюры всех Sizes: центр
+// кадрируется до квадрата и масштабируется. Результат перекодируется, так
+// что EXIF и прочие метаданные исходного файла не сохраняются.
+func Process(data []byte, maxPixels int) ([]Thumbnail, error) {
+	// тип определяем по содержимому, а не по тому, что заявил клиент
+	if !slices.Contains(supportedTypes, http.DetectContentType(data)) {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxPixels/cfg.Height {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	src = squareCrop(src)
+
+	// прозрачность есть только в PNG/GIF/WebP — тогда сохраняем в PNG
+	opaque := isOpaque(src)
+
+	thumbs := make([]Thumbnail, 0, len(Sizes))
+	for _, size := range Sizes {
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+		var buf bytes.Buffer
+		contentType := "image/png"
+		if opaque {
+			contentType = "image/jpeg"
+			err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&buf, dst)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encode %dpx thumbnail: %w", size, err)
+		}
+
+		thumbs = append(thumbs, Thumbnail{Size: size, Data: buf.Bytes(), ContentType: contentType})
+	}
+
+	return thumbs, nil
+}
+
+func squareCrop(img image.Image) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	rect := image.Rect(x, y, x+side, y+side)
+
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+	return img
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Object — содержимое и метаданные сохранённого объекта. Body закрывает
+// вызывающий.
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+	ETag        string
+	ModTime     time.Time
+}
+
+// Store — хранилище бинарных объектов (аватары и т.п.) по ключу вида
+// "avatars/42/256".
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New создаёт хранилище по blob.backend.
+func New(cfg config.BlobConfig) (Store, error) {
+	switch cfg.Backend {
+	case "local":
+		return NewLocalStore(cfg.Dir)
+	case "s3":
+		return NewS3Store(cfg.S3), nil
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q", cfg.Backend)
+	}
+}
@@ -1,9 +1,22 @@
 package cache
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Cache interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value string) error
 	Del(ctx context.Context, key string) error
+}
+
+// Tagged — кэш, записи которого можно удалить по тегу: например, все
+// результаты, зависящие от отозванной сессии.
+type Tagged interface {
+	Cache
+	// SetTagged сохраняет запись на ttl, но не дольше TTL кэша.
+	SetTagged(ctx context.Context, key, value string, ttl time.Duration, tags ...string) error
+	// Invalidate удаляет все записи с тегом tag.
+	Invalidate(ctx context.Context, tag string) error
 }
\ No newline at end of file
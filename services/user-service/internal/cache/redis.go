@@ -2,43 +2,90 @@ package cache
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"sync/atomic"
 	"time"
 
+	"github.com/DmitriiPro/user-service/internal/config"
 	"github.com/redis/go-redis/v9"
 )
 
-var (
-	Ctx = context.Background()
-	TTLTimeRedis = (time.Minute * 25)
-)
-
-type redisCache struct {
+type RedisCache struct {
 	client *redis.Client
+	ttl    atomic.Int64
 }
 
-func NewRedis(addr string) Cache {
-	rdb :=  redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: "", // no password set
-		DB:       0,  // use default DB
+// NewRedisClient создаёт клиента и проверяет доступность Redis.
+func NewRedisClient(ctx context.Context, cfg config.RedisConfig) (*redis.Client, error) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:         cfg.Addr,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
 	})
 
-	if err := rdb.Ping(Ctx).Err(); err != nil {
-		log.Fatalf("Redis ping failed: %v", err)
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		rdb.Close()
+		return nil, fmt.Errorf("redis ping failed: %w", err)
 	}
 
-	return &redisCache{client: rdb}
+	return rdb, nil
+}
+
+func NewRedis(client *redis.Client, ttl time.Duration) *RedisCache {
+	c := &RedisCache{client: client}
+	c.SetTTL(ttl)
+	return c
 }
 
-func (r *redisCache) Del(ctx context.Context, key string) error {
+// SetTTL меняет время жизни для новых записей; уже сохранённые ключи
+// доживают со своим TTL.
+func (r *RedisCache) SetTTL(ttl time.Duration) {
+	r.ttl.Store(int64(ttl))
+}
+
+func (r *RedisCache) Del(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
-func (r *redisCache) Get(ctx context.Context, key string) (string, error) {
+func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
 	return r.client.Get(ctx, key).Result()
 }
 
-func (r *redisCache) Set(ctx context.Context, key string, value string) error {
-	return r.client.Set(ctx, key, value, TTLTimeRedis).Err()
+func (r *RedisCache) Set(ctx context.Context, key string, value string) error {
+	return r.client.Set(ctx, key, value, time.Duration(r.ttl.Load())).Err()
+}
+
+func tagKey(tag string) string {
+	return "cache_tag:" + tag
+}
+
+// SetTagged: тег — множество "cache_tag:<tag>" ключей записей. Оно живёт
+// TTL кэша с последней записи, то есть не меньше любой своей записи.
+func (r *RedisCache) SetTagged(ctx context.Context, key, value string, ttl time.Duration, tags ...string) error {
+	maxTTL := time.Duration(r.ttl.Load())
+	ttl = min(ttl, maxTTL)
+	if ttl <= 0 {
+		return nil
+	}
+	_, err := r.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, key, value, ttl)
+		for _, tag := range tags {
+			p.SAdd(ctx, tagKey(tag), key)
+			p.Expire(ctx, tagKey(tag), maxTTL)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *RedisCache) Invalidate(ctx context.Context, tag string) error {
+	keys, err := r.client.SMembers(ctx, tagKey(tag)).Result()
+	if err != nil {
+		return err
+	}
+	return r.client.Del(ctx, append(keys, tagKey(tag))...).Err()
 }
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const redactedValue = "******"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// binding связывает поле Config с его переменной окружения и флагом.
+type binding struct {
+	path   string
+	env    string
+	flag   string
+	secret bool
+	value  reflect.Value
+}
+
+// bind обходит cfg и возвращает все листовые поля с тегами.
+func bind(cfg *Config) []binding {
+	var out []binding
+	walk(reflect.ValueOf(cfg).Elem(), "", &out)
+	return out
+}
+
+func walk(v reflect.Value, prefix string, out *[]binding) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != durationType {
+			walk(fv, path, out)
+			continue
+		}
+
+		*out = append(*out, binding{
+			path:   path,
+			env:    f.Tag.Get("env"),
+			flag:   f.Tag.Get("flag"),
+			secret: f.Tag.Get("secret") == "true",
+			value:  fv,
+		})
+	}
+}
+
+func (b binding) set(raw string) error {
+	v := b.value
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Bool:
+		ok, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(ok)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s for %s", v.Type(), b.path)
+	}
+
+	return nil
+}
+
+func (b binding) redact() {
+	if b.value.Kind() != reflect.String || b.value.String() == "" {
+		return
+	}
+	b.value.SetString(redact(b.value.String()))
+}
+
+// redact скрывает пароль в URL (DSN), оставляя хост и базу для отладки;
+// остальные секреты заменяются целиком.
+func redact(s string) string {
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.User != nil {
+		return u.Redacted()
+	}
+	return redactedValue
+}
+
+// flagValue хранит сырое значение флага; разбор делает binding.set, чтобы
+// флаги и переменные окружения понимали одинаковый формат.
+type flagValue struct {
+	raw    string
+	isBool bool
+}
+
+func (f *flagValue) String() string { return f.raw }
+
+func (f *flagValue) Set(raw string) error {
+	f.raw = raw
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
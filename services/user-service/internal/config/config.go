@@ -1,36 +1,699 @@
 package config
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
+	"iter"
+	"net"
 	"os"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+// Config собирается слоями: значения по умолчанию < YAML файл < переменные
+// окружения < флаги командной строки. Теги env/flag описывают, как поле
+// переопределяется; secret помечает значения, которые нельзя печатать.
 type Config struct {
-	PostgresDSN string
-	RedisAddr   string
-	GRPCPort    string
+	Postgres PostgresConfig `yaml:"postgres"`
+	Redis    RedisConfig    `yaml:"redis"`
+	GRPC     GRPCConfig     `yaml:"grpc"`
+	HTTP     HTTPConfig     `yaml:"http"`
+	Swagger  SwaggerConfig  `yaml:"swagger"`
+	Cache    CacheConfig    `yaml:"cache"`
+	Email    EmailConfig    `yaml:"email"`
+	Avatar   AvatarConfig   `yaml:"avatar"`
+	Blob     BlobConfig     `yaml:"blob"`
+	Search   SearchConfig   `yaml:"search"`
+	Sessions SessionsConfig `yaml:"sessions"`
+	Lockout  LockoutConfig  `yaml:"lockout"`
+	Notify   NotifyConfig   `yaml:"notify"`
+	Mail     MailConfig     `yaml:"mail"`
+	Admin    AdminConfig    `yaml:"admin"`
+	CORS     CORSConfig     `yaml:"cors"`
+	Log      LogConfig      `yaml:"log"`
+	Secrets  SecretsConfig  `yaml:"secrets"`
+
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	TwoFactor   TwoFactorConfig   `yaml:"two_factor"`
+	MagicLink   MagicLinkConfig   `yaml:"magic_link"`
+	OIDC        OIDCConfig        `yaml:"oidc"`
+	SigningKeys SigningKeysConfig `yaml:"signing_keys"`
+	Social      SocialConfig      `yaml:"social"`
+
+	Organizations OrganizationsConfig `yaml:"organizations"`
+	Privacy       PrivacyConfig       `yaml:"privacy"`
+
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout"`
+	// TLSReloadInterval — как часто проверять сертификаты на диске.
+	TLSReloadInterval time.Duration `yaml:"tls_reload_interval" env:"TLS_RELOAD_INTERVAL"`
+}
+
+type PostgresConfig struct {
+	DSN             string        `yaml:"dsn" env:"POSTGRES_DSN" flag:"postgres-dsn" secret:"true"`
+	DSNSecret       string        `yaml:"dsn_secret" env:"POSTGRES_DSN_SECRET"`
+	MigrationsDir   string        `yaml:"migrations_dir" env:"POSTGRES_MIGRATIONS_DIR" flag:"migrations-dir"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"POSTGRES_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"POSTGRES_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"POSTGRES_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"POSTGRES_CONN_MAX_IDLE_TIME"`
+	PingAttempts    int           `yaml:"ping_attempts" env:"POSTGRES_PING_ATTEMPTS"`
+	PingInterval    time.Duration `yaml:"ping_interval" env:"POSTGRES_PING_INTERVAL"`
+}
+
+type RedisConfig struct {
+	Addr         string        `yaml:"addr" env:"REDIS_ADDR" flag:"redis-addr"`
+	Password     string        `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	DB           int           `yaml:"db" env:"REDIS_DB"`
+	PoolSize     int           `yaml:"pool_size" env:"REDIS_POOL_SIZE"`
+	DialTimeout  time.Duration `yaml:"dial_timeout" env:"REDIS_DIAL_TIMEOUT"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"REDIS_READ_TIMEOUT"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"REDIS_WRITE_TIMEOUT"`
+}
+
+type GRPCConfig struct {
+	Host             string        `yaml:"host" env:"GRPC_HOST" flag:"grpc-host"`
+	Port             string        `yaml:"port" env:"GRPC_PORT" flag:"grpc-port"`
+	KeepaliveTime    time.Duration `yaml:"keepalive_time" env:"GRPC_KEEPALIVE_TIME"`
+	KeepaliveTimeout time.Duration `yaml:"keepalive_timeout" env:"GRPC_KEEPALIVE_TIMEOUT"`
+	KeepaliveMinTime time.Duration `yaml:"keepalive_min_time" env:"GRPC_KEEPALIVE_MIN_TIME"`
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout" env:"GRPC_READINESS_TIMEOUT"`
+	TLS              GRPCTLSConfig `yaml:"tls"`
+	// ClientTLS — как HTTP gateway подключается к gRPC серверу, когда grpc.tls включён.
+	ClientTLS ClientTLSConfig `yaml:"client_tls"`
+}
+
+type GRPCTLSConfig struct {
+	Enabled      bool   `yaml:"enabled" env:"GRPC_TLS_ENABLED"`
+	CertFile     string `yaml:"cert_file" env:"GRPC_TLS_CERT_FILE"`
+	KeyFile      string `yaml:"key_file" env:"GRPC_TLS_KEY_FILE"`
+	ClientCAFile string `yaml:"client_ca_file" env:"GRPC_TLS_CLIENT_CA_FILE"`
+	// ClientAuth: none, verify_if_given или require (mTLS).
+	ClientAuth string `yaml:"client_auth" env:"GRPC_TLS_CLIENT_AUTH"`
+	// AllowedIdentities — SAN (URI или DNS) сервисов, которым разрешён доступ; пусто — всем с валидным сертификатом.
+	AllowedIdentities []string `yaml:"allowed_identities" env:"GRPC_TLS_ALLOWED_IDENTITIES"`
+}
+
+type ClientTLSConfig struct {
+	CAFile     string `yaml:"ca_file" env:"GRPC_CLIENT_TLS_CA_FILE"`
+	CertFile   string `yaml:"cert_file" env:"GRPC_CLIENT_TLS_CERT_FILE"`
+	KeyFile    string `yaml:"key_file" env:"GRPC_CLIENT_TLS_KEY_FILE"`
+	ServerName string `yaml:"server_name" env:"GRPC_CLIENT_TLS_SERVER_NAME"`
+}
+
+func (c GRPCConfig) Addr() string {
+	return net.JoinHostPort(c.Host, c.Port)
+}
+
+type HTTPConfig struct {
+	Addr         string        `yaml:"addr" env:"HTTP_ADDR" flag:"http-addr"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	TLS          HTTPTLSConfig `yaml:"tls"`
+	// TrustedProxies — CIDR прокси (api-gateway, балансировщик), которым
+	// доверяем X-Forwarded-For при определении IP клиента.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES"`
+}
+
+type HTTPTLSConfig struct {
+	Enabled  bool   `yaml:"enabled" env:"HTTP_TLS_ENABLED"`
+	CertFile string `yaml:"cert_file" env:"HTTP_TLS_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" env:"HTTP_TLS_KEY_FILE"`
+}
+
+type SwaggerConfig struct {
+	Enabled  bool   `yaml:"enabled" env:"SWAGGER_ENABLED" flag:"swagger"`
+	Addr     string `yaml:"addr" env:"SWAGGER_ADDR" flag:"swagger-addr"`
+	SpecFile string `yaml:"spec_file" env:"SWAGGER_SPEC_FILE"`
+	SpecURL  string `yaml:"spec_url" env:"SWAGGER_SPEC_URL"`
+}
+
+type CacheConfig struct {
+	UserTTL time.Duration `yaml:"user_ttl" env:"CACHE_USER_TTL" flag:"cache-user-ttl"`
+	// IntrospectionTTL — сколько хранится результат Introspect. Отзыв
+	// сессии или API ключа сбрасывает его сразу.
+	IntrospectionTTL time.Duration `yaml:"introspection_ttl" env:"CACHE_INTROSPECTION_TTL"`
+}
+
+// EmailConfig — нормализация email при регистрации. ProviderRules
+// учитывает правила Gmail, Outlook и Яндекса (точки, метки после "+",
+// домены-синонимы). Включение на базе с пользователями пересчитывает
+// сохранённые адреса при старте; если два адреса совпадут, сервис не
+// запустится, пока их не разведут вручную. Выключить правила после этого
+// нельзя — см. service.ApplyEmailNormalization.
+type EmailConfig struct {
+	ProviderRules bool `yaml:"provider_rules" env:"EMAIL_PROVIDER_RULES"`
+}
+
+// AvatarConfig — ограничения на загружаемые аватары. MaxPixels защищает
+// от изображений, которые занимают мало байт, но огромны после декодирования.
+type AvatarConfig struct {
+	MaxBytes  int `yaml:"max_bytes" env:"AVATAR_MAX_BYTES"`
+	MaxPixels int `yaml:"max_pixels" env:"AVATAR_MAX_PIXELS"`
+}
+
+// BlobConfig — где хранятся файлы (аватары): local — каталог dir,
+// s3 — любое S3-совместимое хранилище (AWS, MinIO, ...).
+type BlobConfig struct {
+	Backend string   `yaml:"backend" env:"BLOB_BACKEND"`
+	Dir     string   `yaml:"dir" env:"BLOB_DIR"`
+	S3      S3Config `yaml:"s3"`
+}
+
+// S3Config — параметры S3. Endpoint вида https://s3.eu-central-1.amazonaws.com
+// или http://localhost:9000; PathStyle нужен MinIO и большинству стабов.
+type S3Config struct {
+	Endpoint        string        `yaml:"endpoint" env:"S3_ENDPOINT"`
+	Region          string        `yaml:"region" env:"S3_REGION"`
+	Bucket          string        `yaml:"bucket" env:"S3_BUCKET"`
+	AccessKeyID     string        `yaml:"access_key_id" env:"S3_ACCESS_KEY_ID"`
+	SecretAccessKey string        `yaml:"secret_access_key" env:"S3_SECRET_ACCESS_KEY" secret:"true"`
+	PathStyle       bool          `yaml:"path_style" env:"S3_PATH_STYLE"`
+	Timeout         time.Duration `yaml:"timeout" env:"S3_TIMEOUT"`
+}
+
+// SearchConfig защищает общий пул соединений от тяжёлого поиска: не больше
+// MaxConcurrent запросов одновременно (остальные ждут до QueueTimeout) и
+// не дольше StatementTimeout каждый.
+type SearchConfig struct {
+	MaxConcurrent    int           `yaml:"max_concurrent" env:"SEARCH_MAX_CONCURRENT"`
+	QueueTimeout     time.Duration `yaml:"queue_timeout" env:"SEARCH_QUEUE_TIMEOUT"`
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"SEARCH_STATEMENT_TIMEOUT"`
+}
+
+// SessionsConfig — сессии, открываемые Login. TTL — срок жизни токена.
+type SessionsConfig struct {
+	TTL time.Duration `yaml:"ttl" env:"SESSION_TTL"`
+}
+
+// LockoutConfig — защита от подбора паролей. После DelayAfter неудач
+// подряд следующая попытка возможна не раньше чем через BaseDelay,
+// удваиваясь до MaxDelay; на MaxFailures учётная запись блокируется на
+// LockDuration. Неудачи забываются через Window после последней. IP
+// блокируется на IPLockDuration после IPMaxFailures неудач по любым учёткам.
+type LockoutConfig struct {
+	MaxFailures    int           `yaml:"max_failures" env:"LOCKOUT_MAX_FAILURES"`
+	Window         time.Duration `yaml:"window" env:"LOCKOUT_WINDOW"`
+	LockDuration   time.Duration `yaml:"lock_duration" env:"LOCKOUT_LOCK_DURATION"`
+	DelayAfter     int           `yaml:"delay_after" env:"LOCKOUT_DELAY_AFTER"`
+	BaseDelay      time.Duration `yaml:"base_delay" env:"LOCKOUT_BASE_DELAY"`
+	MaxDelay       time.Duration `yaml:"max_delay" env:"LOCKOUT_MAX_DELAY"`
+	IPMaxFailures  int           `yaml:"ip_max_failures" env:"LOCKOUT_IP_MAX_FAILURES"`
+	IPLockDuration time.Duration `yaml:"ip_lock_duration" env:"LOCKOUT_IP_LOCK_DURATION"`
+}
+
+// TwoFactorConfig — TOTP. EncryptionKey (base64, 32 байта) шифрует секреты
+// в БД; без него подключить TOTP нельзя. Issuer показывается в приложении-
+// аутентификаторе. Второй фактор нужно ввести за ChallengeTTL после пароля,
+// не больше чем за MaxAttempts попыток.
+type TwoFactorConfig struct {
+	EncryptionKey string        `yaml:"encryption_key" env:"TOTP_ENCRYPTION_KEY" secret:"true"`
+	Issuer        string        `yaml:"issuer" env:"TOTP_ISSUER"`
+	RecoveryCodes int           `yaml:"recovery_codes" env:"TOTP_RECOVERY_CODES"`
+	ChallengeTTL  time.Duration `yaml:"challenge_ttl" env:"TOTP_CHALLENGE_TTL"`
+	MaxAttempts   int           `yaml:"max_attempts" env:"TOTP_MAX_ATTEMPTS"`
+}
+
+// MagicLinkConfig — вход по ссылке из письма. Ссылка ведёт на URL с
+// параметром token; страница по этому адресу вызывает ConsumeMagicLink.
+// На один email письмо уходит не чаще раза в Cooldown, с любых IP.
+type MagicLinkConfig struct {
+	Enabled  bool          `yaml:"enabled" env:"MAGIC_LINK_ENABLED"`
+	TTL      time.Duration `yaml:"ttl" env:"MAGIC_LINK_TTL"`
+	URL      string        `yaml:"url" env:"MAGIC_LINK_URL"`
+	Cooldown time.Duration `yaml:"cooldown" env:"MAGIC_LINK_COOLDOWN"`
+}
+
+// PrivacyConfig — выгрузка и удаление данных (GDPR). ErasureHashKey
+// (base64, 32 байта) — ключ HMAC email в записи об удалении: по записи
+// можно подтвердить, что данные адреса удалены, но только зная ключ. Без
+// ключа email в запись не попадает.
+type PrivacyConfig struct {
+	ErasureHashKey string `yaml:"erasure_hash_key" env:"PRIVACY_ERASURE_HASH_KEY" secret:"true"`
+}
+
+// OrganizationsConfig — приглашения в организации. Письмо ведёт на
+// InvitationURL с параметром token; страница по этому адресу вызывает
+// AcceptInvitation или DeclineInvitation.
+type OrganizationsConfig struct {
+	InvitationTTL time.Duration `yaml:"invitation_ttl" env:"ORGANIZATIONS_INVITATION_TTL"`
+	InvitationURL string        `yaml:"invitation_url" env:"ORGANIZATIONS_INVITATION_URL"`
+}
+
+// OIDCConfig — провайдер OpenID Connect на HTTP стороне. Issuer — внешний
+// адрес сервиса, от него строятся адреса эндпоинтов. Пользователь без
+// сессии отправляется на LoginURL с параметром return_to; страница входа
+// кладёт токен сессии в cookie SessionCookie и возвращает его обратно.
+// Токены подписываются ключами из SigningKeysConfig.
+type OIDCConfig struct {
+	Enabled       bool          `yaml:"enabled" env:"OIDC_ENABLED"`
+	Issuer        string        `yaml:"issuer" env:"OIDC_ISSUER"`
+	LoginURL      string        `yaml:"login_url" env:"OIDC_LOGIN_URL"`
+	SessionCookie string        `yaml:"session_cookie" env:"OIDC_SESSION_COOKIE"`
+	CodeTTL       time.Duration `yaml:"code_ttl" env:"OIDC_CODE_TTL"`
+	TokenTTL      time.Duration `yaml:"token_ttl" env:"OIDC_TOKEN_TTL"`
+}
+
+// SigningKeysConfig — ключи подписи токенов (JWT). Менеджер ключей
+// работает, если задан EncryptionKey (base64, 32 байта): им шифруются
+// закрытые ключи в БД. Ключ алгоритма Algorithm (RS256 или EdDSA)
+// подписывает Rotation; новый появляется в JWKS за PublishAhead до начала
+// подписи — клиенты успевают обновить кэш, — а старый публикуется ещё
+// Retention, пока не истекут его токены. Новый Algorithm действует со
+// следующего ключа.
+type SigningKeysConfig struct {
+	Algorithm     string        `yaml:"algorithm" env:"SIGNING_KEYS_ALGORITHM"`
+	EncryptionKey string        `yaml:"encryption_key" env:"SIGNING_KEYS_ENCRYPTION_KEY" secret:"true"`
+	Rotation      time.Duration `yaml:"rotation" env:"SIGNING_KEYS_ROTATION"`
+	PublishAhead  time.Duration `yaml:"publish_ahead" env:"SIGNING_KEYS_PUBLISH_AHEAD"`
+	Retention     time.Duration `yaml:"retention" env:"SIGNING_KEYS_RETENTION"`
+}
+
+// SocialConfig — вход через внешних провайдеров: Google, GitHub и любой
+// провайдер OpenID Connect (oidc). Start/CompleteSocialLogin должны
+// выполняться из одного браузера (User-Agent) в течение StateTTL.
+type SocialConfig struct {
+	StateTTL time.Duration        `yaml:"state_ttl" env:"SOCIAL_STATE_TTL"`
+	Google   SocialProviderConfig `yaml:"google"`
+	GitHub   SocialProviderConfig `yaml:"github"`
+	OIDC     SocialProviderConfig `yaml:"oidc"`
+}
+
+// Providers перечисляет провайдеров по имени, под которым они доступны в
+// API, в постоянном порядке.
+func (c SocialConfig) Providers() iter.Seq2[string, SocialProviderConfig] {
+	return func(yield func(string, SocialProviderConfig) bool) {
+		_ = yield("google", c.Google) && yield("github", c.GitHub) && yield("oidc", c.OIDC)
+	}
+}
+
+// SocialProviderConfig — клиент OAuth2 у внешнего провайдера. Issuer —
+// адрес провайдера OIDC (эндпоинты берутся из discovery), у GitHub — адрес
+// веб-сайта, а APIURL — его API. RedirectURL — страница фронтенда, куда
+// провайдер вернёт code и state; она вызывает CompleteSocialLogin.
+type SocialProviderConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	Issuer       string   `yaml:"issuer"`
+	APIURL       string   `yaml:"api_url"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret" secret:"true"`
+	Scopes       []string `yaml:"scopes"`
+	RedirectURL  string   `yaml:"redirect_url"`
+}
+
+// NotifyConfig — доставка уведомлений пользователям: log — только в лог,
+// file — JSON строками в File (для разработки).
+type NotifyConfig struct {
+	Backend string `yaml:"backend" env:"NOTIFY_BACKEND"`
+	File    string `yaml:"file" env:"NOTIFY_FILE"`
+}
+
+// MailConfig — отправка писем: log — только тема в лог, file — .eml файлы
+// в Dir (для разработки), smtp — через SMTP сервер.
+type MailConfig struct {
+	Backend string     `yaml:"backend" env:"MAIL_BACKEND"`
+	From    string     `yaml:"from" env:"MAIL_FROM"`
+	Dir     string     `yaml:"dir" env:"MAIL_DIR"`
+	SMTP    SMTPConfig `yaml:"smtp"`
+}
+
+type SMTPConfig struct {
+	Addr     string `yaml:"addr" env:"SMTP_ADDR"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// AdminConfig — пользователи с правами администратора (например, UnlockUser).
+// Сервисы, опознанные по mTLS, имеют эти права всегда.
+type AdminConfig struct {
+	UserIDs []string `yaml:"user_ids" env:"ADMIN_USER_IDS"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" flag:"cors-allowed-origins"`
+}
+
+// RateLimitConfig — лимиты token bucket. Правила HTTP сопоставляются с
+// запросом как шаблоны net/http.ServeMux ("POST /v1/users"), правила gRPC —
+// по полному имени метода или "*" для всех методов. Правила gRPC действуют
+// только на прямые gRPC вызовы: запросы через HTTP gateway ограничиваются
+// правилами HTTP.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	// Backend: redis (общий для реплик, с откатом в память) или memory.
+	Backend string          `yaml:"backend" env:"RATE_LIMIT_BACKEND"`
+	HTTP    []RateLimitRule `yaml:"http"`
+	GRPC    []RateLimitRule `yaml:"grpc"`
+}
+
+type RateLimitRule struct {
+	Pattern string `yaml:"pattern"`
+	// Key: ip, principal (для gRPC; без principal — ip) или method (общий лимит на метод).
+	Key      string        `yaml:"key"`
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"`
+}
+
+// IdempotencyConfig — для каких методов учитывается Idempotency-Key.
+// TTL — сколько хранится ответ, LockTTL — сколько ключ считается занятым
+// выполняющимся запросом (на случай падения реплики посреди запроса).
+type IdempotencyConfig struct {
+	Enabled bool          `yaml:"enabled" env:"IDEMPOTENCY_ENABLED"`
+	TTL     time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL"`
+	LockTTL time.Duration `yaml:"lock_ttl" env:"IDEMPOTENCY_LOCK_TTL"`
+	Methods []string      `yaml:"methods" env:"IDEMPOTENCY_METHODS"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" env:"LOG_LEVEL" flag:"log-level"`
+}
+
+// SecretsConfig описывает внешний источник секретов. Если задан
+// postgres.dsn_secret, DSN берётся у провайдера и перечитывается каждые
+// refresh_interval (0 — без обновления).
+type SecretsConfig struct {
+	Provider        string        `yaml:"provider" env:"SECRETS_PROVIDER"`
+	Dir             string        `yaml:"dir" env:"SECRETS_DIR"`
+	Vault           VaultConfig   `yaml:"vault"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"SECRETS_REFRESH_INTERVAL"`
 }
 
-func Load() *Config {
-	err := godotenv.Load()
+type VaultConfig struct {
+	Addr    string        `yaml:"addr" env:"VAULT_ADDR"`
+	Token   string        `yaml:"token" env:"VAULT_TOKEN" secret:"true"`
+	Mount   string        `yaml:"mount" env:"VAULT_MOUNT"`
+	Timeout time.Duration `yaml:"timeout" env:"VAULT_TIMEOUT"`
+}
+
+func Default() *Config {
+	return &Config{
+		Postgres: PostgresConfig{
+			MigrationsDir:   "./migrations",
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnMaxIdleTime: 2 * time.Minute,
+			PingAttempts:    5,
+			PingInterval:    2 * time.Second,
+		},
+		Redis: RedisConfig{
+			Addr:         "localhost:6379",
+			PoolSize:     10,
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  3 * time.Second,
+			WriteTimeout: 3 * time.Second,
+		},
+		GRPC: GRPCConfig{
+			Host:             "localhost",
+			Port:             "50051",
+			KeepaliveTime:    30 * time.Second,
+			KeepaliveTimeout: 10 * time.Second,
+			KeepaliveMinTime: 5 * time.Second,
+			ReadinessTimeout: 5 * time.Second,
+		},
+		HTTP: HTTPConfig{
+			Addr:         ":8081",
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  120 * time.Second,
+		},
+		Swagger: SwaggerConfig{
+			Enabled:  true,
+			Addr:     ":8082",
+			SpecFile: "./swagger/user/user.swagger.json",
+			SpecURL:  "http://localhost:8081/swagger.json",
+		},
+		Cache: CacheConfig{
+			UserTTL:          25 * time.Minute,
+			IntrospectionTTL: 30 * time.Second,
+		},
+		Avatar: AvatarConfig{
+			MaxBytes:  5 << 20,
+			MaxPixels: 25_000_000,
+		},
+		Blob: BlobConfig{
+			Backend: "local",
+			Dir:     "./data/blobs",
+			S3: S3Config{
+				Region:    "us-east-1",
+				PathStyle: true,
+				Timeout:   10 * time.Second,
+			},
+		},
+		Search: SearchConfig{
+			MaxConcurrent:    4,
+			QueueTimeout:     500 * time.Millisecond,
+			StatementTimeout: 2 * time.Second,
+		},
+		Sessions: SessionsConfig{
+			TTL: 7 * 24 * time.Hour,
+		},
+		Lockout: LockoutConfig{
+			MaxFailures:    10,
+			Window:         15 * time.Minute,
+			LockDuration:   15 * time.Minute,
+			DelayAfter:     3,
+			BaseDelay:      time.Second,
+			MaxDelay:       30 * time.Second,
+			IPMaxFailures:  100,
+			IPLockDuration: 15 * time.Minute,
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:        "user-service",
+			RecoveryCodes: 10,
+			ChallengeTTL:  5 * time.Minute,
+			MaxAttempts:   5,
+		},
+		MagicLink: MagicLinkConfig{
+			Enabled:  true,
+			TTL:      15 * time.Minute,
+			URL:      "http://localhost:8081/magic-link",
+			Cooldown: time.Minute,
+		},
+		Organizations: OrganizationsConfig{
+			InvitationTTL: 7 * 24 * time.Hour,
+			InvitationURL: "http://localhost:8081/invitations",
+		},
+		OIDC: OIDCConfig{
+			Issuer:        "http://localhost:8081",
+			LoginURL:      "http://localhost:8081/login",
+			SessionCookie: "session",
+			CodeTTL:       time.Minute,
+			TokenTTL:      15 * time.Minute,
+		},
+		SigningKeys: SigningKeysConfig{
+			Algorithm:    "RS256",
+			Rotation:     30 * 24 * time.Hour,
+			PublishAhead: 24 * time.Hour,
+			Retention:    24 * time.Hour,
+		},
+		Social: SocialConfig{
+			StateTTL: 10 * time.Minute,
+			Google: SocialProviderConfig{
+				Issuer: "https://accounts.google.com",
+				Scopes: []string{"openid", "email", "profile"},
+			},
+			GitHub: SocialProviderConfig{
+				Issuer: "https://github.com",
+				APIURL: "https://api.github.com",
+				Scopes: []string{"read:user", "user:email"},
+			},
+			OIDC: SocialProviderConfig{
+				Scopes: []string{"openid", "email", "profile"},
+			},
+		},
+		Mail: MailConfig{
+			Backend: "file",
+			From:    "user-service <no-reply@localhost>",
+			Dir:     "./data/mail",
+			SMTP: SMTPConfig{
+				Addr: "localhost:25",
+			},
+		},
+		Notify: NotifyConfig{
+			Backend: "log",
+			File:    "./data/notifications.jsonl",
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled: true,
+			TTL:     24 * time.Hour,
+			LockTTL: time.Minute,
+			Methods: []string{"/user.v1.UserService/CreateUser"},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Backend: "redis",
+			HTTP: []RateLimitRule{
+				{Pattern: "POST /v1/users", Key: "ip", Requests: 10, Per: time.Minute, Burst: 5},
+				{Pattern: "POST /v1/users/{id}/avatar", Key: "ip", Requests: 10, Per: time.Minute, Burst: 5},
+				{Pattern: "POST /v1/auth/login", Key: "ip", Requests: 10, Per: time.Minute, Burst: 5},
+				{Pattern: "POST /v1/auth/login/second-factor", Key: "ip", Requests: 10, Per: time.Minute, Burst: 5},
+				{Pattern: "POST /v1/auth/magic-link", Key: "ip", Requests: 5, Per: time.Minute, Burst: 3},
+				{Pattern: "POST /v1/auth/social/", Key: "ip", Requests: 20, Per: time.Minute, Burst: 10},
+				{Pattern: "GET /oauth2/authorize", Key: "ip", Requests: 30, Per: time.Minute, Burst: 10},
+				{Pattern: "POST /oauth2/token", Key: "ip", Requests: 30, Per: time.Minute, Burst: 10},
+			},
+			GRPC: []RateLimitRule{
+				{Pattern: "/user.v1.UserService/CreateUser", Key: "principal", Requests: 10, Per: time.Minute, Burst: 5},
+				{Pattern: "/user.v1.UserService/UploadAvatar", Key: "principal", Requests: 10, Per: time.Minute, Burst: 5},
+				{Pattern: "/user.v1.UserService/Login", Key: "ip", Requests: 10, Per: time.Minute, Burst: 5},
+				{Pattern: "/user.v1.UserService/VerifySecondFactor", Key: "ip", Requests: 10, Per: time.Minute, Burst: 5},
+				{Pattern: "/user.v1.UserService/RequestMagicLink", Key: "ip", Requests: 5, Per: time.Minute, Burst: 3},
+				{Pattern: "/user.v1.UserService/StartSocialLogin", Key: "ip", Requests: 20, Per: time.Minute, Burst: 10},
+				{Pattern: "/user.v1.UserService/CompleteSocialLogin", Key: "ip", Requests: 20, Per: time.Minute, Burst: 10},
+			},
+		},
+		Secrets: SecretsConfig{
+			Dir: "/run/secrets",
+			Vault: VaultConfig{
+				Addr:    "http://localhost:8200",
+				Mount:   "secret",
+				Timeout: 5 * time.Second,
+			},
+			RefreshInterval: time.Minute,
+		},
+		ShutdownTimeout:   15 * time.Second,
+		TLSReloadInterval: 30 * time.Second,
+	}
+}
+
+// Load читает конфигурацию. args — аргументы командной строки без имени
+// программы. Путь к YAML файлу задаётся флагом -config или CONFIG_FILE;
+// файл .env подхватывается, только если он есть.
+func Load(args []string) (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("load .env: %w", err)
+	}
+
+	cfg := Default()
+	bindings := bind(cfg)
 
+	fset := flag.NewFlagSet("user-service", flag.ContinueOnError)
+	configPath := fset.String("config", os.Getenv("CONFIG_FILE"), "path to YAML config file (env CONFIG_FILE)")
+	flagValues := make(map[string]*flagValue)
+	for _, b := range bindings {
+		if b.flag == "" {
+			continue
+		}
+		usage := fmt.Sprintf("overrides %s", b.path)
+		if b.env != "" {
+			usage += fmt.Sprintf(" (env %s)", b.env)
+		}
+		fv := &flagValue{isBool: b.value.Kind() == reflect.Bool}
+		fset.Var(fv, b.flag, usage)
+		flagValues[b.flag] = fv
+	}
+	if err := fset.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configPath != "" {
+		if err := loadFile(cfg, *configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	for _, b := range bindings {
+		if b.env == "" {
+			continue
+		}
+		raw, ok, err := lookupEnv(b.env)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			if err := b.set(raw); err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", b.env, err))
+			}
+		}
+	}
+
+	setFlags := make(map[string]bool)
+	fset.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	for _, b := range bindings {
+		if setFlags[b.flag] {
+			if err := b.set(flagValues[b.flag].raw); err != nil {
+				errs = append(errs, fmt.Errorf("flag -%s: %w", b.flag, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// lookupEnv читает переменную NAME или содержимое файла из NAME_FILE
+// (Docker/Kubernetes secrets). Задавать обе одновременно нельзя.
+func lookupEnv(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+
+	path, fromFile := os.LookupEnv(name + "_FILE")
+	if !fromFile {
+		return value, ok, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("env %s and %s_FILE are mutually exclusive", name, name)
+	}
+
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		return "", false, fmt.Errorf("env %s_FILE: %w", name, err)
 	}
 
-	cfg := &Config{
-		PostgresDSN: os.Getenv("POSTGRES_DSN"),
-		RedisAddr:   os.Getenv("REDIS_ADDR"),
-		GRPCPort:    os.Getenv("GRPC_PORT"),
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open config file: %w", err)
 	}
-	fmt.Println("cfg", cfg)
+	defer f.Close()
 
-	if cfg.PostgresDSN == "" {
-		log.Fatal("POSTGRES_DSN not set")
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
 	}
 
-	return cfg
+	return nil
+}
+
+// String печатает конфигурацию в YAML, скрывая секреты.
+func (c *Config) String() string {
+	redacted := *c
+	for _, b := range bind(&redacted) {
+		if b.secret {
+			b.redact()
+		}
+	}
+
+	out, err := yaml.Marshal(&redacted)
+	if err != nil {
+		return fmt.Sprintf("<config: %v>", err)
+	}
+	return string(out)
 }
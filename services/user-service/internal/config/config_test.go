@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSecret(t *testing.T, value string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(value), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	t.Setenv("POSTGRES_DSN_FILE", writeSecret(t, "postgres://u:p@db:5432/users\r\n"))
+	t.Setenv("VAULT_TOKEN_FILE", writeSecret(t, "s.token\n"))
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Postgres.DSN != "postgres://u:p@db:5432/users" {
+		t.Errorf("postgres.dsn = %q, want value from file without trailing newline", cfg.Postgres.DSN)
+	}
+	if cfg.Secrets.Vault.Token != "s.token" {
+		t.Errorf("secrets.vault.token = %q, want s.token", cfg.Secrets.Vault.Token)
+	}
+	if strings.Contains(cfg.String(), "u:p@") || strings.Contains(cfg.String(), "s.token") {
+		t.Errorf("String() leaks secrets read from files:\n%s", cfg)
+	}
+}
+
+func TestLoadEnvFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name:    "both set",
+			env:     map[string]string{"POSTGRES_DSN": "postgres://db/users", "POSTGRES_DSN_FILE": "-"},
+			wantErr: "env POSTGRES_DSN and POSTGRES_DSN_FILE are mutually exclusive",
+		},
+		{
+			name:    "missing file",
+			env:     map[string]string{"POSTGRES_DSN_FILE": filepath.Join(t.TempDir(), "missing")},
+			wantErr: "env POSTGRES_DSN_FILE:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load(nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExampleConfigHasNoDSN(t *testing.T) {
+	cfg, err := Load([]string{"-config", "../../config.example.yaml"})
+	if err != nil {
+		t.Fatalf("Load example: %v", err)
+	}
+	if cfg.Postgres.DSN != "" {
+		t.Errorf("config.example.yaml sets postgres.dsn; use postgres.dsn_secret")
+	}
+	if cfg.Postgres.DSNSecret == "" || cfg.Secrets.Provider == "" {
+		t.Errorf("config.example.yaml: dsn_secret = %q, secrets.provider = %q", cfg.Postgres.DSNSecret, cfg.Secrets.Provider)
+	}
+}
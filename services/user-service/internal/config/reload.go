@@ -0,0 +1,106 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+)
+
+// Reloadable — подмножество Config, которое применяется без перезапуска
+// по SIGHUP. Остальные поля читаются только при старте.
+type Reloadable struct {
+	LogLevel    string
+	CacheTTL    time.Duration
+	CORSOrigins []string
+	RateLimit   RateLimitConfig
+}
+
+func (c *Config) Reloadable() Reloadable {
+	return Reloadable{
+		LogLevel:    c.Log.Level,
+		CacheTTL:    c.Cache.UserTTL,
+		CORSOrigins: slices.Clone(c.CORS.AllowedOrigins),
+		RateLimit:   c.RateLimit.clone(),
+	}
+}
+
+func (c *Config) applyReloadable(r Reloadable) {
+	c.Log.Level = r.LogLevel
+	c.Cache.UserTTL = r.CacheTTL
+	c.CORS.AllowedOrigins = slices.Clone(r.CORSOrigins)
+	c.RateLimit = r.RateLimit.clone()
+}
+
+func (c RateLimitConfig) clone() RateLimitConfig {
+	c.HTTP = slices.Clone(c.HTTP)
+	c.GRPC = slices.Clone(c.GRPC)
+	return c
+}
+
+// Watcher хранит текущую конфигурацию и раздаёт изменения Reloadable
+// подписчикам. Новая конфигурация применяется целиком или не применяется
+// вовсе: подписчики получают значения только после успешной валидации.
+type Watcher struct {
+	load func() (*Config, error)
+
+	mu          sync.Mutex
+	current     atomic.Pointer[Config]
+	subscribers []func(Reloadable)
+}
+
+func NewWatcher(cfg *Config, load func() (*Config, error)) *Watcher {
+	w := &Watcher{load: load}
+	w.current.Store(cfg)
+	return w
+}
+
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Subscribe регистрирует fn и сразу вызывает её с текущими значениями.
+func (w *Watcher) Subscribe(fn func(Reloadable)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+	fn(w.current.Load().Reloadable())
+}
+
+// Reload перечитывает конфигурацию. При ошибке текущие настройки остаются
+// без изменений.
+func (w *Watcher) Reload() error {
+	next, err := w.load()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	prev := w.current.Load()
+	r := next.Reloadable()
+
+	// Изменения вне Reloadable требуют перезапуска — только предупреждаем.
+	cmp := *next
+	cmp.applyReloadable(prev.Reloadable())
+	if !reflect.DeepEqual(&cmp, prev) {
+		logger.Warnf("Config reload: non-reloadable settings changed, restart the service to apply them")
+	}
+
+	applied := *prev
+	applied.applyReloadable(r)
+	w.current.Store(&applied)
+
+	for _, fn := range w.subscribers {
+		fn(r)
+	}
+	logger.Infof("Config reloaded: log level %s, cache TTL %v, CORS origins %v, rate limit enabled %v (%d HTTP, %d gRPC rules)",
+		r.LogLevel, r.CacheTTL, r.CORSOrigins, r.RateLimit.Enabled, len(r.RateLimit.HTTP), len(r.RateLimit.GRPC))
+
+	return nil
+}
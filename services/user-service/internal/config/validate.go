@@ -0,0 +1,320 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Validate проверяет конфигурацию целиком и возвращает все ошибки сразу.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Postgres.DSN != "" || c.Postgres.DSNSecret != "", "postgres.dsn or postgres.dsn_secret is required")
+	check(c.Postgres.DSNSecret == "" || c.Secrets.Provider != "", "postgres.dsn_secret requires secrets.provider")
+	if c.Postgres.DSN != "" {
+		_, err := url.Parse(c.Postgres.DSN)
+		check(err == nil, "postgres.dsn is not a valid URL")
+	}
+	check(c.Postgres.MigrationsDir != "", "postgres.migrations_dir is required")
+	check(c.Postgres.MaxOpenConns > 0, "postgres.max_open_conns must be positive")
+	check(c.Postgres.MaxIdleConns >= 0, "postgres.max_idle_conns must not be negative")
+	check(c.Postgres.MaxIdleConns <= c.Postgres.MaxOpenConns, "postgres.max_idle_conns must not exceed max_open_conns")
+	check(c.Postgres.ConnMaxLifetime > 0, "postgres.conn_max_lifetime must be positive")
+	check(c.Postgres.ConnMaxIdleTime > 0, "postgres.conn_max_idle_time must be positive")
+	check(c.Postgres.PingAttempts > 0, "postgres.ping_attempts must be positive")
+	check(c.Postgres.PingInterval > 0, "postgres.ping_interval must be positive")
+
+	check(validAddr(c.Redis.Addr), "redis.addr %q must be host:port", c.Redis.Addr)
+	check(c.Redis.DB >= 0, "redis.db must not be negative")
+	check(c.Redis.PoolSize > 0, "redis.pool_size must be positive")
+	check(c.Redis.DialTimeout > 0, "redis.dial_timeout must be positive")
+	check(c.Redis.ReadTimeout > 0, "redis.read_timeout must be positive")
+	check(c.Redis.WriteTimeout > 0, "redis.write_timeout must be positive")
+
+	check(validPort(c.GRPC.Port), "grpc.port %q must be a number in 1..65535", c.GRPC.Port)
+	check(c.GRPC.KeepaliveTime > 0, "grpc.keepalive_time must be positive")
+	check(c.GRPC.KeepaliveTimeout > 0, "grpc.keepalive_timeout must be positive")
+	check(c.GRPC.KeepaliveMinTime > 0, "grpc.keepalive_min_time must be positive")
+	check(c.GRPC.ReadinessTimeout > 0, "grpc.readiness_timeout must be positive")
+
+	if c.GRPC.TLS.Enabled {
+		check(c.GRPC.TLS.CertFile != "" && c.GRPC.TLS.KeyFile != "", "grpc.tls.cert_file and grpc.tls.key_file are required when TLS is enabled")
+		switch c.GRPC.TLS.ClientAuth {
+		case "", "none":
+		case "verify_if_given", "require":
+			check(c.GRPC.TLS.ClientCAFile != "", "grpc.tls.client_ca_file is required for client_auth %q", c.GRPC.TLS.ClientAuth)
+		default:
+			errs = append(errs, fmt.Errorf("grpc.tls.client_auth %q must be one of none, verify_if_given, require", c.GRPC.TLS.ClientAuth))
+		}
+		check((c.GRPC.ClientTLS.CertFile == "") == (c.GRPC.ClientTLS.KeyFile == ""), "grpc.client_tls.cert_file and key_file must be set together")
+		check(c.GRPC.TLS.ClientAuth != "require" || c.GRPC.ClientTLS.CertFile != "", "grpc.client_tls.cert_file is required when grpc.tls.client_auth is require")
+	}
+
+	check(validAddr(c.HTTP.Addr), "http.addr %q must be [host]:port", c.HTTP.Addr)
+	check(c.HTTP.ReadTimeout > 0, "http.read_timeout must be positive")
+	check(c.HTTP.WriteTimeout > 0, "http.write_timeout must be positive")
+	check(c.HTTP.IdleTimeout > 0, "http.idle_timeout must be positive")
+	for _, cidr := range c.HTTP.TrustedProxies {
+		_, err := netip.ParsePrefix(cidr)
+		check(err == nil, "http.trusted_proxies: %q is not a CIDR", cidr)
+	}
+	check(!c.HTTP.TLS.Enabled || (c.HTTP.TLS.CertFile != "" && c.HTTP.TLS.KeyFile != ""), "http.tls.cert_file and http.tls.key_file are required when TLS is enabled")
+
+	if c.Swagger.Enabled {
+		check(validAddr(c.Swagger.Addr), "swagger.addr %q must be [host]:port", c.Swagger.Addr)
+		check(c.Swagger.Addr != c.HTTP.Addr, "swagger.addr must differ from http.addr")
+		check(c.Swagger.SpecFile != "", "swagger.spec_file is required")
+		_, err := url.ParseRequestURI(c.Swagger.SpecURL)
+		check(err == nil, "swagger.spec_url %q is not a valid URL", c.Swagger.SpecURL)
+	}
+
+	check(c.Cache.UserTTL > 0, "cache.user_ttl must be positive")
+	check(c.Cache.IntrospectionTTL > 0, "cache.introspection_ttl must be positive")
+
+	check(len(c.CORS.AllowedOrigins) > 0, "cors.allowed_origins must not be empty")
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(validOrigin(origin), "cors.allowed_origins: %q must be * or scheme://host[:port]", origin)
+	}
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level %q must be one of debug, info, warn, error", c.Log.Level)
+	check(c.ShutdownTimeout > 0, "shutdown_timeout must be positive")
+	check(c.TLSReloadInterval > 0, "tls_reload_interval must be positive")
+
+	errs = append(errs, c.RateLimit.validate()...)
+
+	if c.Idempotency.Enabled {
+		check(c.Idempotency.TTL > 0, "idempotency.ttl must be positive")
+		check(c.Idempotency.LockTTL > 0, "idempotency.lock_ttl must be positive")
+		for _, m := range c.Idempotency.Methods {
+			check(strings.HasPrefix(m, "/"), "idempotency.methods: %q must be /package.Service/Method", m)
+		}
+	}
+
+	check(c.Search.MaxConcurrent > 0 && c.Search.MaxConcurrent < c.Postgres.MaxOpenConns,
+		"search.max_concurrent must be positive and less than postgres.max_open_conns")
+	check(c.Search.QueueTimeout >= 0, "search.queue_timeout must not be negative")
+	check(c.Search.StatementTimeout > 0, "search.statement_timeout must be positive")
+	check(c.Sessions.TTL > 0, "sessions.ttl must be positive")
+
+	check(c.Lockout.MaxFailures > 0, "lockout.max_failures must be positive")
+	check(c.Lockout.Window > 0, "lockout.window must be positive")
+	check(c.Lockout.LockDuration > 0, "lockout.lock_duration must be positive")
+	check(c.Lockout.DelayAfter >= 0, "lockout.delay_after must not be negative")
+	check(c.Lockout.BaseDelay >= 0, "lockout.base_delay must not be negative")
+	check(c.Lockout.MaxDelay >= c.Lockout.BaseDelay, "lockout.max_delay must not be less than base_delay")
+	check(c.Lockout.IPMaxFailures > 0, "lockout.ip_max_failures must be positive")
+	check(c.Lockout.IPLockDuration > 0, "lockout.ip_lock_duration must be positive")
+
+	if c.TwoFactor.EncryptionKey != "" {
+		key, err := base64.StdEncoding.DecodeString(c.TwoFactor.EncryptionKey)
+		check(err == nil && len(key) == 32, "two_factor.encryption_key must be 32 bytes in base64")
+	}
+	check(c.TwoFactor.Issuer != "" && !strings.Contains(c.TwoFactor.Issuer, ":"), "two_factor.issuer must be non-empty and must not contain ':'")
+	check(c.TwoFactor.RecoveryCodes > 0, "two_factor.recovery_codes must be positive")
+	check(c.TwoFactor.ChallengeTTL > 0, "two_factor.challenge_ttl must be positive")
+	check(c.TwoFactor.MaxAttempts > 0, "two_factor.max_attempts must be positive")
+
+	if c.MagicLink.Enabled {
+		check(c.MagicLink.TTL > 0, "magic_link.ttl must be positive")
+		u, err := url.Parse(c.MagicLink.URL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "magic_link.url %q is not a valid http(s) URL", c.MagicLink.URL)
+	}
+
+	if c.Privacy.ErasureHashKey != "" {
+		key, err := base64.StdEncoding.DecodeString(c.Privacy.ErasureHashKey)
+		check(err == nil && len(key) == 32, "privacy.erasure_hash_key must be 32 bytes in base64")
+	}
+
+	check(c.Organizations.InvitationTTL > 0, "organizations.invitation_ttl must be positive")
+	u, err := url.Parse(c.Organizations.InvitationURL)
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+		"organizations.invitation_url %q is not a valid http(s) URL", c.Organizations.InvitationURL)
+
+	if c.OIDC.Enabled {
+		u, err := url.Parse(c.OIDC.Issuer)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+			u.RawQuery == "" && u.Fragment == "" && !strings.HasSuffix(u.Path, "/"),
+			"oidc.issuer %q must be an http(s) URL without query, fragment and trailing slash", c.OIDC.Issuer)
+		u, err = url.Parse(c.OIDC.LoginURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "oidc.login_url %q is not a valid http(s) URL", c.OIDC.LoginURL)
+		check(c.OIDC.SessionCookie != "", "oidc.session_cookie is required")
+		check(c.OIDC.CodeTTL > 0, "oidc.code_ttl must be positive")
+		check(c.OIDC.TokenTTL > 0, "oidc.token_ttl must be positive")
+		check(c.SigningKeys.EncryptionKey != "", "oidc requires signing_keys.encryption_key")
+		check(c.SigningKeys.Retention >= c.OIDC.TokenTTL, "signing_keys.retention must not be less than oidc.token_ttl")
+	}
+
+	if c.SigningKeys.EncryptionKey != "" {
+		key, err := base64.StdEncoding.DecodeString(c.SigningKeys.EncryptionKey)
+		check(err == nil && len(key) == 32, "signing_keys.encryption_key must be 32 bytes in base64")
+		check(c.SigningKeys.Algorithm == "RS256" || c.SigningKeys.Algorithm == "EdDSA",
+			"signing_keys.algorithm %q must be RS256 or EdDSA", c.SigningKeys.Algorithm)
+		check(c.SigningKeys.PublishAhead > 0 && c.SigningKeys.PublishAhead < c.SigningKeys.Rotation,
+			"signing_keys.publish_ahead must be positive and less than rotation")
+		check(c.SigningKeys.Retention > 0, "signing_keys.retention must be positive")
+	}
+
+	check(c.Social.StateTTL > 0, "social.state_ttl must be positive")
+	for name, p := range c.Social.Providers() {
+		if !p.Enabled {
+			continue
+		}
+		u, err := url.Parse(p.Issuer)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "social.%s.issuer %q is not a valid http(s) URL", name, p.Issuer)
+		check(p.ClientID != "" && p.ClientSecret != "", "social.%s.client_id and client_secret are required", name)
+		u, err = url.Parse(p.RedirectURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "social.%s.redirect_url %q is not a valid http(s) URL", name, p.RedirectURL)
+		if name == "github" {
+			u, err = url.Parse(p.APIURL)
+			check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "social.github.api_url %q is not a valid http(s) URL", p.APIURL)
+		} else {
+			check(slices.Contains(p.Scopes, "openid"), "social.%s.scopes must include openid", name)
+		}
+	}
+
+	switch c.Mail.Backend {
+	case "log":
+	case "file":
+		check(c.Mail.Dir != "", "mail.dir is required for the file backend")
+	case "smtp":
+		check(validAddr(c.Mail.SMTP.Addr), "mail.smtp.addr %q must be host:port", c.Mail.SMTP.Addr)
+	default:
+		errs = append(errs, fmt.Errorf("mail.backend %q must be one of log, file, smtp", c.Mail.Backend))
+	}
+	check(c.Mail.From != "", "mail.from is required")
+
+	switch c.Notify.Backend {
+	case "log":
+	case "file":
+		check(c.Notify.File != "", "notify.file is required for the file backend")
+	default:
+		errs = append(errs, fmt.Errorf("notify.backend %q must be one of log, file", c.Notify.Backend))
+	}
+
+	check(c.Avatar.MaxBytes > 0, "avatar.max_bytes must be positive")
+	check(c.Avatar.MaxPixels > 0, "avatar.max_pixels must be positive")
+
+	switch c.Blob.Backend {
+	case "local":
+		check(c.Blob.Dir != "", "blob.dir is required for the local backend")
+	case "s3":
+		u, err := url.ParseRequestURI(c.Blob.S3.Endpoint)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https"), "blob.s3.endpoint %q is not a valid http(s) URL", c.Blob.S3.Endpoint)
+		check(c.Blob.S3.Region != "", "blob.s3.region is required for the s3 backend")
+		check(c.Blob.S3.Bucket != "", "blob.s3.bucket is required for the s3 backend")
+		check(c.Blob.S3.AccessKeyID != "" && c.Blob.S3.SecretAccessKey != "", "blob.s3.access_key_id and secret_access_key are required for the s3 backend")
+		check(c.Blob.S3.Timeout > 0, "blob.s3.timeout must be positive")
+	default:
+		errs = append(errs, fmt.Errorf("blob.backend %q must be one of local, s3", c.Blob.Backend))
+	}
+
+	switch c.Secrets.Provider {
+	case "":
+	case "file":
+		check(c.Secrets.Dir != "", "secrets.dir is required for the file provider")
+	case "vault":
+		_, err := url.ParseRequestURI(c.Secrets.Vault.Addr)
+		check(err == nil, "secrets.vault.addr %q is not a valid URL", c.Secrets.Vault.Addr)
+		check(c.Secrets.Vault.Token != "", "secrets.vault.token is required for the vault provider")
+		check(c.Secrets.Vault.Mount != "", "secrets.vault.mount is required for the vault provider")
+		check(c.Secrets.Vault.Timeout > 0, "secrets.vault.timeout must be positive")
+	default:
+		errs = append(errs, fmt.Errorf("secrets.provider %q must be one of file, vault", c.Secrets.Provider))
+	}
+	check(c.Secrets.RefreshInterval >= 0, "secrets.refresh_interval must not be negative")
+
+	return errors.Join(errs...)
+}
+
+func (c RateLimitConfig) validate() []error {
+	var errs []error
+	switch c.Backend {
+	case "redis", "memory":
+	default:
+		errs = append(errs, fmt.Errorf("rate_limit.backend %q must be one of redis, memory", c.Backend))
+	}
+
+	// шаблоны HTTP проверяем тем же ServeMux, что и при сопоставлении
+	mux := http.NewServeMux()
+	for i, rule := range c.HTTP {
+		if err := registerPattern(mux, rule.Pattern); err != nil {
+			errs = append(errs, fmt.Errorf("rate_limit.http[%d].pattern: %v", i, err))
+		}
+		errs = append(errs, rule.validate(fmt.Sprintf("rate_limit.http[%d]", i), "ip", "method")...)
+	}
+
+	seen := make(map[string]bool)
+	for i, rule := range c.GRPC {
+		prefix := fmt.Sprintf("rate_limit.grpc[%d]", i)
+		if rule.Pattern != "*" && !strings.HasPrefix(rule.Pattern, "/") {
+			errs = append(errs, fmt.Errorf("%s.pattern %q must be * or /package.Service/Method", prefix, rule.Pattern))
+		}
+		if seen[rule.Pattern] {
+			errs = append(errs, fmt.Errorf("%s.pattern %q is duplicated", prefix, rule.Pattern))
+		}
+		seen[rule.Pattern] = true
+		errs = append(errs, rule.validate(prefix, "ip", "principal", "method")...)
+	}
+
+	return errs
+}
+
+func (r RateLimitRule) validate(prefix string, keys ...string) []error {
+	var errs []error
+	if !slices.Contains(keys, r.Key) {
+		errs = append(errs, fmt.Errorf("%s.key %q must be one of %s", prefix, r.Key, strings.Join(keys, ", ")))
+	}
+	if r.Requests <= 0 {
+		errs = append(errs, fmt.Errorf("%s.requests must be positive", prefix))
+	}
+	if r.Per <= 0 {
+		errs = append(errs, fmt.Errorf("%s.per must be positive", prefix))
+	}
+	if r.Burst < 0 {
+		errs = append(errs, fmt.Errorf("%s.burst must not be negative", prefix))
+	}
+	return errs
+}
+
+func registerPattern(mux *http.ServeMux, pattern string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	mux.Handle(pattern, http.NotFoundHandler())
+	return nil
+}
+
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Scheme != "" && u.Host != "" && (u.Path == "" || u.Path == "/")
+}
+
+func validAddr(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
+	return err == nil && validPort(port)
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
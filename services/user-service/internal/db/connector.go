@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+
+	"github.com/lib/pq"
+)
+
+// Connector открывает новые соединения по текущему DSN. После SetDSN пул
+// постепенно переходит на новые учётные данные: существующие соединения
+// доживают до ConnMaxLifetime, новые открываются уже с новым DSN.
+type Connector struct {
+	dsn atomic.Pointer[string]
+}
+
+func NewConnector(dsn string) *Connector {
+	c := &Connector{}
+	c.SetDSN(dsn)
+	return c
+}
+
+func (c *Connector) SetDSN(dsn string) {
+	c.dsn.Store(&dsn)
+}
+
+func (c *Connector) DSN() string {
+	return *c.dsn.Load()
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	connector, err := pq.NewConnector(c.DSN())
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
@@ -1,41 +1,48 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"fmt"
 	"time"
 
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
 	_ "github.com/lib/pq"
 )
 
-func NewPostgres(dsn string) *sql.DB {
-	log.Printf("Connecting to PostgreSQL with DSN: %s", dsn)
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		log.Fatal("failed to connect to postgres:", err)
-	}
+func NewPostgres(ctx context.Context, cfg config.PostgresConfig, connector *Connector) (*sql.DB, error) {
+	logger.Infof("Connecting to PostgreSQL...")
+	db := sql.OpenDB(connector)
 
 	// Установите настройки пула соединений
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(10)
-	db.SetConnMaxLifetime(5 * time.Minute)
-	db.SetConnMaxIdleTime(2 * time.Minute)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	// Пинг с ретраями
 	var pingErr error
-	for i := 0; i < 5; i++ {
-		pingErr = db.Ping()
+	for i := 0; i < cfg.PingAttempts; i++ {
+		pingErr = db.PingContext(ctx)
 		if pingErr == nil {
 			break
 		}
-		log.Printf("PostgreSQL ping attempt %d failed: %v", i+1, pingErr)
-		time.Sleep(2 * time.Second)
+		logger.Warnf("PostgreSQL ping attempt %d failed: %v", i+1, pingErr)
+
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, ctx.Err()
+		case <-time.After(cfg.PingInterval):
+		}
 	}
 
 	if pingErr != nil {
-		log.Fatalf("postgres ping not responding after retries: %v", pingErr)
+		db.Close()
+		return nil, fmt.Errorf("postgres ping not responding after retries: %w", pingErr)
 	}
 
-	log.Println("PostgreSQL connected successfully")
-	return db
+	logger.Infof("PostgreSQL connected successfully")
+	return db, nil
 }
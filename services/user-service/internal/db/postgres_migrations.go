@@ -2,26 +2,27 @@ package db
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
 )
 
-func RunMigrations(dsn string) error {
-	m, err := migrate.New("file://./migrations", dsn)
+func RunMigrations(dir, dsn string) error {
+	m, err := migrate.New("file://"+dir, dsn)
 	if err != nil {
 		return fmt.Errorf("failed to create migrate instance: %v", err)
 	}
 	defer m.Close()
 	version, dirty, err := m.Version()
-	log.Printf("Current version: %d, dirty: %v, err: %v", version, dirty, err)
+	logger.Debugf("Current version: %d, dirty: %v, err: %v", version, dirty, err)
 
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
 		return fmt.Errorf("failed to run migrations: %v", err)
 	}
 
-	log.Println("Migrations applied successfully")
+	logger.Infof("Migrations applied successfully")
 	return nil
 }
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *UserHandler) CreateAPIKey(ctx context.Context, req *userv1.CreateAPIKeyRequest) (*userv1.CreateAPIKeyResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		t := req.ExpiresAt.AsTime()
+		expiresAt = &t
+	}
+	key, token, err := h.apiKeys.Create(ctx, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		logger.Warnf("UserHandler - CreateAPIKey: Service error for %s: %v", req.Name, err)
+		return nil, publicError(err)
+	}
+	return &userv1.CreateAPIKeyResponse{ApiKey: toAPIKey(key), Key: token}, nil
+}
+
+func (h *UserHandler) ListAPIKeys(ctx context.Context, req *userv1.ListAPIKeysRequest) (*userv1.ListAPIKeysResponse, error) {
+	keys, err := h.apiKeys.List(ctx)
+	if err != nil {
+		logger.Warnf("UserHandler - ListAPIKeys: Service error: %v", err)
+		return nil, publicError(err)
+	}
+
+	resp := &userv1.ListAPIKeysResponse{ApiKeys: make([]*userv1.APIKey, 0, len(keys))}
+	for i := range keys {
+		resp.ApiKeys = append(resp.ApiKeys, toAPIKey(&keys[i]))
+	}
+	return resp, nil
+}
+
+func (h *UserHandler) RevokeAPIKey(ctx context.Context, req *userv1.RevokeAPIKeyRequest) (*emptypb.Empty, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	if err := h.apiKeys.Revoke(ctx, req.KeyId); err != nil {
+		logger.Warnf("UserHandler - RevokeAPIKey: Service error for %s: %v", req.KeyId, err)
+		return nil, publicError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func toAPIKey(k *model.APIKey) *userv1.APIKey {
+	return &userv1.APIKey{
+		KeyId:      k.ID,
+		TenantId:   k.TenantID,
+		Name:       k.Name,
+		Scopes:     k.Scopes,
+		CreatedBy:  k.CreatedBy,
+		CreatedAt:  timestamppb.New(k.CreatedAt),
+		ExpiresAt:  optionalTimestamp(k.ExpiresAt),
+		LastUsedAt: optionalTimestamp(k.LastUsedAt),
+		RevokedAt:  optionalTimestamp(k.RevokedAt),
+	}
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
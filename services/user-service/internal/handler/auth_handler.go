@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"github.com/DmitriiPro/user-service/internal/service"
+	"github.com/DmitriiPro/user-service/internal/session"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *UserHandler) Login(ctx context.Context, req *userv1.LoginRequest) (*userv1.LoginResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	result, err := h.auth.Login(ctx, req.Email, req.Password)
+	if err != nil {
+		logger.Warnf("UserHandler - Login: Service error for %s: %v", req.Email, err)
+		setRetryAfter(ctx, err)
+		return nil, publicError(err)
+	}
+
+	return toLoginResponse(result), nil
+}
+
+func (h *UserHandler) VerifySecondFactor(ctx context.Context, req *userv1.VerifySecondFactorRequest) (*userv1.LoginResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	result, err := h.auth.VerifySecondFactor(ctx, req.MfaToken, req.Code, req.RecoveryCode)
+	if err != nil {
+		logger.Warnf("UserHandler - VerifySecondFactor: Service error: %v", err)
+		setRetryAfter(ctx, err)
+		return nil, publicError(err)
+	}
+
+	return toLoginResponse(result), nil
+}
+
+func (h *UserHandler) RequestMagicLink(ctx context.Context, req *userv1.RequestMagicLinkRequest) (*emptypb.Empty, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	if err := h.magicLinks.Request(ctx, req.Email); err != nil {
+		logger.Warnf("UserHandler - RequestMagicLink: Service error for %s: %v", req.Email, err)
+		return nil, publicError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserHandler) ConsumeMagicLink(ctx context.Context, req *userv1.ConsumeMagicLinkRequest) (*userv1.LoginResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	result, err := h.magicLinks.Consume(ctx, req.Token)
+	if err != nil {
+		logger.Warnf("UserHandler - ConsumeMagicLink: Service error: %v", err)
+		return nil, publicError(err)
+	}
+	return toLoginResponse(result), nil
+}
+
+func (h *UserHandler) StartSocialLogin(ctx context.Context, req *userv1.StartSocialLoginRequest) (*userv1.StartSocialLoginResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	authURL, err := h.social.Start(ctx, req.Provider)
+	if err != nil {
+		logger.Warnf("UserHandler - StartSocialLogin: Service error for %s: %v", req.Provider, err)
+		return nil, publicError(err)
+	}
+	return &userv1.StartSocialLoginResponse{AuthorizationUrl: authURL}, nil
+}
+
+func (h *UserHandler) CompleteSocialLogin(ctx context.Context, req *userv1.CompleteSocialLoginRequest) (*userv1.LoginResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	result, err := h.social.Complete(ctx, req.Provider, req.Code, req.State)
+	if err != nil {
+		logger.Warnf("UserHandler - CompleteSocialLogin: Service error for %s: %v", req.Provider, err)
+		return nil, publicError(err)
+	}
+	return toLoginResponse(result), nil
+}
+
+func toLoginResponse(r *service.LoginResult) *userv1.LoginResponse {
+	if r.MFAToken != "" {
+		return &userv1.LoginResponse{MfaRequired: true, MfaToken: r.MFAToken}
+	}
+	return &userv1.LoginResponse{SessionToken: r.SessionToken, Session: toSession(r.Session, r.Session.ID)}
+}
+
+func (h *UserHandler) ListSessions(ctx context.Context, req *userv1.ListSessionsRequest) (*userv1.ListSessionsResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	sessions, err := h.auth.ListSessions(ctx, req.UserId)
+	if err != nil {
+		logger.Warnf("UserHandler - ListSessions: Service error for ID %d: %v", req.UserId, err)
+		return nil, publicError(err)
+	}
+
+	current := currentSessionID(ctx)
+	resp := &userv1.ListSessionsResponse{}
+	for i := range sessions {
+		resp.Sessions = append(resp.Sessions, toSession(&sessions[i], current))
+	}
+	return resp, nil
+}
+
+func (h *UserHandler) RevokeSession(ctx context.Context, req *userv1.RevokeSessionRequest) (*emptypb.Empty, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	if err := h.auth.RevokeSession(ctx, req.UserId, req.SessionId); err != nil {
+		logger.Warnf("UserHandler - RevokeSession: Service error for ID %d: %v", req.UserId, err)
+		return nil, publicError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserHandler) RevokeAllSessions(ctx context.Context, req *userv1.RevokeAllSessionsRequest) (*userv1.RevokeAllSessionsResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	n, err := h.auth.RevokeAllSessions(ctx, req.UserId, req.KeepCurrent)
+	if err != nil {
+		logger.Warnf("UserHandler - RevokeAllSessions: Service error for ID %d: %v", req.UserId, err)
+		return nil, publicError(err)
+	}
+	return &userv1.RevokeAllSessionsResponse{Revoked: int32(n)}, nil
+}
+
+func (h *UserHandler) UnlockUser(ctx context.Context, req *userv1.UnlockUserRequest) (*emptypb.Empty, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	if err := h.auth.UnlockUser(ctx, req.UserId); err != nil {
+		logger.Warnf("UserHandler - UnlockUser: Service error for ID %d: %v", req.UserId, err)
+		return nil, publicError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserHandler) ListLoginEvents(ctx context.Context, req *userv1.ListLoginEventsRequest) (*userv1.ListLoginEventsResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	events, next, err := h.auth.ListLoginEvents(ctx, req.UserId, int(req.PageSize), req.PageToken)
+	if err != nil {
+		logger.Warnf("UserHandler - ListLoginEvents: Service error for ID %d: %v", req.UserId, err)
+		return nil, publicError(err)
+	}
+
+	resp := &userv1.ListLoginEventsResponse{NextPageToken: next}
+	for _, e := range events {
+		resp.Events = append(resp.Events, &userv1.LoginEvent{
+			Id:            e.ID,
+			UserId:        e.UserID,
+			Email:         e.Email,
+			Method:        e.Method,
+			Ip:            e.IP,
+			UserAgent:     e.UserAgent,
+			Success:       e.Success,
+			FailureReason: e.FailureReason,
+			CreatedAt:     timestamppb.New(e.CreatedAt),
+		})
+	}
+	return resp, nil
+}
+
+// setRetryAfter передаёт задержку из RetryInfo в заголовок retry-after
+// (через gateway — Retry-After).
+func setRetryAfter(ctx context.Context, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			seconds := int(math.Ceil(info.GetRetryDelay().AsDuration().Seconds()))
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(max(1, seconds))))
+			return
+		}
+	}
+}
+
+func currentSessionID(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		return p.SessionID
+	}
+	return ""
+}
+
+func toSession(s *session.Session, current string) *userv1.Session {
+	return &userv1.Session{
+		Id:        s.ID,
+		UserId:    s.UserID,
+		CreatedAt: timestamppb.New(s.CreatedAt),
+		ExpiresAt: timestamppb.New(s.ExpiresAt),
+		Ip:        s.IP,
+		UserAgent: s.UserAgent,
+		Current:   current != "" && s.ID == current,
+	}
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/avatar"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"github.com/DmitriiPro/user-service/internal/service"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+)
+
+const (
+	avatarPathPattern = "/v1/users/{id}/avatar"
+	avatarChunkSize   = 64 << 10
+	// запас на заголовки multipart поверх самого файла
+	multipartOverhead = 64 << 10
+)
+
+// AvatarUploadHTTP — multipart POST /v1/users/{id}/avatar (поле file).
+// Файл потоком передаётся в UploadAvatar через клиент gateway, поэтому
+// проходит те же интерсепторы, что и обычные вызовы.
+func AvatarUploadHTTP(mux *runtime.ServeMux, client userv1.UserServiceClient, maxBytes int) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, userv1.UserService_UploadAvatar_FullMethodName,
+			runtime.WithHTTPPathPattern(avatarPathPattern))
+		if err != nil {
+			runtime.HTTPError(r.Context(), mux, outbound, w, r, err)
+			return
+		}
+		// при раннем выходе отмена закрывает незавершённый поток
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		id, err := strconv.ParseInt(pathParams["id"], 10, 64)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, apperr.InvalidFields(apperr.FieldViolation("id", "value must be an integer")))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes+multipartOverhead))
+		file, err := multipartFile(r, "file")
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, apperr.InvalidFields(apperr.FieldViolation("file", err.Error())))
+			return
+		}
+
+		var md runtime.ServerMetadata
+		stream, err := client.UploadAvatar(ctx, grpc.Header(&md.HeaderMD), grpc.Trailer(&md.TrailerMD))
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+
+		err = stream.Send(&userv1.UploadAvatarRequest{Data: &userv1.UploadAvatarRequest_Info{
+			Info: &userv1.AvatarInfo{UserId: id},
+		}})
+		buf := make([]byte, avatarChunkSize)
+		for err == nil {
+			n, readErr := file.Read(buf)
+			if n > 0 {
+				err = stream.Send(&userv1.UploadAvatarRequest{Data: &userv1.UploadAvatarRequest_Chunk{Chunk: buf[:n]}})
+			}
+			if errors.Is(readErr, io.EOF) {
+				break
+			}
+			if readErr != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(readErr, &tooLarge) {
+					readErr = fmt.Errorf("image must be at most %d bytes", maxBytes)
+				}
+				runtime.HTTPError(ctx, mux, outbound, w, r, apperr.InvalidFields(apperr.FieldViolation("file", readErr.Error())))
+				return
+			}
+		}
+		// io.EOF от Send значит, что сервер уже завершил вызов — настоящая
+		// ошибка придёт из CloseAndRecv
+		if err != nil && !errors.Is(err, io.EOF) {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+
+		resp, err := stream.CloseAndRecv()
+		ctx = runtime.NewServerMetadataContext(ctx, md)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+
+		runtime.ForwardResponseMessage(ctx, mux, outbound, w, r, resp)
+	}
+}
+
+// multipartFile возвращает содержимое части формы с именем field, не
+// буферизуя файл целиком.
+func multipartFile(r *http.Request, field string) (io.Reader, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("request must be multipart/form-data")
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("form field %q is missing", field)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == field {
+			return part, nil
+		}
+	}
+}
+
+// AvatarServeHTTP — GET /v1/users/{id}/avatar?size=256. Отвечает с ETag и
+// 304 на совпадающий If-None-Match. URL из avatar_url содержит версию (v),
+// и такие ответы кэшируются как неизменяемые.
+func AvatarServeHTTP(mux *runtime.ServeMux, avatars service.AvatarService) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := r.Context()
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+
+		id, err := strconv.ParseInt(pathParams["id"], 10, 64)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, apperr.InvalidFields(apperr.FieldViolation("id", "value must be an integer")))
+			return
+		}
+
+		size := avatar.DefaultSize
+		if raw := r.URL.Query().Get("size"); raw != "" {
+			if size, err = strconv.Atoi(raw); err != nil {
+				runtime.HTTPError(ctx, mux, outbound, w, r, apperr.InvalidFields(apperr.FieldViolation("size", "value must be an integer")))
+				return
+			}
+		}
+
+		obj, err := avatars.Open(ctx, id, size)
+		if err != nil {
+			logger.Errorf("UserHandler - AvatarServeHTTP: Error for ID %d: %v", id, err)
+			runtime.HTTPError(ctx, mux, outbound, w, r, publicError(err))
+			return
+		}
+		defer obj.Body.Close()
+
+		h := w.Header()
+		h.Set("ETag", obj.ETag)
+		h.Set("X-Content-Type-Options", "nosniff")
+		if r.URL.Query().Get("v") != "" {
+			h.Set("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			h.Set("Cache-Control", "public, no-cache")
+		}
+		if !obj.ModTime.IsZero() {
+			h.Set("Last-Modified", obj.ModTime.UTC().Format(http.TimeFormat))
+		}
+
+		if etagMatches(r.Header.Get("If-None-Match"), obj.ETag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		h.Set("Content-Type", obj.ContentType)
+		if obj.Size >= 0 {
+			h.Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+		}
+		if r.Method == http.MethodHead {
+			return
+		}
+		if _, err := io.Copy(w, obj.Body); err != nil {
+			logger.Errorf("UserHandler - AvatarServeHTTP: Error writing avatar for ID %d: %v", id, err)
+		}
+	}
+}
+
+// etagMatches сравнивает If-None-Match с ETag (слабое сравнение, RFC 9110).
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
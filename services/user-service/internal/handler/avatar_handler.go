@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+)
+
+func (h *UserHandler) UploadAvatar(stream userv1.UserService_UploadAvatarServer) error {
+	ctx := stream.Context()
+
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	info := first.GetInfo()
+	if info == nil {
+		return apperr.InvalidFields(apperr.FieldViolation("info", "first message must contain avatar info"))
+	}
+	if err := info.ValidateAll(); err != nil {
+		return apperr.Validation(err)
+	}
+
+	var data []byte
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		chunk := msg.GetChunk()
+		if len(data)+len(chunk) > h.avatarMaxBytes {
+			logger.Warnf("UserHandler - UploadAvatar: File too large for ID %d", info.UserId)
+			return apperr.InvalidFields(apperr.FieldViolation("file",
+				fmt.Sprintf("image must be at most %d bytes", h.avatarMaxBytes)))
+		}
+		data = append(data, chunk...)
+	}
+	if len(data) == 0 {
+		return apperr.InvalidFields(apperr.FieldViolation("file", "image is empty"))
+	}
+
+	logger.Debugf("UserHandler - UploadAvatar: Received %d bytes for ID %d", len(data), info.UserId)
+
+	user, err := h.avatars.Upload(ctx, info.UserId, data)
+	if err != nil {
+		logger.Warnf("UserHandler - UploadAvatar: Service error for ID %d: %v", info.UserId, err)
+		return publicError(err)
+	}
+
+	return stream.SendAndClose(toUserResponse(user))
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/DmitriiPro/user-service/internal/blob"
+	"github.com/DmitriiPro/user-service/internal/model"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type uploadStream struct {
+	grpc.ServerStream
+	msgs []*userv1.UploadAvatarRequest
+	resp *userv1.GetUserResponse
+}
+
+func (s *uploadStream) Context() context.Context { return context.Background() }
+
+func (s *uploadStream) Recv() (*userv1.UploadAvatarRequest, error) {
+	if len(s.msgs) == 0 {
+		return nil, io.EOF
+	}
+	msg := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, nil
+}
+
+func (s *uploadStream) SendAndClose(resp *userv1.GetUserResponse) error {
+	s.resp = resp
+	return nil
+}
+
+type recordingAvatars struct {
+	uploaded []byte
+}
+
+func (a *recordingAvatars) Upload(_ context.Context, userID int64, data []byte) (*model.User, error) {
+	a.uploaded = data
+	return &model.User{ID: userID}, nil
+}
+
+func (a *recordingAvatars) Open(context.Context, int64, int) (*blob.Object, error) {
+	return nil, blob.ErrNotFound
+}
+
+func TestUploadAvatarSizeLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks [][]byte
+		want   codes.Code
+	}{
+		{"at limit", [][]byte{make([]byte, 6), make([]byte, 4)}, codes.OK},
+		{"over limit", [][]byte{make([]byte, 6), make([]byte, 5)}, codes.InvalidArgument},
+		{"empty", nil, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			avatars := &recordingAvatars{}
+			h := &UserHandler{avatars: avatars, avatarMaxBytes: 10}
+			stream := &uploadStream{msgs: []*userv1.UploadAvatarRequest{
+				{Data: &userv1.UploadAvatarRequest_Info{Info: &userv1.AvatarInfo{UserId: 1}}},
+			}}
+			for _, c := range tt.chunks {
+				stream.msgs = append(stream.msgs, &userv1.UploadAvatarRequest{Data: &userv1.UploadAvatarRequest_Chunk{Chunk: c}})
+			}
+
+			err := h.UploadAvatar(stream)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %v (%v), want %v", got, err, tt.want)
+			}
+			if uploaded := avatars.uploaded != nil; uploaded != (tt.want == codes.OK) {
+				t.Fatalf("service called = %v, want %v", uploaded, tt.want == codes.OK)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *UserHandler) Introspect(ctx context.Context, req *userv1.IntrospectRequest) (*userv1.IntrospectResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	res, err := h.introspection.Introspect(ctx, req.Token)
+	if err != nil {
+		logger.Warnf("UserHandler - Introspect: Service error: %v", err)
+		return nil, publicError(err)
+	}
+	return &userv1.IntrospectResponse{
+		Active:    res.Active,
+		TokenType: res.TokenType,
+		Sub:       res.Subject,
+		Scope:     res.Scope,
+		ClientId:  res.ClientID,
+		SessionId: res.SessionID,
+		TenantId:  res.TenantID,
+		IssuedAt:  zeroableTimestamp(res.IssuedAt),
+		ExpiresAt: zeroableTimestamp(res.ExpiresAt),
+	}, nil
+}
+
+func zeroableTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
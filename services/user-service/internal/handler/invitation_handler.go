@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *UserHandler) CreateInvitation(ctx context.Context, req *userv1.CreateInvitationRequest) (*userv1.Invitation, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	inv, err := h.invitations.Create(ctx, req.OrganizationId, req.Email, model.Role(req.Role))
+	if err != nil {
+		logger.Warnf("UserHandler - CreateInvitation: Service error for %d: %v", req.OrganizationId, err)
+		return nil, publicError(err)
+	}
+	return toInvitation(inv), nil
+}
+
+func (h *UserHandler) ListInvitations(ctx context.Context, req *userv1.ListInvitationsRequest) (*userv1.ListInvitationsResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	invitations, err := h.invitations.List(ctx, req.OrganizationId)
+	if err != nil {
+		logger.Warnf("UserHandler - ListInvitations: Service error for %d: %v", req.OrganizationId, err)
+		return nil, publicError(err)
+	}
+
+	resp := &userv1.ListInvitationsResponse{Invitations: make([]*userv1.Invitation, 0, len(invitations))}
+	for i := range invitations {
+		resp.Invitations = append(resp.Invitations, toInvitation(&invitations[i]))
+	}
+	return resp, nil
+}
+
+func (h *UserHandler) RevokeInvitation(ctx context.Context, req *userv1.RevokeInvitationRequest) (*emptypb.Empty, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	if err := h.invitations.Revoke(ctx, req.OrganizationId, req.InvitationId); err != nil {
+		logger.Warnf("UserHandler - RevokeInvitation: Service error for %d: %v", req.InvitationId, err)
+		return nil, publicError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserHandler) AcceptInvitation(ctx context.Context, req *userv1.AcceptInvitationRequest) (*userv1.AcceptInvitationResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	res, err := h.invitations.Accept(ctx, req.Token, req.Password)
+	if err != nil {
+		logger.Warnf("UserHandler - AcceptInvitation: Service error: %v", err)
+		return nil, publicError(err)
+	}
+	return &userv1.AcceptInvitationResponse{
+		OrganizationId: res.OrganizationID,
+		UserId:         res.UserID,
+		Created:        res.Created,
+	}, nil
+}
+
+func (h *UserHandler) DeclineInvitation(ctx context.Context, req *userv1.DeclineInvitationRequest) (*emptypb.Empty, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	if err := h.invitations.Decline(ctx, req.Token); err != nil {
+		logger.Warnf("UserHandler - DeclineInvitation: Service error: %v", err)
+		return nil, publicError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func toInvitation(inv *model.Invitation) *userv1.Invitation {
+	return &userv1.Invitation{
+		InvitationId:   inv.ID,
+		OrganizationId: inv.OrganizationID,
+		Email:          inv.Email,
+		Role:           string(inv.Role),
+		InvitedBy:      inv.InvitedBy,
+		CreatedAt:      timestamppb.New(inv.CreatedAt),
+		ExpiresAt:      timestamppb.New(inv.ExpiresAt),
+	}
+}
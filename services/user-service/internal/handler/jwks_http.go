@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DmitriiPro/user-service/internal/signing"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// jwksCacheControl: клиенты перечитывают JWKS намного чаще, чем новый ключ
+// появляется в нём до начала подписи (signing_keys.publish_ahead).
+const jwksCacheControl = "public, max-age=900"
+
+// JWKSHTTP — GET /.well-known/jwks.json: открытые ключи подписи токенов.
+func JWKSHTTP(keys *signing.KeyManager) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.Header().Set("Cache-Control", jwksCacheControl)
+		writeJSON(w, http.StatusOK, map[string]any{"keys": keys.JWKS()})
+	}
+}
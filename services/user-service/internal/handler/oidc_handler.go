@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *UserHandler) CreateOIDCClient(ctx context.Context, req *userv1.CreateOIDCClientRequest) (*userv1.CreateOIDCClientResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	client, secret, err := h.oidc.CreateClient(ctx, req.Name, req.RedirectUris, req.Public)
+	if err != nil {
+		logger.Warnf("UserHandler - CreateOIDCClient: Service error for %s: %v", req.Name, err)
+		return nil, publicError(err)
+	}
+	return &userv1.CreateOIDCClientResponse{Client: toOIDCClient(client), ClientSecret: secret}, nil
+}
+
+func (h *UserHandler) ListOIDCClients(ctx context.Context, req *userv1.ListOIDCClientsRequest) (*userv1.ListOIDCClientsResponse, error) {
+	clients, err := h.oidc.ListClients(ctx)
+	if err != nil {
+		logger.Warnf("UserHandler - ListOIDCClients: Service error: %v", err)
+		return nil, publicError(err)
+	}
+
+	resp := &userv1.ListOIDCClientsResponse{Clients: make([]*userv1.OIDCClient, 0, len(clients))}
+	for i := range clients {
+		resp.Clients = append(resp.Clients, toOIDCClient(&clients[i]))
+	}
+	return resp, nil
+}
+
+func (h *UserHandler) DeleteOIDCClient(ctx context.Context, req *userv1.DeleteOIDCClientRequest) (*emptypb.Empty, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	if err := h.oidc.DeleteClient(ctx, req.ClientId); err != nil {
+		logger.Warnf("UserHandler - DeleteOIDCClient: Service error for %s: %v", req.ClientId, err)
+		return nil, publicError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func toOIDCClient(c *model.OIDCClient) *userv1.OIDCClient {
+	return &userv1.OIDCClient{
+		ClientId:     c.ID,
+		Name:         c.Name,
+		RedirectUris: c.RedirectURIs,
+		Public:       c.Public(),
+		CreatedAt:    timestamppb.New(c.CreatedAt),
+	}
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/oidc"
+	"github.com/DmitriiPro/user-service/internal/service"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// oidcFormMaxBytes — предел тела запросов к /oauth2/*: там только
+// короткие параметры формы.
+const oidcFormMaxBytes = 64 << 10
+
+// OIDCDiscoveryHTTP — GET /.well-known/openid-configuration.
+func OIDCDiscoveryHTTP(svc service.OIDCService) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		writeJSON(w, http.StatusOK, svc.Discovery())
+	}
+}
+
+// OIDCAuthorizeHTTP — GET/POST /oauth2/authorize. Пользователь опознаётся
+// по токену сессии из cookie или заголовка Authorization; без сессии он
+// уходит на страницу входа и возвращается сюда по return_to.
+func OIDCAuthorizeHTTP(svc service.OIDCService, cfg config.OIDCConfig) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		r.Body = http.MaxBytesReader(w, r.Body, oidcFormMaxBytes)
+		if err := r.ParseForm(); err != nil {
+			writeOAuthError(w, &oidc.Error{Code: oidc.ErrorInvalidRequest, Description: "malformed request"})
+			return
+		}
+
+		req := service.AuthorizeRequest{
+			ResponseType:        r.Form.Get("response_type"),
+			ClientID:            r.Form.Get("client_id"),
+			RedirectURI:         r.Form.Get("redirect_uri"),
+			Scope:               r.Form.Get("scope"),
+			State:               r.Form.Get("state"),
+			Nonce:               r.Form.Get("nonce"),
+			CodeChallenge:       r.Form.Get("code_challenge"),
+			CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+			Prompt:              r.Form.Get("prompt"),
+			SessionToken:        bearerToken(r),
+		}
+		if req.SessionToken == "" {
+			if cookie, err := r.Cookie(cfg.SessionCookie); err == nil {
+				req.SessionToken = cookie.Value
+			}
+		}
+
+		redirect, err := svc.Authorize(r.Context(), req)
+		var oauthErr *oidc.Error
+		switch {
+		case err == nil:
+			http.Redirect(w, r, redirect, http.StatusFound)
+		case errors.Is(err, service.ErrLoginRequired):
+			// после входа страница вернёт пользователя на тот же запрос (GET)
+			login, _ := url.Parse(cfg.LoginURL)
+			q := login.Query()
+			q.Set("return_to", cfg.Issuer+"/oauth2/authorize?"+r.Form.Encode())
+			login.RawQuery = q.Encode()
+			http.Redirect(w, r, login.String(), http.StatusFound)
+		case errors.As(err, &oauthErr) && oauthErr.RedirectURI != "":
+			http.Redirect(w, r, errorRedirect(oauthErr, cfg.Issuer), http.StatusFound)
+		case errors.As(err, &oauthErr):
+			writeOAuthError(w, oauthErr)
+		default:
+			logger.Errorf("UserHandler - OIDCAuthorizeHTTP: Error for client %s: %v", req.ClientID, err)
+			writeServerError(w)
+		}
+	}
+}
+
+// OIDCTokenHTTP — POST /oauth2/token. Клиент передаёт секрет в заголовке
+// Authorization (client_secret_basic) или в теле (client_secret_post).
+func OIDCTokenHTTP(svc service.OIDCService) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+
+		r.Body = http.MaxBytesReader(w, r.Body, oidcFormMaxBytes)
+		if err := r.ParseForm(); err != nil {
+			writeOAuthError(w, &oidc.Error{Code: oidc.ErrorInvalidRequest, Description: "malformed request"})
+			return
+		}
+
+		req := service.TokenRequest{
+			GrantType:    r.PostForm.Get("grant_type"),
+			Code:         r.PostForm.Get("code"),
+			RedirectURI:  r.PostForm.Get("redirect_uri"),
+			ClientID:     r.PostForm.Get("client_id"),
+			ClientSecret: r.PostForm.Get("client_secret"),
+			CodeVerifier: r.PostForm.Get("code_verifier"),
+		}
+		rawID, rawSecret, basic := r.BasicAuth()
+		if basic {
+			if req.ClientSecret != "" {
+				writeOAuthError(w, &oidc.Error{Code: oidc.ErrorInvalidRequest, Description: "use only one client authentication method"})
+				return
+			}
+			// RFC 6749, раздел 2.3.1: id и секрет закодированы как в форме
+			id, errID := url.QueryUnescape(rawID)
+			secret, errSecret := url.QueryUnescape(rawSecret)
+			if errID != nil || errSecret != nil || (req.ClientID != "" && req.ClientID != id) {
+				writeOAuthError(w, &oidc.Error{Code: oidc.ErrorInvalidRequest, Description: "malformed client credentials"})
+				return
+			}
+			req.ClientID, req.ClientSecret = id, secret
+		}
+
+		resp, err := svc.Token(r.Context(), req)
+		var oauthErr *oidc.Error
+		switch {
+		case err == nil:
+			writeJSON(w, http.StatusOK, resp)
+		case errors.As(err, &oauthErr):
+			if oauthErr.Code == oidc.ErrorInvalidClient && basic {
+				w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+			}
+			writeOAuthError(w, oauthErr)
+		default:
+			logger.Errorf("UserHandler - OIDCTokenHTTP: Error for client %s: %v", req.ClientID, err)
+			writeServerError(w)
+		}
+	}
+}
+
+// OIDCUserInfoHTTP — GET/POST /oauth2/userinfo с access токеном в
+// заголовке Authorization.
+func OIDCUserInfoHTTP(svc service.OIDCService) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.Header().Set("Cache-Control", "no-store")
+
+		info, err := svc.UserInfo(r.Context(), bearerToken(r))
+		var oauthErr *oidc.Error
+		switch {
+		case err == nil:
+			writeJSON(w, http.StatusOK, info)
+		case errors.As(err, &oauthErr):
+			// RFC 6750, раздел 3
+			w.Header().Set("WWW-Authenticate", `Bearer error="`+oauthErr.Code+`"`)
+			writeOAuthError(w, oauthErr)
+		default:
+			logger.Errorf("UserHandler - OIDCUserInfoHTTP: Error: %v", err)
+			writeServerError(w)
+		}
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// errorRedirect возвращает ошибку авторизации клиенту (RFC 6749, раздел 4.1.2.1).
+func errorRedirect(e *oidc.Error, issuer string) string {
+	u, err := url.Parse(e.RedirectURI)
+	if err != nil {
+		return e.RedirectURI
+	}
+	q := u.Query()
+	q.Set("error", e.Code)
+	q.Set("error_description", e.Description)
+	if e.State != "" {
+		q.Set("state", e.State)
+	}
+	q.Set("iss", issuer)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func writeOAuthError(w http.ResponseWriter, e *oidc.Error) {
+	writeJSON(w, e.Status(), map[string]string{"error": e.Code, "error_description": e.Description})
+}
+
+func writeServerError(w http.ResponseWriter) {
+	writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Errorf("UserHandler - writeJSON: %v", err)
+	}
+}
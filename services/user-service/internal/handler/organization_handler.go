@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *UserHandler) CreateOrganization(ctx context.Context, req *userv1.CreateOrganizationRequest) (*userv1.Organization, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	org, err := h.organizations.Create(ctx, req.Name, req.OwnerId)
+	if err != nil {
+		logger.Warnf("UserHandler - CreateOrganization: Service error for %s: %v", req.Name, err)
+		return nil, publicError(err)
+	}
+	return toOrganization(org), nil
+}
+
+func (h *UserHandler) GetOrganization(ctx context.Context, req *userv1.GetOrganizationRequest) (*userv1.Organization, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	org, err := h.organizations.Get(ctx, req.OrganizationId)
+	if err != nil {
+		logger.Warnf("UserHandler - GetOrganization: Service error for %d: %v", req.OrganizationId, err)
+		return nil, publicError(err)
+	}
+	return toOrganization(org), nil
+}
+
+func (h *UserHandler) ListOrganizations(ctx context.Context, req *userv1.ListOrganizationsRequest) (*userv1.ListOrganizationsResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	orgs, err := h.organizations.ListForUser(ctx, req.UserId)
+	if err != nil {
+		logger.Warnf("UserHandler - ListOrganizations: Service error for user %d: %v", req.UserId, err)
+		return nil, publicError(err)
+	}
+
+	resp := &userv1.ListOrganizationsResponse{Organizations: make([]*userv1.UserOrganization, 0, len(orgs))}
+	for i := range orgs {
+		resp.Organizations = append(resp.Organizations, &userv1.UserOrganization{
+			Organization: toOrganization(&orgs[i].Organization),
+			Role:         string(orgs[i].Role),
+		})
+	}
+	return resp, nil
+}
+
+func (h *UserHandler) UpdateOrganization(ctx context.Context, req *userv1.UpdateOrganizationRequest) (*userv1.Organization, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	org, err := h.organizations.Update(ctx, req.OrganizationId, req.Name)
+	if err != nil {
+		logger.Warnf("UserHandler - UpdateOrganization: Service error for %d: %v", req.OrganizationId, err)
+		return nil, publicError(err)
+	}
+	return toOrganization(org), nil
+}
+
+func (h *UserHandler) DeleteOrganization(ctx context.Context, req *userv1.DeleteOrganizationRequest) (*emptypb.Empty, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	if err := h.organizations.Delete(ctx, req.OrganizationId); err != nil {
+		logger.Warnf("UserHandler - DeleteOrganization: Service error for %d: %v", req.OrganizationId, err)
+		return nil, publicError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserHandler) ListOrganizationMembers(ctx context.Context, req *userv1.ListOrganizationMembersRequest) (*userv1.ListOrganizationMembersResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	members, err := h.organizations.ListMembers(ctx, req.OrganizationId)
+	if err != nil {
+		logger.Warnf("UserHandler - ListOrganizationMembers: Service error for %d: %v", req.OrganizationId, err)
+		return nil, publicError(err)
+	}
+
+	resp := &userv1.ListOrganizationMembersResponse{Members: make([]*userv1.OrganizationMember, 0, len(members))}
+	for i := range members {
+		resp.Members = append(resp.Members, toOrganizationMember(&members[i]))
+	}
+	return resp, nil
+}
+
+func (h *UserHandler) UpdateOrganizationMember(ctx context.Context, req *userv1.UpdateOrganizationMemberRequest) (*userv1.OrganizationMember, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	m, err := h.organizations.UpdateMemberRole(ctx, req.OrganizationId, req.UserId, model.Role(req.Role))
+	if err != nil {
+		logger.Warnf("UserHandler - UpdateOrganizationMember: Service error for user %d in %d: %v", req.UserId, req.OrganizationId, err)
+		return nil, publicError(err)
+	}
+	return toOrganizationMember(m), nil
+}
+
+func (h *UserHandler) RemoveOrganizationMember(ctx context.Context, req *userv1.RemoveOrganizationMemberRequest) (*emptypb.Empty, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	if err := h.organizations.RemoveMember(ctx, req.OrganizationId, req.UserId); err != nil {
+		logger.Warnf("UserHandler - RemoveOrganizationMember: Service error for user %d in %d: %v", req.UserId, req.OrganizationId, err)
+		return nil, publicError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func toOrganization(o *model.Organization) *userv1.Organization {
+	return &userv1.Organization{
+		OrganizationId: o.ID,
+		Name:           o.Name,
+		TenantId:       o.TenantID,
+		CreatedAt:      timestamppb.New(o.CreatedAt),
+		UpdatedAt:      timestamppb.New(o.UpdatedAt),
+	}
+}
+
+func toOrganizationMember(m *model.Membership) *userv1.OrganizationMember {
+	return &userv1.OrganizationMember{
+		UserId:      m.UserID,
+		Email:       m.Email,
+		DisplayName: m.DisplayName,
+		Role:        string(m.Role),
+		JoinedAt:    timestamppb.New(m.CreatedAt),
+	}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *UserHandler) ExportUserData(ctx context.Context, req *userv1.ExportUserDataRequest) (*httpbody.HttpBody, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	export, err := h.privacy.Export(ctx, req.UserId)
+	if err != nil {
+		logger.Warnf("UserHandler - ExportUserData: Service error for ID %d: %v", req.UserId, err)
+		return nil, publicError(err)
+	}
+	// через gateway архив скачивается файлом
+	_ = grpc.SetHeader(ctx, metadata.Pairs("content-disposition",
+		fmt.Sprintf("attachment; filename=%q", export.Filename)))
+	return &httpbody.HttpBody{ContentType: export.ContentType, Data: export.Data}, nil
+}
+
+func (h *UserHandler) EraseUser(ctx context.Context, req *userv1.EraseUserRequest) (*userv1.EraseUserResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	rec, err := h.privacy.Erase(ctx, req.UserId, req.Reason)
+	if err != nil {
+		logger.Warnf("UserHandler - EraseUser: Service error for ID %d: %v", req.UserId, err)
+		return nil, publicError(err)
+	}
+	return &userv1.EraseUserResponse{
+		ErasureId: rec.ID,
+		ErasedAt:  timestamppb.New(rec.CreatedAt),
+		Summary:   rec.Summary,
+	}, nil
+}
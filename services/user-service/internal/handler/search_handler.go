@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+)
+
+func (h *UserHandler) SearchUsers(ctx context.Context, req *userv1.SearchUsersRequest) (*userv1.SearchUsersResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	results, next, err := h.search.SearchUsers(ctx, req.Query, int(req.PageSize), req.PageToken)
+	if err != nil {
+		logger.Warnf("UserHandler - SearchUsers: Service error for query %q: %v", req.Query, err)
+		return nil, publicError(err)
+	}
+
+	resp := &userv1.SearchUsersResponse{NextPageToken: next}
+	for _, r := range results {
+		result := &userv1.UserSearchResult{User: toUserResponse(r.User), Rank: r.Rank}
+		for _, hl := range r.Highlights {
+			result.Highlights = append(result.Highlights, &userv1.Highlight{
+				Field: hl.Field,
+				Start: int32(hl.Start),
+				End:   int32(hl.End),
+			})
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	logger.Debugf("UserHandler - SearchUsers: %d results for query %q", len(resp.Results), req.Query)
+	return resp, nil
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (h *UserHandler) EnrollTOTP(ctx context.Context, req *userv1.EnrollTOTPRequest) (*userv1.EnrollTOTPResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	enrollment, err := h.twoFactor.Enroll(ctx, req.UserId)
+	if err != nil {
+		logger.Warnf("UserHandler - EnrollTOTP: Service error for ID %d: %v", req.UserId, err)
+		return nil, publicError(err)
+	}
+
+	return &userv1.EnrollTOTPResponse{
+		Secret:     enrollment.Secret,
+		OtpauthUri: enrollment.URI,
+		QrPng:      enrollment.QRPNG,
+	}, nil
+}
+
+func (h *UserHandler) ConfirmTOTP(ctx context.Context, req *userv1.ConfirmTOTPRequest) (*userv1.ConfirmTOTPResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	codes, err := h.twoFactor.Confirm(ctx, req.UserId, req.Code)
+	if err != nil {
+		logger.Warnf("UserHandler - ConfirmTOTP: Service error for ID %d: %v", req.UserId, err)
+		return nil, publicError(err)
+	}
+
+	return &userv1.ConfirmTOTPResponse{RecoveryCodes: codes}, nil
+}
+
+func (h *UserHandler) DisableTOTP(ctx context.Context, req *userv1.DisableTOTPRequest) (*emptypb.Empty, error) {
+	if err := req.ValidateAll(); err != nil {
+		return nil, apperr.Validation(err)
+	}
+
+	if err := h.twoFactor.Disable(ctx, req.UserId, req.Code, req.RecoveryCode); err != nil {
+		logger.Warnf("UserHandler - DisableTOTP: Service error for ID %d: %v", req.UserId, err)
+		return nil, publicError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -2,10 +2,16 @@ package handler
 
 import (
 	"context"
-	"log"
+	"net/url"
+	"time"
 
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
 	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
 	"github.com/DmitriiPro/user-service/internal/service"
+	"golang.org/x/text/language"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -13,36 +19,59 @@ import (
 
 type UserHandler struct {
 	userv1.UnimplementedUserServiceServer
-	svc service.UserService
+	svc     service.UserService
+	avatars service.AvatarService
+	search  service.SearchService
+	auth    service.AuthService
+	// twoFactor — TOTP второй фактор.
+	twoFactor  service.TwoFactorService
+	magicLinks service.MagicLinkService
+	oidc       service.OIDCService
+	social     service.SocialLoginService
+	apiKeys    service.APIKeyService
+	// introspection — проверка токенов для других сервисов.
+	introspection service.IntrospectionService
+	organizations service.OrganizationService
+	invitations   service.InvitationService
+	// privacy — выгрузка и удаление персональных данных (GDPR).
+	privacy service.PrivacyService
+	// avatarMaxBytes — предельный размер загружаемого файла аватара.
+	avatarMaxBytes int
 }
 
-func NewUserHandler(svc service.UserService) *UserHandler {
-	return &UserHandler{svc: svc}
+func NewUserHandler(svc service.UserService, avatars service.AvatarService, search service.SearchService,
+	auth service.AuthService, twoFactor service.TwoFactorService, magicLinks service.MagicLinkService,
+	oidc service.OIDCService, social service.SocialLoginService, apiKeys service.APIKeyService,
+	introspection service.IntrospectionService, organizations service.OrganizationService,
+	invitations service.InvitationService, privacy service.PrivacyService, avatarMaxBytes int) *UserHandler {
+	return &UserHandler{svc: svc, avatars: avatars, search: search, auth: auth, twoFactor: twoFactor,
+		magicLinks: magicLinks, oidc: oidc, social: social, apiKeys: apiKeys, introspection: introspection,
+		organizations: organizations, invitations: invitations, privacy: privacy, avatarMaxBytes: avatarMaxBytes}
 }
 
 func (h *UserHandler) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.CreateUserResponse, error) {
 
 	select {
 	case <-ctx.Done():
-		log.Printf("Context cancelled before processing: %v", ctx.Err())
-		return nil, status.Error(codes.Canceled, "request cancelled")
+		logger.Warnf("Context cancelled before processing: %v", ctx.Err())
+		return nil, apperr.New(codes.Canceled, apperr.ReasonCanceled, "request cancelled", nil)
 	default:
-		log.Printf("CreateUser called with email: %s", req.Email)
+		logger.Debugf("CreateUser called with email: %s", req.Email)
 
-		if err := req.Validate(); err != nil {
-			log.Printf("Validation failed: %v", err)
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+		if err := req.ValidateAll(); err != nil {
+			logger.Warnf("Validation failed: %v", err)
+			return nil, apperr.Validation(err)
 		}
 
-		log.Printf("Creating user with email: %s", req.Email)
+		logger.Infof("Creating user with email: %s", req.Email)
 		id, err := h.svc.CreateUser(ctx, req.Email, req.Password)
 
 		if err != nil {
-			log.Printf("CreateUser service error: %v", err)
-			return nil, err
+			logger.Warnf("CreateUser service error: %v", err)
+			return nil, publicError(err)
 		}
 
-		log.Printf("User created successfully with ID: %d", id)
+		logger.Infof("User created successfully with ID: %d", id)
 		return &userv1.CreateUserResponse{Id: id}, nil
 	}
 
@@ -52,22 +81,101 @@ func (h *UserHandler) GetUserByID(ctx context.Context, req *userv1.GetUserByIDRe
 
 	// Валидация ID
 	if req.Id <= 0 {
-		return nil, status.Error(codes.InvalidArgument, "user ID must be positive")
+		return nil, apperr.InvalidFields(apperr.FieldViolation("id", "user ID must be positive"))
 	}
 
-	log.Printf("UserHandler - GetUserByID: Request for ID %d", req.Id)
+	logger.Debugf("UserHandler - GetUserByID: Request for ID %d", req.Id)
 
-	user, err := h.svc.GetUserByID(ctx, req.Id)
+	user, err := h.svc.GetProfile(ctx, req.Id)
 	if err != nil {
-		log.Printf("UserHandler - GetUserByID: Service error for ID %d: %v", req.Id, err)
-		return nil, err
+		logger.Warnf("UserHandler - GetUserByID: Service error for ID %d: %v", req.Id, err)
+		return nil, publicError(err)
 	}
 
-	log.Printf("UserHandler - GetUserByID: Success for ID %d", req.Id)
+	logger.Debugf("UserHandler - GetUserByID: Success for ID %d", req.Id)
 
-	return &userv1.GetUserResponse{
-		Id:        user.ID,
-		Email:     user.Email,
-		CreatedAt: timestamppb.New(user.CreatedAt),
-	}, nil
+	return toUserResponse(user), nil
+}
+
+func (h *UserHandler) UpdateProfile(ctx context.Context, req *userv1.UpdateProfileRequest) (*userv1.GetUserResponse, error) {
+	if err := req.ValidateAll(); err != nil {
+		logger.Warnf("UserHandler - UpdateProfile: Validation failed: %v", err)
+		return nil, apperr.Validation(err)
+	}
+
+	upd := model.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		Phone:       req.Phone,
+		Timezone:    req.Timezone,
+		AvatarURL:   req.AvatarUrl,
+		Metadata:    req.Metadata,
+	}
+
+	// правила, которые не выразить в user.proto
+	var violations []*errdetails.BadRequest_FieldViolation
+	if req.Locale != nil && *req.Locale != "" {
+		tag, err := language.Parse(*req.Locale)
+		if err != nil {
+			violations = append(violations, apperr.FieldViolation("locale", "value must be a BCP 47 language tag"))
+		} else {
+			locale := tag.String()
+			upd.Locale = &locale
+		}
+	} else {
+		upd.Locale = req.Locale
+	}
+	if tz := req.GetTimezone(); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+			violations = append(violations, apperr.FieldViolation("timezone", "value must be an IANA time zone name"))
+		}
+	}
+	if raw := req.GetAvatarUrl(); raw != "" {
+		if u, err := url.Parse(raw); err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+			violations = append(violations, apperr.FieldViolation("avatar_url", "value must be an http or https URL"))
+		}
+	}
+	if len(violations) > 0 {
+		return nil, apperr.InvalidFields(violations...)
+	}
+
+	logger.Debugf("UserHandler - UpdateProfile: Request for ID %d", req.Id)
+
+	user, err := h.svc.UpdateProfile(ctx, req.Id, upd)
+	if err != nil {
+		logger.Warnf("UserHandler - UpdateProfile: Service error for ID %d: %v", req.Id, err)
+		return nil, publicError(err)
+	}
+
+	return toUserResponse(user), nil
+}
+
+func toUserResponse(user *model.User) *userv1.GetUserResponse {
+	resp := &userv1.GetUserResponse{
+		Id:          user.ID,
+		Email:       user.Email,
+		DisplayName: user.DisplayName,
+		Phone:       user.Phone,
+		Locale:      user.Locale,
+		Timezone:    user.Timezone,
+		AvatarUrl:   user.AvatarURL,
+		Metadata:    user.Metadata,
+	}
+	// в публичном профиле дат нет; в кэше могут лежать записи без
+	// updated_at, сохранённые до появления профиля
+	if !user.CreatedAt.IsZero() {
+		resp.CreatedAt = timestamppb.New(user.CreatedAt)
+	}
+	if !user.UpdatedAt.IsZero() {
+		resp.UpdatedAt = timestamppb.New(user.UpdatedAt)
+	}
+	return resp
+}
+
+// publicError пропускает gRPC статусы сервиса как есть, а внутренние ошибки
+// (БД, Redis) заменяет на Internal без подробностей.
+func publicError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return apperr.Internal()
 }
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/model"
+	userv1 "github.com/DmitriiPro/user-service/internal/pb/user"
+	"github.com/DmitriiPro/user-service/internal/service"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// profiles — UserService, отдающий заранее заданный профиль.
+type profiles struct {
+	service.UserService
+	user *model.User
+}
+
+func (p profiles) GetProfile(context.Context, int64) (*model.User, error) { return p.user, nil }
+
+func TestGetUserByIDResponse(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		user *model.User
+		want *userv1.GetUserResponse
+	}{
+		{
+			name: "full profile",
+			user: &model.User{ID: 1, Email: "a@example.com", CreatedAt: created, UpdatedAt: created, DisplayName: "Alice",
+				Phone: "+79991234567", Locale: "ru-RU", Timezone: "Europe/Moscow", AvatarURL: "https://cdn.example.com/a.png",
+				Metadata: map[string]string{"plan": "pro"}},
+			want: &userv1.GetUserResponse{Id: 1, Email: "a@example.com", CreatedAt: timestamppb.New(created),
+				UpdatedAt: timestamppb.New(created), DisplayName: "Alice", Phone: "+79991234567", Locale: "ru-RU",
+				Timezone: "Europe/Moscow", AvatarUrl: "https://cdn.example.com/a.png", Metadata: map[string]string{"plan": "pro"}},
+		},
+		{
+			// публичный профиль не выдаёт даже дату регистрации
+			name: "public profile",
+			user: &model.User{ID: 1, DisplayName: "Alice", AvatarURL: "https://cdn.example.com/a.png"},
+			want: &userv1.GetUserResponse{Id: 1, DisplayName: "Alice", AvatarUrl: "https://cdn.example.com/a.png"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &UserHandler{svc: profiles{user: tt.user}}
+			got, err := h.GetUserByID(context.Background(), &userv1.GetUserByIDRequest{Id: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Fatalf("response = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package i18n
+
+import (
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"golang.org/x/text/language"
+)
+
+// catalog — тексты ошибок по коду причины (ErrorInfo.reason). Новую причину
+// нужно добавить во все языки.
+var catalog = map[language.Tag]map[string]string{
+	language.English: {
+		apperr.ReasonValidationFailed:       "The request contains invalid fields.",
+		apperr.ReasonUserNotFound:           "User with id {id} was not found.",
+		apperr.ReasonUserAlreadyExists:      "A user with email {email} already exists.",
+		apperr.ReasonAvatarNotFound:         "User with id {id} has no avatar.",
+		apperr.ReasonSearchThrottled:        "Too many searches are running right now. Please try again shortly.",
+		apperr.ReasonSearchTimeout:          "The search took too long. Try a more specific query.",
+		apperr.ReasonInvalidCredentials:     "Invalid email or password.",
+		apperr.ReasonUnauthenticated:        "Your session is invalid or has expired. Please sign in again.",
+		apperr.ReasonPermissionDenied:       "You do not have permission to perform this action.",
+		apperr.ReasonSessionNotFound:        "Session {session_id} was not found.",
+		apperr.ReasonLoginThrottled:         "Too many failed sign-in attempts. Try again in {retry_after} s.",
+		apperr.ReasonLoginLocked:            "Sign-in is temporarily locked after too many failed attempts. Try again in {retry_after} s.",
+		apperr.ReasonInvalidSecondFactor:    "The verification code is invalid or has already been used.",
+		apperr.ReasonMFAChallengeExpired:    "The sign-in attempt has expired. Please sign in again.",
+		apperr.ReasonTOTPAlreadyEnabled:     "Two-factor authentication is already enabled.",
+		apperr.ReasonTOTPNotEnabled:         "Two-factor authentication is not enabled.",
+		apperr.ReasonTwoFactorUnavailable:   "Two-factor authentication is not available right now.",
+		apperr.ReasonMagicLinkInvalid:       "The sign-in link is invalid, expired or was opened in another browser.",
+		apperr.ReasonMagicLinkDisabled:      "Sign-in by email link is disabled.",
+		apperr.ReasonOIDCClientNotFound:     "OIDC client {client_id} was not found.",
+		apperr.ReasonSocialProviderNotFound: "Sign-in with {provider} is not available.",
+		apperr.ReasonSocialLoginFailed:      "Sign-in with the external provider failed or has expired. Please try again.",
+		apperr.ReasonSocialEmailUnverified:  "The external account has no verified email address.",
+		apperr.ReasonAPIKeyNotFound:         "API key {key_id} was not found.",
+		apperr.ReasonTenantNotFound:         "Tenant {tenant_id} was not found.",
+		apperr.ReasonTenantMismatch:         "You are signed in to another tenant.",
+		apperr.ReasonOrganizationNotFound:   "Organization {organization_id} was not found.",
+		apperr.ReasonMemberNotFound:         "The user is not a member of this organization.",
+		apperr.ReasonLastOwner:              "The organization must keep at least one owner.",
+		apperr.ReasonInvitationNotFound:     "The invitation is invalid, expired or already used.",
+		apperr.ReasonRateLimited:            "Too many requests. Please try again later.",
+		apperr.ReasonIdempotencyKeyReused:   "This idempotency key was already used with a different request.",
+		apperr.ReasonIdempotencyInProgress:  "A request with this idempotency key is still being processed.",
+		apperr.ReasonCanceled:               "The request was cancelled.",
+		apperr.ReasonInternal:               "Something went wrong on our side. Please try again later.",
+	},
+	language.Russian: {
+		apperr.ReasonValidationFailed:       "Запрос содержит некорректные поля.",
+		apperr.ReasonUserNotFound:           "Пользователь с id {id} не найден.",
+		apperr.ReasonUserAlreadyExists:      "Пользователь с email {email} уже существует.",
+		apperr.ReasonAvatarNotFound:         "У пользователя с id {id} нет аватара.",
+		apperr.ReasonSearchThrottled:        "Сейчас выполняется слишком много поисковых запросов. Повторите чуть позже.",
+		apperr.ReasonSearchTimeout:          "Поиск занял слишком много времени. Уточните запрос.",
+		apperr.ReasonInvalidCredentials:     "Неверный email или пароль.",
+		apperr.ReasonUnauthenticated:        "Сессия недействительна или истекла. Войдите снова.",
+		apperr.ReasonPermissionDenied:       "Недостаточно прав для этого действия.",
+		apperr.ReasonSessionNotFound:        "Сессия {session_id} не найдена.",
+		apperr.ReasonLoginThrottled:         "Слишком много неудачных попыток входа. Повторите через {retry_after} с.",
+		apperr.ReasonLoginLocked:            "Вход временно заблокирован из-за неудачных попыток. Повторите через {retry_after} с.",
+		apperr.ReasonInvalidSecondFactor:    "Код подтверждения неверен или уже использован.",
+		apperr.ReasonMFAChallengeExpired:    "Время на вход истекло. Войдите снова.",
+		apperr.ReasonTOTPAlreadyEnabled:     "Двухфакторная аутентификация уже включена.",
+		apperr.ReasonTOTPNotEnabled:         "Двухфакторная аутентификация не включена.",
+		apperr.ReasonTwoFactorUnavailable:   "Двухфакторная аутентификация сейчас недоступна.",
+		apperr.ReasonMagicLinkInvalid:       "Ссылка для входа недействительна, устарела или открыта в другом браузере.",
+		apperr.ReasonMagicLinkDisabled:      "Вход по ссылке из письма отключён.",
+		apperr.ReasonOIDCClientNotFound:     "OIDC клиент {client_id} не найден.",
+		apperr.ReasonSocialProviderNotFound: "Вход через {provider} недоступен.",
+		apperr.ReasonSocialLoginFailed:      "Не удалось войти через внешний сервис или время входа истекло. Попробуйте ещё раз.",
+		apperr.ReasonSocialEmailUnverified:  "У внешней учётной записи нет подтверждённого адреса email.",
+		apperr.ReasonAPIKeyNotFound:         "API ключ {key_id} не найден.",
+		apperr.ReasonTenantNotFound:         "Тенант {tenant_id} не найден.",
+		apperr.ReasonTenantMismatch:         "Вы вошли в другой тенант.",
+		apperr.ReasonOrganizationNotFound:   "Организация {organization_id} не найдена.",
+		apperr.ReasonMemberNotFound:         "Пользователь не состоит в этой организации.",
+		apperr.ReasonLastOwner:              "В организации должен остаться хотя бы один владелец.",
+		apperr.ReasonInvitationNotFound:     "Приглашение недействительно, истекло или уже использовано.",
+		apperr.ReasonRateLimited:            "Слишком много запросов. Попробуйте позже.",
+		apperr.ReasonIdempotencyKeyReused:   "Этот ключ идемпотентности уже использован с другим запросом.",
+		apperr.ReasonIdempotencyInProgress:  "Запрос с этим ключом идемпотентности ещё выполняется.",
+		apperr.ReasonCanceled:               "Запрос отменён.",
+		apperr.ReasonInternal:               "Внутренняя ошибка сервиса. Попробуйте позже.",
+	},
+}
@@ -0,0 +1,86 @@
+package i18n
+
+import (
+	"context"
+	"strings"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"golang.org/x/text/language"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// Поддерживаемые языки; первый — язык по умолчанию.
+var supported = []language.Tag{language.English, language.Russian}
+
+var matcher = language.NewMatcher(supported)
+
+type localeKey struct{}
+
+func WithLocale(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, localeKey{}, tag)
+}
+
+// FromContext возвращает язык запроса, по умолчанию английский.
+func FromContext(ctx context.Context) language.Tag {
+	if tag, ok := ctx.Value(localeKey{}).(language.Tag); ok {
+		return tag
+	}
+	return supported[0]
+}
+
+// Match выбирает поддерживаемый язык по значению Accept-Language.
+func Match(acceptLanguage string) language.Tag {
+	prefs, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(prefs) == 0 {
+		return supported[0]
+	}
+	_, idx, _ := matcher.Match(prefs...)
+	return supported[idx]
+}
+
+// Message возвращает текст ошибки reason на языке tag. Плейсхолдеры вида
+// {email} заменяются значениями из args (метаданные ErrorInfo).
+func Message(tag language.Tag, reason string, args map[string]string) (string, bool) {
+	msg, ok := catalog[tag][reason]
+	if !ok {
+		return "", false
+	}
+
+	pairs := make([]string, 0, len(args)*2)
+	for k, v := range args {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg), true
+}
+
+// Localize добавляет к gRPC ошибке google.rpc.LocalizedMessage на языке
+// запроса, если для её причины есть текст в каталоге.
+func Localize(ctx context.Context, err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	info := apperr.ErrorInfo(st)
+	if info == nil {
+		return err
+	}
+	for _, d := range st.Details() {
+		if _, ok := d.(*errdetails.LocalizedMessage); ok {
+			return err
+		}
+	}
+
+	tag := FromContext(ctx)
+	msg, ok := Message(tag, info.GetReason(), info.GetMetadata())
+	if !ok {
+		return err
+	}
+
+	localized, detailsErr := st.WithDetails(&errdetails.LocalizedMessage{Locale: tag.String(), Message: msg})
+	if detailsErr != nil {
+		return err
+	}
+	return localized.Err()
+}
@@ -0,0 +1,183 @@
+package i18n
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"golang.org/x/text/language"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// reasons читает из исходника apperr все константы Reason*, чтобы новая
+// причина без перевода не прошла незамеченной.
+func reasons(t *testing.T) map[string]string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "../apperr/apperr.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]string)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if !strings.HasPrefix(name.Name, "Reason") {
+					continue
+				}
+				lit, ok := vs.Values[i].(*ast.BasicLit)
+				if !ok {
+					t.Fatalf("%s is not a string literal", name.Name)
+				}
+				out[name.Name], _ = strconv.Unquote(lit.Value)
+			}
+		}
+	}
+	if len(out) == 0 {
+		t.Fatal("no Reason constants found")
+	}
+	return out
+}
+
+func TestCatalogCoversAllReasons(t *testing.T) {
+	all := reasons(t)
+	for _, tag := range supported {
+		for name, reason := range all {
+			if msg := catalog[tag][reason]; msg == "" {
+				t.Errorf("%s: no message for apperr.%s", tag, name)
+			}
+		}
+		if len(catalog[tag]) != len(all) {
+			t.Errorf("%s: catalog has %d messages for %d reasons", tag, len(catalog[tag]), len(all))
+		}
+	}
+	// плейсхолдеры во всех языках одинаковые
+	for reason, msg := range catalog[language.English] {
+		for _, tag := range supported[1:] {
+			if en, other := placeholders(msg), placeholders(catalog[tag][reason]); en != other {
+				t.Errorf("%s: placeholders %q in %s, %q in en", reason, other, tag, en)
+			}
+		}
+	}
+}
+
+func placeholders(msg string) string {
+	var out []string
+	for rest := msg; ; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			break
+		}
+		out = append(out, rest[start:start+end+1])
+		rest = rest[start+end+1:]
+	}
+	return strings.Join(out, " ")
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   language.Tag
+	}{
+		{"", language.English},
+		{"ru", language.Russian},
+		{"ru-RU,ru;q=0.9,en;q=0.8", language.Russian},
+		{"en-GB,ru;q=0.5", language.English},
+		{"de-DE,ru;q=0.7", language.Russian},
+		{"fr", language.English},
+		{"not a language tag;;", language.English},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.accept); got != tt.want {
+			t.Errorf("Match(%q) = %s, want %s", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	notFound := apperr.New(codes.NotFound, apperr.ReasonUserNotFound, "user not found", map[string]string{"id": "42"})
+	tests := []struct {
+		name       string
+		locale     *language.Tag
+		err        error
+		wantLocale string
+		wantMsg    string
+	}{
+		{name: "default language", err: notFound, wantLocale: "en", wantMsg: "User with id 42 was not found."},
+		{name: "russian", locale: &language.Russian, err: notFound, wantLocale: "ru", wantMsg: "Пользователь с id 42 не найден."},
+		{name: "several placeholders", locale: &language.English,
+			err: apperr.New(codes.ResourceExhausted, apperr.ReasonLoginLocked, "locked",
+				map[string]string{"retry_after": "30", "unused": "x"}),
+			wantLocale: "en", wantMsg: "Sign-in is temporarily locked after too many failed attempts. Try again in 30 s."},
+		{name: "missing placeholder value stays", err: apperr.New(codes.NotFound, apperr.ReasonUserNotFound, "user not found", nil),
+			wantLocale: "en", wantMsg: "User with id {id} was not found."},
+		{name: "unknown reason", err: apperr.New(codes.Internal, "SOMETHING_NEW", "boom", nil)},
+		{name: "no ErrorInfo", err: status.Error(codes.Internal, "boom")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.locale != nil {
+				ctx = WithLocale(ctx, *tt.locale)
+			}
+			st := status.Convert(Localize(ctx, tt.err))
+			if st.Code() != status.Code(tt.err) || st.Message() != status.Convert(tt.err).Message() {
+				t.Fatalf("Localize changed the error: %v", st.Err())
+			}
+			got := localized(st)
+			if tt.wantMsg == "" {
+				if got != nil {
+					t.Fatalf("unexpected LocalizedMessage %v", got)
+				}
+				return
+			}
+			if got.GetLocale() != tt.wantLocale || got.GetMessage() != tt.wantMsg {
+				t.Fatalf("LocalizedMessage = %s %q, want %s %q", got.GetLocale(), got.GetMessage(), tt.wantLocale, tt.wantMsg)
+			}
+		})
+	}
+}
+
+// Повторная локализация не добавляет второе сообщение.
+func TestLocalizeOnce(t *testing.T) {
+	err := apperr.New(codes.NotFound, apperr.ReasonUserNotFound, "user not found", map[string]string{"id": "1"})
+	err = Localize(WithLocale(context.Background(), language.Russian), err)
+	err = Localize(context.Background(), err)
+
+	var n int
+	for _, d := range status.Convert(err).Details() {
+		if m, ok := d.(*errdetails.LocalizedMessage); ok {
+			n++
+			if m.GetLocale() != "ru" {
+				t.Fatalf("locale = %s, want the first one", m.GetLocale())
+			}
+		}
+	}
+	if n != 1 {
+		t.Fatalf("%d LocalizedMessage details, want 1", n)
+	}
+}
+
+func localized(st *status.Status) *errdetails.LocalizedMessage {
+	for _, d := range st.Details() {
+		if m, ok := d.(*errdetails.LocalizedMessage); ok {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type State string
+
+const (
+	StateInProgress State = "in_progress"
+	StateCompleted  State = "completed"
+)
+
+// Record — сохранённый результат первого запроса с данным ключом.
+// Response — ответ, упакованный в google.protobuf.Any; Status —
+// google.rpc.Status ошибки. Заполнено одно из двух.
+type Record struct {
+	RequestHash string    `json:"request_hash"`
+	State       State     `json:"state"`
+	Response    []byte    `json:"response,omitempty"`
+	Status      []byte    `json:"status,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Store interface {
+	// Begin атомарно занимает ключ записью in_progress на lockTTL. Если ключ
+	// уже занят, возвращает существующую запись и created=false.
+	Begin(ctx context.Context, key, requestHash string, lockTTL time.Duration) (rec *Record, created bool, err error)
+	// Complete сохраняет результат на ttl.
+	Complete(ctx context.Context, key string, rec *Record, ttl time.Duration) error
+	// Release освобождает ключ, чтобы повтор выполнился заново.
+	Release(ctx context.Context, key string) error
+}
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) Store {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Begin(ctx context.Context, key, requestHash string, lockTTL time.Duration) (*Record, bool, error) {
+	rec := &Record{RequestHash: requestHash, State: StateInProgress, CreatedAt: time.Now().UTC()}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, false, err
+	}
+
+	created, err := s.client.SetNX(ctx, key, data, lockTTL).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if created {
+		return rec, true, nil
+	}
+
+	raw, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// ключ истёк между SETNX и GET — пробуем ещё раз
+		return s.Begin(ctx, key, requestHash, lockTTL)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var existing Record
+	if err := json.Unmarshal(raw, &existing); err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+func (s *redisStore) Complete(ctx context.Context, key string, rec *Record, ttl time.Duration) error {
+	rec.State = StateCompleted
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, key, data, ttl).Err()
+}
+
+func (s *redisStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
@@ -0,0 +1,78 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestStore(t *testing.T) (Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisStore(client), mr
+}
+
+func TestRedisStore(t *testing.T) {
+	store, mr := newTestStore(t)
+	ctx := t.Context()
+
+	rec, created, err := store.Begin(ctx, "k", "hash-a", time.Minute)
+	if err != nil || !created || rec.State != StateInProgress {
+		t.Fatalf("first Begin = %+v, %v, %v", rec, created, err)
+	}
+
+	// пока запрос выполняется, ключ занят — в том числе для другого тела
+	for _, hash := range []string{"hash-a", "hash-b"} {
+		got, created, err := store.Begin(ctx, "k", hash, time.Minute)
+		if err != nil || created || got.State != StateInProgress || got.RequestHash != "hash-a" {
+			t.Fatalf("Begin(%s) while in progress = %+v, %v, %v", hash, got, created, err)
+		}
+	}
+
+	rec.Response = []byte("response")
+	if err := store.Complete(ctx, "k", rec, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	got, created, err := store.Begin(ctx, "k", "hash-a", time.Minute)
+	if err != nil || created || got.State != StateCompleted || string(got.Response) != "response" {
+		t.Fatalf("Begin after Complete = %+v, %v, %v", got, created, err)
+	}
+	// результат живёт ttl, а не lockTTL
+	mr.FastForward(time.Minute + time.Second)
+	if _, created, _ := store.Begin(ctx, "k", "hash-a", time.Minute); created {
+		t.Fatal("completed record expired with the lock ttl")
+	}
+	mr.FastForward(time.Hour)
+	if _, created, _ := store.Begin(ctx, "k", "hash-a", time.Minute); !created {
+		t.Fatal("completed record did not expire")
+	}
+}
+
+func TestRedisStoreRelease(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := t.Context()
+	if _, created, _ := store.Begin(ctx, "k", "hash-a", time.Minute); !created {
+		t.Fatal("key is already taken")
+	}
+	if err := store.Release(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if rec, created, err := store.Begin(ctx, "k", "hash-b", time.Minute); err != nil || !created || rec.RequestHash != "hash-b" {
+		t.Fatalf("Begin after Release = %+v, %v, %v", rec, created, err)
+	}
+}
+
+// Брошенная блокировка (процесс упал посреди запроса) истекает через lockTTL.
+func TestRedisStoreLockExpires(t *testing.T) {
+	store, mr := newTestStore(t)
+	ctx := t.Context()
+	store.Begin(ctx, "k", "hash-a", time.Minute)
+	mr.FastForward(time.Minute + time.Second)
+	if _, created, err := store.Begin(ctx, "k", "hash-a", time.Minute); err != nil || !created {
+		t.Fatalf("Begin after lock expiry: created %v, %v", created, err)
+	}
+}
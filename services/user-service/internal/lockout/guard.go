@@ -0,0 +1,222 @@
+package lockout
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+// Decision — можно ли сейчас проверять пароль. Если нельзя, Locked
+// отличает блокировку от паузы между попытками, RetryAfter — сколько ждать.
+type Decision struct {
+	Allowed    bool
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+// Guard ограничивает подбор паролей. Неудачные попытки считаются отдельно
+// для учётной записи (по email) и для IP. После delay_after неудач подряд
+// каждая следующая попытка откладывается на экспоненциально растущую паузу,
+// после max_failures учётная запись блокируется на lock_duration. Счётчики
+// лежат в Redis, поэтому общие для всех реплик и переживают перезапуск.
+type Guard interface {
+	// Check вызывается до проверки пароля. Разрешённая попытка занимается
+	// атомарно вместе с проверкой: параллельные попытки учитываются, как
+	// будто каждая из них неудачна, поэтому не проходят все разом мимо
+	// паузы или блокировки. Занятую попытку освобождает Failure или Release.
+	Check(ctx context.Context, account, ip string) (Decision, error)
+	// Failure учитывает неудачную попытку и освобождает её. Если именно
+	// она заблокировала учётную запись, lockedFor — срок блокировки, иначе 0.
+	Failure(ctx context.Context, account, ip string) (lockedFor time.Duration, err error)
+	// Release освобождает попытку, которая не была неудачной: пароль
+	// верен или проверка не состоялась из-за внутренней ошибки.
+	Release(ctx context.Context, account, ip string) error
+	// Success сбрасывает счётчик учётной записи. Счётчик IP не сбрасывается:
+	// иначе перебор с одного адреса обходился бы входом в свою учётку.
+	Success(ctx context.Context, account string) error
+	Unlock(ctx context.Context, account string) error
+}
+
+// attemptTimeout — сколько живёт занятая попытка, если её не освободили
+// (например, реплика упала посреди проверки пароля).
+const attemptTimeout = 30 * time.Second
+
+// pendingRetryAfter — через сколько повторить попытку, отклонённую из-за
+// ещё не завершённых параллельных попыток.
+const pendingRetryAfter = time.Second
+
+type redisGuard struct {
+	client *redis.Client
+	cfg    config.LockoutConfig
+}
+
+func NewRedisGuard(client *redis.Client, cfg config.LockoutConfig) Guard {
+	return &redisGuard{client: client, cfg: cfg}
+}
+
+// accountKey: email уникален только в пределах тенанта, поэтому счётчики
+// учётной записи тоже раздельные.
+func accountKey(ctx context.Context, account, suffix string) string {
+	return "lockout:account:" + tenant.FromContext(ctx) + ":" + strings.ToLower(account) + ":" + suffix
+}
+
+func ipKey(ip, suffix string) string {
+	return "lockout:ip:" + ip + ":" + suffix
+}
+
+// checkScript проверяет блокировки учётной записи (KEYS[1..4]: lock,
+// wait, failures, pending) и IP (KEYS[5..7]: lock, failures, pending) и,
+// если попытка разрешена, занимает её, увеличивая pending. Пока чужие
+// попытки не завершены, новая отклоняется, если вместе с ними неудач
+// хватило бы на блокировку или паузу. Возвращает {0, 0} — разрешено,
+// {1, ms} — блокировка, {2, ms} — пауза, {3, 0} — ждём параллельные попытки.
+var checkScript = redis.NewScript(`
+local lock = math.max(redis.call('PTTL', KEYS[1]), redis.call('PTTL', KEYS[5]))
+if lock > 0 then
+	return {1, lock}
+end
+local wait = redis.call('PTTL', KEYS[2])
+if wait > 0 then
+	return {2, wait}
+end
+
+local failures = tonumber(redis.call('GET', KEYS[3]) or '0')
+local pending = tonumber(redis.call('GET', KEYS[4]) or '0')
+if pending > 0 then
+	local n = failures + pending
+	if n >= tonumber(ARGV[1]) or (tonumber(ARGV[3]) > 0 and n > tonumber(ARGV[2])) then
+		return {3, 0}
+	end
+end
+local ip = ARGV[4] == '1'
+if ip then
+	local ipPending = tonumber(redis.call('GET', KEYS[7]) or '0')
+	if ipPending > 0 and tonumber(redis.call('GET', KEYS[6]) or '0') + ipPending >= tonumber(ARGV[5]) then
+		return {3, 0}
+	end
+end
+
+redis.call('INCR', KEYS[4])
+redis.call('PEXPIRE', KEYS[4], ARGV[6])
+if ip then
+	redis.call('INCR', KEYS[7])
+	redis.call('PEXPIRE', KEYS[7], ARGV[6])
+end
+return {0, 0}
+`)
+
+func (g *redisGuard) Check(ctx context.Context, account, ip string) (Decision, error) {
+	hasIP := "0"
+	if ip != "" {
+		hasIP = "1"
+	}
+	res, err := checkScript.Run(ctx, g.client,
+		[]string{
+			accountKey(ctx, account, "lock"), accountKey(ctx, account, "wait"),
+			accountKey(ctx, account, "failures"), accountKey(ctx, account, "pending"),
+			ipKey(ip, "lock"), ipKey(ip, "failures"), ipKey(ip, "pending"),
+		},
+		g.cfg.MaxFailures, g.cfg.DelayAfter, g.cfg.BaseDelay.Milliseconds(),
+		hasIP, g.cfg.IPMaxFailures, attemptTimeout.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Decision{}, err
+	}
+
+	retryAfter := time.Duration(res[1]) * time.Millisecond
+	switch res[0] {
+	case 1:
+		return Decision{Locked: true, RetryAfter: retryAfter}, nil
+	case 2:
+		return Decision{RetryAfter: retryAfter}, nil
+	case 3:
+		return Decision{RetryAfter: pendingRetryAfter}, nil
+	}
+	return Decision{Allowed: true}, nil
+}
+
+// releaseScript уменьшает счётчики занятых попыток KEYS, не опуская их
+// ниже нуля (попытка могла истечь по attemptTimeout).
+var releaseScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	if redis.call('DECR', key) <= 0 then
+		redis.call('DEL', key)
+	end
+end
+return 0
+`)
+
+// failureScript освобождает попытку KEYS[4] и увеличивает счётчик неудач
+// KEYS[1] (живёт window после последней неудачи). На max_failures ставит
+// блокировку KEYS[3] и сбрасывает счётчик, после delay_after — паузу
+// KEYS[2]. Возвращает 1, если попытка привела к блокировке.
+var failureScript = redis.NewScript(`
+if redis.call('DECR', KEYS[4]) <= 0 then
+	redis.call('DEL', KEYS[4])
+end
+local n = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+if n >= tonumber(ARGV[2]) then
+	redis.call('SET', KEYS[3], '1', 'PX', ARGV[3])
+	redis.call('DEL', KEYS[1], KEYS[2])
+	return 1
+end
+local base = tonumber(ARGV[5])
+if base > 0 and n > tonumber(ARGV[4]) then
+	local delay = math.min(base * 2 ^ (n - tonumber(ARGV[4]) - 1), tonumber(ARGV[6]))
+	redis.call('SET', KEYS[2], '1', 'PX', math.floor(delay))
+end
+return 0
+`)
+
+func (g *redisGuard) Failure(ctx context.Context, account, ip string) (time.Duration, error) {
+	locked, err := failureScript.Run(ctx, g.client,
+		[]string{accountKey(ctx, account, "failures"), accountKey(ctx, account, "wait"), accountKey(ctx, account, "lock"),
+			accountKey(ctx, account, "pending")},
+		g.cfg.Window.Milliseconds(), g.cfg.MaxFailures, g.cfg.LockDuration.Milliseconds(),
+		g.cfg.DelayAfter, g.cfg.BaseDelay.Milliseconds(), g.cfg.MaxDelay.Milliseconds(),
+	).Int()
+	if err != nil {
+		return 0, err
+	}
+	var lockedFor time.Duration
+	if locked == 1 {
+		lockedFor = g.cfg.LockDuration
+	}
+
+	if ip == "" {
+		return lockedFor, nil
+	}
+	// для IP пауз нет, только блокировка
+	err = failureScript.Run(ctx, g.client,
+		[]string{ipKey(ip, "failures"), ipKey(ip, "wait"), ipKey(ip, "lock"), ipKey(ip, "pending")},
+		g.cfg.Window.Milliseconds(), g.cfg.IPMaxFailures, g.cfg.IPLockDuration.Milliseconds(),
+		0, 0, 0,
+	).Err()
+	if err != nil {
+		return 0, err
+	}
+
+	return lockedFor, nil
+}
+
+func (g *redisGuard) Release(ctx context.Context, account, ip string) error {
+	keys := []string{accountKey(ctx, account, "pending")}
+	if ip != "" {
+		keys = append(keys, ipKey(ip, "pending"))
+	}
+	return releaseScript.Run(ctx, g.client, keys).Err()
+}
+
+func (g *redisGuard) Success(ctx context.Context, account string) error {
+	return g.client.Del(ctx, accountKey(ctx, account, "failures"), accountKey(ctx, account, "wait")).Err()
+}
+
+func (g *redisGuard) Unlock(ctx context.Context, account string) error {
+	return g.client.Del(ctx, accountKey(ctx, account, "failures"), accountKey(ctx, account, "wait"),
+		accountKey(ctx, account, "lock"), accountKey(ctx, account, "pending")).Err()
+}
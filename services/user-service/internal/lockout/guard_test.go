@@ -0,0 +1,218 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestGuard(t *testing.T, cfg config.LockoutConfig) (Guard, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisGuard(client, cfg), mr
+}
+
+var testConfig = config.LockoutConfig{
+	MaxFailures:    3,
+	LockDuration:   time.Hour,
+	Window:         time.Hour,
+	DelayAfter:     10,
+	BaseDelay:      0,
+	MaxDelay:       0,
+	IPMaxFailures:  100,
+	IPLockDuration: time.Hour,
+}
+
+// Параллельные попытки с неверным паролем не проверяют больше паролей,
+// чем max_failures, хотя Check и Failure — отдельные вызовы.
+func TestCheckReservesAttempts(t *testing.T) {
+	guard, _ := newTestGuard(t, testConfig)
+	ctx := context.Background()
+
+	var (
+		mu      sync.Mutex
+		allowed int
+		locks   int
+		wg      sync.WaitGroup
+	)
+	start := make(chan struct{})
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			d, err := guard.Check(ctx, "a@example.com", "10.0.0.1")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !d.Allowed {
+				return
+			}
+			// пароль проверяется, пока остальные попытки уже идут
+			time.Sleep(10 * time.Millisecond)
+			lockedFor, err := guard.Failure(ctx, "a@example.com", "10.0.0.1")
+			if err != nil {
+				t.Error(err)
+			}
+			mu.Lock()
+			allowed++
+			if lockedFor > 0 {
+				locks++
+			}
+			mu.Unlock()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if allowed > testConfig.MaxFailures {
+		t.Fatalf("%d parallel attempts checked a password, want at most %d", allowed, testConfig.MaxFailures)
+	}
+
+	// повторы после отказов упираются в блокировку
+	for allowed < testConfig.MaxFailures {
+		d, err := guard.Check(ctx, "a@example.com", "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !d.Allowed {
+			t.Fatalf("attempt %d denied before lock: %+v", allowed+1, d)
+		}
+		lockedFor, err := guard.Failure(ctx, "a@example.com", "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		allowed++
+		if lockedFor > 0 {
+			locks++
+		}
+	}
+	if locks != 1 {
+		t.Fatalf("account locked %d times, want 1", locks)
+	}
+	d, err := guard.Check(ctx, "a@example.com", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Allowed || !d.Locked {
+		t.Fatalf("Check after lock = %+v, want locked", d)
+	}
+}
+
+func TestCheckDecisions(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		cfg  config.LockoutConfig
+		// prepare выполняет попытки до проверяемой
+		prepare func(t *testing.T, g Guard)
+		want    Decision
+	}{
+		{
+			name:    "first attempt",
+			cfg:     testConfig,
+			prepare: func(*testing.T, Guard) {},
+			want:    Decision{Allowed: true},
+		},
+		{
+			name: "released attempt is not counted",
+			cfg:  testConfig,
+			prepare: func(t *testing.T, g Guard) {
+				for range testConfig.MaxFailures * 2 {
+					mustCheck(t, g, "10.0.0.1")
+					if err := g.Release(ctx, "a@example.com", "10.0.0.1"); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			want: Decision{Allowed: true},
+		},
+		{
+			name: "attempt in flight could lock",
+			cfg:  testConfig,
+			prepare: func(t *testing.T, g Guard) {
+				for range testConfig.MaxFailures - 1 {
+					mustCheck(t, g, "10.0.0.1")
+					g.Failure(ctx, "a@example.com", "10.0.0.1")
+				}
+				mustCheck(t, g, "10.0.0.2")
+			},
+			want: Decision{RetryAfter: pendingRetryAfter},
+		},
+		{
+			name: "attempt in flight could start delays",
+			cfg:  config.LockoutConfig{MaxFailures: 10, LockDuration: time.Hour, Window: time.Hour, DelayAfter: 1, BaseDelay: time.Second, MaxDelay: time.Minute, IPMaxFailures: 100, IPLockDuration: time.Hour},
+			prepare: func(t *testing.T, g Guard) {
+				mustCheck(t, g, "10.0.0.1")
+				g.Failure(ctx, "a@example.com", "10.0.0.1")
+				mustCheck(t, g, "10.0.0.2")
+			},
+			want: Decision{RetryAfter: pendingRetryAfter},
+		},
+		{
+			name: "ip attempts in flight could lock the ip",
+			cfg:  config.LockoutConfig{MaxFailures: 10, LockDuration: time.Hour, Window: time.Hour, DelayAfter: 10, IPMaxFailures: 2, IPLockDuration: time.Hour},
+			prepare: func(t *testing.T, g Guard) {
+				if d, _ := g.Check(ctx, "b@example.com", "10.0.0.1"); !d.Allowed {
+					t.Fatalf("Check = %+v", d)
+				}
+				if d, _ := g.Check(ctx, "c@example.com", "10.0.0.1"); !d.Allowed {
+					t.Fatalf("Check = %+v", d)
+				}
+			},
+			want: Decision{RetryAfter: pendingRetryAfter},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _ := newTestGuard(t, tt.cfg)
+			tt.prepare(t, g)
+
+			got, err := g.Check(ctx, "a@example.com", "10.0.0.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("Check = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbandonedAttemptExpires(t *testing.T) {
+	g, mr := newTestGuard(t, testConfig)
+	ctx := context.Background()
+	for range testConfig.MaxFailures - 1 {
+		mustCheck(t, g, "10.0.0.1")
+		g.Failure(ctx, "a@example.com", "10.0.0.1")
+	}
+	// попытка занята и не освобождена: реплика упала
+	mustCheck(t, g, "10.0.0.1")
+
+	if d, _ := g.Check(ctx, "a@example.com", "10.0.0.1"); d.Allowed {
+		t.Fatal("attempt allowed while another could lock the account")
+	}
+	mr.FastForward(attemptTimeout)
+	if d, _ := g.Check(ctx, "a@example.com", "10.0.0.1"); !d.Allowed {
+		t.Fatalf("Check after abandoned attempt expired = %+v", d)
+	}
+}
+
+func mustCheck(t *testing.T, g Guard, ip string) {
+	t.Helper()
+	d, err := g.Check(context.Background(), "a@example.com", ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Allowed {
+		t.Fatalf("Check = %+v, want allowed", d)
+	}
+}
@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+var level = new(slog.LevelVar)
+
+// Setup направляет логи через slog, чтобы уровень логирования можно было
+// менять на лету. Код пишет через Debugf/Infof/Warnf/Errorf; сообщения
+// стандартного log (сторонние библиотеки) идут с уровнем INFO.
+func Setup(lvl string) error {
+	if err := SetLevel(lvl); err != nil {
+		return err
+	}
+
+	slog.SetDefault(slog.New(&plainHandler{mu: new(sync.Mutex), out: os.Stderr}))
+	return nil
+}
+
+func SetLevel(lvl string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(lvl)); err != nil {
+		return err
+	}
+
+	level.Set(l)
+	return nil
+}
+
+// Debugf — подробности для отладки: ход запроса, попадания в кэш.
+func Debugf(format string, args ...any) { logf(slog.LevelDebug, format, args...) }
+
+// Infof — штатные события: вход, создание и удаление объектов.
+func Infof(format string, args ...any) { logf(slog.LevelInfo, format, args...) }
+
+// Warnf — отказы клиентам и сбои, которые сервис обошёл сам.
+func Warnf(format string, args ...any) { logf(slog.LevelWarn, format, args...) }
+
+// Errorf — ошибки, требующие внимания: сбои БД, Redis, внешних систем.
+func Errorf(format string, args ...any) { logf(slog.LevelError, format, args...) }
+
+func logf(l slog.Level, format string, args ...any) {
+	ctx := context.Background()
+	if !slog.Default().Enabled(ctx, l) {
+		// аргументы не форматируются, если уровень отключён
+		return
+	}
+	slog.Default().Log(ctx, l, fmt.Sprintf(format, args...))
+}
+
+// plainHandler пишет в привычном формате log ("2006/01/02 15:04:05 ...")
+// с уровнем; многострочные сообщения (например, конфиг) не экранируются.
+// Атрибуты группы пишутся с префиксом "группа.".
+type plainHandler struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	attrs  []slog.Attr
+	prefix string
+}
+
+func (h *plainHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= level.Level()
+}
+
+func (h *plainHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+	b.WriteString(r.Level.String())
+	b.WriteByte(' ')
+	b.WriteString(r.Message)
+
+	for _, a := range h.attrs {
+		writeAttr(&b, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.out, b.String())
+	return err
+}
+
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		// атрибуты группы без имени встраиваются в текущий уровень
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			writeAttr(b, prefix, ga)
+		}
+		return
+	}
+	fmt.Fprintf(b, " %s%s=%v", prefix, a.Key, a.Value)
+}
+
+func (h *plainHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	// атрибуты запоминаются уже с префиксом текущих групп
+	grouped := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if h.prefix != "" {
+			a.Key = h.prefix + a.Key
+		}
+		grouped = append(grouped, a)
+	}
+	return &plainHandler{mu: h.mu, out: h.out, attrs: append(append([]slog.Attr{}, h.attrs...), grouped...), prefix: h.prefix}
+}
+
+func (h *plainHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &plainHandler{mu: h.mu, out: h.out, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
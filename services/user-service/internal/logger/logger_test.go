@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func capture(t *testing.T, lvl string) *bytes.Buffer {
+	t.Helper()
+	if err := SetLevel(lvl); err != nil {
+		t.Fatalf("SetLevel(%q): %v", lvl, err)
+	}
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	buf := new(bytes.Buffer)
+	slog.SetDefault(slog.New(&plainHandler{mu: new(sync.Mutex), out: buf}))
+	return buf
+}
+
+func TestLevels(t *testing.T) {
+	tests := []struct {
+		level string
+		want  []string
+		skip  []string
+	}{
+		{"debug", []string{"DEBUG d", "INFO i", "WARN w", "ERROR e"}, nil},
+		{"info", []string{"INFO i", "WARN w", "ERROR e"}, []string{"DEBUG"}},
+		{"warn", []string{"WARN w", "ERROR e"}, []string{"DEBUG", "INFO"}},
+		{"error", []string{"ERROR e"}, []string{"DEBUG", "INFO", "WARN"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			buf := capture(t, tt.level)
+			Debugf("d")
+			Infof("i")
+			Warnf("w")
+			Errorf("e")
+
+			out := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("output %q does not contain %q", out, s)
+				}
+			}
+			for _, s := range tt.skip {
+				if strings.Contains(out, s) {
+					t.Errorf("output %q contains %q", out, s)
+				}
+			}
+		})
+	}
+}
+
+func TestSetLevelRejectsUnknown(t *testing.T) {
+	if err := SetLevel("verbose"); err == nil {
+		t.Fatal("SetLevel(verbose) returned no error")
+	}
+}
+
+func TestWithGroup(t *testing.T) {
+	buf := capture(t, "info")
+	slog.Default().
+		With("service", "user").
+		WithGroup("req").
+		With("id", 7).
+		WithGroup("client").
+		Info("done", "ip", "10.0.0.1", slog.Group("tls", "version", "1.3"))
+
+	out := buf.String()
+	for _, s := range []string{"INFO done", " service=user", " req.id=7", " req.client.ip=10.0.0.1", " req.client.tls.version=1.3"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output %q does not contain %q", out, s)
+		}
+	}
+}
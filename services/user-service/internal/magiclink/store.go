@@ -0,0 +1,110 @@
+package magiclink
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrNotFound          = errors.New("magic link not found")
+	ErrUserAgentMismatch = errors.New("magic link was requested from another user agent")
+)
+
+// Link — выданная ссылка для входа. Сам токен не хранится, только его хэш
+// (ключ в Redis) и хэш User-Agent, из которого ссылку запросили.
+type Link struct {
+	UserID        int64     `json:"user_id"`
+	Email         string    `json:"email"`
+	UserAgentHash string    `json:"user_agent_hash"`
+	CreatedAt     time.Time `json:"created_at"`
+	TenantID      string    `json:"tenant_id,omitempty"`
+}
+
+type Store interface {
+	// Create выдаёт одноразовый токен, действующий ttl.
+	Create(ctx context.Context, tenantID string, userID int64, email, userAgent string, ttl time.Duration) (string, error)
+	// Cooldown занимает интервал cooldown для email в тенанте. false —
+	// ссылку на этот адрес уже запрашивали меньше cooldown назад.
+	Cooldown(ctx context.Context, tenantID, email string, cooldown time.Duration) (bool, error)
+	// Consume гасит токен и возвращает ссылку. Токен из другого User-Agent
+	// не гасится: иначе перехвативший ссылку мог бы сжечь её у владельца;
+	// вместе с ErrUserAgentMismatch возвращается сама ссылка (для аудита).
+	Consume(ctx context.Context, token, userAgent string) (*Link, error)
+}
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) Store {
+	return &redisStore{client: client}
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func key(token string) string {
+	return "magic_link:" + hash(token)
+}
+
+func (s *redisStore) Create(ctx context.Context, tenantID string, userID int64, email, userAgent string, ttl time.Duration) (string, error) {
+	token := rand.Text()
+	data, err := json.Marshal(Link{
+		UserID:        userID,
+		Email:         email,
+		UserAgentHash: hash(userAgent),
+		CreatedAt:     time.Now().UTC(),
+		TenantID:      tenantID,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := s.client.Set(ctx, key(token), data, ttl).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *redisStore) Cooldown(ctx context.Context, tenantID, email string, cooldown time.Duration) (bool, error) {
+	// email в ключе хэшируется, как и токен: адреса не видны в Redis
+	return s.client.SetNX(ctx, "magic_link_cooldown:"+tenantID+":"+hash(email), 1, cooldown).Result()
+}
+
+func (s *redisStore) Consume(ctx context.Context, token, userAgent string) (*Link, error) {
+	k := key(token)
+	raw, err := s.client.Get(ctx, k).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var link Link
+	if err := json.Unmarshal(raw, &link); err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(link.UserAgentHash), []byte(hash(userAgent))) != 1 {
+		return &link, ErrUserAgentMismatch
+	}
+
+	// из двух одновременных запросов ссылку получит только удаливший ключ
+	n, err := s.client.Del(ctx, k).Result()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrNotFound
+	}
+	return &link, nil
+}
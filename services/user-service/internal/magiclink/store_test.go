@@ -0,0 +1,90 @@
+package magiclink
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestStore(t *testing.T) (Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisStore(client), mr
+}
+
+func TestConsumeOnce(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+	token, err := store.Create(ctx, "acme", 7, "alice@example.com", "Firefox", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := store.Consume(ctx, token, "Firefox")
+	if err != nil || link.UserID != 7 || link.Email != "alice@example.com" || link.TenantID != "acme" {
+		t.Fatalf("Consume = %+v, %v", link, err)
+	}
+	if _, err := store.Consume(ctx, token, "Firefox"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Consume err = %v, want ErrNotFound", err)
+	}
+}
+
+// Ссылка, открытая в другом браузере, отклоняется, но остаётся у владельца.
+func TestConsumeUserAgentMismatch(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+	token, _ := store.Create(ctx, "", 7, "alice@example.com", "Firefox", time.Minute)
+
+	link, err := store.Consume(ctx, token, "curl/8.0")
+	if !errors.Is(err, ErrUserAgentMismatch) || link == nil || link.UserID != 7 {
+		t.Fatalf("Consume from another user agent = %+v, %v", link, err)
+	}
+	if _, err := store.Consume(ctx, token, "Firefox"); err != nil {
+		t.Fatalf("owner's Consume after mismatch: %v", err)
+	}
+}
+
+func TestConsumeExpired(t *testing.T) {
+	store, mr := newTestStore(t)
+	ctx := context.Background()
+	token, _ := store.Create(ctx, "", 7, "alice@example.com", "Firefox", time.Minute)
+	mr.FastForward(time.Minute)
+	if _, err := store.Consume(ctx, token, "Firefox"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expired Consume err = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Consume(ctx, "unknown-token", "Firefox"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("unknown token err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	store, mr := newTestStore(t)
+	ctx := context.Background()
+	cooldown := func(tenantID, email string, want bool) {
+		t.Helper()
+		ok, err := store.Cooldown(ctx, tenantID, email, time.Minute)
+		if err != nil || ok != want {
+			t.Fatalf("Cooldown(%q, %q) = %v, %v, want %v", tenantID, email, ok, err, want)
+		}
+	}
+
+	cooldown("acme", "alice@example.com", true)
+	cooldown("acme", "alice@example.com", false)
+	// другой адрес и тот же адрес в другом тенанте не затронуты
+	cooldown("acme", "bob@example.com", true)
+	cooldown("globex", "alice@example.com", true)
+	for _, k := range mr.Keys() {
+		if k == "magic_link_cooldown:acme:alice@example.com" {
+			t.Fatal("email is stored in plain text")
+		}
+	}
+
+	mr.FastForward(time.Minute)
+	cooldown("acme", "alice@example.com", true)
+}
@@ -0,0 +1,30 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSender сохраняет каждое письмо в отдельный .eml файл каталога dir —
+// для локальной разработки: письмо можно открыть почтовым клиентом.
+type FileSender struct {
+	dir  string
+	from string
+}
+
+func NewFileSender(dir, from string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create mail dir: %w", err)
+	}
+	return &FileSender{dir: dir, from: from}, nil
+}
+
+func (f *FileSender) Send(_ context.Context, msg Message) error {
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), rand.Text()[:8])
+	return os.WriteFile(filepath.Join(f.dir, name), compose(f.from, msg, now), 0o600)
+}
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
+)
+
+// Message — письмо в виде простого текста.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender отправляет письма пользователям.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New создаёт Sender по mail.backend.
+func New(cfg config.MailConfig) (Sender, error) {
+	switch cfg.Backend {
+	case "log":
+		return logSender{}, nil
+	case "file":
+		return NewFileSender(cfg.Dir, cfg.From)
+	case "smtp":
+		return NewSMTPSender(cfg.SMTP, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q", cfg.Backend)
+	}
+}
+
+// logSender пишет в лог только адресата и тему: тело может содержать
+// секреты (ссылки для входа).
+type logSender struct{}
+
+func (logSender) Send(_ context.Context, msg Message) error {
+	logger.Infof("Mail: %q to %s (body not logged)", msg.Subject, msg.To)
+	return nil
+}
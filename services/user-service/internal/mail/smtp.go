@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+)
+
+// SMTPSender отправляет письма через SMTP сервер (STARTTLS, если сервер
+// его поддерживает; PLAIN авторизация, если задан username).
+type SMTPSender struct {
+	cfg  config.SMTPConfig
+	from string
+}
+
+func NewSMTPSender(cfg config.SMTPConfig, from string) *SMTPSender {
+	return &SMTPSender{cfg: cfg, from: from}
+}
+
+func (s *SMTPSender) Send(_ context.Context, msg Message) error {
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		host, _, _ := net.SplitHostPort(s.cfg.Addr)
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, host)
+	}
+	return smtp.SendMail(s.cfg.Addr, auth, s.from, []string{msg.To}, compose(s.from, msg, time.Now()))
+}
+
+// compose собирает письмо (RFC 5322) с телом в UTF-8.
+func compose(from string, msg Message, date time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,106 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrChallengeNotFound = errors.New("mfa challenge not found")
+
+// Challenge — вход, который прошёл первый фактор (Method — пароль, ссылка
+// из письма и т.п.) и ждёт второго.
+type Challenge struct {
+	UserID    int64  `json:"user_id"`
+	TenantID  string `json:"tenant_id,omitempty"`
+	Email     string `json:"email"`
+	Method    string `json:"method"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+type ChallengeStore interface {
+	// Create сохраняет challenge на ttl и возвращает его токен.
+	Create(ctx context.Context, c Challenge, ttl time.Duration) (string, error)
+	Get(ctx context.Context, token string) (*Challenge, error)
+	// Fail учитывает неверный код и удаляет challenge после maxAttempts
+	// неудач. Возвращает true, если challenge удалён.
+	Fail(ctx context.Context, token string, maxAttempts int) (bool, error)
+	Delete(ctx context.Context, token string) error
+}
+
+// redisChallengeStore: "mfa_challenge:<sha256(token)>" — JSON challenge,
+// "...:attempts" — счётчик неверных кодов с тем же сроком жизни.
+type redisChallengeStore struct {
+	client *redis.Client
+}
+
+func NewRedisChallengeStore(client *redis.Client) ChallengeStore {
+	return &redisChallengeStore{client: client}
+}
+
+func challengeKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "mfa_challenge:" + hex.EncodeToString(sum[:])
+}
+
+func (s *redisChallengeStore) Create(ctx context.Context, c Challenge, ttl time.Duration) (string, error) {
+	token := rand.Text()
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	if err := s.client.Set(ctx, challengeKey(token), data, ttl).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *redisChallengeStore) Get(ctx context.Context, token string) (*Challenge, error) {
+	raw, err := s.client.Get(ctx, challengeKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrChallengeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Challenge
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *redisChallengeStore) Fail(ctx context.Context, token string, maxAttempts int) (bool, error) {
+	key := challengeKey(token)
+	ttl, err := s.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	if ttl <= 0 {
+		return true, nil
+	}
+
+	attempts, err := s.client.Incr(ctx, key+":attempts").Result()
+	if err != nil {
+		return false, err
+	}
+	if attempts == 1 {
+		s.client.PExpire(ctx, key+":attempts", ttl)
+	}
+	if attempts >= int64(maxAttempts) {
+		return true, s.Delete(ctx, token)
+	}
+	return false, nil
+}
+
+func (s *redisChallengeStore) Delete(ctx context.Context, token string) error {
+	key := challengeKey(token)
+	return s.client.Del(ctx, key, key+":attempts").Err()
+}
@@ -0,0 +1,86 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestChallenges(t *testing.T) (ChallengeStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisChallengeStore(client), mr
+}
+
+// После maxAttempts неверных кодов challenge удаляется: перебирать коды
+// дальше нужно, заново пройдя первый фактор.
+func TestChallengeBurnsAfterMaxAttempts(t *testing.T) {
+	store, mr := newTestChallenges(t)
+	ctx := context.Background()
+	want := Challenge{UserID: 7, Email: "alice@example.com", Method: "password", IP: "10.0.0.1"}
+	token, err := store.Create(ctx, want, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Get(ctx, token); err != nil || *got != want {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	if mr.Exists("mfa_challenge:" + token) {
+		t.Fatal("token is stored in plain text")
+	}
+
+	for i := 1; i < 3; i++ {
+		burned, err := store.Fail(ctx, token, 3)
+		if err != nil || burned {
+			t.Fatalf("attempt %d: burned = %v, %v", i, burned, err)
+		}
+	}
+	burned, err := store.Fail(ctx, token, 3)
+	if err != nil || !burned {
+		t.Fatalf("attempt 3: burned = %v, %v", burned, err)
+	}
+	if _, err := store.Get(ctx, token); !errors.Is(err, ErrChallengeNotFound) {
+		t.Fatalf("burned challenge: Get err = %v", err)
+	}
+	if len(mr.Keys()) != 0 {
+		t.Fatalf("keys left: %v", mr.Keys())
+	}
+	if burned, err := store.Fail(ctx, token, 3); err != nil || !burned {
+		t.Fatalf("Fail on a burned challenge = %v, %v", burned, err)
+	}
+}
+
+func TestChallengeExpires(t *testing.T) {
+	store, mr := newTestChallenges(t)
+	ctx := context.Background()
+	token, err := store.Create(ctx, Challenge{UserID: 7}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Fail(ctx, token, 3)
+	mr.FastForward(time.Minute)
+	if _, err := store.Get(ctx, token); !errors.Is(err, ErrChallengeNotFound) {
+		t.Fatalf("expired challenge: Get err = %v", err)
+	}
+	if len(mr.Keys()) != 0 {
+		t.Fatalf("attempt counter outlived the challenge: %v", mr.Keys())
+	}
+}
+
+func TestChallengeDelete(t *testing.T) {
+	store, _ := newTestChallenges(t)
+	ctx := context.Background()
+	token, _ := store.Create(ctx, Challenge{UserID: 7}, time.Minute)
+	if err := store.Delete(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, token); !errors.Is(err, ErrChallengeNotFound) {
+		t.Fatalf("deleted challenge: Get err = %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package mfa
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func newTestCipher(t *testing.T, fill byte) *Cipher {
+	t.Helper()
+	c, err := NewCipher(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{fill}, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	c := newTestCipher(t, 1)
+	secret, ad := []byte("totp secret bytes"), []byte("totp:1")
+	a, b := c.Seal(secret, ad), c.Seal(secret, ad)
+	if bytes.Equal(a, b) {
+		t.Fatal("Seal reused a nonce")
+	}
+	got, err := c.Open(a, ad)
+	if err != nil || !bytes.Equal(got, secret) {
+		t.Fatalf("Open = %q, %v", got, err)
+	}
+}
+
+// Любое изменение шифротекста, чужая associated data или чужой ключ
+// приводят к ошибке, а не к другому секрету.
+func TestCipherRejectsTampering(t *testing.T) {
+	c := newTestCipher(t, 1)
+	ad := []byte("totp:1")
+	sealed := c.Seal([]byte("totp secret bytes"), ad)
+	flip := func(i int) []byte {
+		out := bytes.Clone(sealed)
+		out[i] ^= 0x01
+		return out
+	}
+	tests := []struct {
+		name   string
+		cipher *Cipher
+		sealed []byte
+		ad     []byte
+	}{
+		{"nonce", c, flip(0), ad},
+		{"ciphertext", c, flip(len(sealed) / 2), ad},
+		{"tag", c, flip(len(sealed) - 1), ad},
+		{"truncated", c, sealed[:len(sealed)-1], ad},
+		{"too short", c, sealed[:5], ad},
+		{"other user", c, sealed, []byte("totp:2")},
+		{"other key", newTestCipher(t, 2), sealed, ad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := tt.cipher.Open(tt.sealed, tt.ad); err == nil {
+				t.Fatalf("Open accepted tampered data: %q", got)
+			}
+		})
+	}
+}
+
+func TestNewCipherRejectsBadKeys(t *testing.T) {
+	for _, key := range []string{"", "not base64!", base64.StdEncoding.EncodeToString(make([]byte, 16))} {
+		if _, err := NewCipher(key); err == nil {
+			t.Errorf("NewCipher(%q) accepted the key", key)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// recoveryAlphabet без похожих символов (0/O, 1/I/L).
+const recoveryAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// GenerateRecoveryCodes возвращает n одноразовых кодов вида XXXXX-XXXXX.
+func GenerateRecoveryCodes(n int) []string {
+	codes := make([]string, n)
+	buf := make([]byte, 10)
+	for i := range codes {
+		rand.Read(buf)
+		var b strings.Builder
+		for j, c := range buf {
+			if j == 5 {
+				b.WriteByte('-')
+			}
+			// 256 не делится на 31 нацело; смещение распределения здесь
+			// несущественно при ~49 битах энтропии на код
+			b.WriteByte(recoveryAlphabet[int(c)%len(recoveryAlphabet)])
+		}
+		codes[i] = b.String()
+	}
+	return codes
+}
+
+// HashRecoveryCode — хэш кода для хранения. Коды случайны и длинны,
+// поэтому медленный хэш (bcrypt) не нужен; регистр и дефисы не важны.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,37 @@
+package mfa
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	format := regexp.MustCompile(`^[` + recoveryAlphabet + `]{5}-[` + recoveryAlphabet + `]{5}$`)
+	codes := GenerateRecoveryCodes(10)
+	seen := map[string]bool{}
+	for _, c := range codes {
+		if !format.MatchString(c) {
+			t.Errorf("code %q has unexpected format", c)
+		}
+		if seen[c] {
+			t.Errorf("code %q generated twice", c)
+		}
+		seen[c] = true
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+}
+
+// Пользователь может ввести код без дефиса, с пробелами и в нижнем регистре.
+func TestHashRecoveryCode(t *testing.T) {
+	want := HashRecoveryCode("ABCDE-FGHJK")
+	for _, code := range []string{"abcde-fghjk", "ABCDEFGHJK", " abcde fghjk "} {
+		if got := HashRecoveryCode(code); got != want {
+			t.Errorf("HashRecoveryCode(%q) differs from the canonical form", code)
+		}
+	}
+	if HashRecoveryCode("ABCDE-FGHJM") == want {
+		t.Fatal("different codes have the same hash")
+	}
+}
@@ -0,0 +1,84 @@
+package mfa
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Параметры TOTP (RFC 6238) — те, что понимают все приложения-
+// аутентификаторы: HMAC-SHA1, 6 цифр, шаг 30 секунд.
+const (
+	Digits     = 6
+	Period     = 30 * time.Second
+	secretSize = 20
+	// skew — сколько соседних шагов принимается из-за расхождения часов.
+	skew = 1
+)
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret возвращает новый случайный секрет.
+func GenerateSecret() []byte {
+	secret := make([]byte, secretSize)
+	rand.Read(secret)
+	return secret
+}
+
+// EncodeSecret — секрет в base32 для ручного ввода в приложение.
+func EncodeSecret(secret []byte) string {
+	return secretEncoding.EncodeToString(secret)
+}
+
+// URI возвращает otpauth:// ссылку (формат Google Authenticator Key URI).
+func URI(issuer, account string, secret []byte) string {
+	label := url.PathEscape(issuer + ":" + account)
+	q := url.Values{}
+	q.Set("secret", EncodeSecret(secret))
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(Digits))
+	q.Set("period", fmt.Sprint(int(Period.Seconds())))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// Step — номер шага времени для t.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code вычисляет код для шага step (RFC 4226, dynamic truncation).
+func Code(secret []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1_000_000)
+}
+
+// Verify ищет шаг, для которого code верен, среди шагов около now. Шаг
+// нужен вызывающему для защиты от повторного использования кода.
+func Verify(secret []byte, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+	current := Step(now)
+	for step := current - skew; step <= current+skew; step++ {
+		if subtle.ConstantTimeCompare([]byte(Code(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,77 @@
+package mfa
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret — ключ SHA1 из приложения B RFC 6238.
+var rfc6238Secret = []byte("12345678901234567890")
+
+// Векторы RFC 6238 (SHA1); RFC приводит 8 цифр, сервис выдаёт последние 6.
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		step := Step(time.Unix(tt.unix, 0))
+		if got := Code(rfc6238Secret, step); got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+		got, ok := Verify(rfc6238Secret, tt.want, time.Unix(tt.unix, 0))
+		if !ok || got != step {
+			t.Errorf("Verify at %d = %d, %v, want %d", tt.unix, got, ok, step)
+		}
+	}
+}
+
+func TestVerifySkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	tests := []struct {
+		step   int64
+		code   string
+		wantOK bool
+	}{
+		{current - 2, Code(rfc6238Secret, current-2), false},
+		{current - 1, Code(rfc6238Secret, current-1), true},
+		{current, " " + Code(rfc6238Secret, current) + " ", true},
+		{current + 1, Code(rfc6238Secret, current+1), true},
+		{current + 2, Code(rfc6238Secret, current+2), false},
+		{current, "12345", false},
+		{current, "1234567", false},
+	}
+	for _, tt := range tests {
+		step, ok := Verify(rfc6238Secret, tt.code, now)
+		if ok != tt.wantOK || (ok && step != tt.step) {
+			t.Errorf("Verify(%q) for step %d = %d, %v, want %v", tt.code, tt.step-current, step, ok, tt.wantOK)
+		}
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Acme Corp", "alice@example.com", rfc6238Secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Acme Corp:alice@example.com" {
+		t.Fatalf("URI = %s", u)
+	}
+	q := u.Query()
+	want := map[string]string{"secret": "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "issuer": "Acme Corp",
+		"algorithm": "SHA1", "digits": "6", "period": "30"}
+	for k, v := range want {
+		if q.Get(k) != v {
+			t.Errorf("%s = %q, want %q", k, q.Get(k), v)
+		}
+	}
+}
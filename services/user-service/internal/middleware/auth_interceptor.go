@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/DmitriiPro/user-service/internal/apikey"
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/session"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// AuthInterceptor опознаёт пользователя по заголовку authorization:
+// Bearer <токен сессии>, или машинного клиента по API ключу (Bearer usk_...).
+// Запросы без заголовка проходят анонимно — права проверяют сами методы;
+// недействительный токен — Unauthenticated, метод вне scopes ключа —
+// PermissionDenied. Сервис, уже опознанный по mTLS, токеном не
+// переопределяется.
+func AuthInterceptor(sessions session.Store, apiKeys apikey.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		header := first(md, "authorization")
+		if header == "" {
+			return handler(ctx, req)
+		}
+		if _, ok := auth.FromContext(ctx); ok {
+			return handler(ctx, req)
+		}
+
+		scheme, token, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return nil, apperr.New(codes.Unauthenticated, apperr.ReasonUnauthenticated, "authorization header must be Bearer <token>", nil)
+		}
+
+		token = strings.TrimSpace(token)
+		if apikey.IsKey(token) {
+			return authenticateAPIKey(ctx, apiKeys, token, req, info, handler)
+		}
+
+		sess, err := sessions.Get(ctx, token)
+		if errors.Is(err, session.ErrNotFound) {
+			return nil, apperr.New(codes.Unauthenticated, apperr.ReasonUnauthenticated, "session is invalid or expired", nil)
+		}
+		if err != nil {
+			logger.Errorf("gRPC %s: session lookup failed: %v", info.FullMethod, err)
+			return nil, apperr.Internal()
+		}
+
+		ctx = auth.WithPrincipal(ctx, &auth.Principal{
+			Type:      auth.PrincipalUser,
+			ID:        strconv.FormatInt(sess.UserID, 10),
+			SessionID: sess.ID,
+			TenantID:  tenant.OrDefault(sess.TenantID),
+		})
+		return handler(ctx, req)
+	}
+}
+
+func authenticateAPIKey(ctx context.Context, apiKeys apikey.Authenticator, token string, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	key, err := apiKeys.Authenticate(ctx, token)
+	if errors.Is(err, apikey.ErrInvalid) {
+		return nil, apperr.New(codes.Unauthenticated, apperr.ReasonUnauthenticated, "api key is invalid, expired or revoked", nil)
+	}
+	if err != nil {
+		logger.Errorf("gRPC %s: api key lookup failed: %v", info.FullMethod, err)
+		return nil, apperr.Internal()
+	}
+
+	method := path.Base(info.FullMethod)
+	if !key.Allows(method) {
+		logger.Warnf("gRPC %s: api key %s has no scope for it", info.FullMethod, key.ID)
+		return nil, apperr.New(codes.PermissionDenied, apperr.ReasonPermissionDenied,
+			fmt.Sprintf("api key is not allowed to call %s", method), nil)
+	}
+
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Type: auth.PrincipalAPIKey, ID: key.ID, TenantID: tenant.OrDefault(key.TenantID)})
+	return handler(ctx, req)
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientInfoInterceptor кладёт в контекст auth.ClientInfo. Для вызовов из
+// встроенного gateway (проверяется gatewayToken) IP и User-Agent берутся из
+// переданных им метаданных, для остальных — из соединения.
+func ClientInfoInterceptor(gatewayToken string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var client auth.ClientInfo
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			client.IP = remoteHost(p.Addr.String())
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		client.UserAgent = first(md, "user-agent")
+		if token := first(md, mdGatewayToken); token != "" &&
+			subtle.ConstantTimeCompare([]byte(token), []byte(gatewayToken)) == 1 {
+			client.ViaGateway = true
+			client.IP = first(md, mdClientIP)
+			client.UserAgent = first(md, mdClientUserAgent)
+		}
+
+		return handler(auth.WithClientInfo(ctx, client), req)
+	}
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
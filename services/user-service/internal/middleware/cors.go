@@ -1,12 +1,43 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+	"slices"
+	"sync/atomic"
+)
 
-func CORSMiddleware(next http.Handler) http.Handler {
+// CORS хранит список разрешённых origin; список можно заменить без
+// перезапуска сервера, запросы в обработке видят старый или новый список целиком.
+type CORS struct {
+	origins atomic.Pointer[[]string]
+}
+
+func NewCORS(origins []string) *CORS {
+	c := &CORS{}
+	c.SetAllowedOrigins(origins)
+	return c
+}
+
+func (c *CORS) SetAllowedOrigins(origins []string) {
+	origins = slices.Clone(origins)
+	c.origins.Store(&origins)
+}
+
+func (c *CORS) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		origins := *c.origins.Load()
+		origin := r.Header.Get("Origin")
+
+		switch {
+		case slices.Contains(origins, "*"):
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		case origin != "" && slices.Contains(origins, origin):
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept-Language, Idempotency-Key, X-Request-Id, X-Tenant-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-Id, Retry-After, Idempotent-Replayed, Content-Language")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Метаданные, которые выставляет только встроенный HTTP gateway. gRPC
+// сервер доверяет им, лишь если запрос несёт токен gateway.
+const (
+	mdGatewayToken    = "x-gateway-token"
+	mdClientIP        = "x-client-ip"
+	mdClientUserAgent = "x-client-user-agent"
+)
+
+var gatewayOnlyMetadata = map[string]bool{
+	mdGatewayToken:    true,
+	mdClientIP:        true,
+	mdClientUserAgent: true,
+}
+
+// GatewayMetadata передаёт в gRPC IP и User-Agent исходного HTTP клиента
+// (используется с runtime.WithMetadata).
+func GatewayMetadata(_ context.Context, r *http.Request) metadata.MD {
+	return metadata.Pairs(
+		mdClientIP, ClientIP(r),
+		mdClientUserAgent, r.UserAgent(),
+	)
+}
+
+// GatewayHeaderMatcher — как runtime.DefaultHeaderMatcher, но передаёт
+// Idempotency-Key, X-Request-Id и X-Tenant-ID и не даёт HTTP клиенту подделать служебные метаданные
+// через Grpc-Metadata-*.
+func GatewayHeaderMatcher(defaultMatcher func(string) (string, bool)) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		if strings.EqualFold(key, "Idempotency-Key") {
+			return mdIdempotencyKey, true
+		}
+		if strings.EqualFold(key, requestIDHeader) {
+			return mdRequestID, true
+		}
+		if strings.EqualFold(key, tenant.Header) {
+			return tenant.MetadataKey, true
+		}
+
+		name, ok := defaultMatcher(key)
+		if ok && gatewayOnlyMetadata[strings.ToLower(name)] {
+			return "", false
+		}
+		return name, ok
+	}
+}
+
+// GatewayOutgoingHeaderMatcher отдаёт retry-after, idempotent-replayed и
+// content-disposition (выгрузки файлами) как обычные HTTP заголовки, остальные метаданные — с префиксом Grpc-Metadata-.
+func GatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	switch key {
+	case "retry-after":
+		return "Retry-After", true
+	case mdIdempotentReplayed:
+		return "Idempotent-Replayed", true
+	case "content-disposition":
+		return "Content-Disposition", true
+	}
+	return "Grpc-Metadata-" + key, true
+}
+
+// GatewayTokenInterceptor подписывает исходящие вызовы gateway токеном,
+// известным только этому процессу.
+func GatewayTokenInterceptor(token string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		md = md.Copy()
+		md.Set(mdGatewayToken, token)
+		return invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+	}
+}
@@ -1,17 +1,24 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
 	"runtime/debug"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"google.golang.org/grpc/codes"
 )
 
 func HTTPRecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("PANIC recovered in HTTP handler: %v\n%s", err, debug.Stack())
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				logger.Errorf("PANIC recovered in HTTP handler: %v\n%s", err, debug.Stack())
+				WriteProblem(w, r, Problem{
+					Status: http.StatusInternalServerError,
+					Code:   codes.Internal.String(),
+					Reason: apperr.ReasonInternal,
+				})
 			}
 		}()
 
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/idempotency"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	mdIdempotencyKey      = "idempotency-key"
+	mdIdempotentReplayed  = "idempotent-replayed"
+	maxIdempotencyKeySize = 255
+)
+
+// Ошибки с такими кодами повторятся при повторе запроса — их сохраняем и
+// воспроизводим. Остальные (Internal, Unavailable, ...) освобождают ключ.
+var replayableCodes = []codes.Code{
+	codes.InvalidArgument,
+	codes.NotFound,
+	codes.AlreadyExists,
+	codes.PermissionDenied,
+	codes.FailedPrecondition,
+	codes.OutOfRange,
+	codes.Unauthenticated,
+}
+
+// IdempotencyInterceptor сохраняет первый ответ на запрос с метаданными
+// idempotency-key (HTTP заголовок Idempotency-Key) и воспроизводит его для
+// повторов с тем же телом. Повтор с другим телом получает FailedPrecondition,
+// параллельный повтор, пока первый запрос выполняется, — Aborted.
+func IdempotencyInterceptor(store idempotency.Store, cfg config.IdempotencyConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !cfg.Enabled || !slices.Contains(cfg.Methods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		key := first(md, mdIdempotencyKey)
+		if key == "" {
+			return handler(ctx, req)
+		}
+		if len(key) > maxIdempotencyKeySize {
+			return nil, apperr.InvalidFields(apperr.FieldViolation("Idempotency-Key",
+				fmt.Sprintf("idempotency key must be at most %d characters", maxIdempotencyKeySize)))
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return handler(ctx, req)
+		}
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+
+		// Ключ действует в пределах тенанта и клиента: принципала, а для
+		// анонимных запросов — IP адреса. Анонимные клиенты за одним NAT
+		// по-прежнему делят ключи, но чужой ответ получит лишь тот, кто
+		// прислал то же самое тело.
+		scope, ok := idempotencyScope(ctx)
+		if !ok {
+			logger.Debugf("Idempotency: anonymous %s without client IP, executing without key", info.FullMethod)
+			return handler(ctx, req)
+		}
+		storeKey := "idempotency:" + info.FullMethod + ":" + tenant.FromContext(ctx) + ":" + scope + ":" + key
+
+		rec, created, err := store.Begin(ctx, storeKey, hash, cfg.LockTTL)
+		if err != nil {
+			logger.Warnf("Idempotency: store unavailable, executing %s without key: %v", info.FullMethod, err)
+			return handler(ctx, req)
+		}
+
+		if !created {
+			return replay(ctx, rec, hash)
+		}
+
+		resp, err := handler(ctx, req)
+		saveCtx := context.WithoutCancel(ctx)
+
+		if err != nil && !slices.Contains(replayableCodes, status.Code(err)) {
+			if relErr := store.Release(saveCtx, storeKey); relErr != nil {
+				logger.Errorf("Idempotency: failed to release key for %s: %v", info.FullMethod, relErr)
+			}
+			return resp, err
+		}
+
+		if err != nil {
+			rec.Status, _ = proto.Marshal(status.Convert(err).Proto())
+		} else if m, ok := resp.(proto.Message); ok {
+			a, marshalErr := anypb.New(m)
+			if marshalErr == nil {
+				rec.Response, _ = proto.Marshal(a)
+			}
+		}
+		if saveErr := store.Complete(saveCtx, storeKey, rec, cfg.TTL); saveErr != nil {
+			logger.Errorf("Idempotency: failed to save response for %s: %v", info.FullMethod, saveErr)
+		}
+
+		return resp, err
+	}
+}
+
+// idempotencyScope — чей это ключ; false, если клиента не различить.
+func idempotencyScope(ctx context.Context) (string, bool) {
+	if p, ok := auth.FromContext(ctx); ok {
+		return string(p.Type) + ":" + p.ID, true
+	}
+	if ip := auth.ClientInfoFromContext(ctx).IP; ip != "" {
+		return "anonymous:" + ip, true
+	}
+	return "", false
+}
+
+func replay(ctx context.Context, rec *idempotency.Record, hash string) (interface{}, error) {
+	if rec.RequestHash != hash {
+		return nil, apperr.New(codes.FailedPrecondition, apperr.ReasonIdempotencyKeyReused,
+			"idempotency key was already used with a different request", nil)
+	}
+	if rec.State != idempotency.StateCompleted {
+		return nil, apperr.New(codes.Aborted, apperr.ReasonIdempotencyInProgress,
+			"request with this idempotency key is still in progress", nil)
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(mdIdempotentReplayed, "true"))
+
+	if len(rec.Status) > 0 {
+		var st spb.Status
+		if err := proto.Unmarshal(rec.Status, &st); err != nil {
+			return nil, status.Error(codes.Internal, "failed to replay stored response")
+		}
+		return nil, status.FromProto(&st).Err()
+	}
+
+	var a anypb.Any
+	if err := proto.Unmarshal(rec.Response, &a); err != nil {
+		return nil, status.Error(codes.Internal, "failed to replay stored response")
+	}
+	resp, err := a.UnmarshalNew()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to replay stored response")
+	}
+	return resp, nil
+}
@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/idempotency"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const idempotentMethod = "/user.v1.UserService/CreateUser"
+
+// idempotencyTest — IdempotencyInterceptor перед обработчиком, который
+// считает вызовы и отвечает номером вызова.
+type idempotencyTest struct {
+	interceptor grpc.UnaryServerInterceptor
+	calls       int
+	// err, если задан, возвращается обработчиком вместо ответа.
+	err error
+	// started получает сигнал о начале вызова, block держит обработчик
+	// до закрытия канала; оба не обязательны.
+	started chan struct{}
+	block   chan struct{}
+}
+
+func newIdempotencyTest(t *testing.T) *idempotencyTest {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	cfg := config.IdempotencyConfig{Enabled: true, TTL: time.Hour, LockTTL: time.Minute, Methods: []string{idempotentMethod}}
+	return &idempotencyTest{interceptor: IdempotencyInterceptor(idempotency.NewRedisStore(client), cfg)}
+}
+
+// call выполняет запрос с телом body и ключом key; ответ — строка ответа.
+func (it *idempotencyTest) call(ctx context.Context, key, body string) (string, error) {
+	if key != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(mdIdempotencyKey, key))
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: idempotentMethod}
+	resp, err := it.interceptor(ctx, wrapperspb.String(body), info, func(context.Context, interface{}) (interface{}, error) {
+		it.calls++
+		n := it.calls
+		if it.started != nil {
+			it.started <- struct{}{}
+		}
+		if it.block != nil {
+			<-it.block
+		}
+		if it.err != nil {
+			return nil, it.err
+		}
+		return wrapperspb.String(fmt.Sprintf("response %d", n)), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.(*wrapperspb.StringValue).GetValue(), nil
+}
+
+func asPrincipal(typ auth.PrincipalType, id string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Type: typ, ID: id})
+}
+
+func fromIP(ip string) context.Context {
+	return auth.WithClientInfo(context.Background(), auth.ClientInfo{IP: ip})
+}
+
+func wantIdempotencyReason(t *testing.T, err error, code codes.Code, reason string) {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != code {
+		t.Fatalf("code = %v (%v), want %v", st.Code(), err, code)
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetReason() == reason {
+			return
+		}
+	}
+	t.Fatalf("error %v has no reason %s", err, reason)
+}
+
+func TestIdempotencyInterceptorReplay(t *testing.T) {
+	it := newIdempotencyTest(t)
+	ctx := asPrincipal(auth.PrincipalUser, "1")
+
+	first, err := it.call(ctx, "key-1", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := it.call(ctx, "key-1", "alice")
+	if err != nil || again != first || it.calls != 1 {
+		t.Fatalf("replay = %q (%v) after %d calls, want %q from one call", again, err, it.calls, first)
+	}
+
+	// другое тело с тем же ключом — ошибка клиента, а не новый запрос
+	_, err = it.call(ctx, "key-1", "bob")
+	wantIdempotencyReason(t, err, codes.FailedPrecondition, apperr.ReasonIdempotencyKeyReused)
+
+	// без ключа и с новым ключом запрос выполняется
+	it.call(ctx, "", "alice")
+	it.call(ctx, "key-2", "alice")
+	if it.calls != 3 {
+		t.Fatalf("handler called %d times, want 3", it.calls)
+	}
+}
+
+func TestIdempotencyInterceptorErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantReplay bool
+	}{
+		// ошибка повторится и при повторе — её воспроизводим
+		{"client error is replayed", status.Error(codes.AlreadyExists, "email taken"), true},
+		// временная ошибка освобождает ключ для повтора
+		{"server error releases key", status.Error(codes.Unavailable, "db down"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := newIdempotencyTest(t)
+			ctx := asPrincipal(auth.PrincipalUser, "1")
+			it.err = tt.err
+			if _, err := it.call(ctx, "key-1", "alice"); status.Code(err) != status.Code(tt.err) {
+				t.Fatalf("first call err = %v", err)
+			}
+
+			it.err = nil
+			resp, err := it.call(ctx, "key-1", "alice")
+			if tt.wantReplay {
+				if status.Code(err) != status.Code(tt.err) || it.calls != 1 {
+					t.Fatalf("retry = %q, %v after %d calls, want replayed %v", resp, err, it.calls, tt.err)
+				}
+				return
+			}
+			if err != nil || resp != "response 2" {
+				t.Fatalf("retry = %q, %v, want a new execution", resp, err)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInterceptorInProgress(t *testing.T) {
+	it := newIdempotencyTest(t)
+	ctx := asPrincipal(auth.PrincipalUser, "1")
+	it.started, it.block = make(chan struct{}), make(chan struct{})
+
+	done := make(chan error)
+	go func() {
+		_, err := it.call(ctx, "key-1", "alice")
+		done <- err
+	}()
+	<-it.started
+	_, err := it.call(ctx, "key-1", "alice")
+	wantIdempotencyReason(t, err, codes.Aborted, apperr.ReasonIdempotencyInProgress)
+	// другое тело, пока ключ занят, — по-прежнему ошибка клиента
+	_, err = it.call(ctx, "key-1", "bob")
+	wantIdempotencyReason(t, err, codes.FailedPrecondition, apperr.ReasonIdempotencyKeyReused)
+
+	close(it.block)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := it.call(ctx, "key-1", "alice"); err != nil || resp != "response 1" {
+		t.Fatalf("retry after completion = %q, %v", resp, err)
+	}
+}
+
+// Один и тот же ключ у разных клиентов — разные запросы.
+func TestIdempotencyInterceptorScopes(t *testing.T) {
+	inTenant := func(ctx context.Context, id string) context.Context { return tenant.WithID(ctx, id) }
+	tests := []struct {
+		name        string
+		first, next context.Context
+		wantShared  bool
+	}{
+		{"same user", asPrincipal(auth.PrincipalUser, "1"), asPrincipal(auth.PrincipalUser, "1"), true},
+		{"different users", asPrincipal(auth.PrincipalUser, "1"), asPrincipal(auth.PrincipalUser, "2"), false},
+		{"user and api key with same id", asPrincipal(auth.PrincipalUser, "1"), asPrincipal(auth.PrincipalAPIKey, "1"), false},
+		{"service in different tenants", inTenant(asPrincipal(auth.PrincipalService, "billing"), "acme"),
+			inTenant(asPrincipal(auth.PrincipalService, "billing"), "globex"), false},
+		{"anonymous from same IP", fromIP("203.0.113.7"), fromIP("203.0.113.7"), true},
+		{"anonymous from different IPs", fromIP("203.0.113.7"), fromIP("198.51.100.1"), false},
+		{"anonymous and user", fromIP("203.0.113.7"), asPrincipal(auth.PrincipalUser, "1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := newIdempotencyTest(t)
+			if _, err := it.call(tt.first, "shared-key", "alice"); err != nil {
+				t.Fatal(err)
+			}
+			// тот же клиент с тем же ключом и телом получает сохранённый
+			// ответ, другой клиент — выполняет свой запрос
+			resp, err := it.call(tt.next, "shared-key", "alice")
+			if err != nil || (resp == "response 1") != tt.wantShared {
+				t.Fatalf("second client got %q (%v), want shared %v", resp, err, tt.wantShared)
+			}
+			if tt.wantShared {
+				return
+			}
+			// и может использовать тот же ключ с другим телом
+			it2 := newIdempotencyTest(t)
+			it2.call(tt.first, "shared-key", "alice")
+			if _, err := it2.call(tt.next, "shared-key", "bob"); err != nil {
+				t.Fatalf("other client's key with another body: %v", err)
+			}
+		})
+	}
+}
+
+// Анонимный запрос без IP не с чем связать — ключ не применяется.
+func TestIdempotencyInterceptorAnonymousWithoutIP(t *testing.T) {
+	it := newIdempotencyTest(t)
+	for range 2 {
+		if _, err := it.call(context.Background(), "key-1", "alice"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if it.calls != 2 {
+		t.Fatalf("handler called %d times, want 2", it.calls)
+	}
+}
+
+func TestIdempotencyInterceptorKeyTooLong(t *testing.T) {
+	it := newIdempotencyTest(t)
+	_, err := it.call(asPrincipal(auth.PrincipalUser, "1"), strings.Repeat("k", maxIdempotencyKeySize+1), "alice")
+	if status.Code(err) != codes.InvalidArgument || it.calls != 0 {
+		t.Fatalf("err = %v after %d calls", err, it.calls)
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/DmitriiPro/user-service/internal/i18n"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// LocaleInterceptor определяет язык по метаданным accept-language (через
+// gateway — заголовок Accept-Language) и добавляет к ошибкам сервиса
+// google.rpc.LocalizedMessage на этом языке.
+func LocaleInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		accept := first(md, "accept-language")
+		if accept == "" {
+			accept = first(md, "grpcgateway-accept-language")
+		}
+		ctx = i18n.WithLocale(ctx, i18n.Match(accept))
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			err = i18n.Localize(ctx, err)
+		}
+		return resp, err
+	}
+}
@@ -1,22 +1,23 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
 	"time"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
 )
 
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		log.Printf("HTTP %s %s - started", r.Method, r.URL.Path)
+		logger.Debugf("HTTP %s %s - started", r.Method, r.URL.Path)
 
 		// Создаем wrapper для ResponseWriter чтобы перехватить статус
 		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 		next.ServeHTTP(rw, r)
 
-		log.Printf("HTTP %s %s - completed in %v with status %d",
+		logger.Infof("HTTP %s %s - completed in %v with status %d",
 		r.Method, r.URL.Path, time.Since(start), rw.statusCode)
 	})
 }
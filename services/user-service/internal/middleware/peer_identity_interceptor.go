@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// PeerIdentityInterceptor кладёт в контекст идентичность сервиса из
+// проверенного клиентского сертификата (mTLS). Если allowed не пуст,
+// сервисы вне списка получают PermissionDenied. Запросы без сертификата
+// пропускаются: обязательность mTLS задаётся на уровне TLS (client_auth).
+// Сертификат встроенного gateway говорит лишь о самом gateway, поэтому
+// его вызовы идут от имени HTTP клиента, а не сервиса.
+func PeerIdentityInterceptor(allowed []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+		tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+		if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+			return handler(ctx, req)
+		}
+
+		identity, ok := auth.ServiceIdentity(tlsInfo.State.VerifiedChains[0][0])
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "client certificate has no SAN identity")
+		}
+		if len(allowed) > 0 && !slices.Contains(allowed, identity) {
+			logger.Warnf("gRPC %s: service %s is not allowed", info.FullMethod, identity)
+			return nil, status.Errorf(codes.PermissionDenied, "service %s is not allowed", identity)
+		}
+
+		if auth.ClientInfoFromContext(ctx).ViaGateway {
+			return handler(ctx, req)
+		}
+		ctx = auth.WithPrincipal(ctx, &auth.Principal{Type: auth.PrincipalService, ID: identity})
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// withPeerCert — контекст запроса, пришедшего по mTLS с проверенным
+// клиентским сертификатом cert.
+func withPeerCert(ctx context.Context, cert *x509.Certificate) context.Context {
+	state := tls.ConnectionState{}
+	if cert != nil {
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+	return peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+}
+
+func spiffe(id string) *url.URL {
+	u, _ := url.Parse(id)
+	return u
+}
+
+func TestPeerIdentityInterceptor(t *testing.T) {
+	allowed := []string{"spiffe://example.org/billing", "reports.internal"}
+	tests := []struct {
+		name       string
+		cert       *x509.Certificate
+		gateway    bool
+		want       codes.Code
+		wantCaller string
+	}{
+		{name: "no client certificate", want: codes.OK},
+		{name: "allowed URI SAN", cert: &x509.Certificate{URIs: []*url.URL{spiffe("spiffe://example.org/billing")}},
+			want: codes.OK, wantCaller: "spiffe://example.org/billing"},
+		{name: "allowed DNS SAN", cert: &x509.Certificate{DNSNames: []string{"reports.internal"}},
+			want: codes.OK, wantCaller: "reports.internal"},
+		// URI SAN главнее DNS SAN: разрешённое DNS имя не спасает чужой URI
+		{name: "URI SAN not allowed", cert: &x509.Certificate{URIs: []*url.URL{spiffe("spiffe://example.org/mallory")},
+			DNSNames: []string{"reports.internal"}}, want: codes.PermissionDenied},
+		{name: "DNS SAN not allowed", cert: &x509.Certificate{DNSNames: []string{"mallory.internal"}},
+			want: codes.PermissionDenied},
+		{name: "certificate without SAN", cert: &x509.Certificate{}, want: codes.Unauthenticated},
+		{name: "gateway acts for HTTP client", cert: &x509.Certificate{DNSNames: []string{"reports.internal"}},
+			gateway: true, want: codes.OK},
+		{name: "gateway certificate not allowed", cert: &x509.Certificate{DNSNames: []string{"mallory.internal"}},
+			gateway: true, want: codes.PermissionDenied},
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/user.v1.UserService/GetUser"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := withPeerCert(context.Background(), tt.cert)
+			if tt.gateway {
+				ctx = auth.WithClientInfo(ctx, auth.ClientInfo{ViaGateway: true})
+			}
+			called := false
+			var caller string
+			_, err := PeerIdentityInterceptor(allowed)(ctx, nil, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+				called = true
+				if p, ok := auth.FromContext(ctx); ok {
+					caller = p.ID
+				}
+				return nil, nil
+			})
+			if status.Code(err) != tt.want {
+				t.Fatalf("code = %v (%v), want %v", status.Code(err), err, tt.want)
+			}
+			if called != (tt.want == codes.OK) || caller != tt.wantCaller {
+				t.Fatalf("handler called %v as %q, want %q", called, caller, tt.wantCaller)
+			}
+		})
+	}
+}
+
+// Пустой список разрешает любой сервис с SAN.
+func TestPeerIdentityInterceptorAllowsAnyWithoutList(t *testing.T) {
+	ctx := withPeerCert(context.Background(), &x509.Certificate{DNSNames: []string{"anything.internal"}})
+	var p *auth.Principal
+	_, err := PeerIdentityInterceptor(nil)(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		p, _ = auth.FromContext(ctx)
+		return nil, nil
+	})
+	if err != nil || p == nil || p.Type != auth.PrincipalService || p.ID != "anything.internal" {
+		t.Fatalf("principal = %+v, err = %v", p, err)
+	}
+}
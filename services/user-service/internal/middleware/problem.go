@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/i18n"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem — тело ответа об ошибке по RFC 7807. Code, Reason, RequestID и
+// Errors — расширения: gRPC код, стабильная причина из ErrorInfo,
+// идентификатор запроса и нарушения из BadRequest.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code,omitempty"`
+	Reason    string            `json:"reason,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    []ProblemField    `json:"errors,omitempty"`
+}
+
+type ProblemField struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// WriteProblem дополняет p значениями по умолчанию и пишет его в ответ.
+func WriteProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+		if p.Reason != "" {
+			p.Type = "urn:" + apperr.Domain + ":error:" + strings.ToLower(p.Reason)
+		}
+	}
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = RequestIDFromContext(r.Context())
+	}
+	// ошибки самого HTTP слоя (429, 500) локализуем здесь
+	if p.Reason != "" && w.Header().Get("Content-Language") == "" {
+		tag := i18n.Match(r.Header.Get("Accept-Language"))
+		if msg, ok := i18n.Message(tag, p.Reason, p.Metadata); ok {
+			p.Detail = msg
+			w.Header().Set("Content-Language", tag.String())
+		}
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		logger.Errorf("Failed to write problem response: %v", err)
+	}
+}
+
+// GatewayErrorHandler заменяет runtime.DefaultHTTPErrorHandler: вместо
+// google.rpc.Status отдаёт application/problem+json с деталями ошибки.
+// Detail берётся из LocalizedMessage, если сервис его приложил.
+func GatewayErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	var customStatus *runtime.HTTPStatusError
+	if errors.As(err, &customStatus) {
+		err = customStatus.Err
+	}
+
+	st := status.Convert(err)
+	p := Problem{
+		Status: runtime.HTTPStatusFromCode(st.Code()),
+		Detail: st.Message(),
+		Code:   st.Code().String(),
+	}
+	if customStatus != nil {
+		p.Status = customStatus.HTTPStatus
+	}
+
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			p.Reason = d.GetReason()
+			p.Metadata = d.GetMetadata()
+		case *errdetails.LocalizedMessage:
+			p.Detail = d.GetMessage()
+			w.Header().Set("Content-Language", d.GetLocale())
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				p.Errors = append(p.Errors, ProblemField{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+
+	w.Header().Del("Trailer")
+	w.Header().Del("Transfer-Encoding")
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		for k, vs := range md.HeaderMD {
+			if h, ok := GatewayOutgoingHeaderMatcher(k); ok {
+				for _, v := range vs {
+					w.Header().Add(h, v)
+				}
+			}
+		}
+	}
+
+	WriteProblem(w, r, p)
+}
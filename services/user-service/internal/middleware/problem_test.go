@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/i18n"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// serveProblem прогоняет запрос через RequestIDMiddleware и write.
+func serveProblem(t *testing.T, r *http.Request, write func(w http.ResponseWriter, r *http.Request)) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+	r.Header.Set(requestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	RequestIDMiddleware(http.HandlerFunc(write)).ServeHTTP(w, r)
+	if ct := w.Header().Get("Content-Type"); ct != problemContentType {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("body %q: %v", w.Body.String(), err)
+	}
+	return w, p
+}
+
+func TestGatewayErrorHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		locale       *language.Tag
+		want         Problem
+		wantLanguage string
+	}{
+		{
+			name: "validation",
+			err: apperr.InvalidFields(apperr.FieldViolation("email", "value must be a valid email address"),
+				apperr.FieldViolation("password", "value length must be at least 8 runes")),
+			want: Problem{Type: "urn:user-service:error:validation_failed", Title: "Bad Request", Status: 400,
+				Detail: "The request contains invalid fields.", Instance: "/v1/users", Code: "InvalidArgument",
+				Reason: apperr.ReasonValidationFailed, RequestID: "req-1", Errors: []ProblemField{
+					{Field: "email", Description: "value must be a valid email address"},
+					{Field: "password", Description: "value length must be at least 8 runes"},
+				}},
+			wantLanguage: "en",
+		},
+		{
+			name:   "localized with metadata",
+			err:    apperr.New(codes.NotFound, apperr.ReasonUserNotFound, "user not found", map[string]string{"id": "7"}),
+			locale: &language.Russian,
+			want: Problem{Type: "urn:user-service:error:user_not_found", Title: "Not Found", Status: 404,
+				Detail: "Пользователь с id 7 не найден.", Instance: "/v1/users", Code: "NotFound",
+				Reason: apperr.ReasonUserNotFound, Metadata: map[string]string{"id": "7"}, RequestID: "req-1"},
+			wantLanguage: "ru",
+		},
+		{
+			name: "plain status",
+			err:  status.Error(codes.Unavailable, "db is down"),
+			want: Problem{Type: "about:blank", Title: "Service Unavailable", Status: 503, Detail: "db is down",
+				Instance: "/v1/users", Code: "Unavailable", RequestID: "req-1"},
+		},
+		{
+			name: "custom HTTP status",
+			err:  &runtime.HTTPStatusError{HTTPStatus: http.StatusMethodNotAllowed, Err: status.Error(codes.Unimplemented, "method not allowed")},
+			want: Problem{Type: "about:blank", Title: "Method Not Allowed", Status: 405, Detail: "method not allowed",
+				Instance: "/v1/users", Code: "Unimplemented", RequestID: "req-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// сервис локализует ошибку сам (LocaleInterceptor + i18n.Localize)
+			err := tt.err
+			if _, isHTTP := err.(*runtime.HTTPStatusError); !isHTTP {
+				ctx := context.Background()
+				if tt.locale != nil {
+					ctx = i18n.WithLocale(ctx, *tt.locale)
+				}
+				err = i18n.Localize(ctx, err)
+			}
+			w, got := serveProblem(t, httptest.NewRequest(http.MethodPost, "/v1/users", nil), func(w http.ResponseWriter, r *http.Request) {
+				GatewayErrorHandler(r.Context(), nil, nil, w, r, err)
+			})
+			if w.Code != tt.want.Status {
+				t.Fatalf("HTTP status = %d, want %d", w.Code, tt.want.Status)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("problem =\n%+v\nwant\n%+v", got, tt.want)
+			}
+			if lang := w.Header().Get("Content-Language"); lang != tt.wantLanguage {
+				t.Fatalf("Content-Language = %q, want %q", lang, tt.wantLanguage)
+			}
+		})
+	}
+}
+
+// Заголовки из метаданных сервиса доходят до HTTP клиента.
+func TestGatewayErrorHandlerHeaders(t *testing.T) {
+	err := apperr.New(codes.ResourceExhausted, apperr.ReasonRateLimited, "rate limited", nil)
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{
+		HeaderMD: metadata.Pairs("retry-after", "30"),
+	})
+	w, got := serveProblem(t, httptest.NewRequest(http.MethodGet, "/v1/users/1", nil), func(w http.ResponseWriter, r *http.Request) {
+		GatewayErrorHandler(ctx, nil, nil, w, r, err)
+	})
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" || got.Reason != apperr.ReasonRateLimited {
+		t.Fatalf("status %d, Retry-After %q, problem %+v", w.Code, w.Header().Get("Retry-After"), got)
+	}
+}
+
+// Ошибки HTTP слоя локализуются по Accept-Language.
+func TestWriteProblemLocalizes(t *testing.T) {
+	tests := []struct {
+		accept, wantLanguage, wantDetail string
+	}{
+		{"", "en", "Too many requests. Please try again later."},
+		{"ru-RU,ru;q=0.9", "ru", "Слишком много запросов. Попробуйте позже."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantLanguage, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Language", tt.accept)
+			}
+			w, got := serveProblem(t, r, func(w http.ResponseWriter, r *http.Request) {
+				WriteProblem(w, r, Problem{Status: http.StatusTooManyRequests, Reason: apperr.ReasonRateLimited, Detail: "rate limited"})
+			})
+			if w.Header().Get("Content-Language") != tt.wantLanguage || got.Detail != tt.wantDetail {
+				t.Fatalf("Content-Language %q, detail %q", w.Header().Get("Content-Language"), got.Detail)
+			}
+			if got.Type != "urn:user-service:error:rate_limited" || got.Title != "Too Many Requests" || got.RequestID != "req-1" {
+				t.Fatalf("problem = %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type rateRule struct {
+	pattern string
+	key     string
+	limit   ratelimit.Limit
+}
+
+type rateRules struct {
+	enabled bool
+	mux     *http.ServeMux
+	http    map[string]rateRule
+	grpc    map[string]rateRule
+}
+
+// RateLimiter применяет правила rate_limit к HTTP запросам и gRPC вызовам.
+// Правила можно заменить на лету через SetConfig.
+type RateLimiter struct {
+	limiter ratelimit.Limiter
+	rules   atomic.Pointer[rateRules]
+}
+
+func NewRateLimiter(limiter ratelimit.Limiter, cfg config.RateLimitConfig) *RateLimiter {
+	rl := &RateLimiter{limiter: limiter}
+	rl.SetConfig(cfg)
+	return rl
+}
+
+// SetConfig ожидает конфигурацию, уже прошедшую config.Validate.
+func (rl *RateLimiter) SetConfig(cfg config.RateLimitConfig) {
+	rules := &rateRules{
+		enabled: cfg.Enabled,
+		mux:     http.NewServeMux(),
+		http:    make(map[string]rateRule, len(cfg.HTTP)),
+		grpc:    make(map[string]rateRule, len(cfg.GRPC)),
+	}
+	for _, r := range cfg.HTTP {
+		rules.mux.Handle(r.Pattern, http.NotFoundHandler())
+		rules.http[r.Pattern] = newRateRule(r)
+	}
+	for _, r := range cfg.GRPC {
+		rules.grpc[r.Pattern] = newRateRule(r)
+	}
+
+	rl.rules.Store(rules)
+}
+
+func newRateRule(r config.RateLimitRule) rateRule {
+	return rateRule{
+		pattern: r.Pattern,
+		key:     r.Key,
+		limit:   ratelimit.PerPeriod(r.Requests, r.Per, r.Burst),
+	}
+}
+
+// allow при ошибке лимитера пропускает запрос: лимиты не должны ронять сервис.
+func (rl *RateLimiter) allow(ctx context.Context, bucket string, rule rateRule) ratelimit.Result {
+	res, err := rl.limiter.Allow(ctx, bucket, rule.limit)
+	if err != nil {
+		logger.Warnf("RateLimit: %s: %v", bucket, err)
+		return ratelimit.Result{Allowed: true}
+	}
+	return res
+}
+
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rules := rl.rules.Load()
+		if !rules.enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		_, pattern := rules.mux.Handler(r)
+		rule, ok := rules.http[pattern]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var key string
+		if rule.key == "ip" {
+			key = ClientIP(r)
+		}
+
+		res := rl.allow(r.Context(), "http:"+rule.pattern+":"+key, rule)
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		if !res.Allowed {
+			logger.Warnf("RateLimit: HTTP %s %s from %s rejected", r.Method, r.URL.Path, ClientIP(r))
+			w.Header().Set("Retry-After", retryAfterSeconds(res.RetryAfter))
+			WriteProblem(w, r, Problem{
+				Status: http.StatusTooManyRequests,
+				Detail: "rate limit exceeded",
+				Code:   codes.ResourceExhausted.String(),
+				Reason: apperr.ReasonRateLimited,
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (rl *RateLimiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		rules := rl.rules.Load()
+		// запросы через gateway уже посчитаны HTTP правилами Middleware
+		if !rules.enabled || auth.ClientInfoFromContext(ctx).ViaGateway {
+			return handler(ctx, req)
+		}
+
+		rule, ok := rules.grpc[info.FullMethod]
+		if !ok {
+			rule, ok = rules.grpc["*"]
+		}
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		client := auth.ClientInfoFromContext(ctx)
+		var key string
+		switch rule.key {
+		case "ip":
+			key = "ip:" + client.IP
+		case "principal":
+			if p, ok := auth.FromContext(ctx); ok {
+				key = string(p.Type) + ":" + p.ID
+			} else {
+				key = "ip:" + client.IP
+			}
+		case "method":
+			key = info.FullMethod
+		}
+
+		res := rl.allow(ctx, "grpc:"+rule.pattern+":"+key, rule)
+		if !res.Allowed {
+			logger.Warnf("RateLimit: gRPC %s from %s rejected", info.FullMethod, key)
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfterSeconds(res.RetryAfter)))
+
+			return nil, apperr.New(codes.ResourceExhausted, apperr.ReasonRateLimited, "rate limit exceeded", nil,
+				&errdetails.RetryInfo{RetryDelay: durationpb.New(res.RetryAfter)})
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(d.Seconds()))))
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiterCountsGatewayRequestsOnce(t *testing.T) {
+	rl := NewRateLimiter(ratelimit.NewMemory(), config.RateLimitConfig{
+		Enabled: true,
+		HTTP:    []config.RateLimitRule{{Pattern: "POST /v1/auth/login", Key: "ip", Requests: 2, Per: time.Hour, Burst: 2}},
+		GRPC:    []config.RateLimitRule{{Pattern: "/user.v1.UserService/Login", Key: "ip", Requests: 2, Per: time.Hour, Burst: 2}},
+	})
+	info := &grpc.UnaryServerInfo{FullMethod: "/user.v1.UserService/Login"}
+	grpcCall := rl.UnaryInterceptor()
+
+	// HTTP запрос через gateway проходит оба ограничителя с одним IP
+	gateway := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := auth.WithClientInfo(r.Context(), auth.ClientInfo{IP: ClientIP(r), ViaGateway: true})
+		_, err := grpcCall(ctx, nil, info, func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		if err != nil {
+			t.Errorf("gRPC limiter rejected a gateway call: %v", err)
+		}
+	}))
+
+	codesSeen := make([]int, 0, 3)
+	for range 3 {
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		gateway.ServeHTTP(rec, req)
+		codesSeen = append(codesSeen, rec.Code)
+	}
+	want := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i := range want {
+		if codesSeen[i] != want[i] {
+			t.Fatalf("HTTP statuses = %v, want %v", codesSeen, want)
+		}
+	}
+
+	// прямые gRPC вызовы с того же IP считаются отдельно, по правилам gRPC
+	direct := auth.WithClientInfo(context.Background(), auth.ClientInfo{IP: "10.0.0.1"})
+	for i := range 3 {
+		_, err := grpcCall(direct, nil, info, func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		if i < 2 && err != nil {
+			t.Fatalf("direct call %d: %v", i, err)
+		}
+		if i == 2 && status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("direct call %d: code = %v, want ResourceExhausted", i, status.Code(err))
+		}
+	}
+}
@@ -2,21 +2,20 @@ package middleware
 
 import (
 	"context"
-	"log"
 	"runtime/debug"
 
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/logger"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 func RecoveryInterceptor() func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		defer func() {
 			if r := recover(); r != nil {
-				log.Printf("PANIC recovered in gRPC handler %s: %v\n%s",
+				logger.Errorf("PANIC recovered in gRPC handler %s: %v\n%s",
 					info.FullMethod, r, debug.Stack())
-				err = status.Errorf(codes.Internal, "internal server error")
+				err = apperr.Internal()
 			}
 		}()
 
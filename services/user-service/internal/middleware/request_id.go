@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+)
+
+const (
+	requestIDHeader    = "X-Request-Id"
+	mdRequestID        = "x-request-id"
+	maxRequestIDLength = 128
+)
+
+type requestIDKey struct{}
+
+// RequestIDMiddleware берёт X-Request-Id клиента или генерирует новый,
+// возвращает его в ответе и передаёт дальше (в том числе в gRPC метаданные
+// через gateway).
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = rand.Text()
+			r.Header.Set(requestIDHeader, id)
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID пропускает только короткие печатные ASCII значения, чтобы
+// клиентский идентификатор можно было безопасно писать в логи и заголовки.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// StreamInterceptor позволяет использовать unary интерсептор для потоковых
+// методов. Интерсептор получает req == nil и может менять контекст и
+// ошибку, но не сообщения потока.
+func StreamInterceptor(unary grpc.UnaryServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		unaryInfo := &grpc.UnaryServerInfo{Server: srv, FullMethod: info.FullMethod}
+		_, err := unary(ss.Context(), nil, unaryInfo, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return nil, handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		})
+		return err
+	}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// GatewayTokenStreamInterceptor — GatewayTokenInterceptor для потоковых вызовов.
+func GatewayTokenStreamInterceptor(token string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		md = md.Copy()
+		md.Set(mdGatewayToken, token)
+		return streamer(metadata.NewOutgoingContext(ctx, md), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// TenantInterceptor определяет тенант запроса. У пользователя это тенант
+// его сессии, у API ключа — тенант, в котором он выпущен; заголовок
+// x-tenant-id с другим тенантом — PermissionDenied. Выбирать тенант
+// заголовком могут только сервисы, опознанные по mTLS, и анонимные
+// запросы (вход и регистрация в тенанте); без заголовка это
+// tenant.Default. Ставится после AuthInterceptor.
+func TenantInterceptor(tenants *tenant.Directory) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		requested := first(md, tenant.MetadataKey)
+
+		if p, ok := auth.FromContext(ctx); ok && p.Type != auth.PrincipalService {
+			own := tenant.OrDefault(p.TenantID)
+			if requested != "" && requested != own {
+				logger.Warnf("gRPC %s: %s %s of tenant %s asked for tenant %s", info.FullMethod, p.Type, p.ID, own, requested)
+				return nil, apperr.New(codes.PermissionDenied, apperr.ReasonTenantMismatch,
+					"credentials belong to another tenant", map[string]string{"tenant_id": requested})
+			}
+			return handler(tenant.WithID(ctx, own), req)
+		}
+
+		id, err := resolveTenant(ctx, tenants, requested)
+		if err != nil {
+			logger.Errorf("gRPC %s: tenant lookup failed: %v", info.FullMethod, err)
+			return nil, apperr.Internal()
+		}
+		if id == "" {
+			return nil, apperr.New(codes.NotFound, apperr.ReasonTenantNotFound, "tenant not found",
+				map[string]string{"tenant_id": requested})
+		}
+		return handler(tenant.WithID(ctx, id), req)
+	}
+}
+
+// TenantMiddleware — то же для HTTP обработчиков вне gateway (аватары):
+// тенант берётся из заголовка X-Tenant-ID.
+func TenantMiddleware(tenants *tenant.Directory) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested := r.Header.Get(tenant.Header)
+			id, err := resolveTenant(r.Context(), tenants, requested)
+			if err != nil {
+				logger.Errorf("HTTP %s: tenant lookup failed: %v", r.URL.Path, err)
+				WriteProblem(w, r, Problem{
+					Status: http.StatusInternalServerError,
+					Code:   codes.Internal.String(),
+					Reason: apperr.ReasonInternal,
+				})
+				return
+			}
+			if id == "" {
+				WriteProblem(w, r, Problem{
+					Status:   http.StatusNotFound,
+					Detail:   "tenant not found",
+					Code:     codes.NotFound.String(),
+					Reason:   apperr.ReasonTenantNotFound,
+					Metadata: map[string]string{"tenant_id": requested},
+				})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(tenant.WithID(r.Context(), id)))
+		})
+	}
+}
+
+// resolveTenant возвращает запрошенный тенант, tenant.Default без запроса
+// и пустую строку, если такого тенанта нет.
+func resolveTenant(ctx context.Context, tenants *tenant.Directory, requested string) (string, error) {
+	if requested == "" {
+		return tenant.Default, nil
+	}
+	ok, err := tenants.Exists(ctx, requested)
+	if err != nil || !ok {
+		return "", err
+	}
+	return requested, nil
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apikey"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/session"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type knownTenants map[string]bool
+
+func (k knownTenants) Exists(_ context.Context, id string) (bool, error) { return k[id], nil }
+
+// sessionTokens — session.Store, знающий только токены теста.
+type sessionTokens struct {
+	session.Store
+	byToken map[string]*session.Session
+}
+
+func (s sessionTokens) Get(_ context.Context, token string) (*session.Session, error) {
+	sess, ok := s.byToken[token]
+	if !ok {
+		return nil, session.ErrNotFound
+	}
+	return sess, nil
+}
+
+type apiKeyTokens map[string]*model.APIKey
+
+func (k apiKeyTokens) Authenticate(_ context.Context, token string) (*model.APIKey, error) {
+	key, ok := k[token]
+	if !ok {
+		return nil, apikey.ErrInvalid
+	}
+	return key, nil
+}
+
+// Пользователь и API ключ работают только в своём тенанте; выбрать
+// чужой заголовком x-tenant-id может лишь сервис, опознанный по mTLS.
+func TestTenantInterceptorCrossTenant(t *testing.T) {
+	sessions := sessionTokens{byToken: map[string]*session.Session{
+		"acme-session":   {ID: "s1", UserID: 1, TenantID: "acme"},
+		"legacy-session": {ID: "s2", UserID: 2},
+	}}
+	keys := apiKeyTokens{
+		"usk_acme_secret":   {ID: "acme", TenantID: "acme", Scopes: []string{model.ScopeAll}},
+		"usk_legacy_secret": {ID: "legacy", Scopes: []string{model.ScopeAll}},
+	}
+	authn := AuthInterceptor(sessions, keys)
+	tenants := TenantInterceptor(tenant.NewDirectory(knownTenants{"default": true, "acme": true, "globex": true}, time.Minute))
+	info := &grpc.UnaryServerInfo{FullMethod: "/user.v1.UserService/GetUser"}
+
+	service := &auth.Principal{Type: auth.PrincipalService, ID: "spiffe://test/billing"}
+	tests := []struct {
+		name string
+		// principal — уже опознан по mTLS до AuthInterceptor.
+		principal  *auth.Principal
+		token      string
+		requested  string
+		want       codes.Code
+		wantTenant string
+	}{
+		{"user in own tenant", nil, "acme-session", "", codes.OK, "acme"},
+		{"user names own tenant", nil, "acme-session", "acme", codes.OK, "acme"},
+		{"user asks for another tenant", nil, "acme-session", "globex", codes.PermissionDenied, ""},
+		{"legacy session asks for another tenant", nil, "legacy-session", "globex", codes.PermissionDenied, ""},
+		{"api key in own tenant", nil, "usk_acme_secret", "", codes.OK, "acme"},
+		{"api key asks for another tenant", nil, "usk_acme_secret", "globex", codes.PermissionDenied, ""},
+		{"api key asks for default", nil, "usk_acme_secret", "default", codes.PermissionDenied, ""},
+		{"legacy api key stays in default", nil, "usk_legacy_secret", "", codes.OK, "default"},
+		{"legacy api key asks for another tenant", nil, "usk_legacy_secret", "acme", codes.PermissionDenied, ""},
+		{"service chooses tenant", service, "", "globex", codes.OK, "globex"},
+		{"service without header", service, "", "", codes.OK, "default"},
+		{"service asks for unknown tenant", service, "", "initech", codes.NotFound, ""},
+		{"anonymous chooses tenant to log in", nil, "", "globex", codes.OK, "globex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := metadata.MD{}
+			if tt.token != "" {
+				md.Set("authorization", "Bearer "+tt.token)
+			}
+			if tt.requested != "" {
+				md.Set(tenant.MetadataKey, tt.requested)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+
+			var got string
+			_, err := authn(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return tenants(ctx, req, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+					got = tenant.FromContext(ctx)
+					return nil, nil
+				})
+			})
+			if status.Code(err) != tt.want {
+				t.Fatalf("code = %v (%v), want %v", status.Code(err), err, tt.want)
+			}
+			if got != tt.wantTenant {
+				t.Fatalf("handler ran in tenant %q, want %q", got, tt.wantTenant)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// ScopeAll разрешает API ключу все методы.
+const ScopeAll = "*"
+
+// APIKey — ключ машинного клиента. Scopes — имена методов UserService,
+// которые он может вызывать, TenantID — тенант, в котором он выпущен и
+// только в котором действует. ExpiresAt равен nil у бессрочного ключа.
+type APIKey struct {
+	ID         string
+	TenantID   string
+	Name       string
+	SecretHash string
+	Scopes     []string
+	// CreatedBy — принципал, выпустивший ключ ("user:42", "service:...").
+	CreatedBy  string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// Active — ключ не отозван и не истёк.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+func (k *APIKey) Allows(method string) bool {
+	return slices.Contains(k.Scopes, ScopeAll) || slices.Contains(k.Scopes, method)
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Erasure — запись об удалении персональных данных пользователя.
+type Erasure struct {
+	ID       int64
+	TenantID string
+	UserID   int64
+	// EmailHash — HMAC-SHA256 email в нижнем регистре с ключом
+	// privacy.erasure_hash_key, в hex; пусто, если ключ не задан.
+	EmailHash string
+	// RequestedBy — принципал, запросивший удаление ("user:42", "service:...").
+	RequestedBy string
+	Reason      string
+	// Summary — число удалённых и обезличенных строк по таблицам.
+	Summary   map[string]int64
+	CreatedAt time.Time
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// FederatedIdentity — учётная запись внешнего провайдера, через которую
+// пользователь входит. Email — адрес у провайдера на момент привязки.
+type FederatedIdentity struct {
+	ID          int64
+	UserID      int64
+	Provider    string
+	Subject     string
+	Email       string
+	CreatedAt   time.Time
+	LastLoginAt time.Time
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Способы входа (LoginEvent.Method).
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodMagicLink = "magic_link"
+	// вход через внешнего провайдера: "social:google", "social:github" и т.д.
+	LoginMethodSocialPrefix = "social:"
+)
+
+// LoginEvent — попытка входа способом Method. UserID равен 0, если
+// пользователь с таким email не найден.
+type LoginEvent struct {
+	ID            int64
+	UserID        int64
+	Email         string
+	Method        string
+	IP            string
+	UserAgent     string
+	Success       bool
+	FailureReason string
+	CreatedAt     time.Time
+}
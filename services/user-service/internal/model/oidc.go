@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// OIDCClient — приложение, которое входит через user-service по OpenID
+// Connect. SecretHash пуст у публичных клиентов: они подтверждают обмен
+// кода только через PKCE.
+type OIDCClient struct {
+	ID           string
+	SecretHash   string
+	Name         string
+	RedirectURIs []string
+	CreatedAt    time.Time
+}
+
+func (c *OIDCClient) Public() bool {
+	return c.SecretHash == ""
+}
+
+// SigningKey — ключ подписи токенов. PrivateKey зашифрован. Ключ
+// подписывает с NotBefore до NotAfter и публикуется до ExpiresAt, чтобы
+// выданные им токены проверялись и после смены ключа.
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey []byte
+	NotBefore  time.Time
+	NotAfter   time.Time
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
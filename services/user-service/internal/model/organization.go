@@ -0,0 +1,67 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// Role — роль участника организации.
+type Role string
+
+const (
+	// RoleOwner управляет организацией целиком, включая других владельцев.
+	RoleOwner Role = "owner"
+	// RoleAdmin управляет участниками и приглашениями.
+	RoleAdmin Role = "admin"
+	// RoleMember видит организацию и её участников.
+	RoleMember Role = "member"
+)
+
+var roles = []Role{RoleOwner, RoleAdmin, RoleMember}
+
+func (r Role) Valid() bool {
+	return slices.Contains(roles, r)
+}
+
+type Organization struct {
+	ID        int64
+	TenantID  string
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Membership — участие пользователя в организации. Email и DisplayName
+// заполняются при выборке участников.
+type Membership struct {
+	OrganizationID int64
+	UserID         int64
+	Role           Role
+	CreatedAt      time.Time
+
+	Email       string
+	DisplayName string
+}
+
+// UserOrganization — организация пользователя и его роль в ней.
+type UserOrganization struct {
+	Organization Organization
+	Role         Role
+}
+
+// Invitation — приглашение в организацию по email. Токен хранится только
+// хэшем; TenantID — тенант организации.
+type Invitation struct {
+	ID             int64
+	OrganizationID int64
+	TenantID       string
+	Email          string
+	Role           Role
+	TokenHash      string
+	// InvitedBy — принципал, отправивший приглашение ("user:42", "service:...").
+	InvitedBy  string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	AcceptedAt *time.Time
+	DeclinedAt *time.Time
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// TOTP — второй фактор пользователя. Secret зашифрован; ConfirmedAt равен
+// nil, пока подключение не подтверждено кодом из приложения.
+type TOTP struct {
+	UserID       int64
+	Secret       []byte
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+func (t *TOTP) Confirmed() bool {
+	return t.ConfirmedAt != nil
+}
@@ -4,7 +4,53 @@ import "time"
 
 type User struct {
 	ID           int64
+	TenantID     string
 	Email        string
 	PasswordHash string
 	CreatedAt    time.Time
+
+	DisplayName string
+	Phone       string
+	Locale      string
+	Timezone    string
+	AvatarURL   string
+	Metadata    map[string]string
+	UpdatedAt   time.Time
+}
+
+// ProfileUpdate — изменения профиля; nil поле не меняется. В Metadata
+// пустое значение удаляет ключ.
+type ProfileUpdate struct {
+	DisplayName *string
+	Phone       *string
+	Locale      *string
+	Timezone    *string
+	AvatarURL   *string
+	Metadata    map[string]string
+}
+
+// SearchCursor — позиция в выдаче поиска: следующая страница начинается
+// после пользователя ID с релевантностью Rank.
+type SearchCursor struct {
+	Rank float64
+	ID   int64
+}
+
+type SearchResult struct {
+	User       *User
+	Rank       float64
+	Highlights []Highlight
+}
+
+// Highlight — совпавший фрагмент поля, [Start, End) в рунах.
+type Highlight struct {
+	Field      string
+	Start, End int
+}
+
+// UserEmail — email пользователя в том виде, в каком он хранится.
+type UserEmail struct {
+	ID       int64
+	TenantID string
+	Email    string
 }
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileNotifier дописывает уведомления в файл по одному JSON на строку —
+// для локальной разработки вместо настоящей доставки.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) (*FileNotifier, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create notifications dir: %w", err)
+	}
+	return &FileNotifier{path: path}, nil
+}
+
+func (f *FileNotifier) Notify(_ context.Context, n Notification) error {
+	line, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// файл открывается на каждую запись, чтобы его можно было удалить или
+	// ротировать, не перезапуская сервис
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
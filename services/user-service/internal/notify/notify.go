@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
+)
+
+// Виды уведомлений (Notification.Kind).
+const (
+	KindAccountLocked = "account_locked"
+)
+
+// Notification — сообщение пользователю о событии в его учётной записи.
+type Notification struct {
+	Kind      string            `json:"kind"`
+	UserID    int64             `json:"user_id"`
+	Email     string            `json:"email"`
+	CreatedAt time.Time         `json:"created_at"`
+	Data      map[string]string `json:"data,omitempty"`
+}
+
+// Notifier доставляет уведомления (email, мессенджер, файл для разработки).
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// New создаёт Notifier по notify.backend.
+func New(cfg config.NotifyConfig) (Notifier, error) {
+	switch cfg.Backend {
+	case "log":
+		return logNotifier{}, nil
+	case "file":
+		return NewFileNotifier(cfg.File)
+	default:
+		return nil, fmt.Errorf("unknown notify backend %q", cfg.Backend)
+	}
+}
+
+// logNotifier только пишет уведомление в лог.
+type logNotifier struct{}
+
+func (logNotifier) Notify(_ context.Context, n Notification) error {
+	logger.Infof("Notify: %s for user %d <%s>: %v", n.Kind, n.UserID, n.Email, n.Data)
+	return nil
+}
@@ -0,0 +1,101 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrCodeNotFound = errors.New("authorization code not found")
+
+// AuthorizationCode — выданный /oauth2/authorize код, который клиент
+// обменивает на токены.
+type AuthorizationCode struct {
+	ClientID      string    `json:"client_id"`
+	RedirectURI   string    `json:"redirect_uri"`
+	UserID        int64     `json:"user_id"`
+	SessionID     string    `json:"session_id"`
+	TenantID      string    `json:"tenant_id,omitempty"`
+	Scope         string    `json:"scope"`
+	Nonce         string    `json:"nonce"`
+	CodeChallenge string    `json:"code_challenge"`
+	AuthTime      time.Time `json:"auth_time"`
+}
+
+type CodeStore interface {
+	Create(ctx context.Context, code AuthorizationCode, ttl time.Duration) (string, error)
+	// Consume гасит код, если check не вернул ошибку, и возвращает его:
+	// второй обмен того же кода получит ErrCodeNotFound. Ошибка check
+	// возвращается как есть, и код остаётся действующим — предъявивший
+	// чужой код (другой клиент, redirect_uri или code_verifier) не может
+	// его сжечь.
+	Consume(ctx context.Context, code string, check func(*AuthorizationCode) error) (*AuthorizationCode, error)
+}
+
+// redisCodeStore хранит код в "oidc_code:<sha256(code)>".
+type redisCodeStore struct {
+	client *redis.Client
+}
+
+func NewRedisCodeStore(client *redis.Client) CodeStore {
+	return &redisCodeStore{client: client}
+}
+
+func codeKey(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return "oidc_code:" + hex.EncodeToString(sum[:])
+}
+
+func (s *redisCodeStore) Create(ctx context.Context, code AuthorizationCode, ttl time.Duration) (string, error) {
+	token := rand.Text()
+	data, err := json.Marshal(code)
+	if err != nil {
+		return "", err
+	}
+	if err := s.client.Set(ctx, codeKey(token), data, ttl).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeScript удаляет код, только если он не изменился с чтения: из
+// двух одновременных обменов код получит один.
+var consumeScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (s *redisCodeStore) Consume(ctx context.Context, code string, check func(*AuthorizationCode) error) (*AuthorizationCode, error) {
+	key := codeKey(code)
+	raw, err := s.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c AuthorizationCode
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		return nil, err
+	}
+	if err := check(&c); err != nil {
+		return nil, err
+	}
+
+	n, err := consumeScript.Run(ctx, s.client, []string{key}, raw).Int()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrCodeNotFound
+	}
+	return &c, nil
+}
@@ -0,0 +1,102 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestCodes(t *testing.T) (CodeStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisCodeStore(client), mr
+}
+
+func accept(*AuthorizationCode) error { return nil }
+
+func TestCodeConsumeOnce(t *testing.T) {
+	store, _ := newTestCodes(t)
+	ctx := context.Background()
+	want := AuthorizationCode{ClientID: "app", RedirectURI: "https://app.example.com/cb", UserID: 7, Scope: "openid"}
+	code, err := store.Create(ctx, want, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Consume(ctx, code, accept)
+	if err != nil || got.ClientID != "app" || got.UserID != 7 {
+		t.Fatalf("Consume = %+v, %v", got, err)
+	}
+	if _, err := store.Consume(ctx, code, accept); !errors.Is(err, ErrCodeNotFound) {
+		t.Fatalf("second Consume err = %v, want ErrCodeNotFound", err)
+	}
+}
+
+// Код, не прошедший check, остаётся у законного клиента.
+func TestCodeConsumeCheckKeepsCode(t *testing.T) {
+	store, _ := newTestCodes(t)
+	ctx := context.Background()
+	code, _ := store.Create(ctx, AuthorizationCode{ClientID: "app"}, time.Minute)
+
+	errWrongClient := errors.New("wrong client")
+	_, err := store.Consume(ctx, code, func(c *AuthorizationCode) error {
+		if c.ClientID != "evil" {
+			return errWrongClient
+		}
+		return nil
+	})
+	if !errors.Is(err, errWrongClient) {
+		t.Fatalf("Consume by another client err = %v", err)
+	}
+	if _, err := store.Consume(ctx, code, accept); err != nil {
+		t.Fatalf("owner's Consume: %v", err)
+	}
+}
+
+func TestCodeConsumeConcurrent(t *testing.T) {
+	store, _ := newTestCodes(t)
+	ctx := context.Background()
+	code, _ := store.Create(ctx, AuthorizationCode{ClientID: "app"}, time.Minute)
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		won int
+	)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.Consume(ctx, code, accept)
+			if err != nil && !errors.Is(err, ErrCodeNotFound) {
+				t.Error(err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				won++
+			}
+		}()
+	}
+	wg.Wait()
+	if won != 1 {
+		t.Fatalf("code exchanged %d times", won)
+	}
+}
+
+func TestCodeExpires(t *testing.T) {
+	store, mr := newTestCodes(t)
+	ctx := context.Background()
+	code, _ := store.Create(ctx, AuthorizationCode{ClientID: "app"}, time.Minute)
+	mr.FastForward(time.Minute)
+	if _, err := store.Consume(ctx, code, accept); !errors.Is(err, ErrCodeNotFound) {
+		t.Fatalf("expired Consume err = %v, want ErrCodeNotFound", err)
+	}
+}
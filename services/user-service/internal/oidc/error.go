@@ -0,0 +1,38 @@
+package oidc
+
+import "net/http"
+
+// Коды ошибок OAuth 2.0 (RFC 6749, разделы 4.1.2.1 и 5.2) и OpenID Connect.
+const (
+	ErrorInvalidRequest          = "invalid_request"
+	ErrorInvalidClient           = "invalid_client"
+	ErrorInvalidGrant            = "invalid_grant"
+	ErrorInvalidScope            = "invalid_scope"
+	ErrorInvalidToken            = "invalid_token"
+	ErrorUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorUnsupportedResponseType = "unsupported_response_type"
+	ErrorLoginRequired           = "login_required"
+)
+
+// Error — ошибка протокола, которая показывается клиенту как есть. Если
+// задан RedirectURI (уже сверенный с зарегистрированными у клиента),
+// ошибка авторизации возвращается туда; иначе — ответом на запрос.
+type Error struct {
+	Code        string
+	Description string
+	RedirectURI string
+	State       string
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// Status — HTTP статус ответа с ошибкой.
+func (e *Error) Status() int {
+	switch e.Code {
+	case ErrorInvalidClient, ErrorInvalidToken:
+		return http.StatusUnauthorized
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,96 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/signing"
+)
+
+const (
+	// TypeAccessToken — typ access токена (RFC 9068): его нельзя выдать за ID токен.
+	TypeAccessToken = "at+jwt"
+	TypeIDToken     = "JWT"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims — утверждения access и ID токенов; лишние для конкретного токена
+// поля пусты и не сериализуются.
+type Claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  Audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	IssuedAt  int64    `json:"iat"`
+	ID        string   `json:"jti,omitempty"`
+
+	// access токен
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+
+	SessionID string `json:"sid,omitempty"`
+	// TenantID — тенант пользователя.
+	TenantID string `json:"tid,omitempty"`
+
+	// ID токен
+	AuthTime      int64  `json:"auth_time,omitempty"`
+	Nonce         string `json:"nonce,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Locale        string `json:"locale,omitempty"`
+	Zoneinfo      string `json:"zoneinfo,omitempty"`
+}
+
+// Audience — claim aud: строка или массив строк (RFC 7519, раздел 4.1.3).
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+// Sign подписывает claims ключом key.
+func Sign(key *signing.Key, typ string, claims *Claims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	token, err := signing.Sign(key, typ, payload)
+	if err != nil {
+		return "", fmt.Errorf("sign token: %w", err)
+	}
+	return token, nil
+}
+
+// Verify проверяет подпись и срок действия токена и возвращает его claims.
+// lookup находит ключ по kid. Пустой typ не проверяется — для токенов
+// внешних провайдеров, которые заголовок typ не ставят.
+func Verify(token, typ string, lookup func(kid string) (*signing.Key, bool), now time.Time) (*Claims, error) {
+	payload, err := signing.Verify(token, typ, lookup)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
@@ -0,0 +1,150 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/signing"
+)
+
+// memKeys — SigningKeyRepository в памяти.
+type memKeys struct {
+	keys []model.SigningKey
+}
+
+func (r *memKeys) Create(_ context.Context, key *model.SigningKey) error {
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *memKeys) ListActive(_ context.Context, now time.Time) ([]model.SigningKey, error) {
+	var out []model.SigningKey
+	for _, k := range r.keys {
+		if k.ExpiresAt.After(now) {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func (r *memKeys) DeleteExpired(context.Context, time.Time) (int64, error) { return 0, nil }
+
+// plainSealer не шифрует: тесту не нужна защита ключей в БД.
+type plainSealer struct{}
+
+func (plainSealer) Seal(plaintext, _ []byte) []byte       { return plaintext }
+func (plainSealer) Open(sealed, _ []byte) ([]byte, error) { return sealed, nil }
+
+func newTestKeys(t *testing.T, algorithm string) *signing.KeyManager {
+	t.Helper()
+	keys := signing.NewKeyManager(&memKeys{}, plainSealer{}, config.SigningKeysConfig{
+		Algorithm: algorithm, Rotation: time.Hour, PublishAhead: time.Minute, Retention: time.Hour})
+	if err := keys.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func signTest(t *testing.T, keys *signing.KeyManager, typ string, claims Claims) string {
+	t.Helper()
+	key, err := keys.Signer(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := Sign(key, typ, &claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// withHeader подменяет заголовок токена, оставляя payload и подпись.
+func withHeader(t *testing.T, token string, change func(h map[string]any)) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	raw, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	var h map[string]any
+	if err := json.Unmarshal(raw, &h); err != nil {
+		t.Fatal(err)
+	}
+	change(h)
+	raw, _ = json.Marshal(h)
+	parts[0] = base64.RawURLEncoding.EncodeToString(raw)
+	return strings.Join(parts, ".")
+}
+
+func TestVerify(t *testing.T) {
+	keys := newTestKeys(t, signing.AlgorithmEdDSA)
+	rsaKeys := newTestKeys(t, signing.AlgorithmRS256)
+	now := time.Now()
+	claims := Claims{Issuer: "https://id.example.com", Subject: "7", Audience: Audience{"app"},
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix(), ClientID: "app"}
+	access := signTest(t, keys, TypeAccessToken, claims)
+
+	got, err := Verify(access, TypeAccessToken, keys.Lookup, now)
+	if err != nil || got.Subject != "7" || !slices.Equal(got.Audience, Audience{"app"}) || got.ClientID != "app" {
+		t.Fatalf("Verify = %+v, %v", got, err)
+	}
+	if _, err := Verify(signTest(t, rsaKeys, TypeAccessToken, claims), TypeAccessToken, rsaKeys.Lookup, now); err != nil {
+		t.Fatalf("RS256 token: %v", err)
+	}
+
+	tampered := strings.Split(access, ".")
+	payload, _ := json.Marshal(Claims{Issuer: claims.Issuer, Subject: "1", Audience: claims.Audience,
+		ExpiresAt: claims.ExpiresAt, ClientID: "app"})
+	tampered[1] = base64.RawURLEncoding.EncodeToString(payload)
+
+	tests := []struct {
+		name   string
+		token  string
+		typ    string
+		lookup func(string) (*signing.Key, bool)
+		now    time.Time
+	}{
+		{"ID token as access token", signTest(t, keys, TypeIDToken, claims), TypeAccessToken, keys.Lookup, now},
+		{"access token as ID token", access, TypeIDToken, keys.Lookup, now},
+		{"unknown kid", withHeader(t, access, func(h map[string]any) { h["kid"] = "unknown" }), TypeAccessToken, keys.Lookup, now},
+		{"other provider's keys", access, TypeAccessToken, rsaKeys.Lookup, now},
+		{"alg none", withHeader(t, access, func(h map[string]any) { h["alg"] = "none" }), TypeAccessToken, keys.Lookup, now},
+		{"alg of another key type", withHeader(t, access, func(h map[string]any) { h["alg"] = signing.AlgorithmRS256 }),
+			TypeAccessToken, keys.Lookup, now},
+		{"typ removed", withHeader(t, access, func(h map[string]any) { delete(h, "typ") }), TypeAccessToken, keys.Lookup, now},
+		{"tampered payload", strings.Join(tampered, "."), TypeAccessToken, keys.Lookup, now},
+		{"no signature", strings.Join(strings.Split(access, ".")[:2], ".") + ".", TypeAccessToken, keys.Lookup, now},
+		{"expired", access, TypeAccessToken, keys.Lookup, now.Add(time.Minute)},
+		{"garbage", "not.a.token", TypeAccessToken, keys.Lookup, now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Verify(tt.token, tt.typ, tt.lookup, tt.now); err == nil {
+				t.Fatalf("Verify accepted the token: %+v", got)
+			}
+		})
+	}
+}
+
+func TestAudienceJSON(t *testing.T) {
+	for _, tt := range []struct {
+		aud  Audience
+		json string
+	}{
+		{Audience{"app"}, `"app"`},
+		{Audience{"app", "api"}, `["app","api"]`},
+	} {
+		data, err := json.Marshal(tt.aud)
+		if err != nil || string(data) != tt.json {
+			t.Errorf("Marshal(%v) = %s, %v", tt.aud, data, err)
+		}
+		var got Audience
+		if err := json.Unmarshal(data, &got); err != nil || !slices.Equal(got, tt.aud) {
+			t.Errorf("Unmarshal(%s) = %v, %v", data, got, err)
+		}
+	}
+}
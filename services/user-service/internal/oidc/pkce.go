@@ -0,0 +1,29 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// PKCEMethod — единственный поддерживаемый code_challenge_method: plain
+// не защищает от перехвата challenge.
+const PKCEMethod = "S256"
+
+// RFC 7636, раздел 4.1: 43–128 символов из unreserved.
+var verifierPattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// VerifyPKCE сверяет code_verifier с code_challenge метода S256.
+func VerifyPKCE(verifier, challenge string) bool {
+	if !verifierPattern.MatchString(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(PKCEChallenge(verifier)), []byte(challenge)) == 1
+}
+
+// PKCEChallenge — code_challenge метода S256 для verifier.
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
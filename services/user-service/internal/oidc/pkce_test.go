@@ -0,0 +1,42 @@
+package oidc
+
+import (
+	"strings"
+	"testing"
+)
+
+// Пример из приложения B RFC 7636.
+const (
+	rfcVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	rfcChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestPKCEChallenge(t *testing.T) {
+	if got := PKCEChallenge(rfcVerifier); got != rfcChallenge {
+		t.Fatalf("PKCEChallenge = %s, want %s", got, rfcChallenge)
+	}
+}
+
+func TestVerifyPKCE(t *testing.T) {
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{"RFC 7636 example", rfcVerifier, rfcChallenge, true},
+		{"other verifier", strings.Repeat("a", 43), rfcChallenge, false},
+		// plain: challenge равен verifier — не поддерживается
+		{"plain method", rfcVerifier, rfcVerifier, false},
+		{"empty verifier", "", PKCEChallenge(""), false},
+		{"too short", strings.Repeat("a", 42), PKCEChallenge(strings.Repeat("a", 42)), false},
+		{"too long", strings.Repeat("a", 129), PKCEChallenge(strings.Repeat("a", 129)), false},
+		{"longest", strings.Repeat("a", 128), PKCEChallenge(strings.Repeat("a", 128)), true},
+		{"reserved characters", strings.Repeat("a", 42) + "+", PKCEChallenge(strings.Repeat("a", 42) + "+"), false},
+	}
+	for _, tt := range tests {
+		if got := VerifyPKCE(tt.verifier, tt.challenge); got != tt.want {
+			t.Errorf("%s: VerifyPKCE = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
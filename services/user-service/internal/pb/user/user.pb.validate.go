@@ -90,6 +90,47 @@ func (m *GetUserResponse) validate(all bool) error {
 		}
 	}
 
+	// no validation rules for DisplayName
+
+	// no validation rules for Phone
+
+	// no validation rules for Locale
+
+	// no validation rules for Timezone
+
+	// no validation rules for AvatarUrl
+
+	// no validation rules for Metadata
+
+	if all {
+		switch v := interface{}(m.GetUpdatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, GetUserResponseValidationError{
+					field:  "UpdatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, GetUserResponseValidationError{
+					field:  "UpdatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetUpdatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetUserResponseValidationError{
+				field:  "UpdatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
 	if len(errors) > 0 {
 		return GetUserResponseMultiError(errors)
 	}
@@ -168,6 +209,286 @@ var _ interface {
 	ErrorName() string
 } = GetUserResponseValidationError{}
 
+// Validate checks the field values on UpdateProfileRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *UpdateProfileRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UpdateProfileRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UpdateProfileRequestMultiError, or nil if none found.
+func (m *UpdateProfileRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UpdateProfileRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetId() <= 0 {
+		err := UpdateProfileRequestValidationError{
+			field:  "Id",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(m.GetMetadata()) > 32 {
+		err := UpdateProfileRequestValidationError{
+			field:  "Metadata",
+			reason: "value must contain no more than 32 pair(s)",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	{
+		sorted_keys := make([]string, len(m.GetMetadata()))
+		i := 0
+		for key := range m.GetMetadata() {
+			sorted_keys[i] = key
+			i++
+		}
+		sort.Slice(sorted_keys, func(i, j int) bool { return sorted_keys[i] < sorted_keys[j] })
+		for _, key := range sorted_keys {
+			val := m.GetMetadata()[key]
+			_ = val
+
+			if l := utf8.RuneCountInString(key); l < 1 || l > 64 {
+				err := UpdateProfileRequestValidationError{
+					field:  fmt.Sprintf("Metadata[%v]", key),
+					reason: "value length must be between 1 and 64 runes, inclusive",
+				}
+				if !all {
+					return err
+				}
+				errors = append(errors, err)
+			}
+
+			if !_UpdateProfileRequest_Metadata_Pattern.MatchString(key) {
+				err := UpdateProfileRequestValidationError{
+					field:  fmt.Sprintf("Metadata[%v]", key),
+					reason: "value does not match regex pattern \"^[A-Za-z0-9_.-]+$\"",
+				}
+				if !all {
+					return err
+				}
+				errors = append(errors, err)
+			}
+
+			if utf8.RuneCountInString(val) > 512 {
+				err := UpdateProfileRequestValidationError{
+					field:  fmt.Sprintf("Metadata[%v]", key),
+					reason: "value length must be at most 512 runes",
+				}
+				if !all {
+					return err
+				}
+				errors = append(errors, err)
+			}
+
+		}
+	}
+
+	if m.DisplayName != nil {
+
+		if utf8.RuneCountInString(m.GetDisplayName()) > 64 {
+			err := UpdateProfileRequestValidationError{
+				field:  "DisplayName",
+				reason: "value length must be at most 64 runes",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if m.Phone != nil {
+
+		if m.GetPhone() != "" {
+
+			if !_UpdateProfileRequest_Phone_Pattern.MatchString(m.GetPhone()) {
+				err := UpdateProfileRequestValidationError{
+					field:  "Phone",
+					reason: "value does not match regex pattern \"^\\\\+[1-9][0-9]{1,14}$\"",
+				}
+				if !all {
+					return err
+				}
+				errors = append(errors, err)
+			}
+
+		}
+
+	}
+
+	if m.Locale != nil {
+
+		if utf8.RuneCountInString(m.GetLocale()) > 35 {
+			err := UpdateProfileRequestValidationError{
+				field:  "Locale",
+				reason: "value length must be at most 35 runes",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if m.Timezone != nil {
+
+		if utf8.RuneCountInString(m.GetTimezone()) > 64 {
+			err := UpdateProfileRequestValidationError{
+				field:  "Timezone",
+				reason: "value length must be at most 64 runes",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if m.AvatarUrl != nil {
+
+		if m.GetAvatarUrl() != "" {
+
+			if utf8.RuneCountInString(m.GetAvatarUrl()) > 2048 {
+				err := UpdateProfileRequestValidationError{
+					field:  "AvatarUrl",
+					reason: "value length must be at most 2048 runes",
+				}
+				if !all {
+					return err
+				}
+				errors = append(errors, err)
+			}
+
+			if uri, err := url.Parse(m.GetAvatarUrl()); err != nil {
+				err = UpdateProfileRequestValidationError{
+					field:  "AvatarUrl",
+					reason: "value must be a valid URI",
+					cause:  err,
+				}
+				if !all {
+					return err
+				}
+				errors = append(errors, err)
+			} else if !uri.IsAbs() {
+				err := UpdateProfileRequestValidationError{
+					field:  "AvatarUrl",
+					reason: "value must be absolute",
+				}
+				if !all {
+					return err
+				}
+				errors = append(errors, err)
+			}
+
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return UpdateProfileRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// UpdateProfileRequestMultiError is an error wrapping multiple validation
+// errors returned by UpdateProfileRequest.ValidateAll() if the designated
+// constraints aren't met.
+type UpdateProfileRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UpdateProfileRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UpdateProfileRequestMultiError) AllErrors() []error { return m }
+
+// UpdateProfileRequestValidationError is the validation error returned by
+// UpdateProfileRequest.Validate if the designated constraints aren't met.
+type UpdateProfileRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UpdateProfileRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UpdateProfileRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UpdateProfileRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UpdateProfileRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UpdateProfileRequestValidationError) ErrorName() string {
+	return "UpdateProfileRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e UpdateProfileRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUpdateProfileRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UpdateProfileRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UpdateProfileRequestValidationError{}
+
+var _UpdateProfileRequest_Phone_Pattern = regexp.MustCompile("^\\+[1-9][0-9]{1,14}$")
+
+var _UpdateProfileRequest_Metadata_Pattern = regexp.MustCompile("^[A-Za-z0-9_.-]+$")
+
 // Validate checks the field values on GetUserByIDRequest with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the first error encountered is returned, or nil if there are no violations.
@@ -561,3 +882,8809 @@ var _ interface {
 	Cause() error
 	ErrorName() string
 } = CreateUserResponseValidationError{}
+
+// Validate checks the field values on UploadAvatarRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *UploadAvatarRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UploadAvatarRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UploadAvatarRequestMultiError, or nil if none found.
+func (m *UploadAvatarRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UploadAvatarRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	switch v := m.Data.(type) {
+	case *UploadAvatarRequest_Info:
+		if v == nil {
+			err := UploadAvatarRequestValidationError{
+				field:  "Data",
+				reason: "oneof value cannot be a typed-nil",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+		if all {
+			switch v := interface{}(m.GetInfo()).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, UploadAvatarRequestValidationError{
+						field:  "Info",
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, UploadAvatarRequestValidationError{
+						field:  "Info",
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(m.GetInfo()).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return UploadAvatarRequestValidationError{
+					field:  "Info",
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	case *UploadAvatarRequest_Chunk:
+		if v == nil {
+			err := UploadAvatarRequestValidationError{
+				field:  "Data",
+				reason: "oneof value cannot be a typed-nil",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+		// no validation rules for Chunk
+	default:
+		_ = v // ensures v is used
+	}
+
+	if len(errors) > 0 {
+		return UploadAvatarRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// UploadAvatarRequestMultiError is an error wrapping multiple validation
+// errors returned by UploadAvatarRequest.ValidateAll() if the designated
+// constraints aren't met.
+type UploadAvatarRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UploadAvatarRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UploadAvatarRequestMultiError) AllErrors() []error { return m }
+
+// UploadAvatarRequestValidationError is the validation error returned by
+// UploadAvatarRequest.Validate if the designated constraints aren't met.
+type UploadAvatarRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UploadAvatarRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UploadAvatarRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UploadAvatarRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UploadAvatarRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UploadAvatarRequestValidationError) ErrorName() string {
+	return "UploadAvatarRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e UploadAvatarRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUploadAvatarRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UploadAvatarRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UploadAvatarRequestValidationError{}
+
+// Validate checks the field values on AvatarInfo with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *AvatarInfo) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on AvatarInfo with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in AvatarInfoMultiError, or
+// nil if none found.
+func (m *AvatarInfo) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *AvatarInfo) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := AvatarInfoValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return AvatarInfoMultiError(errors)
+	}
+
+	return nil
+}
+
+// AvatarInfoMultiError is an error wrapping multiple validation errors
+// returned by AvatarInfo.ValidateAll() if the designated constraints aren't met.
+type AvatarInfoMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m AvatarInfoMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m AvatarInfoMultiError) AllErrors() []error { return m }
+
+// AvatarInfoValidationError is the validation error returned by
+// AvatarInfo.Validate if the designated constraints aren't met.
+type AvatarInfoValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e AvatarInfoValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e AvatarInfoValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e AvatarInfoValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e AvatarInfoValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e AvatarInfoValidationError) ErrorName() string { return "AvatarInfoValidationError" }
+
+// Error satisfies the builtin error interface
+func (e AvatarInfoValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sAvatarInfo.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = AvatarInfoValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = AvatarInfoValidationError{}
+
+// Validate checks the field values on SearchUsersRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *SearchUsersRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on SearchUsersRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// SearchUsersRequestMultiError, or nil if none found.
+func (m *SearchUsersRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *SearchUsersRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetQuery()); l < 2 || l > 100 {
+		err := SearchUsersRequestValidationError{
+			field:  "Query",
+			reason: "value length must be between 2 and 100 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if val := m.GetPageSize(); val < 0 || val > 100 {
+		err := SearchUsersRequestValidationError{
+			field:  "PageSize",
+			reason: "value must be inside range [0, 100]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetPageToken()) > 512 {
+		err := SearchUsersRequestValidationError{
+			field:  "PageToken",
+			reason: "value length must be at most 512 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return SearchUsersRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// SearchUsersRequestMultiError is an error wrapping multiple validation errors
+// returned by SearchUsersRequest.ValidateAll() if the designated constraints
+// aren't met.
+type SearchUsersRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m SearchUsersRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m SearchUsersRequestMultiError) AllErrors() []error { return m }
+
+// SearchUsersRequestValidationError is the validation error returned by
+// SearchUsersRequest.Validate if the designated constraints aren't met.
+type SearchUsersRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e SearchUsersRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e SearchUsersRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e SearchUsersRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e SearchUsersRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e SearchUsersRequestValidationError) ErrorName() string {
+	return "SearchUsersRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e SearchUsersRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sSearchUsersRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = SearchUsersRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = SearchUsersRequestValidationError{}
+
+// Validate checks the field values on SearchUsersResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *SearchUsersResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on SearchUsersResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// SearchUsersResponseMultiError, or nil if none found.
+func (m *SearchUsersResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *SearchUsersResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetResults() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, SearchUsersResponseValidationError{
+						field:  fmt.Sprintf("Results[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, SearchUsersResponseValidationError{
+						field:  fmt.Sprintf("Results[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return SearchUsersResponseValidationError{
+					field:  fmt.Sprintf("Results[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for NextPageToken
+
+	if len(errors) > 0 {
+		return SearchUsersResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// SearchUsersResponseMultiError is an error wrapping multiple validation
+// errors returned by SearchUsersResponse.ValidateAll() if the designated
+// constraints aren't met.
+type SearchUsersResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m SearchUsersResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m SearchUsersResponseMultiError) AllErrors() []error { return m }
+
+// SearchUsersResponseValidationError is the validation error returned by
+// SearchUsersResponse.Validate if the designated constraints aren't met.
+type SearchUsersResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e SearchUsersResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e SearchUsersResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e SearchUsersResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e SearchUsersResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e SearchUsersResponseValidationError) ErrorName() string {
+	return "SearchUsersResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e SearchUsersResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sSearchUsersResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = SearchUsersResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = SearchUsersResponseValidationError{}
+
+// Validate checks the field values on UserSearchResult with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *UserSearchResult) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UserSearchResult with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UserSearchResultMultiError, or nil if none found.
+func (m *UserSearchResult) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UserSearchResult) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetUser()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, UserSearchResultValidationError{
+					field:  "User",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, UserSearchResultValidationError{
+					field:  "User",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetUser()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return UserSearchResultValidationError{
+				field:  "User",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Rank
+
+	for idx, item := range m.GetHighlights() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, UserSearchResultValidationError{
+						field:  fmt.Sprintf("Highlights[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, UserSearchResultValidationError{
+						field:  fmt.Sprintf("Highlights[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return UserSearchResultValidationError{
+					field:  fmt.Sprintf("Highlights[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return UserSearchResultMultiError(errors)
+	}
+
+	return nil
+}
+
+// UserSearchResultMultiError is an error wrapping multiple validation errors
+// returned by UserSearchResult.ValidateAll() if the designated constraints
+// aren't met.
+type UserSearchResultMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UserSearchResultMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UserSearchResultMultiError) AllErrors() []error { return m }
+
+// UserSearchResultValidationError is the validation error returned by
+// UserSearchResult.Validate if the designated constraints aren't met.
+type UserSearchResultValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UserSearchResultValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UserSearchResultValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UserSearchResultValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UserSearchResultValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UserSearchResultValidationError) ErrorName() string { return "UserSearchResultValidationError" }
+
+// Error satisfies the builtin error interface
+func (e UserSearchResultValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUserSearchResult.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UserSearchResultValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UserSearchResultValidationError{}
+
+// Validate checks the field values on Highlight with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Highlight) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Highlight with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in HighlightMultiError, or nil
+// if none found.
+func (m *Highlight) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Highlight) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Field
+
+	// no validation rules for Start
+
+	// no validation rules for End
+
+	if len(errors) > 0 {
+		return HighlightMultiError(errors)
+	}
+
+	return nil
+}
+
+// HighlightMultiError is an error wrapping multiple validation errors returned
+// by Highlight.ValidateAll() if the designated constraints aren't met.
+type HighlightMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m HighlightMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m HighlightMultiError) AllErrors() []error { return m }
+
+// HighlightValidationError is the validation error returned by
+// Highlight.Validate if the designated constraints aren't met.
+type HighlightValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e HighlightValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e HighlightValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e HighlightValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e HighlightValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e HighlightValidationError) ErrorName() string { return "HighlightValidationError" }
+
+// Error satisfies the builtin error interface
+func (e HighlightValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sHighlight.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = HighlightValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = HighlightValidationError{}
+
+// Validate checks the field values on LoginRequest with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *LoginRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on LoginRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in LoginRequestMultiError, or
+// nil if none found.
+func (m *LoginRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *LoginRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetEmail()); l < 1 || l > 254 {
+		err := LoginRequestValidationError{
+			field:  "Email",
+			reason: "value length must be between 1 and 254 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if l := utf8.RuneCountInString(m.GetPassword()); l < 1 || l > 72 {
+		err := LoginRequestValidationError{
+			field:  "Password",
+			reason: "value length must be between 1 and 72 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return LoginRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// LoginRequestMultiError is an error wrapping multiple validation errors
+// returned by LoginRequest.ValidateAll() if the designated constraints aren't met.
+type LoginRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m LoginRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m LoginRequestMultiError) AllErrors() []error { return m }
+
+// LoginRequestValidationError is the validation error returned by
+// LoginRequest.Validate if the designated constraints aren't met.
+type LoginRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e LoginRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e LoginRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e LoginRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e LoginRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e LoginRequestValidationError) ErrorName() string { return "LoginRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e LoginRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sLoginRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = LoginRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = LoginRequestValidationError{}
+
+// Validate checks the field values on LoginResponse with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *LoginResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on LoginResponse with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in LoginResponseMultiError, or
+// nil if none found.
+func (m *LoginResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *LoginResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for SessionToken
+
+	if all {
+		switch v := interface{}(m.GetSession()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, LoginResponseValidationError{
+					field:  "Session",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, LoginResponseValidationError{
+					field:  "Session",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetSession()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return LoginResponseValidationError{
+				field:  "Session",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for MfaRequired
+
+	// no validation rules for MfaToken
+
+	if len(errors) > 0 {
+		return LoginResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// LoginResponseMultiError is an error wrapping multiple validation errors
+// returned by LoginResponse.ValidateAll() if the designated constraints
+// aren't met.
+type LoginResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m LoginResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m LoginResponseMultiError) AllErrors() []error { return m }
+
+// LoginResponseValidationError is the validation error returned by
+// LoginResponse.Validate if the designated constraints aren't met.
+type LoginResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e LoginResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e LoginResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e LoginResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e LoginResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e LoginResponseValidationError) ErrorName() string { return "LoginResponseValidationError" }
+
+// Error satisfies the builtin error interface
+func (e LoginResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sLoginResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = LoginResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = LoginResponseValidationError{}
+
+// Validate checks the field values on VerifySecondFactorRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *VerifySecondFactorRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on VerifySecondFactorRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// VerifySecondFactorRequestMultiError, or nil if none found.
+func (m *VerifySecondFactorRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *VerifySecondFactorRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetMfaToken()); l < 1 || l > 64 {
+		err := VerifySecondFactorRequestValidationError{
+			field:  "MfaToken",
+			reason: "value length must be between 1 and 64 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetCode() != "" {
+
+		if !_VerifySecondFactorRequest_Code_Pattern.MatchString(m.GetCode()) {
+			err := VerifySecondFactorRequestValidationError{
+				field:  "Code",
+				reason: "value does not match regex pattern \"^[0-9]{6}$\"",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if utf8.RuneCountInString(m.GetRecoveryCode()) > 32 {
+		err := VerifySecondFactorRequestValidationError{
+			field:  "RecoveryCode",
+			reason: "value length must be at most 32 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return VerifySecondFactorRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// VerifySecondFactorRequestMultiError is an error wrapping multiple validation
+// errors returned by VerifySecondFactorRequest.ValidateAll() if the
+// designated constraints aren't met.
+type VerifySecondFactorRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m VerifySecondFactorRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m VerifySecondFactorRequestMultiError) AllErrors() []error { return m }
+
+// VerifySecondFactorRequestValidationError is the validation error returned by
+// VerifySecondFactorRequest.Validate if the designated constraints aren't met.
+type VerifySecondFactorRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e VerifySecondFactorRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e VerifySecondFactorRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e VerifySecondFactorRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e VerifySecondFactorRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e VerifySecondFactorRequestValidationError) ErrorName() string {
+	return "VerifySecondFactorRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e VerifySecondFactorRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sVerifySecondFactorRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = VerifySecondFactorRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = VerifySecondFactorRequestValidationError{}
+
+var _VerifySecondFactorRequest_Code_Pattern = regexp.MustCompile("^[0-9]{6}$")
+
+// Validate checks the field values on RequestMagicLinkRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *RequestMagicLinkRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on RequestMagicLinkRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// RequestMagicLinkRequestMultiError, or nil if none found.
+func (m *RequestMagicLinkRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *RequestMagicLinkRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetEmail()) > 254 {
+		err := RequestMagicLinkRequestValidationError{
+			field:  "Email",
+			reason: "value length must be at most 254 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if err := m._validateEmail(m.GetEmail()); err != nil {
+		err = RequestMagicLinkRequestValidationError{
+			field:  "Email",
+			reason: "value must be a valid email address",
+			cause:  err,
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return RequestMagicLinkRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+func (m *RequestMagicLinkRequest) _validateHostname(host string) error {
+	s := strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if len(host) > 253 {
+		return errors.New("hostname cannot exceed 253 characters")
+	}
+
+	for _, part := range strings.Split(s, ".") {
+		if l := len(part); l == 0 || l > 63 {
+			return errors.New("hostname part must be non-empty and cannot exceed 63 characters")
+		}
+
+		if part[0] == '-' {
+			return errors.New("hostname parts cannot begin with hyphens")
+		}
+
+		if part[len(part)-1] == '-' {
+			return errors.New("hostname parts cannot end with hyphens")
+		}
+
+		for _, r := range part {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return fmt.Errorf("hostname parts can only contain alphanumeric characters or hyphens, got %q", string(r))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *RequestMagicLinkRequest) _validateEmail(addr string) error {
+	a, err := mail.ParseAddress(addr)
+	if err != nil {
+		return err
+	}
+	addr = a.Address
+
+	if len(addr) > 254 {
+		return errors.New("email addresses cannot exceed 254 characters")
+	}
+
+	parts := strings.SplitN(addr, "@", 2)
+
+	if len(parts[0]) > 64 {
+		return errors.New("email address local phrase cannot exceed 64 characters")
+	}
+
+	return m._validateHostname(parts[1])
+}
+
+// RequestMagicLinkRequestMultiError is an error wrapping multiple validation
+// errors returned by RequestMagicLinkRequest.ValidateAll() if the designated
+// constraints aren't met.
+type RequestMagicLinkRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m RequestMagicLinkRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m RequestMagicLinkRequestMultiError) AllErrors() []error { return m }
+
+// RequestMagicLinkRequestValidationError is the validation error returned by
+// RequestMagicLinkRequest.Validate if the designated constraints aren't met.
+type RequestMagicLinkRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e RequestMagicLinkRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e RequestMagicLinkRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e RequestMagicLinkRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e RequestMagicLinkRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e RequestMagicLinkRequestValidationError) ErrorName() string {
+	return "RequestMagicLinkRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e RequestMagicLinkRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sRequestMagicLinkRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = RequestMagicLinkRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = RequestMagicLinkRequestValidationError{}
+
+// Validate checks the field values on ConsumeMagicLinkRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ConsumeMagicLinkRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ConsumeMagicLinkRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ConsumeMagicLinkRequestMultiError, or nil if none found.
+func (m *ConsumeMagicLinkRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ConsumeMagicLinkRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetToken()); l < 1 || l > 64 {
+		err := ConsumeMagicLinkRequestValidationError{
+			field:  "Token",
+			reason: "value length must be between 1 and 64 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return ConsumeMagicLinkRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ConsumeMagicLinkRequestMultiError is an error wrapping multiple validation
+// errors returned by ConsumeMagicLinkRequest.ValidateAll() if the designated
+// constraints aren't met.
+type ConsumeMagicLinkRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ConsumeMagicLinkRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ConsumeMagicLinkRequestMultiError) AllErrors() []error { return m }
+
+// ConsumeMagicLinkRequestValidationError is the validation error returned by
+// ConsumeMagicLinkRequest.Validate if the designated constraints aren't met.
+type ConsumeMagicLinkRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ConsumeMagicLinkRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ConsumeMagicLinkRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ConsumeMagicLinkRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ConsumeMagicLinkRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ConsumeMagicLinkRequestValidationError) ErrorName() string {
+	return "ConsumeMagicLinkRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ConsumeMagicLinkRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sConsumeMagicLinkRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ConsumeMagicLinkRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ConsumeMagicLinkRequestValidationError{}
+
+// Validate checks the field values on StartSocialLoginRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *StartSocialLoginRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on StartSocialLoginRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// StartSocialLoginRequestMultiError, or nil if none found.
+func (m *StartSocialLoginRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *StartSocialLoginRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetProvider()); l < 1 || l > 32 {
+		err := StartSocialLoginRequestValidationError{
+			field:  "Provider",
+			reason: "value length must be between 1 and 32 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return StartSocialLoginRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// StartSocialLoginRequestMultiError is an error wrapping multiple validation
+// errors returned by StartSocialLoginRequest.ValidateAll() if the designated
+// constraints aren't met.
+type StartSocialLoginRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m StartSocialLoginRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m StartSocialLoginRequestMultiError) AllErrors() []error { return m }
+
+// StartSocialLoginRequestValidationError is the validation error returned by
+// StartSocialLoginRequest.Validate if the designated constraints aren't met.
+type StartSocialLoginRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e StartSocialLoginRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e StartSocialLoginRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e StartSocialLoginRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e StartSocialLoginRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e StartSocialLoginRequestValidationError) ErrorName() string {
+	return "StartSocialLoginRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e StartSocialLoginRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sStartSocialLoginRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = StartSocialLoginRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = StartSocialLoginRequestValidationError{}
+
+// Validate checks the field values on StartSocialLoginResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *StartSocialLoginResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on StartSocialLoginResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// StartSocialLoginResponseMultiError, or nil if none found.
+func (m *StartSocialLoginResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *StartSocialLoginResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for AuthorizationUrl
+
+	if len(errors) > 0 {
+		return StartSocialLoginResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// StartSocialLoginResponseMultiError is an error wrapping multiple validation
+// errors returned by StartSocialLoginResponse.ValidateAll() if the designated
+// constraints aren't met.
+type StartSocialLoginResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m StartSocialLoginResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m StartSocialLoginResponseMultiError) AllErrors() []error { return m }
+
+// StartSocialLoginResponseValidationError is the validation error returned by
+// StartSocialLoginResponse.Validate if the designated constraints aren't met.
+type StartSocialLoginResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e StartSocialLoginResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e StartSocialLoginResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e StartSocialLoginResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e StartSocialLoginResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e StartSocialLoginResponseValidationError) ErrorName() string {
+	return "StartSocialLoginResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e StartSocialLoginResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sStartSocialLoginResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = StartSocialLoginResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = StartSocialLoginResponseValidationError{}
+
+// Validate checks the field values on CompleteSocialLoginRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CompleteSocialLoginRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CompleteSocialLoginRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CompleteSocialLoginRequestMultiError, or nil if none found.
+func (m *CompleteSocialLoginRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CompleteSocialLoginRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetProvider()); l < 1 || l > 32 {
+		err := CompleteSocialLoginRequestValidationError{
+			field:  "Provider",
+			reason: "value length must be between 1 and 32 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if l := utf8.RuneCountInString(m.GetCode()); l < 1 || l > 2048 {
+		err := CompleteSocialLoginRequestValidationError{
+			field:  "Code",
+			reason: "value length must be between 1 and 2048 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if l := utf8.RuneCountInString(m.GetState()); l < 1 || l > 64 {
+		err := CompleteSocialLoginRequestValidationError{
+			field:  "State",
+			reason: "value length must be between 1 and 64 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return CompleteSocialLoginRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CompleteSocialLoginRequestMultiError is an error wrapping multiple
+// validation errors returned by CompleteSocialLoginRequest.ValidateAll() if
+// the designated constraints aren't met.
+type CompleteSocialLoginRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CompleteSocialLoginRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CompleteSocialLoginRequestMultiError) AllErrors() []error { return m }
+
+// CompleteSocialLoginRequestValidationError is the validation error returned
+// by CompleteSocialLoginRequest.Validate if the designated constraints aren't met.
+type CompleteSocialLoginRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CompleteSocialLoginRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CompleteSocialLoginRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CompleteSocialLoginRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CompleteSocialLoginRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CompleteSocialLoginRequestValidationError) ErrorName() string {
+	return "CompleteSocialLoginRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CompleteSocialLoginRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCompleteSocialLoginRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CompleteSocialLoginRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CompleteSocialLoginRequestValidationError{}
+
+// Validate checks the field values on EnrollTOTPRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *EnrollTOTPRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on EnrollTOTPRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// EnrollTOTPRequestMultiError, or nil if none found.
+func (m *EnrollTOTPRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *EnrollTOTPRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := EnrollTOTPRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return EnrollTOTPRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// EnrollTOTPRequestMultiError is an error wrapping multiple validation errors
+// returned by EnrollTOTPRequest.ValidateAll() if the designated constraints
+// aren't met.
+type EnrollTOTPRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m EnrollTOTPRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m EnrollTOTPRequestMultiError) AllErrors() []error { return m }
+
+// EnrollTOTPRequestValidationError is the validation error returned by
+// EnrollTOTPRequest.Validate if the designated constraints aren't met.
+type EnrollTOTPRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e EnrollTOTPRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e EnrollTOTPRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e EnrollTOTPRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e EnrollTOTPRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e EnrollTOTPRequestValidationError) ErrorName() string {
+	return "EnrollTOTPRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e EnrollTOTPRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sEnrollTOTPRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = EnrollTOTPRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = EnrollTOTPRequestValidationError{}
+
+// Validate checks the field values on EnrollTOTPResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *EnrollTOTPResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on EnrollTOTPResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// EnrollTOTPResponseMultiError, or nil if none found.
+func (m *EnrollTOTPResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *EnrollTOTPResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Secret
+
+	// no validation rules for OtpauthUri
+
+	// no validation rules for QrPng
+
+	if len(errors) > 0 {
+		return EnrollTOTPResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// EnrollTOTPResponseMultiError is an error wrapping multiple validation errors
+// returned by EnrollTOTPResponse.ValidateAll() if the designated constraints
+// aren't met.
+type EnrollTOTPResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m EnrollTOTPResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m EnrollTOTPResponseMultiError) AllErrors() []error { return m }
+
+// EnrollTOTPResponseValidationError is the validation error returned by
+// EnrollTOTPResponse.Validate if the designated constraints aren't met.
+type EnrollTOTPResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e EnrollTOTPResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e EnrollTOTPResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e EnrollTOTPResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e EnrollTOTPResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e EnrollTOTPResponseValidationError) ErrorName() string {
+	return "EnrollTOTPResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e EnrollTOTPResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sEnrollTOTPResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = EnrollTOTPResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = EnrollTOTPResponseValidationError{}
+
+// Validate checks the field values on ConfirmTOTPRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ConfirmTOTPRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ConfirmTOTPRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ConfirmTOTPRequestMultiError, or nil if none found.
+func (m *ConfirmTOTPRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ConfirmTOTPRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := ConfirmTOTPRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if !_ConfirmTOTPRequest_Code_Pattern.MatchString(m.GetCode()) {
+		err := ConfirmTOTPRequestValidationError{
+			field:  "Code",
+			reason: "value does not match regex pattern \"^[0-9]{6}$\"",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return ConfirmTOTPRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ConfirmTOTPRequestMultiError is an error wrapping multiple validation errors
+// returned by ConfirmTOTPRequest.ValidateAll() if the designated constraints
+// aren't met.
+type ConfirmTOTPRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ConfirmTOTPRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ConfirmTOTPRequestMultiError) AllErrors() []error { return m }
+
+// ConfirmTOTPRequestValidationError is the validation error returned by
+// ConfirmTOTPRequest.Validate if the designated constraints aren't met.
+type ConfirmTOTPRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ConfirmTOTPRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ConfirmTOTPRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ConfirmTOTPRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ConfirmTOTPRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ConfirmTOTPRequestValidationError) ErrorName() string {
+	return "ConfirmTOTPRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ConfirmTOTPRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sConfirmTOTPRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ConfirmTOTPRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ConfirmTOTPRequestValidationError{}
+
+var _ConfirmTOTPRequest_Code_Pattern = regexp.MustCompile("^[0-9]{6}$")
+
+// Validate checks the field values on ConfirmTOTPResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ConfirmTOTPResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ConfirmTOTPResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ConfirmTOTPResponseMultiError, or nil if none found.
+func (m *ConfirmTOTPResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ConfirmTOTPResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(errors) > 0 {
+		return ConfirmTOTPResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ConfirmTOTPResponseMultiError is an error wrapping multiple validation
+// errors returned by ConfirmTOTPResponse.ValidateAll() if the designated
+// constraints aren't met.
+type ConfirmTOTPResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ConfirmTOTPResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ConfirmTOTPResponseMultiError) AllErrors() []error { return m }
+
+// ConfirmTOTPResponseValidationError is the validation error returned by
+// ConfirmTOTPResponse.Validate if the designated constraints aren't met.
+type ConfirmTOTPResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ConfirmTOTPResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ConfirmTOTPResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ConfirmTOTPResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ConfirmTOTPResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ConfirmTOTPResponseValidationError) ErrorName() string {
+	return "ConfirmTOTPResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ConfirmTOTPResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sConfirmTOTPResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ConfirmTOTPResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ConfirmTOTPResponseValidationError{}
+
+// Validate checks the field values on DisableTOTPRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *DisableTOTPRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DisableTOTPRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DisableTOTPRequestMultiError, or nil if none found.
+func (m *DisableTOTPRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DisableTOTPRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := DisableTOTPRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetCode() != "" {
+
+		if !_DisableTOTPRequest_Code_Pattern.MatchString(m.GetCode()) {
+			err := DisableTOTPRequestValidationError{
+				field:  "Code",
+				reason: "value does not match regex pattern \"^[0-9]{6}$\"",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if utf8.RuneCountInString(m.GetRecoveryCode()) > 32 {
+		err := DisableTOTPRequestValidationError{
+			field:  "RecoveryCode",
+			reason: "value length must be at most 32 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return DisableTOTPRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// DisableTOTPRequestMultiError is an error wrapping multiple validation errors
+// returned by DisableTOTPRequest.ValidateAll() if the designated constraints
+// aren't met.
+type DisableTOTPRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DisableTOTPRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DisableTOTPRequestMultiError) AllErrors() []error { return m }
+
+// DisableTOTPRequestValidationError is the validation error returned by
+// DisableTOTPRequest.Validate if the designated constraints aren't met.
+type DisableTOTPRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DisableTOTPRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DisableTOTPRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DisableTOTPRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DisableTOTPRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DisableTOTPRequestValidationError) ErrorName() string {
+	return "DisableTOTPRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DisableTOTPRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDisableTOTPRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DisableTOTPRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DisableTOTPRequestValidationError{}
+
+var _DisableTOTPRequest_Code_Pattern = regexp.MustCompile("^[0-9]{6}$")
+
+// Validate checks the field values on Session with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Session) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Session with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in SessionMultiError, or nil if none found.
+func (m *Session) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Session) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Id
+
+	// no validation rules for UserId
+
+	if all {
+		switch v := interface{}(m.GetCreatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, SessionValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, SessionValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetCreatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return SessionValidationError{
+				field:  "CreatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetExpiresAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, SessionValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, SessionValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetExpiresAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return SessionValidationError{
+				field:  "ExpiresAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Ip
+
+	// no validation rules for UserAgent
+
+	// no validation rules for Current
+
+	if len(errors) > 0 {
+		return SessionMultiError(errors)
+	}
+
+	return nil
+}
+
+// SessionMultiError is an error wrapping multiple validation errors returned
+// by Session.ValidateAll() if the designated constraints aren't met.
+type SessionMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m SessionMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m SessionMultiError) AllErrors() []error { return m }
+
+// SessionValidationError is the validation error returned by Session.Validate
+// if the designated constraints aren't met.
+type SessionValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e SessionValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e SessionValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e SessionValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e SessionValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e SessionValidationError) ErrorName() string { return "SessionValidationError" }
+
+// Error satisfies the builtin error interface
+func (e SessionValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sSession.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = SessionValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = SessionValidationError{}
+
+// Validate checks the field values on ListSessionsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListSessionsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListSessionsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListSessionsRequestMultiError, or nil if none found.
+func (m *ListSessionsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListSessionsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := ListSessionsRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return ListSessionsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListSessionsRequestMultiError is an error wrapping multiple validation
+// errors returned by ListSessionsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type ListSessionsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListSessionsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListSessionsRequestMultiError) AllErrors() []error { return m }
+
+// ListSessionsRequestValidationError is the validation error returned by
+// ListSessionsRequest.Validate if the designated constraints aren't met.
+type ListSessionsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListSessionsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListSessionsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListSessionsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListSessionsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListSessionsRequestValidationError) ErrorName() string {
+	return "ListSessionsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListSessionsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListSessionsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListSessionsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListSessionsRequestValidationError{}
+
+// Validate checks the field values on ListSessionsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListSessionsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListSessionsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListSessionsResponseMultiError, or nil if none found.
+func (m *ListSessionsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListSessionsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetSessions() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListSessionsResponseValidationError{
+						field:  fmt.Sprintf("Sessions[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListSessionsResponseValidationError{
+						field:  fmt.Sprintf("Sessions[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListSessionsResponseValidationError{
+					field:  fmt.Sprintf("Sessions[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return ListSessionsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListSessionsResponseMultiError is an error wrapping multiple validation
+// errors returned by ListSessionsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type ListSessionsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListSessionsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListSessionsResponseMultiError) AllErrors() []error { return m }
+
+// ListSessionsResponseValidationError is the validation error returned by
+// ListSessionsResponse.Validate if the designated constraints aren't met.
+type ListSessionsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListSessionsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListSessionsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListSessionsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListSessionsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListSessionsResponseValidationError) ErrorName() string {
+	return "ListSessionsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListSessionsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListSessionsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListSessionsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListSessionsResponseValidationError{}
+
+// Validate checks the field values on RevokeSessionRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *RevokeSessionRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on RevokeSessionRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// RevokeSessionRequestMultiError, or nil if none found.
+func (m *RevokeSessionRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *RevokeSessionRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := RevokeSessionRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if l := utf8.RuneCountInString(m.GetSessionId()); l < 1 || l > 64 {
+		err := RevokeSessionRequestValidationError{
+			field:  "SessionId",
+			reason: "value length must be between 1 and 64 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return RevokeSessionRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// RevokeSessionRequestMultiError is an error wrapping multiple validation
+// errors returned by RevokeSessionRequest.ValidateAll() if the designated
+// constraints aren't met.
+type RevokeSessionRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m RevokeSessionRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m RevokeSessionRequestMultiError) AllErrors() []error { return m }
+
+// RevokeSessionRequestValidationError is the validation error returned by
+// RevokeSessionRequest.Validate if the designated constraints aren't met.
+type RevokeSessionRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e RevokeSessionRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e RevokeSessionRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e RevokeSessionRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e RevokeSessionRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e RevokeSessionRequestValidationError) ErrorName() string {
+	return "RevokeSessionRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e RevokeSessionRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sRevokeSessionRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = RevokeSessionRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = RevokeSessionRequestValidationError{}
+
+// Validate checks the field values on RevokeAllSessionsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *RevokeAllSessionsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on RevokeAllSessionsRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// RevokeAllSessionsRequestMultiError, or nil if none found.
+func (m *RevokeAllSessionsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *RevokeAllSessionsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := RevokeAllSessionsRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	// no validation rules for KeepCurrent
+
+	if len(errors) > 0 {
+		return RevokeAllSessionsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// RevokeAllSessionsRequestMultiError is an error wrapping multiple validation
+// errors returned by RevokeAllSessionsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type RevokeAllSessionsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m RevokeAllSessionsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m RevokeAllSessionsRequestMultiError) AllErrors() []error { return m }
+
+// RevokeAllSessionsRequestValidationError is the validation error returned by
+// RevokeAllSessionsRequest.Validate if the designated constraints aren't met.
+type RevokeAllSessionsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e RevokeAllSessionsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e RevokeAllSessionsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e RevokeAllSessionsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e RevokeAllSessionsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e RevokeAllSessionsRequestValidationError) ErrorName() string {
+	return "RevokeAllSessionsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e RevokeAllSessionsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sRevokeAllSessionsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = RevokeAllSessionsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = RevokeAllSessionsRequestValidationError{}
+
+// Validate checks the field values on RevokeAllSessionsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *RevokeAllSessionsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on RevokeAllSessionsResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// RevokeAllSessionsResponseMultiError, or nil if none found.
+func (m *RevokeAllSessionsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *RevokeAllSessionsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Revoked
+
+	if len(errors) > 0 {
+		return RevokeAllSessionsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// RevokeAllSessionsResponseMultiError is an error wrapping multiple validation
+// errors returned by RevokeAllSessionsResponse.ValidateAll() if the
+// designated constraints aren't met.
+type RevokeAllSessionsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m RevokeAllSessionsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m RevokeAllSessionsResponseMultiError) AllErrors() []error { return m }
+
+// RevokeAllSessionsResponseValidationError is the validation error returned by
+// RevokeAllSessionsResponse.Validate if the designated constraints aren't met.
+type RevokeAllSessionsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e RevokeAllSessionsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e RevokeAllSessionsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e RevokeAllSessionsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e RevokeAllSessionsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e RevokeAllSessionsResponseValidationError) ErrorName() string {
+	return "RevokeAllSessionsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e RevokeAllSessionsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sRevokeAllSessionsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = RevokeAllSessionsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = RevokeAllSessionsResponseValidationError{}
+
+// Validate checks the field values on UnlockUserRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *UnlockUserRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UnlockUserRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UnlockUserRequestMultiError, or nil if none found.
+func (m *UnlockUserRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UnlockUserRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := UnlockUserRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return UnlockUserRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// UnlockUserRequestMultiError is an error wrapping multiple validation errors
+// returned by UnlockUserRequest.ValidateAll() if the designated constraints
+// aren't met.
+type UnlockUserRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UnlockUserRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UnlockUserRequestMultiError) AllErrors() []error { return m }
+
+// UnlockUserRequestValidationError is the validation error returned by
+// UnlockUserRequest.Validate if the designated constraints aren't met.
+type UnlockUserRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UnlockUserRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UnlockUserRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UnlockUserRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UnlockUserRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UnlockUserRequestValidationError) ErrorName() string {
+	return "UnlockUserRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e UnlockUserRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUnlockUserRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UnlockUserRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UnlockUserRequestValidationError{}
+
+// Validate checks the field values on ListLoginEventsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListLoginEventsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListLoginEventsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListLoginEventsRequestMultiError, or nil if none found.
+func (m *ListLoginEventsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListLoginEventsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := ListLoginEventsRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if val := m.GetPageSize(); val < 0 || val > 100 {
+		err := ListLoginEventsRequestValidationError{
+			field:  "PageSize",
+			reason: "value must be inside range [0, 100]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetPageToken()) > 64 {
+		err := ListLoginEventsRequestValidationError{
+			field:  "PageToken",
+			reason: "value length must be at most 64 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return ListLoginEventsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListLoginEventsRequestMultiError is an error wrapping multiple validation
+// errors returned by ListLoginEventsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type ListLoginEventsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListLoginEventsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListLoginEventsRequestMultiError) AllErrors() []error { return m }
+
+// ListLoginEventsRequestValidationError is the validation error returned by
+// ListLoginEventsRequest.Validate if the designated constraints aren't met.
+type ListLoginEventsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListLoginEventsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListLoginEventsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListLoginEventsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListLoginEventsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListLoginEventsRequestValidationError) ErrorName() string {
+	return "ListLoginEventsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListLoginEventsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListLoginEventsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListLoginEventsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListLoginEventsRequestValidationError{}
+
+// Validate checks the field values on ListLoginEventsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListLoginEventsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListLoginEventsResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListLoginEventsResponseMultiError, or nil if none found.
+func (m *ListLoginEventsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListLoginEventsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetEvents() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListLoginEventsResponseValidationError{
+						field:  fmt.Sprintf("Events[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListLoginEventsResponseValidationError{
+						field:  fmt.Sprintf("Events[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListLoginEventsResponseValidationError{
+					field:  fmt.Sprintf("Events[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for NextPageToken
+
+	if len(errors) > 0 {
+		return ListLoginEventsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListLoginEventsResponseMultiError is an error wrapping multiple validation
+// errors returned by ListLoginEventsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type ListLoginEventsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListLoginEventsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListLoginEventsResponseMultiError) AllErrors() []error { return m }
+
+// ListLoginEventsResponseValidationError is the validation error returned by
+// ListLoginEventsResponse.Validate if the designated constraints aren't met.
+type ListLoginEventsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListLoginEventsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListLoginEventsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListLoginEventsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListLoginEventsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListLoginEventsResponseValidationError) ErrorName() string {
+	return "ListLoginEventsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListLoginEventsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListLoginEventsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListLoginEventsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListLoginEventsResponseValidationError{}
+
+// Validate checks the field values on LoginEvent with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *LoginEvent) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on LoginEvent with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in LoginEventMultiError, or
+// nil if none found.
+func (m *LoginEvent) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *LoginEvent) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Id
+
+	// no validation rules for UserId
+
+	// no validation rules for Email
+
+	// no validation rules for Ip
+
+	// no validation rules for UserAgent
+
+	// no validation rules for Success
+
+	// no validation rules for FailureReason
+
+	if all {
+		switch v := interface{}(m.GetCreatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, LoginEventValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, LoginEventValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetCreatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return LoginEventValidationError{
+				field:  "CreatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Method
+
+	if len(errors) > 0 {
+		return LoginEventMultiError(errors)
+	}
+
+	return nil
+}
+
+// LoginEventMultiError is an error wrapping multiple validation errors
+// returned by LoginEvent.ValidateAll() if the designated constraints aren't met.
+type LoginEventMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m LoginEventMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m LoginEventMultiError) AllErrors() []error { return m }
+
+// LoginEventValidationError is the validation error returned by
+// LoginEvent.Validate if the designated constraints aren't met.
+type LoginEventValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e LoginEventValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e LoginEventValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e LoginEventValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e LoginEventValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e LoginEventValidationError) ErrorName() string { return "LoginEventValidationError" }
+
+// Error satisfies the builtin error interface
+func (e LoginEventValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sLoginEvent.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = LoginEventValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = LoginEventValidationError{}
+
+// Validate checks the field values on OIDCClient with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *OIDCClient) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on OIDCClient with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in OIDCClientMultiError, or
+// nil if none found.
+func (m *OIDCClient) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *OIDCClient) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ClientId
+
+	// no validation rules for Name
+
+	// no validation rules for Public
+
+	if all {
+		switch v := interface{}(m.GetCreatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, OIDCClientValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, OIDCClientValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetCreatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return OIDCClientValidationError{
+				field:  "CreatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return OIDCClientMultiError(errors)
+	}
+
+	return nil
+}
+
+// OIDCClientMultiError is an error wrapping multiple validation errors
+// returned by OIDCClient.ValidateAll() if the designated constraints aren't met.
+type OIDCClientMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m OIDCClientMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m OIDCClientMultiError) AllErrors() []error { return m }
+
+// OIDCClientValidationError is the validation error returned by
+// OIDCClient.Validate if the designated constraints aren't met.
+type OIDCClientValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e OIDCClientValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e OIDCClientValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e OIDCClientValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e OIDCClientValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e OIDCClientValidationError) ErrorName() string { return "OIDCClientValidationError" }
+
+// Error satisfies the builtin error interface
+func (e OIDCClientValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sOIDCClient.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = OIDCClientValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = OIDCClientValidationError{}
+
+// Validate checks the field values on CreateOIDCClientRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateOIDCClientRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateOIDCClientRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateOIDCClientRequestMultiError, or nil if none found.
+func (m *CreateOIDCClientRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateOIDCClientRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetName()); l < 1 || l > 100 {
+		err := CreateOIDCClientRequestValidationError{
+			field:  "Name",
+			reason: "value length must be between 1 and 100 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if l := len(m.GetRedirectUris()); l < 1 || l > 10 {
+		err := CreateOIDCClientRequestValidationError{
+			field:  "RedirectUris",
+			reason: "value must contain between 1 and 10 items, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	for idx, item := range m.GetRedirectUris() {
+		_, _ = idx, item
+
+		if utf8.RuneCountInString(item) > 2048 {
+			err := CreateOIDCClientRequestValidationError{
+				field:  fmt.Sprintf("RedirectUris[%v]", idx),
+				reason: "value length must be at most 2048 runes",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+		if uri, err := url.Parse(item); err != nil {
+			err = CreateOIDCClientRequestValidationError{
+				field:  fmt.Sprintf("RedirectUris[%v]", idx),
+				reason: "value must be a valid URI",
+				cause:  err,
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		} else if !uri.IsAbs() {
+			err := CreateOIDCClientRequestValidationError{
+				field:  fmt.Sprintf("RedirectUris[%v]", idx),
+				reason: "value must be absolute",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	// no validation rules for Public
+
+	if len(errors) > 0 {
+		return CreateOIDCClientRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateOIDCClientRequestMultiError is an error wrapping multiple validation
+// errors returned by CreateOIDCClientRequest.ValidateAll() if the designated
+// constraints aren't met.
+type CreateOIDCClientRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateOIDCClientRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateOIDCClientRequestMultiError) AllErrors() []error { return m }
+
+// CreateOIDCClientRequestValidationError is the validation error returned by
+// CreateOIDCClientRequest.Validate if the designated constraints aren't met.
+type CreateOIDCClientRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateOIDCClientRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateOIDCClientRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateOIDCClientRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateOIDCClientRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateOIDCClientRequestValidationError) ErrorName() string {
+	return "CreateOIDCClientRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateOIDCClientRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateOIDCClientRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateOIDCClientRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateOIDCClientRequestValidationError{}
+
+// Validate checks the field values on CreateOIDCClientResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateOIDCClientResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateOIDCClientResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateOIDCClientResponseMultiError, or nil if none found.
+func (m *CreateOIDCClientResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateOIDCClientResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetClient()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, CreateOIDCClientResponseValidationError{
+					field:  "Client",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, CreateOIDCClientResponseValidationError{
+					field:  "Client",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetClient()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return CreateOIDCClientResponseValidationError{
+				field:  "Client",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for ClientSecret
+
+	if len(errors) > 0 {
+		return CreateOIDCClientResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateOIDCClientResponseMultiError is an error wrapping multiple validation
+// errors returned by CreateOIDCClientResponse.ValidateAll() if the designated
+// constraints aren't met.
+type CreateOIDCClientResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateOIDCClientResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateOIDCClientResponseMultiError) AllErrors() []error { return m }
+
+// CreateOIDCClientResponseValidationError is the validation error returned by
+// CreateOIDCClientResponse.Validate if the designated constraints aren't met.
+type CreateOIDCClientResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateOIDCClientResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateOIDCClientResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateOIDCClientResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateOIDCClientResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateOIDCClientResponseValidationError) ErrorName() string {
+	return "CreateOIDCClientResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateOIDCClientResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateOIDCClientResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateOIDCClientResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateOIDCClientResponseValidationError{}
+
+// Validate checks the field values on ListOIDCClientsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListOIDCClientsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListOIDCClientsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListOIDCClientsRequestMultiError, or nil if none found.
+func (m *ListOIDCClientsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListOIDCClientsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(errors) > 0 {
+		return ListOIDCClientsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListOIDCClientsRequestMultiError is an error wrapping multiple validation
+// errors returned by ListOIDCClientsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type ListOIDCClientsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListOIDCClientsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListOIDCClientsRequestMultiError) AllErrors() []error { return m }
+
+// ListOIDCClientsRequestValidationError is the validation error returned by
+// ListOIDCClientsRequest.Validate if the designated constraints aren't met.
+type ListOIDCClientsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListOIDCClientsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListOIDCClientsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListOIDCClientsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListOIDCClientsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListOIDCClientsRequestValidationError) ErrorName() string {
+	return "ListOIDCClientsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListOIDCClientsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListOIDCClientsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListOIDCClientsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListOIDCClientsRequestValidationError{}
+
+// Validate checks the field values on ListOIDCClientsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListOIDCClientsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListOIDCClientsResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListOIDCClientsResponseMultiError, or nil if none found.
+func (m *ListOIDCClientsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListOIDCClientsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetClients() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListOIDCClientsResponseValidationError{
+						field:  fmt.Sprintf("Clients[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListOIDCClientsResponseValidationError{
+						field:  fmt.Sprintf("Clients[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListOIDCClientsResponseValidationError{
+					field:  fmt.Sprintf("Clients[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return ListOIDCClientsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListOIDCClientsResponseMultiError is an error wrapping multiple validation
+// errors returned by ListOIDCClientsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type ListOIDCClientsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListOIDCClientsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListOIDCClientsResponseMultiError) AllErrors() []error { return m }
+
+// ListOIDCClientsResponseValidationError is the validation error returned by
+// ListOIDCClientsResponse.Validate if the designated constraints aren't met.
+type ListOIDCClientsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListOIDCClientsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListOIDCClientsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListOIDCClientsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListOIDCClientsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListOIDCClientsResponseValidationError) ErrorName() string {
+	return "ListOIDCClientsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListOIDCClientsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListOIDCClientsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListOIDCClientsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListOIDCClientsResponseValidationError{}
+
+// Validate checks the field values on DeleteOIDCClientRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *DeleteOIDCClientRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeleteOIDCClientRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeleteOIDCClientRequestMultiError, or nil if none found.
+func (m *DeleteOIDCClientRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteOIDCClientRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetClientId()); l < 1 || l > 64 {
+		err := DeleteOIDCClientRequestValidationError{
+			field:  "ClientId",
+			reason: "value length must be between 1 and 64 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return DeleteOIDCClientRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeleteOIDCClientRequestMultiError is an error wrapping multiple validation
+// errors returned by DeleteOIDCClientRequest.ValidateAll() if the designated
+// constraints aren't met.
+type DeleteOIDCClientRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeleteOIDCClientRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeleteOIDCClientRequestMultiError) AllErrors() []error { return m }
+
+// DeleteOIDCClientRequestValidationError is the validation error returned by
+// DeleteOIDCClientRequest.Validate if the designated constraints aren't met.
+type DeleteOIDCClientRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeleteOIDCClientRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeleteOIDCClientRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeleteOIDCClientRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeleteOIDCClientRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeleteOIDCClientRequestValidationError) ErrorName() string {
+	return "DeleteOIDCClientRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DeleteOIDCClientRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeleteOIDCClientRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeleteOIDCClientRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeleteOIDCClientRequestValidationError{}
+
+// Validate checks the field values on APIKey with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *APIKey) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on APIKey with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in APIKeyMultiError, or nil if none found.
+func (m *APIKey) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *APIKey) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for KeyId
+
+	// no validation rules for Name
+
+	// no validation rules for CreatedBy
+
+	if all {
+		switch v := interface{}(m.GetCreatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, APIKeyValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, APIKeyValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetCreatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return APIKeyValidationError{
+				field:  "CreatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetExpiresAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, APIKeyValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, APIKeyValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetExpiresAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return APIKeyValidationError{
+				field:  "ExpiresAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetLastUsedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, APIKeyValidationError{
+					field:  "LastUsedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, APIKeyValidationError{
+					field:  "LastUsedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetLastUsedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return APIKeyValidationError{
+				field:  "LastUsedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetRevokedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, APIKeyValidationError{
+					field:  "RevokedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, APIKeyValidationError{
+					field:  "RevokedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetRevokedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return APIKeyValidationError{
+				field:  "RevokedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for TenantId
+
+	if len(errors) > 0 {
+		return APIKeyMultiError(errors)
+	}
+
+	return nil
+}
+
+// APIKeyMultiError is an error wrapping multiple validation errors returned by
+// APIKey.ValidateAll() if the designated constraints aren't met.
+type APIKeyMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m APIKeyMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m APIKeyMultiError) AllErrors() []error { return m }
+
+// APIKeyValidationError is the validation error returned by APIKey.Validate if
+// the designated constraints aren't met.
+type APIKeyValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e APIKeyValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e APIKeyValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e APIKeyValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e APIKeyValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e APIKeyValidationError) ErrorName() string { return "APIKeyValidationError" }
+
+// Error satisfies the builtin error interface
+func (e APIKeyValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sAPIKey.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = APIKeyValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = APIKeyValidationError{}
+
+// Validate checks the field values on CreateAPIKeyRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateAPIKeyRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateAPIKeyRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateAPIKeyRequestMultiError, or nil if none found.
+func (m *CreateAPIKeyRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateAPIKeyRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetName()); l < 1 || l > 100 {
+		err := CreateAPIKeyRequestValidationError{
+			field:  "Name",
+			reason: "value length must be between 1 and 100 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if l := len(m.GetScopes()); l < 1 || l > 50 {
+		err := CreateAPIKeyRequestValidationError{
+			field:  "Scopes",
+			reason: "value must contain between 1 and 50 items, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	for idx, item := range m.GetScopes() {
+		_, _ = idx, item
+
+		if l := utf8.RuneCountInString(item); l < 1 || l > 64 {
+			err := CreateAPIKeyRequestValidationError{
+				field:  fmt.Sprintf("Scopes[%v]", idx),
+				reason: "value length must be between 1 and 64 runes, inclusive",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if all {
+		switch v := interface{}(m.GetExpiresAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, CreateAPIKeyRequestValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, CreateAPIKeyRequestValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetExpiresAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return CreateAPIKeyRequestValidationError{
+				field:  "ExpiresAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return CreateAPIKeyRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateAPIKeyRequestMultiError is an error wrapping multiple validation
+// errors returned by CreateAPIKeyRequest.ValidateAll() if the designated
+// constraints aren't met.
+type CreateAPIKeyRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateAPIKeyRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateAPIKeyRequestMultiError) AllErrors() []error { return m }
+
+// CreateAPIKeyRequestValidationError is the validation error returned by
+// CreateAPIKeyRequest.Validate if the designated constraints aren't met.
+type CreateAPIKeyRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateAPIKeyRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateAPIKeyRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateAPIKeyRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateAPIKeyRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateAPIKeyRequestValidationError) ErrorName() string {
+	return "CreateAPIKeyRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateAPIKeyRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateAPIKeyRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateAPIKeyRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateAPIKeyRequestValidationError{}
+
+// Validate checks the field values on CreateAPIKeyResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateAPIKeyResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateAPIKeyResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateAPIKeyResponseMultiError, or nil if none found.
+func (m *CreateAPIKeyResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateAPIKeyResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetApiKey()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, CreateAPIKeyResponseValidationError{
+					field:  "ApiKey",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, CreateAPIKeyResponseValidationError{
+					field:  "ApiKey",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetApiKey()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return CreateAPIKeyResponseValidationError{
+				field:  "ApiKey",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Key
+
+	if len(errors) > 0 {
+		return CreateAPIKeyResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateAPIKeyResponseMultiError is an error wrapping multiple validation
+// errors returned by CreateAPIKeyResponse.ValidateAll() if the designated
+// constraints aren't met.
+type CreateAPIKeyResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateAPIKeyResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateAPIKeyResponseMultiError) AllErrors() []error { return m }
+
+// CreateAPIKeyResponseValidationError is the validation error returned by
+// CreateAPIKeyResponse.Validate if the designated constraints aren't met.
+type CreateAPIKeyResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateAPIKeyResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateAPIKeyResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateAPIKeyResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateAPIKeyResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateAPIKeyResponseValidationError) ErrorName() string {
+	return "CreateAPIKeyResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateAPIKeyResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateAPIKeyResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateAPIKeyResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateAPIKeyResponseValidationError{}
+
+// Validate checks the field values on ListAPIKeysRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListAPIKeysRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListAPIKeysRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListAPIKeysRequestMultiError, or nil if none found.
+func (m *ListAPIKeysRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListAPIKeysRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(errors) > 0 {
+		return ListAPIKeysRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListAPIKeysRequestMultiError is an error wrapping multiple validation errors
+// returned by ListAPIKeysRequest.ValidateAll() if the designated constraints
+// aren't met.
+type ListAPIKeysRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListAPIKeysRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListAPIKeysRequestMultiError) AllErrors() []error { return m }
+
+// ListAPIKeysRequestValidationError is the validation error returned by
+// ListAPIKeysRequest.Validate if the designated constraints aren't met.
+type ListAPIKeysRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListAPIKeysRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListAPIKeysRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListAPIKeysRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListAPIKeysRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListAPIKeysRequestValidationError) ErrorName() string {
+	return "ListAPIKeysRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListAPIKeysRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListAPIKeysRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListAPIKeysRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListAPIKeysRequestValidationError{}
+
+// Validate checks the field values on ListAPIKeysResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListAPIKeysResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListAPIKeysResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListAPIKeysResponseMultiError, or nil if none found.
+func (m *ListAPIKeysResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListAPIKeysResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetApiKeys() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListAPIKeysResponseValidationError{
+						field:  fmt.Sprintf("ApiKeys[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListAPIKeysResponseValidationError{
+						field:  fmt.Sprintf("ApiKeys[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListAPIKeysResponseValidationError{
+					field:  fmt.Sprintf("ApiKeys[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return ListAPIKeysResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListAPIKeysResponseMultiError is an error wrapping multiple validation
+// errors returned by ListAPIKeysResponse.ValidateAll() if the designated
+// constraints aren't met.
+type ListAPIKeysResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListAPIKeysResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListAPIKeysResponseMultiError) AllErrors() []error { return m }
+
+// ListAPIKeysResponseValidationError is the validation error returned by
+// ListAPIKeysResponse.Validate if the designated constraints aren't met.
+type ListAPIKeysResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListAPIKeysResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListAPIKeysResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListAPIKeysResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListAPIKeysResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListAPIKeysResponseValidationError) ErrorName() string {
+	return "ListAPIKeysResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListAPIKeysResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListAPIKeysResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListAPIKeysResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListAPIKeysResponseValidationError{}
+
+// Validate checks the field values on RevokeAPIKeyRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *RevokeAPIKeyRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on RevokeAPIKeyRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// RevokeAPIKeyRequestMultiError, or nil if none found.
+func (m *RevokeAPIKeyRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *RevokeAPIKeyRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetKeyId()); l < 1 || l > 32 {
+		err := RevokeAPIKeyRequestValidationError{
+			field:  "KeyId",
+			reason: "value length must be between 1 and 32 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return RevokeAPIKeyRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// RevokeAPIKeyRequestMultiError is an error wrapping multiple validation
+// errors returned by RevokeAPIKeyRequest.ValidateAll() if the designated
+// constraints aren't met.
+type RevokeAPIKeyRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m RevokeAPIKeyRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m RevokeAPIKeyRequestMultiError) AllErrors() []error { return m }
+
+// RevokeAPIKeyRequestValidationError is the validation error returned by
+// RevokeAPIKeyRequest.Validate if the designated constraints aren't met.
+type RevokeAPIKeyRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e RevokeAPIKeyRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e RevokeAPIKeyRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e RevokeAPIKeyRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e RevokeAPIKeyRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e RevokeAPIKeyRequestValidationError) ErrorName() string {
+	return "RevokeAPIKeyRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e RevokeAPIKeyRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sRevokeAPIKeyRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = RevokeAPIKeyRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = RevokeAPIKeyRequestValidationError{}
+
+// Validate checks the field values on IntrospectRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *IntrospectRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on IntrospectRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// IntrospectRequestMultiError, or nil if none found.
+func (m *IntrospectRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *IntrospectRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetToken()); l < 1 || l > 4096 {
+		err := IntrospectRequestValidationError{
+			field:  "Token",
+			reason: "value length must be between 1 and 4096 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return IntrospectRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// IntrospectRequestMultiError is an error wrapping multiple validation errors
+// returned by IntrospectRequest.ValidateAll() if the designated constraints
+// aren't met.
+type IntrospectRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m IntrospectRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m IntrospectRequestMultiError) AllErrors() []error { return m }
+
+// IntrospectRequestValidationError is the validation error returned by
+// IntrospectRequest.Validate if the designated constraints aren't met.
+type IntrospectRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e IntrospectRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e IntrospectRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e IntrospectRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e IntrospectRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e IntrospectRequestValidationError) ErrorName() string {
+	return "IntrospectRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e IntrospectRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sIntrospectRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = IntrospectRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = IntrospectRequestValidationError{}
+
+// Validate checks the field values on IntrospectResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *IntrospectResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on IntrospectResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// IntrospectResponseMultiError, or nil if none found.
+func (m *IntrospectResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *IntrospectResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Active
+
+	// no validation rules for TokenType
+
+	// no validation rules for Sub
+
+	// no validation rules for Scope
+
+	// no validation rules for ClientId
+
+	// no validation rules for SessionId
+
+	if all {
+		switch v := interface{}(m.GetIssuedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, IntrospectResponseValidationError{
+					field:  "IssuedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, IntrospectResponseValidationError{
+					field:  "IssuedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetIssuedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return IntrospectResponseValidationError{
+				field:  "IssuedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetExpiresAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, IntrospectResponseValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, IntrospectResponseValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetExpiresAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return IntrospectResponseValidationError{
+				field:  "ExpiresAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for TenantId
+
+	if len(errors) > 0 {
+		return IntrospectResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// IntrospectResponseMultiError is an error wrapping multiple validation errors
+// returned by IntrospectResponse.ValidateAll() if the designated constraints
+// aren't met.
+type IntrospectResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m IntrospectResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m IntrospectResponseMultiError) AllErrors() []error { return m }
+
+// IntrospectResponseValidationError is the validation error returned by
+// IntrospectResponse.Validate if the designated constraints aren't met.
+type IntrospectResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e IntrospectResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e IntrospectResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e IntrospectResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e IntrospectResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e IntrospectResponseValidationError) ErrorName() string {
+	return "IntrospectResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e IntrospectResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sIntrospectResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = IntrospectResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = IntrospectResponseValidationError{}
+
+// Validate checks the field values on Organization with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Organization) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Organization with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in OrganizationMultiError, or
+// nil if none found.
+func (m *Organization) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Organization) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for OrganizationId
+
+	// no validation rules for Name
+
+	// no validation rules for TenantId
+
+	if all {
+		switch v := interface{}(m.GetCreatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, OrganizationValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, OrganizationValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetCreatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return OrganizationValidationError{
+				field:  "CreatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetUpdatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, OrganizationValidationError{
+					field:  "UpdatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, OrganizationValidationError{
+					field:  "UpdatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetUpdatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return OrganizationValidationError{
+				field:  "UpdatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return OrganizationMultiError(errors)
+	}
+
+	return nil
+}
+
+// OrganizationMultiError is an error wrapping multiple validation errors
+// returned by Organization.ValidateAll() if the designated constraints aren't met.
+type OrganizationMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m OrganizationMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m OrganizationMultiError) AllErrors() []error { return m }
+
+// OrganizationValidationError is the validation error returned by
+// Organization.Validate if the designated constraints aren't met.
+type OrganizationValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e OrganizationValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e OrganizationValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e OrganizationValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e OrganizationValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e OrganizationValidationError) ErrorName() string { return "OrganizationValidationError" }
+
+// Error satisfies the builtin error interface
+func (e OrganizationValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sOrganization.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = OrganizationValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = OrganizationValidationError{}
+
+// Validate checks the field values on CreateOrganizationRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateOrganizationRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateOrganizationRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateOrganizationRequestMultiError, or nil if none found.
+func (m *CreateOrganizationRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateOrganizationRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetName()); l < 1 || l > 100 {
+		err := CreateOrganizationRequestValidationError{
+			field:  "Name",
+			reason: "value length must be between 1 and 100 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetOwnerId() < 0 {
+		err := CreateOrganizationRequestValidationError{
+			field:  "OwnerId",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return CreateOrganizationRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateOrganizationRequestMultiError is an error wrapping multiple validation
+// errors returned by CreateOrganizationRequest.ValidateAll() if the
+// designated constraints aren't met.
+type CreateOrganizationRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateOrganizationRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateOrganizationRequestMultiError) AllErrors() []error { return m }
+
+// CreateOrganizationRequestValidationError is the validation error returned by
+// CreateOrganizationRequest.Validate if the designated constraints aren't met.
+type CreateOrganizationRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateOrganizationRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateOrganizationRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateOrganizationRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateOrganizationRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateOrganizationRequestValidationError) ErrorName() string {
+	return "CreateOrganizationRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateOrganizationRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateOrganizationRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateOrganizationRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateOrganizationRequestValidationError{}
+
+// Validate checks the field values on GetOrganizationRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetOrganizationRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetOrganizationRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetOrganizationRequestMultiError, or nil if none found.
+func (m *GetOrganizationRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetOrganizationRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetOrganizationId() <= 0 {
+		err := GetOrganizationRequestValidationError{
+			field:  "OrganizationId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return GetOrganizationRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetOrganizationRequestMultiError is an error wrapping multiple validation
+// errors returned by GetOrganizationRequest.ValidateAll() if the designated
+// constraints aren't met.
+type GetOrganizationRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetOrganizationRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetOrganizationRequestMultiError) AllErrors() []error { return m }
+
+// GetOrganizationRequestValidationError is the validation error returned by
+// GetOrganizationRequest.Validate if the designated constraints aren't met.
+type GetOrganizationRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetOrganizationRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetOrganizationRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetOrganizationRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetOrganizationRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetOrganizationRequestValidationError) ErrorName() string {
+	return "GetOrganizationRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetOrganizationRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetOrganizationRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetOrganizationRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetOrganizationRequestValidationError{}
+
+// Validate checks the field values on ListOrganizationsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListOrganizationsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListOrganizationsRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListOrganizationsRequestMultiError, or nil if none found.
+func (m *ListOrganizationsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListOrganizationsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := ListOrganizationsRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return ListOrganizationsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListOrganizationsRequestMultiError is an error wrapping multiple validation
+// errors returned by ListOrganizationsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type ListOrganizationsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListOrganizationsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListOrganizationsRequestMultiError) AllErrors() []error { return m }
+
+// ListOrganizationsRequestValidationError is the validation error returned by
+// ListOrganizationsRequest.Validate if the designated constraints aren't met.
+type ListOrganizationsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListOrganizationsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListOrganizationsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListOrganizationsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListOrganizationsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListOrganizationsRequestValidationError) ErrorName() string {
+	return "ListOrganizationsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListOrganizationsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListOrganizationsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListOrganizationsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListOrganizationsRequestValidationError{}
+
+// Validate checks the field values on UserOrganization with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *UserOrganization) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UserOrganization with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UserOrganizationMultiError, or nil if none found.
+func (m *UserOrganization) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UserOrganization) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetOrganization()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, UserOrganizationValidationError{
+					field:  "Organization",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, UserOrganizationValidationError{
+					field:  "Organization",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetOrganization()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return UserOrganizationValidationError{
+				field:  "Organization",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Role
+
+	if len(errors) > 0 {
+		return UserOrganizationMultiError(errors)
+	}
+
+	return nil
+}
+
+// UserOrganizationMultiError is an error wrapping multiple validation errors
+// returned by UserOrganization.ValidateAll() if the designated constraints
+// aren't met.
+type UserOrganizationMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UserOrganizationMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UserOrganizationMultiError) AllErrors() []error { return m }
+
+// UserOrganizationValidationError is the validation error returned by
+// UserOrganization.Validate if the designated constraints aren't met.
+type UserOrganizationValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UserOrganizationValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UserOrganizationValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UserOrganizationValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UserOrganizationValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UserOrganizationValidationError) ErrorName() string { return "UserOrganizationValidationError" }
+
+// Error satisfies the builtin error interface
+func (e UserOrganizationValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUserOrganization.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UserOrganizationValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UserOrganizationValidationError{}
+
+// Validate checks the field values on ListOrganizationsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListOrganizationsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListOrganizationsResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListOrganizationsResponseMultiError, or nil if none found.
+func (m *ListOrganizationsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListOrganizationsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetOrganizations() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListOrganizationsResponseValidationError{
+						field:  fmt.Sprintf("Organizations[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListOrganizationsResponseValidationError{
+						field:  fmt.Sprintf("Organizations[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListOrganizationsResponseValidationError{
+					field:  fmt.Sprintf("Organizations[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return ListOrganizationsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListOrganizationsResponseMultiError is an error wrapping multiple validation
+// errors returned by ListOrganizationsResponse.ValidateAll() if the
+// designated constraints aren't met.
+type ListOrganizationsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListOrganizationsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListOrganizationsResponseMultiError) AllErrors() []error { return m }
+
+// ListOrganizationsResponseValidationError is the validation error returned by
+// ListOrganizationsResponse.Validate if the designated constraints aren't met.
+type ListOrganizationsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListOrganizationsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListOrganizationsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListOrganizationsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListOrganizationsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListOrganizationsResponseValidationError) ErrorName() string {
+	return "ListOrganizationsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListOrganizationsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListOrganizationsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListOrganizationsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListOrganizationsResponseValidationError{}
+
+// Validate checks the field values on UpdateOrganizationRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *UpdateOrganizationRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UpdateOrganizationRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UpdateOrganizationRequestMultiError, or nil if none found.
+func (m *UpdateOrganizationRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UpdateOrganizationRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetOrganizationId() <= 0 {
+		err := UpdateOrganizationRequestValidationError{
+			field:  "OrganizationId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if l := utf8.RuneCountInString(m.GetName()); l < 1 || l > 100 {
+		err := UpdateOrganizationRequestValidationError{
+			field:  "Name",
+			reason: "value length must be between 1 and 100 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return UpdateOrganizationRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// UpdateOrganizationRequestMultiError is an error wrapping multiple validation
+// errors returned by UpdateOrganizationRequest.ValidateAll() if the
+// designated constraints aren't met.
+type UpdateOrganizationRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UpdateOrganizationRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UpdateOrganizationRequestMultiError) AllErrors() []error { return m }
+
+// UpdateOrganizationRequestValidationError is the validation error returned by
+// UpdateOrganizationRequest.Validate if the designated constraints aren't met.
+type UpdateOrganizationRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UpdateOrganizationRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UpdateOrganizationRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UpdateOrganizationRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UpdateOrganizationRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UpdateOrganizationRequestValidationError) ErrorName() string {
+	return "UpdateOrganizationRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e UpdateOrganizationRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUpdateOrganizationRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UpdateOrganizationRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UpdateOrganizationRequestValidationError{}
+
+// Validate checks the field values on DeleteOrganizationRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *DeleteOrganizationRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeleteOrganizationRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeleteOrganizationRequestMultiError, or nil if none found.
+func (m *DeleteOrganizationRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteOrganizationRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetOrganizationId() <= 0 {
+		err := DeleteOrganizationRequestValidationError{
+			field:  "OrganizationId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return DeleteOrganizationRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeleteOrganizationRequestMultiError is an error wrapping multiple validation
+// errors returned by DeleteOrganizationRequest.ValidateAll() if the
+// designated constraints aren't met.
+type DeleteOrganizationRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeleteOrganizationRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeleteOrganizationRequestMultiError) AllErrors() []error { return m }
+
+// DeleteOrganizationRequestValidationError is the validation error returned by
+// DeleteOrganizationRequest.Validate if the designated constraints aren't met.
+type DeleteOrganizationRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeleteOrganizationRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeleteOrganizationRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeleteOrganizationRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeleteOrganizationRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeleteOrganizationRequestValidationError) ErrorName() string {
+	return "DeleteOrganizationRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DeleteOrganizationRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeleteOrganizationRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeleteOrganizationRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeleteOrganizationRequestValidationError{}
+
+// Validate checks the field values on OrganizationMember with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *OrganizationMember) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on OrganizationMember with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// OrganizationMemberMultiError, or nil if none found.
+func (m *OrganizationMember) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *OrganizationMember) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for UserId
+
+	// no validation rules for Email
+
+	// no validation rules for DisplayName
+
+	// no validation rules for Role
+
+	if all {
+		switch v := interface{}(m.GetJoinedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, OrganizationMemberValidationError{
+					field:  "JoinedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, OrganizationMemberValidationError{
+					field:  "JoinedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetJoinedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return OrganizationMemberValidationError{
+				field:  "JoinedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return OrganizationMemberMultiError(errors)
+	}
+
+	return nil
+}
+
+// OrganizationMemberMultiError is an error wrapping multiple validation errors
+// returned by OrganizationMember.ValidateAll() if the designated constraints
+// aren't met.
+type OrganizationMemberMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m OrganizationMemberMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m OrganizationMemberMultiError) AllErrors() []error { return m }
+
+// OrganizationMemberValidationError is the validation error returned by
+// OrganizationMember.Validate if the designated constraints aren't met.
+type OrganizationMemberValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e OrganizationMemberValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e OrganizationMemberValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e OrganizationMemberValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e OrganizationMemberValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e OrganizationMemberValidationError) ErrorName() string {
+	return "OrganizationMemberValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e OrganizationMemberValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sOrganizationMember.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = OrganizationMemberValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = OrganizationMemberValidationError{}
+
+// Validate checks the field values on ListOrganizationMembersRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListOrganizationMembersRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListOrganizationMembersRequest with
+// the rules defined in the proto definition for this message. If any rules
+// are violated, the result is a list of violation errors wrapped in
+// ListOrganizationMembersRequestMultiError, or nil if none found.
+func (m *ListOrganizationMembersRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListOrganizationMembersRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetOrganizationId() <= 0 {
+		err := ListOrganizationMembersRequestValidationError{
+			field:  "OrganizationId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return ListOrganizationMembersRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListOrganizationMembersRequestMultiError is an error wrapping multiple
+// validation errors returned by ListOrganizationMembersRequest.ValidateAll()
+// if the designated constraints aren't met.
+type ListOrganizationMembersRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListOrganizationMembersRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListOrganizationMembersRequestMultiError) AllErrors() []error { return m }
+
+// ListOrganizationMembersRequestValidationError is the validation error
+// returned by ListOrganizationMembersRequest.Validate if the designated
+// constraints aren't met.
+type ListOrganizationMembersRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListOrganizationMembersRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListOrganizationMembersRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListOrganizationMembersRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListOrganizationMembersRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListOrganizationMembersRequestValidationError) ErrorName() string {
+	return "ListOrganizationMembersRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListOrganizationMembersRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListOrganizationMembersRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListOrganizationMembersRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListOrganizationMembersRequestValidationError{}
+
+// Validate checks the field values on ListOrganizationMembersResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListOrganizationMembersResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListOrganizationMembersResponse with
+// the rules defined in the proto definition for this message. If any rules
+// are violated, the result is a list of violation errors wrapped in
+// ListOrganizationMembersResponseMultiError, or nil if none found.
+func (m *ListOrganizationMembersResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListOrganizationMembersResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetMembers() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListOrganizationMembersResponseValidationError{
+						field:  fmt.Sprintf("Members[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListOrganizationMembersResponseValidationError{
+						field:  fmt.Sprintf("Members[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListOrganizationMembersResponseValidationError{
+					field:  fmt.Sprintf("Members[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return ListOrganizationMembersResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListOrganizationMembersResponseMultiError is an error wrapping multiple
+// validation errors returned by ListOrganizationMembersResponse.ValidateAll()
+// if the designated constraints aren't met.
+type ListOrganizationMembersResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListOrganizationMembersResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListOrganizationMembersResponseMultiError) AllErrors() []error { return m }
+
+// ListOrganizationMembersResponseValidationError is the validation error
+// returned by ListOrganizationMembersResponse.Validate if the designated
+// constraints aren't met.
+type ListOrganizationMembersResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListOrganizationMembersResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListOrganizationMembersResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListOrganizationMembersResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListOrganizationMembersResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListOrganizationMembersResponseValidationError) ErrorName() string {
+	return "ListOrganizationMembersResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListOrganizationMembersResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListOrganizationMembersResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListOrganizationMembersResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListOrganizationMembersResponseValidationError{}
+
+// Validate checks the field values on UpdateOrganizationMemberRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *UpdateOrganizationMemberRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UpdateOrganizationMemberRequest with
+// the rules defined in the proto definition for this message. If any rules
+// are violated, the result is a list of violation errors wrapped in
+// UpdateOrganizationMemberRequestMultiError, or nil if none found.
+func (m *UpdateOrganizationMemberRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UpdateOrganizationMemberRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetOrganizationId() <= 0 {
+		err := UpdateOrganizationMemberRequestValidationError{
+			field:  "OrganizationId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetUserId() <= 0 {
+		err := UpdateOrganizationMemberRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _UpdateOrganizationMemberRequest_Role_InLookup[m.GetRole()]; !ok {
+		err := UpdateOrganizationMemberRequestValidationError{
+			field:  "Role",
+			reason: "value must be in list [owner admin member]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return UpdateOrganizationMemberRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// UpdateOrganizationMemberRequestMultiError is an error wrapping multiple
+// validation errors returned by UpdateOrganizationMemberRequest.ValidateAll()
+// if the designated constraints aren't met.
+type UpdateOrganizationMemberRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UpdateOrganizationMemberRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UpdateOrganizationMemberRequestMultiError) AllErrors() []error { return m }
+
+// UpdateOrganizationMemberRequestValidationError is the validation error
+// returned by UpdateOrganizationMemberRequest.Validate if the designated
+// constraints aren't met.
+type UpdateOrganizationMemberRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UpdateOrganizationMemberRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UpdateOrganizationMemberRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UpdateOrganizationMemberRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UpdateOrganizationMemberRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UpdateOrganizationMemberRequestValidationError) ErrorName() string {
+	return "UpdateOrganizationMemberRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e UpdateOrganizationMemberRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUpdateOrganizationMemberRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UpdateOrganizationMemberRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UpdateOrganizationMemberRequestValidationError{}
+
+var _UpdateOrganizationMemberRequest_Role_InLookup = map[string]struct{}{
+	"owner":  {},
+	"admin":  {},
+	"member": {},
+}
+
+// Validate checks the field values on RemoveOrganizationMemberRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *RemoveOrganizationMemberRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on RemoveOrganizationMemberRequest with
+// the rules defined in the proto definition for this message. If any rules
+// are violated, the result is a list of violation errors wrapped in
+// RemoveOrganizationMemberRequestMultiError, or nil if none found.
+func (m *RemoveOrganizationMemberRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *RemoveOrganizationMemberRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetOrganizationId() <= 0 {
+		err := RemoveOrganizationMemberRequestValidationError{
+			field:  "OrganizationId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetUserId() <= 0 {
+		err := RemoveOrganizationMemberRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return RemoveOrganizationMemberRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// RemoveOrganizationMemberRequestMultiError is an error wrapping multiple
+// validation errors returned by RemoveOrganizationMemberRequest.ValidateAll()
+// if the designated constraints aren't met.
+type RemoveOrganizationMemberRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m RemoveOrganizationMemberRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m RemoveOrganizationMemberRequestMultiError) AllErrors() []error { return m }
+
+// RemoveOrganizationMemberRequestValidationError is the validation error
+// returned by RemoveOrganizationMemberRequest.Validate if the designated
+// constraints aren't met.
+type RemoveOrganizationMemberRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e RemoveOrganizationMemberRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e RemoveOrganizationMemberRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e RemoveOrganizationMemberRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e RemoveOrganizationMemberRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e RemoveOrganizationMemberRequestValidationError) ErrorName() string {
+	return "RemoveOrganizationMemberRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e RemoveOrganizationMemberRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sRemoveOrganizationMemberRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = RemoveOrganizationMemberRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = RemoveOrganizationMemberRequestValidationError{}
+
+// Validate checks the field values on Invitation with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Invitation) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Invitation with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in InvitationMultiError, or
+// nil if none found.
+func (m *Invitation) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Invitation) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for InvitationId
+
+	// no validation rules for OrganizationId
+
+	// no validation rules for Email
+
+	// no validation rules for Role
+
+	// no validation rules for InvitedBy
+
+	if all {
+		switch v := interface{}(m.GetCreatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, InvitationValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, InvitationValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetCreatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return InvitationValidationError{
+				field:  "CreatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetExpiresAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, InvitationValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, InvitationValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetExpiresAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return InvitationValidationError{
+				field:  "ExpiresAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return InvitationMultiError(errors)
+	}
+
+	return nil
+}
+
+// InvitationMultiError is an error wrapping multiple validation errors
+// returned by Invitation.ValidateAll() if the designated constraints aren't met.
+type InvitationMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m InvitationMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m InvitationMultiError) AllErrors() []error { return m }
+
+// InvitationValidationError is the validation error returned by
+// Invitation.Validate if the designated constraints aren't met.
+type InvitationValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e InvitationValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e InvitationValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e InvitationValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e InvitationValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e InvitationValidationError) ErrorName() string { return "InvitationValidationError" }
+
+// Error satisfies the builtin error interface
+func (e InvitationValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sInvitation.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = InvitationValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = InvitationValidationError{}
+
+// Validate checks the field values on CreateInvitationRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateInvitationRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateInvitationRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateInvitationRequestMultiError, or nil if none found.
+func (m *CreateInvitationRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateInvitationRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetOrganizationId() <= 0 {
+		err := CreateInvitationRequestValidationError{
+			field:  "OrganizationId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetEmail()) > 254 {
+		err := CreateInvitationRequestValidationError{
+			field:  "Email",
+			reason: "value length must be at most 254 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if err := m._validateEmail(m.GetEmail()); err != nil {
+		err = CreateInvitationRequestValidationError{
+			field:  "Email",
+			reason: "value must be a valid email address",
+			cause:  err,
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _CreateInvitationRequest_Role_InLookup[m.GetRole()]; !ok {
+		err := CreateInvitationRequestValidationError{
+			field:  "Role",
+			reason: "value must be in list [admin member]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return CreateInvitationRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+func (m *CreateInvitationRequest) _validateHostname(host string) error {
+	s := strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if len(host) > 253 {
+		return errors.New("hostname cannot exceed 253 characters")
+	}
+
+	for _, part := range strings.Split(s, ".") {
+		if l := len(part); l == 0 || l > 63 {
+			return errors.New("hostname part must be non-empty and cannot exceed 63 characters")
+		}
+
+		if part[0] == '-' {
+			return errors.New("hostname parts cannot begin with hyphens")
+		}
+
+		if part[len(part)-1] == '-' {
+			return errors.New("hostname parts cannot end with hyphens")
+		}
+
+		for _, r := range part {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return fmt.Errorf("hostname parts can only contain alphanumeric characters or hyphens, got %q", string(r))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateInvitationRequest) _validateEmail(addr string) error {
+	a, err := mail.ParseAddress(addr)
+	if err != nil {
+		return err
+	}
+	addr = a.Address
+
+	if len(addr) > 254 {
+		return errors.New("email addresses cannot exceed 254 characters")
+	}
+
+	parts := strings.SplitN(addr, "@", 2)
+
+	if len(parts[0]) > 64 {
+		return errors.New("email address local phrase cannot exceed 64 characters")
+	}
+
+	return m._validateHostname(parts[1])
+}
+
+// CreateInvitationRequestMultiError is an error wrapping multiple validation
+// errors returned by CreateInvitationRequest.ValidateAll() if the designated
+// constraints aren't met.
+type CreateInvitationRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateInvitationRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateInvitationRequestMultiError) AllErrors() []error { return m }
+
+// CreateInvitationRequestValidationError is the validation error returned by
+// CreateInvitationRequest.Validate if the designated constraints aren't met.
+type CreateInvitationRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateInvitationRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateInvitationRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateInvitationRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateInvitationRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateInvitationRequestValidationError) ErrorName() string {
+	return "CreateInvitationRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateInvitationRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateInvitationRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateInvitationRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateInvitationRequestValidationError{}
+
+var _CreateInvitationRequest_Role_InLookup = map[string]struct{}{
+	"admin":  {},
+	"member": {},
+}
+
+// Validate checks the field values on ListInvitationsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListInvitationsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListInvitationsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListInvitationsRequestMultiError, or nil if none found.
+func (m *ListInvitationsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListInvitationsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetOrganizationId() <= 0 {
+		err := ListInvitationsRequestValidationError{
+			field:  "OrganizationId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return ListInvitationsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListInvitationsRequestMultiError is an error wrapping multiple validation
+// errors returned by ListInvitationsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type ListInvitationsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListInvitationsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListInvitationsRequestMultiError) AllErrors() []error { return m }
+
+// ListInvitationsRequestValidationError is the validation error returned by
+// ListInvitationsRequest.Validate if the designated constraints aren't met.
+type ListInvitationsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListInvitationsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListInvitationsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListInvitationsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListInvitationsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListInvitationsRequestValidationError) ErrorName() string {
+	return "ListInvitationsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListInvitationsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListInvitationsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListInvitationsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListInvitationsRequestValidationError{}
+
+// Validate checks the field values on ListInvitationsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListInvitationsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListInvitationsResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListInvitationsResponseMultiError, or nil if none found.
+func (m *ListInvitationsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListInvitationsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetInvitations() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListInvitationsResponseValidationError{
+						field:  fmt.Sprintf("Invitations[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListInvitationsResponseValidationError{
+						field:  fmt.Sprintf("Invitations[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListInvitationsResponseValidationError{
+					field:  fmt.Sprintf("Invitations[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return ListInvitationsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListInvitationsResponseMultiError is an error wrapping multiple validation
+// errors returned by ListInvitationsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type ListInvitationsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListInvitationsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListInvitationsResponseMultiError) AllErrors() []error { return m }
+
+// ListInvitationsResponseValidationError is the validation error returned by
+// ListInvitationsResponse.Validate if the designated constraints aren't met.
+type ListInvitationsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListInvitationsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListInvitationsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListInvitationsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListInvitationsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListInvitationsResponseValidationError) ErrorName() string {
+	return "ListInvitationsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListInvitationsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListInvitationsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListInvitationsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListInvitationsResponseValidationError{}
+
+// Validate checks the field values on RevokeInvitationRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *RevokeInvitationRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on RevokeInvitationRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// RevokeInvitationRequestMultiError, or nil if none found.
+func (m *RevokeInvitationRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *RevokeInvitationRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetOrganizationId() <= 0 {
+		err := RevokeInvitationRequestValidationError{
+			field:  "OrganizationId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetInvitationId() <= 0 {
+		err := RevokeInvitationRequestValidationError{
+			field:  "InvitationId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return RevokeInvitationRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// RevokeInvitationRequestMultiError is an error wrapping multiple validation
+// errors returned by RevokeInvitationRequest.ValidateAll() if the designated
+// constraints aren't met.
+type RevokeInvitationRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m RevokeInvitationRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m RevokeInvitationRequestMultiError) AllErrors() []error { return m }
+
+// RevokeInvitationRequestValidationError is the validation error returned by
+// RevokeInvitationRequest.Validate if the designated constraints aren't met.
+type RevokeInvitationRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e RevokeInvitationRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e RevokeInvitationRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e RevokeInvitationRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e RevokeInvitationRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e RevokeInvitationRequestValidationError) ErrorName() string {
+	return "RevokeInvitationRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e RevokeInvitationRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sRevokeInvitationRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = RevokeInvitationRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = RevokeInvitationRequestValidationError{}
+
+// Validate checks the field values on AcceptInvitationRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *AcceptInvitationRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on AcceptInvitationRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// AcceptInvitationRequestMultiError, or nil if none found.
+func (m *AcceptInvitationRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *AcceptInvitationRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetToken()); l < 1 || l > 128 {
+		err := AcceptInvitationRequestValidationError{
+			field:  "Token",
+			reason: "value length must be between 1 and 128 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetPassword() != "" {
+
+		if l := utf8.RuneCountInString(m.GetPassword()); l < 8 || l > 50 {
+			err := AcceptInvitationRequestValidationError{
+				field:  "Password",
+				reason: "value length must be between 8 and 50 runes, inclusive",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return AcceptInvitationRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// AcceptInvitationRequestMultiError is an error wrapping multiple validation
+// errors returned by AcceptInvitationRequest.ValidateAll() if the designated
+// constraints aren't met.
+type AcceptInvitationRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m AcceptInvitationRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m AcceptInvitationRequestMultiError) AllErrors() []error { return m }
+
+// AcceptInvitationRequestValidationError is the validation error returned by
+// AcceptInvitationRequest.Validate if the designated constraints aren't met.
+type AcceptInvitationRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e AcceptInvitationRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e AcceptInvitationRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e AcceptInvitationRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e AcceptInvitationRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e AcceptInvitationRequestValidationError) ErrorName() string {
+	return "AcceptInvitationRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e AcceptInvitationRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sAcceptInvitationRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = AcceptInvitationRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = AcceptInvitationRequestValidationError{}
+
+// Validate checks the field values on AcceptInvitationResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *AcceptInvitationResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on AcceptInvitationResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// AcceptInvitationResponseMultiError, or nil if none found.
+func (m *AcceptInvitationResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *AcceptInvitationResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for OrganizationId
+
+	// no validation rules for UserId
+
+	// no validation rules for Created
+
+	if len(errors) > 0 {
+		return AcceptInvitationResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// AcceptInvitationResponseMultiError is an error wrapping multiple validation
+// errors returned by AcceptInvitationResponse.ValidateAll() if the designated
+// constraints aren't met.
+type AcceptInvitationResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m AcceptInvitationResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m AcceptInvitationResponseMultiError) AllErrors() []error { return m }
+
+// AcceptInvitationResponseValidationError is the validation error returned by
+// AcceptInvitationResponse.Validate if the designated constraints aren't met.
+type AcceptInvitationResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e AcceptInvitationResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e AcceptInvitationResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e AcceptInvitationResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e AcceptInvitationResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e AcceptInvitationResponseValidationError) ErrorName() string {
+	return "AcceptInvitationResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e AcceptInvitationResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sAcceptInvitationResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = AcceptInvitationResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = AcceptInvitationResponseValidationError{}
+
+// Validate checks the field values on DeclineInvitationRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *DeclineInvitationRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeclineInvitationRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeclineInvitationRequestMultiError, or nil if none found.
+func (m *DeclineInvitationRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeclineInvitationRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := utf8.RuneCountInString(m.GetToken()); l < 1 || l > 128 {
+		err := DeclineInvitationRequestValidationError{
+			field:  "Token",
+			reason: "value length must be between 1 and 128 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return DeclineInvitationRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeclineInvitationRequestMultiError is an error wrapping multiple validation
+// errors returned by DeclineInvitationRequest.ValidateAll() if the designated
+// constraints aren't met.
+type DeclineInvitationRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeclineInvitationRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeclineInvitationRequestMultiError) AllErrors() []error { return m }
+
+// DeclineInvitationRequestValidationError is the validation error returned by
+// DeclineInvitationRequest.Validate if the designated constraints aren't met.
+type DeclineInvitationRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeclineInvitationRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeclineInvitationRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeclineInvitationRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeclineInvitationRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeclineInvitationRequestValidationError) ErrorName() string {
+	return "DeclineInvitationRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DeclineInvitationRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeclineInvitationRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeclineInvitationRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeclineInvitationRequestValidationError{}
+
+// Validate checks the field values on ExportUserDataRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ExportUserDataRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ExportUserDataRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ExportUserDataRequestMultiError, or nil if none found.
+func (m *ExportUserDataRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ExportUserDataRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := ExportUserDataRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return ExportUserDataRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ExportUserDataRequestMultiError is an error wrapping multiple validation
+// errors returned by ExportUserDataRequest.ValidateAll() if the designated
+// constraints aren't met.
+type ExportUserDataRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ExportUserDataRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ExportUserDataRequestMultiError) AllErrors() []error { return m }
+
+// ExportUserDataRequestValidationError is the validation error returned by
+// ExportUserDataRequest.Validate if the designated constraints aren't met.
+type ExportUserDataRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ExportUserDataRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ExportUserDataRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ExportUserDataRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ExportUserDataRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ExportUserDataRequestValidationError) ErrorName() string {
+	return "ExportUserDataRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ExportUserDataRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sExportUserDataRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ExportUserDataRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ExportUserDataRequestValidationError{}
+
+// Validate checks the field values on EraseUserRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *EraseUserRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on EraseUserRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// EraseUserRequestMultiError, or nil if none found.
+func (m *EraseUserRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *EraseUserRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() <= 0 {
+		err := EraseUserRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetReason()) > 500 {
+		err := EraseUserRequestValidationError{
+			field:  "Reason",
+			reason: "value length must be at most 500 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return EraseUserRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// EraseUserRequestMultiError is an error wrapping multiple validation errors
+// returned by EraseUserRequest.ValidateAll() if the designated constraints
+// aren't met.
+type EraseUserRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m EraseUserRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m EraseUserRequestMultiError) AllErrors() []error { return m }
+
+// EraseUserRequestValidationError is the validation error returned by
+// EraseUserRequest.Validate if the designated constraints aren't met.
+type EraseUserRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e EraseUserRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e EraseUserRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e EraseUserRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e EraseUserRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e EraseUserRequestValidationError) ErrorName() string { return "EraseUserRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e EraseUserRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sEraseUserRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = EraseUserRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = EraseUserRequestValidationError{}
+
+// Validate checks the field values on EraseUserResponse with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *EraseUserResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on EraseUserResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// EraseUserResponseMultiError, or nil if none found.
+func (m *EraseUserResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *EraseUserResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ErasureId
+
+	if all {
+		switch v := interface{}(m.GetErasedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, EraseUserResponseValidationError{
+					field:  "ErasedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, EraseUserResponseValidationError{
+					field:  "ErasedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetErasedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return EraseUserResponseValidationError{
+				field:  "ErasedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Summary
+
+	if len(errors) > 0 {
+		return EraseUserResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// EraseUserResponseMultiError is an error wrapping multiple validation errors
+// returned by EraseUserResponse.ValidateAll() if the designated constraints
+// aren't met.
+type EraseUserResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m EraseUserResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m EraseUserResponseMultiError) AllErrors() []error { return m }
+
+// EraseUserResponseValidationError is the validation error returned by
+// EraseUserResponse.Validate if the designated constraints aren't met.
+type EraseUserResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e EraseUserResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e EraseUserResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e EraseUserResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e EraseUserResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e EraseUserResponseValidationError) ErrorName() string {
+	return "EraseUserResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e EraseUserResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sEraseUserResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = EraseUserResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = EraseUserResponseValidationError{}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+)
+
+// Limit — параметры token bucket: Rate токенов в секунду, не больше Burst.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func PerPeriod(requests int, per time.Duration, burst int) Limit {
+	if burst <= 0 {
+		burst = requests
+	}
+	return Limit{Rate: float64(requests) / per.Seconds(), Burst: burst}
+}
+
+// retryAfter — время до появления целого токена.
+func retryAfter(tokens float64, limit Limit) time.Duration {
+	return time.Duration(math.Ceil((1-tokens)/limit.Rate*1000)) * time.Millisecond
+}
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Fallback использует primary (Redis), а при его недоступности —
+// локальный fallback, чтобы сбой Redis не отключал защиту полностью.
+// После ошибки primary не опрашивается retryPrimary, чтобы каждый запрос
+// не ждал таймаута соединения.
+type Fallback struct {
+	primary, fallback Limiter
+	downUntil         atomic.Int64
+}
+
+const retryPrimary = 5 * time.Second
+
+func NewFallback(primary, fallback Limiter) *Fallback {
+	return &Fallback{primary: primary, fallback: fallback}
+}
+
+func (f *Fallback) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+	if now.UnixNano() < f.downUntil.Load() {
+		return f.fallback.Allow(ctx, key, limit)
+	}
+
+	res, err := f.primary.Allow(ctx, key, limit)
+	if err == nil {
+		return res, nil
+	}
+
+	// логируем только переход в деградированный режим, а не каждый запрос
+	if prev := f.downUntil.Swap(now.Add(retryPrimary).UnixNano()); prev < now.UnixNano() {
+		logger.Warnf("RateLimit: primary limiter failed, using in-memory fallback: %v", err)
+	}
+	return f.fallback.Allow(ctx, key, limit)
+}
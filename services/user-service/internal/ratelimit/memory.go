@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	ts     time.Time
+	// full — момент, когда корзина снова заполнится и её можно забыть
+	full time.Time
+}
+
+// Memory — лимитер в памяти процесса: считает только запросы этой реплики.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (m *Memory) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), ts: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.ts).Seconds()*limit.Rate)
+	b.ts = now
+
+	res := Result{Remaining: 0}
+	if b.tokens < 1 {
+		res.RetryAfter = retryAfter(b.tokens, limit)
+	} else {
+		b.tokens--
+		res = Result{Allowed: true, Remaining: int(b.tokens)}
+	}
+	b.full = now.Add(time.Duration((float64(limit.Burst) - b.tokens) / limit.Rate * float64(time.Second)))
+
+	return res, nil
+}
+
+// Cleanup удаляет заполнившиеся корзины, чтобы map не росла бесконечно:
+// полная корзина ничем не отличается от отсутствующей.
+// Возвращается при отмене ctx.
+func (m *Memory) Cleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.Lock()
+		now := m.now()
+		for key, b := range m.buckets {
+			if !now.Before(b.full) {
+				delete(m.buckets, key)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
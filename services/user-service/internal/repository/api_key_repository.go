@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"github.com/lib/pq"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	// Get ищет ключ во всех тенантах: по нему ключ аутентифицируется, и
+	// тенант запроса берётся из самого ключа.
+	Get(ctx context.Context, id string) (*model.APIKey, error)
+	// List и Revoke работают в тенанте из контекста (tenant.FromContext):
+	// ключи других тенантов для них не существуют.
+	List(ctx context.Context) ([]model.APIKey, error)
+	// Revoke отзывает ключ; повторный отзыв не меняет revoked_at.
+	Revoke(ctx context.Context, id string) error
+	// Touch отмечает использование ключа, если прошлое было раньше
+	// чем granularity назад: иначе каждый запрос писал бы в БД.
+	Touch(ctx context.Context, id string, granularity time.Duration) error
+}
+
+type postgresAPIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &postgresAPIKeyRepository{db: db}
+}
+
+func (r *postgresAPIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	query := `INSERT INTO api_keys (id, tenant_id, name, secret_hash, scopes, created_by, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, query, key.ID, key.TenantID, key.Name, key.SecretHash, pq.Array(key.Scopes),
+		key.CreatedBy, key.ExpiresAt).Scan(&key.CreatedAt)
+	if err != nil {
+		logger.Errorf("Repository: Error creating API key %s: %v", key.ID, err)
+		return err
+	}
+	return nil
+}
+
+const apiKeyColumns = `id, tenant_id, name, secret_hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at`
+
+func scanAPIKey(row interface{ Scan(...any) error }, k *model.APIKey) error {
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&k.ID, &k.TenantID, &k.Name, &k.SecretHash, pq.Array(&k.Scopes), &k.CreatedBy, &k.CreatedAt,
+		&expiresAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return err
+	}
+	if expiresAt.Valid {
+		k.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return nil
+}
+
+func (r *postgresAPIKeyRepository) Get(ctx context.Context, id string) (*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+
+	var k model.APIKey
+	err := scanAPIKey(r.db.QueryRowContext(ctx, query, id), &k)
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		logger.Errorf("Repository: Error getting API key %s: %v", id, err)
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *postgresAPIKeyRepository) List(ctx context.Context) ([]model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE tenant_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.FromContext(ctx))
+	if err != nil {
+		logger.Errorf("Repository: Error listing API keys: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		var k model.APIKey
+		if err := scanAPIKey(rows, &k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *postgresAPIKeyRepository) Revoke(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now())
+	WHERE id = $1 AND tenant_id = $2`, id, tenant.FromContext(ctx))
+	if err != nil {
+		logger.Errorf("Repository: Error revoking API key %s: %v", id, err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *postgresAPIKeyRepository) Touch(ctx context.Context, id string, granularity time.Duration) error {
+	query := `UPDATE api_keys SET last_used_at = now()
+	WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - make_interval(secs => $2))`
+
+	if _, err := r.db.ExecContext(ctx, query, id, granularity.Seconds()); err != nil {
+		logger.Errorf("Repository: Error touching API key %s: %v", id, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+)
+
+// EmailPlan получает правила, с которыми нормализованы сохранённые email
+// (false, если сервис их ещё не записывал), и все email из users;
+// возвращает адреса, которые нужно изменить.
+type EmailPlan func(providerRules bool, emails []model.UserEmail) ([]model.UserEmail, error)
+
+type EmailNormalizationRepository interface {
+	// Apply, если сохранённые правила отличаются от providerRules, в одной
+	// транзакции пересчитывает email по plan и записывает новые правила.
+	// Ошибка plan ничего не меняет. Реплики, стартующие одновременно,
+	// выполняют пересчёт по очереди.
+	Apply(ctx context.Context, providerRules bool, plan EmailPlan) error
+}
+
+type postgresEmailNormalizationRepository struct {
+	db *sql.DB
+}
+
+func NewEmailNormalizationRepository(db *sql.DB) EmailNormalizationRepository {
+	return &postgresEmailNormalizationRepository{db: db}
+}
+
+func (r *postgresEmailNormalizationRepository) Apply(ctx context.Context, providerRules bool, plan EmailPlan) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE email_normalization IN EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	var applied bool
+	err = tx.QueryRowContext(ctx, `SELECT provider_rules FROM email_normalization`).Scan(&applied)
+	switch {
+	case err == nil && applied == providerRules:
+		return nil
+	case err != nil && err != sql.ErrNoRows:
+		return err
+	}
+
+	// новые пользователи не должны появиться между чтением и записью
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT id, tenant_id, email FROM users ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	var emails []model.UserEmail
+	for rows.Next() {
+		var e model.UserEmail
+		if err := rows.Scan(&e.ID, &e.TenantID, &e.Email); err != nil {
+			rows.Close()
+			return err
+		}
+		emails = append(emails, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	changes, err := plan(applied, emails)
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET email = $1, updated_at = now() WHERE id = $2`, c.Email, c.ID); err != nil {
+			logger.Errorf("Repository: Error renormalizing email of user %d: %v", c.ID, err)
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO email_normalization (id, provider_rules) VALUES (TRUE, $1)
+	ON CONFLICT (id) DO UPDATE SET provider_rules = EXCLUDED.provider_rules, applied_at = now()`, providerRules)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	logger.Infof("Repository: Email provider rules set to %v, %d of %d emails renormalized", providerRules, len(changes), len(emails))
+	return nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+)
+
+// ErasureRepository удаляет персональные данные пользователя из БД.
+type ErasureRepository interface {
+	// Erase в одной транзакции обезличивает события входа пользователя,
+	// удаляет приглашения на его email, организации, где он единственный
+	// участник, и его самого (TOTP, коды восстановления, внешние учётные
+	// записи и участие в организациях удаляются каскадом), затем
+	// сохраняет rec с итогами. ErrLastOwner — пользователь последний
+	// владелец организации, в которой есть другие участники.
+	Erase(ctx context.Context, user *model.User, rec *model.Erasure) error
+}
+
+type postgresErasureRepository struct {
+	db *sql.DB
+}
+
+func NewErasureRepository(db *sql.DB) ErasureRepository {
+	return &postgresErasureRepository{db: db}
+}
+
+func (r *postgresErasureRepository) Erase(ctx context.Context, user *model.User, rec *model.Erasure) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tenantID := tenant.FromContext(ctx)
+
+	// организация без владельца осталась бы без управления; права нужно
+	// передать до удаления
+	var orgID int64
+	err = tx.QueryRowContext(ctx, `SELECT m.organization_id FROM organization_members m
+	WHERE m.user_id = $1 AND m.role = 'owner'
+		AND NOT EXISTS (SELECT 1 FROM organization_members o
+			WHERE o.organization_id = m.organization_id AND o.role = 'owner' AND o.user_id <> $1)
+		AND EXISTS (SELECT 1 FROM organization_members o
+			WHERE o.organization_id = m.organization_id AND o.user_id <> $1)
+	LIMIT 1`, user.ID).Scan(&orgID)
+	if err == nil {
+		logger.Warnf("Repository: User %d is the last owner of organization %d", user.ID, orgID)
+		return ErrLastOwner
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	steps := []struct {
+		name  string
+		query string
+		args  []any
+	}{
+		// неудачные попытки входа с неизвестным тогда email тоже его содержат;
+		// тот же email в других тенантах — другие люди, их события не трогаются
+		{"login_events", `UPDATE login_events SET user_id = NULL, email = '', ip = '', user_agent = ''
+		WHERE user_id = $1 OR (user_id IS NULL AND tenant_id = $2 AND lower(email) = lower($3))`,
+			[]any{user.ID, tenantID, user.Email}},
+		{"organization_invitations", `DELETE FROM organization_invitations i USING organizations o
+		WHERE o.id = i.organization_id AND o.tenant_id = $1 AND lower(i.email) = lower($2)`, []any{tenantID, user.Email}},
+		{"organizations", `DELETE FROM organizations o
+		WHERE o.tenant_id = $1
+			AND EXISTS (SELECT 1 FROM organization_members m WHERE m.organization_id = o.id AND m.user_id = $2)
+			AND NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.organization_id = o.id AND m.user_id <> $2)`,
+			[]any{tenantID, user.ID}},
+		{"organization_members", `DELETE FROM organization_members WHERE user_id = $1`, []any{user.ID}},
+		{"federated_identities", `DELETE FROM federated_identities WHERE user_id = $1`, []any{user.ID}},
+		{"user_recovery_codes", `DELETE FROM user_recovery_codes WHERE user_id = $1`, []any{user.ID}},
+		{"user_totp", `DELETE FROM user_totp WHERE user_id = $1`, []any{user.ID}},
+		{"users", `DELETE FROM users WHERE tenant_id = $1 AND id = $2`, []any{tenantID, user.ID}},
+	}
+	rec.Summary = make(map[string]int64, len(steps))
+	for _, step := range steps {
+		res, err := tx.ExecContext(ctx, step.query, step.args...)
+		if err != nil {
+			logger.Errorf("Repository: Error erasing %s of user %d: %v", step.name, user.ID, err)
+			return err
+		}
+		n, _ := res.RowsAffected()
+		rec.Summary[step.name] = n
+	}
+	if rec.Summary["users"] == 0 {
+		return ErrNotFoundUser
+	}
+
+	summary, err := json.Marshal(rec.Summary)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO user_erasures (tenant_id, user_id, email_hash, requested_by, reason, summary)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, created_at`
+	err = tx.QueryRowContext(ctx, query, tenantID, user.ID, rec.EmailHash, rec.RequestedBy, rec.Reason, summary).
+		Scan(&rec.ID, &rec.CreatedAt)
+	if err != nil {
+		logger.Errorf("Repository: Error recording erasure of user %d: %v", user.ID, err)
+		return err
+	}
+	rec.TenantID = tenantID
+	rec.UserID = user.ID
+	return tx.Commit()
+}
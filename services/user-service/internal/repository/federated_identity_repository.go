@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrFederatedIdentityNotFound = errors.New("federated identity not found")
+	ErrFederatedIdentityExists   = errors.New("federated identity already linked")
+)
+
+// FederatedIdentityRepository: Get и Create работают в тенанте из
+// контекста — одна внешняя учётная запись может быть привязана к
+// пользователям разных тенантов.
+type FederatedIdentityRepository interface {
+	// Get находит привязку по провайдеру и subject и отмечает вход через неё.
+	Get(ctx context.Context, provider, subject string) (*model.FederatedIdentity, error)
+	Create(ctx context.Context, identity *model.FederatedIdentity) error
+	ListByUser(ctx context.Context, userID int64) ([]model.FederatedIdentity, error)
+}
+
+type postgresFederatedIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewFederatedIdentityRepository(db *sql.DB) FederatedIdentityRepository {
+	return &postgresFederatedIdentityRepository{db: db}
+}
+
+const federatedIdentityColumns = `id, user_id, provider, subject, email, created_at, last_login_at`
+
+func scanFederatedIdentity(row interface{ Scan(...any) error }, i *model.FederatedIdentity) error {
+	return row.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt, &i.LastLoginAt)
+}
+
+func (r *postgresFederatedIdentityRepository) Get(ctx context.Context, provider, subject string) (*model.FederatedIdentity, error) {
+	query := `UPDATE federated_identities SET last_login_at = now()
+	WHERE tenant_id = $1 AND provider = $2 AND subject = $3
+	RETURNING ` + federatedIdentityColumns
+
+	var i model.FederatedIdentity
+	err := scanFederatedIdentity(r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), provider, subject), &i)
+	if err == sql.ErrNoRows {
+		return nil, ErrFederatedIdentityNotFound
+	}
+	if err != nil {
+		logger.Errorf("Repository: Error getting federated identity %s/%s: %v", provider, subject, err)
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *postgresFederatedIdentityRepository) Create(ctx context.Context, identity *model.FederatedIdentity) error {
+	query := `INSERT INTO federated_identities (tenant_id, user_id, provider, subject, email)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, created_at, last_login_at`
+
+	err := r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), identity.UserID, identity.Provider,
+		identity.Subject, identity.Email).
+		Scan(&identity.ID, &identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation {
+			return ErrFederatedIdentityExists
+		}
+		logger.Errorf("Repository: Error creating federated identity %s/%s: %v", identity.Provider, identity.Subject, err)
+		return err
+	}
+	return nil
+}
+
+func (r *postgresFederatedIdentityRepository) ListByUser(ctx context.Context, userID int64) ([]model.FederatedIdentity, error) {
+	query := `SELECT ` + federatedIdentityColumns + ` FROM federated_identities
+	WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		logger.Errorf("Repository: Error listing federated identities of user %d: %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []model.FederatedIdentity
+	for rows.Next() {
+		var i model.FederatedIdentity
+		if err := scanFederatedIdentity(rows, &i); err != nil {
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+	return identities, rows.Err()
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+)
+
+var ErrInvitationNotFound = errors.New("invitation not found")
+
+// InvitationRepository хранит приглашения в организации. Ожидающее
+// приглашение — не принятое, не отклонённое и не истёкшее. Права на
+// организацию проверяет сервис; по токену приглашение находится в
+// любом тенанте.
+type InvitationRepository interface {
+	// Create сохраняет приглашение; ожидающее приглашение того же email в
+	// ту же организацию заменяется новым.
+	Create(ctx context.Context, inv *model.Invitation) error
+	// GetByToken находит ожидающее приглашение по хэшу токена.
+	GetByToken(ctx context.Context, tokenHash string) (*model.Invitation, error)
+	// List — ожидающие приглашения организации, новые первыми.
+	List(ctx context.Context, orgID int64) ([]model.Invitation, error)
+	// Delete отзывает ожидающее приглашение.
+	Delete(ctx context.Context, orgID, id int64) error
+	// Accept гасит приглашение и добавляет пользователя в организацию с
+	// ролью из приглашения; роль уже состоящего участника не меняется.
+	Accept(ctx context.Context, id, userID int64) error
+	Decline(ctx context.Context, id int64) error
+}
+
+type postgresInvitationRepository struct {
+	db *sql.DB
+}
+
+func NewInvitationRepository(db *sql.DB) InvitationRepository {
+	return &postgresInvitationRepository{db: db}
+}
+
+// pendingInvitation — условие на i (organization_invitations).
+const pendingInvitation = `i.accepted_at IS NULL AND i.declined_at IS NULL AND i.expires_at > now()`
+
+const invitationColumns = `i.id, i.organization_id, o.tenant_id, i.email, i.role, i.token_hash, i.invited_by,
+	i.created_at, i.expires_at, i.accepted_at, i.declined_at`
+
+func scanInvitation(row interface{ Scan(...any) error }, inv *model.Invitation) error {
+	var acceptedAt, declinedAt sql.NullTime
+	err := row.Scan(&inv.ID, &inv.OrganizationID, &inv.TenantID, &inv.Email, &inv.Role, &inv.TokenHash, &inv.InvitedBy,
+		&inv.CreatedAt, &inv.ExpiresAt, &acceptedAt, &declinedAt)
+	if err != nil {
+		return err
+	}
+	if acceptedAt.Valid {
+		inv.AcceptedAt = &acceptedAt.Time
+	}
+	if declinedAt.Valid {
+		inv.DeclinedAt = &declinedAt.Time
+	}
+	return nil
+}
+
+func (r *postgresInvitationRepository) Create(ctx context.Context, inv *model.Invitation) error {
+	// истёкшее, но не погашенное приглашение тоже занимает место в индексе:
+	// повторное приглашение перезаписывает его
+	query := `INSERT INTO organization_invitations (organization_id, email, role, token_hash, invited_by, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (organization_id, lower(email)) WHERE accepted_at IS NULL AND declined_at IS NULL
+	DO UPDATE SET email = EXCLUDED.email, role = EXCLUDED.role, token_hash = EXCLUDED.token_hash,
+		invited_by = EXCLUDED.invited_by, created_at = now(), expires_at = EXCLUDED.expires_at
+	RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, inv.OrganizationID, inv.Email, inv.Role, inv.TokenHash, inv.InvitedBy, inv.ExpiresAt).
+		Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil {
+		logger.Errorf("Repository: Error creating invitation of %s to organization %d: %v", inv.Email, inv.OrganizationID, err)
+		return err
+	}
+	return nil
+}
+
+func (r *postgresInvitationRepository) GetByToken(ctx context.Context, tokenHash string) (*model.Invitation, error) {
+	query := `SELECT ` + invitationColumns + `
+	FROM organization_invitations i JOIN organizations o ON o.id = i.organization_id
+	WHERE i.token_hash = $1 AND ` + pendingInvitation
+
+	var inv model.Invitation
+	err := scanInvitation(r.db.QueryRowContext(ctx, query, tokenHash), &inv)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		logger.Errorf("Repository: Error getting invitation by token: %v", err)
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *postgresInvitationRepository) List(ctx context.Context, orgID int64) ([]model.Invitation, error) {
+	query := `SELECT ` + invitationColumns + `
+	FROM organization_invitations i JOIN organizations o ON o.id = i.organization_id
+	WHERE i.organization_id = $1 AND ` + pendingInvitation + `
+	ORDER BY i.created_at DESC, i.id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		logger.Errorf("Repository: Error listing invitations of organization %d: %v", orgID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []model.Invitation
+	for rows.Next() {
+		var inv model.Invitation
+		if err := scanInvitation(rows, &inv); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, rows.Err()
+}
+
+func (r *postgresInvitationRepository) Delete(ctx context.Context, orgID, id int64) error {
+	query := `DELETE FROM organization_invitations i WHERE i.organization_id = $1 AND i.id = $2 AND ` + pendingInvitation
+	res, err := r.db.ExecContext(ctx, query, orgID, id)
+	if err != nil {
+		logger.Errorf("Repository: Error deleting invitation %d: %v", id, err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+func (r *postgresInvitationRepository) Accept(ctx context.Context, id, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var orgID int64
+	var role model.Role
+	query := `UPDATE organization_invitations i SET accepted_at = now()
+	WHERE i.id = $1 AND ` + pendingInvitation + `
+	RETURNING i.organization_id, i.role`
+	err = tx.QueryRowContext(ctx, query, id).Scan(&orgID, &role)
+	if err == sql.ErrNoRows {
+		return ErrInvitationNotFound
+	}
+	if err != nil {
+		logger.Errorf("Repository: Error accepting invitation %d: %v", id, err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)
+	ON CONFLICT (organization_id, user_id) DO NOTHING`, orgID, userID, role)
+	if err != nil {
+		logger.Errorf("Repository: Error adding member %d to organization %d: %v", userID, orgID, err)
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *postgresInvitationRepository) Decline(ctx context.Context, id int64) error {
+	query := `UPDATE organization_invitations i SET declined_at = now() WHERE i.id = $1 AND ` + pendingInvitation
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		logger.Errorf("Repository: Error declining invitation %d: %v", id, err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
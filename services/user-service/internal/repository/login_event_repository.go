@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+)
+
+type LoginEventRepository interface {
+	// Record сохраняет событие в тенанте из контекста (tenant.FromContext).
+	Record(ctx context.Context, event *model.LoginEvent) error
+	// ListByUser возвращает до limit событий пользователя с id меньше
+	// beforeID (0 — с самого нового), новые первыми.
+	ListByUser(ctx context.Context, userID, beforeID int64, limit int) ([]model.LoginEvent, error)
+}
+
+type postgresLoginEventRepository struct {
+	db *sql.DB
+}
+
+func NewLoginEventRepository(db *sql.DB) LoginEventRepository {
+	return &postgresLoginEventRepository{db: db}
+}
+
+func (r *postgresLoginEventRepository) Record(ctx context.Context, event *model.LoginEvent) error {
+	query := `INSERT INTO login_events (tenant_id, user_id, email, method, ip, user_agent, success, failure_reason)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id, created_at`
+
+	userID := sql.NullInt64{Int64: event.UserID, Valid: event.UserID != 0}
+	err := r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), userID, event.Email, event.Method, event.IP,
+		event.UserAgent, event.Success, event.FailureReason).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		logger.Errorf("Repository: Error recording login event for %s: %v", event.Email, err)
+		return err
+	}
+	return nil
+}
+
+func (r *postgresLoginEventRepository) ListByUser(ctx context.Context, userID, beforeID int64, limit int) ([]model.LoginEvent, error) {
+	query := `SELECT id, user_id, email, method, ip, user_agent, success, failure_reason, created_at
+	FROM login_events
+	WHERE user_id = $1 AND ($2::bigint = 0 OR id < $2)
+	ORDER BY id DESC
+	LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, beforeID, limit)
+	if err != nil {
+		logger.Errorf("Repository: Error listing login events for ID %d: %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.LoginEvent
+	for rows.Next() {
+		var e model.LoginEvent
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Email, &e.Method, &e.IP, &e.UserAgent,
+			&e.Success, &e.FailureReason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
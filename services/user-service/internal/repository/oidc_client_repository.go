@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/lib/pq"
+)
+
+var ErrOIDCClientNotFound = errors.New("oidc client not found")
+
+type OIDCClientRepository interface {
+	Create(ctx context.Context, client *model.OIDCClient) error
+	Get(ctx context.Context, id string) (*model.OIDCClient, error)
+	List(ctx context.Context) ([]model.OIDCClient, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type postgresOIDCClientRepository struct {
+	db *sql.DB
+}
+
+func NewOIDCClientRepository(db *sql.DB) OIDCClientRepository {
+	return &postgresOIDCClientRepository{db: db}
+}
+
+func (r *postgresOIDCClientRepository) Create(ctx context.Context, client *model.OIDCClient) error {
+	query := `INSERT INTO oidc_clients (id, secret_hash, name, redirect_uris)
+	VALUES ($1, $2, $3, $4)
+	RETURNING created_at`
+
+	secretHash := sql.NullString{String: client.SecretHash, Valid: client.SecretHash != ""}
+	err := r.db.QueryRowContext(ctx, query, client.ID, secretHash, client.Name, pq.Array(client.RedirectURIs)).
+		Scan(&client.CreatedAt)
+	if err != nil {
+		logger.Errorf("Repository: Error creating OIDC client %s: %v", client.ID, err)
+		return err
+	}
+	return nil
+}
+
+const oidcClientColumns = `id, COALESCE(secret_hash, ''), name, redirect_uris, created_at`
+
+func scanOIDCClient(row interface{ Scan(...any) error }, c *model.OIDCClient) error {
+	return row.Scan(&c.ID, &c.SecretHash, &c.Name, pq.Array(&c.RedirectURIs), &c.CreatedAt)
+}
+
+func (r *postgresOIDCClientRepository) Get(ctx context.Context, id string) (*model.OIDCClient, error) {
+	query := `SELECT ` + oidcClientColumns + ` FROM oidc_clients WHERE id = $1`
+
+	var c model.OIDCClient
+	err := scanOIDCClient(r.db.QueryRowContext(ctx, query, id), &c)
+	if err == sql.ErrNoRows {
+		return nil, ErrOIDCClientNotFound
+	}
+	if err != nil {
+		logger.Errorf("Repository: Error getting OIDC client %s: %v", id, err)
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *postgresOIDCClientRepository) List(ctx context.Context) ([]model.OIDCClient, error) {
+	query := `SELECT ` + oidcClientColumns + ` FROM oidc_clients ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		logger.Errorf("Repository: Error listing OIDC clients: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []model.OIDCClient
+	for rows.Next() {
+		var c model.OIDCClient
+		if err := scanOIDCClient(rows, &c); err != nil {
+			return nil, err
+		}
+		clients = append(clients, c)
+	}
+	return clients, rows.Err()
+}
+
+func (r *postgresOIDCClientRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM oidc_clients WHERE id = $1`, id)
+	if err != nil {
+		logger.Errorf("Repository: Error deleting OIDC client %s: %v", id, err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrOIDCClientNotFound
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrMemberNotFound       = errors.New("organization member not found")
+	ErrLastOwner            = errors.New("organization must keep at least one owner")
+)
+
+// OrganizationRepository работает в тенанте из контекста, как UserRepository.
+type OrganizationRepository interface {
+	// Create создаёт организацию с владельцем ownerID.
+	Create(ctx context.Context, org *model.Organization, ownerID int64) error
+	Get(ctx context.Context, id int64) (*model.Organization, error)
+	Update(ctx context.Context, id int64, name string) (*model.Organization, error)
+	Delete(ctx context.Context, id int64) error
+	// ListByUser — организации пользователя с его ролью, по имени.
+	ListByUser(ctx context.Context, userID int64) ([]model.UserOrganization, error)
+
+	GetMember(ctx context.Context, orgID, userID int64) (*model.Membership, error)
+	ListMembers(ctx context.Context, orgID int64) ([]model.Membership, error)
+	// SetMemberRole и RemoveMember возвращают ErrLastOwner, если в
+	// организации не осталось бы владельцев.
+	SetMemberRole(ctx context.Context, orgID, userID int64, role model.Role) (*model.Membership, error)
+	RemoveMember(ctx context.Context, orgID, userID int64) error
+}
+
+type postgresOrganizationRepository struct {
+	db *sql.DB
+}
+
+func NewOrganizationRepository(db *sql.DB) OrganizationRepository {
+	return &postgresOrganizationRepository{db: db}
+}
+
+const organizationColumns = `id, tenant_id, name, created_at, updated_at`
+
+func scanOrganization(row interface{ Scan(...any) error }, o *model.Organization, extra ...any) error {
+	return row.Scan(append([]any{&o.ID, &o.TenantID, &o.Name, &o.CreatedAt, &o.UpdatedAt}, extra...)...)
+}
+
+func (r *postgresOrganizationRepository) Create(ctx context.Context, org *model.Organization, ownerID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO organizations (tenant_id, name) VALUES ($1, $2) RETURNING ` + organizationColumns
+	if err := scanOrganization(tx.QueryRowContext(ctx, query, tenant.FromContext(ctx), org.Name), org); err != nil {
+		logger.Errorf("Repository: Error creating organization %q: %v", org.Name, err)
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)`,
+		org.ID, ownerID, model.RoleOwner)
+	if err != nil {
+		logger.Errorf("Repository: Error adding owner %d to organization %d: %v", ownerID, org.ID, err)
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *postgresOrganizationRepository) Get(ctx context.Context, id int64) (*model.Organization, error) {
+	query := `SELECT ` + organizationColumns + ` FROM organizations WHERE tenant_id = $1 AND id = $2`
+
+	var o model.Organization
+	err := scanOrganization(r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), id), &o)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		logger.Errorf("Repository: Error getting organization %d: %v", id, err)
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *postgresOrganizationRepository) Update(ctx context.Context, id int64, name string) (*model.Organization, error) {
+	query := `UPDATE organizations SET name = $3, updated_at = now()
+	WHERE tenant_id = $1 AND id = $2
+	RETURNING ` + organizationColumns
+
+	var o model.Organization
+	err := scanOrganization(r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), id, name), &o)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		logger.Errorf("Repository: Error updating organization %d: %v", id, err)
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *postgresOrganizationRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM organizations WHERE tenant_id = $1 AND id = $2`, tenant.FromContext(ctx), id)
+	if err != nil {
+		logger.Errorf("Repository: Error deleting organization %d: %v", id, err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrOrganizationNotFound
+	}
+	return nil
+}
+
+func (r *postgresOrganizationRepository) ListByUser(ctx context.Context, userID int64) ([]model.UserOrganization, error) {
+	query := `SELECT o.id, o.tenant_id, o.name, o.created_at, o.updated_at, m.role
+	FROM organizations o JOIN organization_members m ON m.organization_id = o.id
+	WHERE o.tenant_id = $1 AND m.user_id = $2
+	ORDER BY o.name, o.id`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.FromContext(ctx), userID)
+	if err != nil {
+		logger.Errorf("Repository: Error listing organizations of user %d: %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []model.UserOrganization
+	for rows.Next() {
+		var o model.UserOrganization
+		if err := scanOrganization(rows, &o.Organization, &o.Role); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+	return orgs, rows.Err()
+}
+
+const memberColumns = `m.organization_id, m.user_id, m.role, m.created_at, u.email, u.display_name`
+
+// memberFrom — участники вместе с пользователями; $1 — тенант.
+const memberFrom = ` FROM organization_members m
+	JOIN organizations o ON o.id = m.organization_id
+	JOIN users u ON u.id = m.user_id
+	WHERE o.tenant_id = $1`
+
+func scanMember(row interface{ Scan(...any) error }, m *model.Membership) error {
+	return row.Scan(&m.OrganizationID, &m.UserID, &m.Role, &m.CreatedAt, &m.Email, &m.DisplayName)
+}
+
+func (r *postgresOrganizationRepository) GetMember(ctx context.Context, orgID, userID int64) (*model.Membership, error) {
+	return getMember(ctx, r.db, orgID, userID)
+}
+
+func getMember(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, orgID, userID int64) (*model.Membership, error) {
+	query := `SELECT ` + memberColumns + memberFrom + ` AND m.organization_id = $2 AND m.user_id = $3`
+
+	var m model.Membership
+	err := scanMember(q.QueryRowContext(ctx, query, tenant.FromContext(ctx), orgID, userID), &m)
+	if err == sql.ErrNoRows {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		logger.Errorf("Repository: Error getting member %d of organization %d: %v", userID, orgID, err)
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *postgresOrganizationRepository) ListMembers(ctx context.Context, orgID int64) ([]model.Membership, error) {
+	query := `SELECT ` + memberColumns + memberFrom + ` AND m.organization_id = $2 ORDER BY m.created_at, m.user_id`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.FromContext(ctx), orgID)
+	if err != nil {
+		logger.Errorf("Repository: Error listing members of organization %d: %v", orgID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []model.Membership
+	for rows.Next() {
+		var m model.Membership
+		if err := scanMember(rows, &m); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// lockMember блокирует организацию до конца транзакции — изменения
+// владельцев выполняются по очереди — и проверяет, что после снятия
+// роли владельца у участника останется другой владелец.
+func lockMember(ctx context.Context, tx *sql.Tx, orgID, userID int64, newRole model.Role) (*model.Membership, error) {
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM organizations WHERE tenant_id = $1 AND id = $2 FOR UPDATE`,
+		tenant.FromContext(ctx), orgID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := getMember(ctx, tx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if m.Role != model.RoleOwner || newRole == model.RoleOwner {
+		return m, nil
+	}
+	var owners int
+	err = tx.QueryRowContext(ctx, `SELECT count(*) FROM organization_members WHERE organization_id = $1 AND role = $2`,
+		orgID, model.RoleOwner).Scan(&owners)
+	if err != nil {
+		return nil, err
+	}
+	if owners <= 1 {
+		return nil, ErrLastOwner
+	}
+	return m, nil
+}
+
+func (r *postgresOrganizationRepository) SetMemberRole(ctx context.Context, orgID, userID int64, role model.Role) (*model.Membership, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	m, err := lockMember(ctx, tx, orgID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE organization_members SET role = $3 WHERE organization_id = $1 AND user_id = $2`,
+		orgID, userID, role)
+	if err != nil {
+		logger.Errorf("Repository: Error changing role of member %d in organization %d: %v", userID, orgID, err)
+		return nil, err
+	}
+	m.Role = role
+	return m, tx.Commit()
+}
+
+func (r *postgresOrganizationRepository) RemoveMember(ctx context.Context, orgID, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockMember(ctx, tx, orgID, userID, ""); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`, orgID, userID)
+	if err != nil {
+		logger.Errorf("Repository: Error removing member %d from organization %d: %v", userID, orgID, err)
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+)
+
+type SigningKeyRepository interface {
+	Create(ctx context.Context, key *model.SigningKey) error
+	// ListActive возвращает ключи, которые ещё публикуются (expires_at
+	// позже now), по возрастанию not_before.
+	ListActive(ctx context.Context, now time.Time) ([]model.SigningKey, error)
+	// DeleteExpired удаляет ключи, срок публикации которых истёк.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type postgresSigningKeyRepository struct {
+	db *sql.DB
+}
+
+func NewSigningKeyRepository(db *sql.DB) SigningKeyRepository {
+	return &postgresSigningKeyRepository{db: db}
+}
+
+func (r *postgresSigningKeyRepository) Create(ctx context.Context, key *model.SigningKey) error {
+	query := `INSERT INTO signing_keys (id, algorithm, private_key_encrypted, not_before, not_after, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, query, key.ID, key.Algorithm, key.PrivateKey,
+		key.NotBefore, key.NotAfter, key.ExpiresAt).Scan(&key.CreatedAt)
+	if err != nil {
+		logger.Errorf("Repository: Error creating signing key %s: %v", key.ID, err)
+		return err
+	}
+	return nil
+}
+
+func (r *postgresSigningKeyRepository) ListActive(ctx context.Context, now time.Time) ([]model.SigningKey, error) {
+	query := `SELECT id, algorithm, private_key_encrypted, not_before, not_after, expires_at, created_at
+	FROM signing_keys
+	WHERE expires_at > $1
+	ORDER BY not_before, created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, now)
+	if err != nil {
+		logger.Errorf("Repository: Error listing signing keys: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []model.SigningKey
+	for rows.Next() {
+		var k model.SigningKey
+		if err := rows.Scan(&k.ID, &k.Algorithm, &k.PrivateKey, &k.NotBefore, &k.NotAfter, &k.ExpiresAt, &k.CreatedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *postgresSigningKeyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM signing_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		logger.Errorf("Repository: Error deleting expired signing keys: %v", err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+)
+
+type TenantRepository interface {
+	Exists(ctx context.Context, id string) (bool, error)
+}
+
+type postgresTenantRepository struct {
+	db *sql.DB
+}
+
+func NewTenantRepository(db *sql.DB) TenantRepository {
+	return &postgresTenantRepository{db: db}
+}
+
+func (r *postgresTenantRepository) Exists(ctx context.Context, id string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tenants WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		logger.Errorf("Repository: Error checking tenant %s: %v", id, err)
+		return false, err
+	}
+	return exists, nil
+}
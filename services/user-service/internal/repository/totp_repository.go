@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTOTPNotFound = errors.New("totp not found")
+	ErrTOTPEnabled  = errors.New("totp already enabled")
+)
+
+type TOTPRepository interface {
+	// SavePending сохраняет неподтверждённый секрет, заменяя прежний
+	// неподтверждённый. Если TOTP уже подключён — ErrTOTPEnabled.
+	SavePending(ctx context.Context, userID int64, secret []byte) error
+	Get(ctx context.Context, userID int64) (*model.TOTP, error)
+	// Confirm подтверждает подключение, запоминает использованный шаг и
+	// заменяет коды восстановления.
+	Confirm(ctx context.Context, userID, step int64, recoveryHashes []string) error
+	// UseStep отмечает шаг использованным; false — шаг не новее уже
+	// использованного (повтор кода).
+	UseStep(ctx context.Context, userID, step int64) (bool, error)
+	// UseRecoveryCode гасит код восстановления; false — кода нет или он
+	// уже использован.
+	UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error)
+	// Delete отключает TOTP и удаляет коды восстановления.
+	Delete(ctx context.Context, userID int64) error
+}
+
+type postgresTOTPRepository struct {
+	db *sql.DB
+}
+
+func NewTOTPRepository(db *sql.DB) TOTPRepository {
+	return &postgresTOTPRepository{db: db}
+}
+
+func (r *postgresTOTPRepository) SavePending(ctx context.Context, userID int64, secret []byte) error {
+	query := `INSERT INTO user_totp (user_id, secret_encrypted) VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted, last_used_step = 0, created_at = now()
+		WHERE user_totp.confirmed_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		logger.Errorf("Repository: Error saving TOTP secret for ID %d: %v", userID, err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTOTPEnabled
+	}
+	return nil
+}
+
+func (r *postgresTOTPRepository) Get(ctx context.Context, userID int64) (*model.TOTP, error) {
+	query := `SELECT user_id, secret_encrypted, confirmed_at, last_used_step, created_at
+	FROM user_totp WHERE user_id = $1`
+
+	var t model.TOTP
+	var confirmedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&t.UserID, &t.Secret, &confirmedAt, &t.LastUsedStep, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrTOTPNotFound
+	}
+	if err != nil {
+		logger.Errorf("Repository: Error getting TOTP for ID %d: %v", userID, err)
+		return nil, err
+	}
+	if confirmedAt.Valid {
+		t.ConfirmedAt = &confirmedAt.Time
+	}
+	return &t, nil
+}
+
+func (r *postgresTOTPRepository) Confirm(ctx context.Context, userID, step int64, recoveryHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE user_totp SET confirmed_at = now(), last_used_step = $2
+	WHERE user_id = $1 AND confirmed_at IS NULL AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTOTPNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO user_recovery_codes (user_id, code_hash)
+	SELECT $1, unnest($2::text[])`, userID, pq.Array(recoveryHashes))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresTOTPRepository) UseStep(ctx context.Context, userID, step int64) (bool, error) {
+	// условие на last_used_step делает проверку и запись атомарными: из
+	// двух одновременных запросов с одним кодом пройдёт только один
+	res, err := r.db.ExecContext(ctx, `UPDATE user_totp SET last_used_step = $2
+	WHERE user_id = $1 AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *postgresTOTPRepository) UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE user_recovery_codes SET used_at = now()
+	WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *postgresTOTPRepository) Delete(ctx context.Context, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTOTPNotFound
+	}
+	return tx.Commit()
+}
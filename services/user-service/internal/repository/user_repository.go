@@ -3,16 +3,28 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/DmitriiPro/user-service/internal/logger"
 	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"github.com/lib/pq"
 )
 
+// UserRepository работает в тенанте из контекста (tenant.FromContext):
+// пользователи других тенантов для него не существуют.
 type UserRepository interface {
 	CreateUser(ctx context.Context, email, password_hash string) (*model.User, error)
 	GetUserByID(ctx context.Context, id int64) (*model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	UpdateProfile(ctx context.Context, id int64, upd model.ProfileUpdate) (*model.User, error)
+	// SearchUsers возвращает до limit пользователей по убыванию релевантности
+	// после курсора after (nil — с начала). timeout ограничивает время запроса в БД.
+	SearchUsers(ctx context.Context, query string, after *model.SearchCursor, limit int, timeout time.Duration) ([]model.SearchResult, error)
 }
 
 type postgresRepository struct {
@@ -23,51 +35,189 @@ func NewUserRepository(db *sql.DB) UserRepository {
 	return &postgresRepository{db: db}
 }
 
-var ErrNotFoundUser = errors.New("user not found")
+var (
+	ErrNotFoundUser  = errors.New("user not found")
+	ErrUserExists    = errors.New("user already exists")
+	ErrQueryTimedOut = errors.New("query timed out")
+)
 
-func (r *postgresRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
-	query := `SELECT id, email, password_hash, created_at FROM users WHERE email = $1`
+// Коды ошибок Postgres: unique_violation и query_canceled (statement_timeout).
+const (
+	pgUniqueViolation = "23505"
+	pgQueryCanceled   = "57014"
+)
+
+const userColumns = `id, tenant_id, email, password_hash, created_at,
+	display_name, phone, locale, timezone, avatar_url, metadata, updated_at`
+
+// scanUser читает строку с колонками userColumns; extra — колонки после них.
+func scanUser(row interface{ Scan(dest ...any) error }, extra ...any) (*model.User, error) {
 	var user model.User
-	err := r.db.QueryRowContext(ctx, query, email).
-		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt)
+	var metadata []byte
+	err := row.Scan(append([]any{&user.ID, &user.TenantID, &user.Email, &user.PasswordHash, &user.CreatedAt,
+		&user.DisplayName, &user.Phone, &user.Locale, &user.Timezone, &user.AvatarURL,
+		&metadata, &user.UpdatedAt}, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(metadata, &user.Metadata); err != nil {
+		return nil, fmt.Errorf("decode metadata: %w", err)
+	}
+	return &user, nil
+}
+
+func (r *postgresRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id = $1 AND lower(email) = lower($2)`
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), email))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFoundUser // пользователя нет
 		}
 		return nil, err
 	}
-	return &user, nil
+	return user, nil
 }
 
 func (r *postgresRepository) CreateUser(ctx context.Context, email, password_hash string) (*model.User, error) {
-	query := `INSERT INTO users (email, password_hash) VALUES ($1, $2) RETURNING id, email, password_hash, created_at`
-	var user model.User
-	err := r.db.QueryRowContext(ctx, query, email, password_hash).Scan(&user.ID,
-		&user.Email,
-		&user.PasswordHash,
-		&user.CreatedAt)
+	query := `INSERT INTO users (tenant_id, email, password_hash) VALUES ($1, $2, $3) RETURNING ` + userColumns
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), email, password_hash))
 
 	if err != nil {
-		log.Printf("Repository: Error creating user: %v", err)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation {
+			return nil, ErrUserExists
+		}
+		logger.Errorf("Repository: Error creating user: %v", err)
 		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 
 func (r *postgresRepository) GetUserByID(ctx context.Context, id int64) (*model.User, error) {
-	query := `SELECT id, email, password_hash, created_at FROM users WHERE id = $1`
-	var user model.User
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt)
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id = $1 AND id = $2`
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFoundUser
 		}
-		log.Printf("Repository: Error getting user by ID %d: %v", id, err)
+		logger.Errorf("Repository: Error getting user by ID %d: %v", id, err)
 		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+func (r *postgresRepository) UpdateProfile(ctx context.Context, id int64, upd model.ProfileUpdate) (*model.User, error) {
+	// metadata: пустое значение удаляет ключ, остальные сливаются с текущими
+	set := map[string]string{}
+	var del []string
+	for k, v := range upd.Metadata {
+		if v == "" {
+			del = append(del, k)
+			continue
+		}
+		set[k] = v
+	}
+	setJSON, err := json.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE users SET
+		display_name = COALESCE($2, display_name),
+		phone = COALESCE($3, phone),
+		locale = COALESCE($4, locale),
+		timezone = COALESCE($5, timezone),
+		avatar_url = COALESCE($6, avatar_url),
+		metadata = (metadata || $7::jsonb) - $8::text[],
+		updated_at = now()
+	WHERE id = $1 AND tenant_id = $9
+	RETURNING ` + userColumns
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id,
+		upd.DisplayName, upd.Phone, upd.Locale, upd.Timezone, upd.AvatarURL,
+		setJSON, pq.Array(del), tenant.FromContext(ctx)))
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFoundUser
+		}
+		logger.Errorf("Repository: Error updating profile for ID %d: %v", id, err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// searchQuery находит совпадения по подстроке ($2 — LIKE шаблон), словам
+// (tsvector) и нечётко (pg_trgm %). Подстрока весит больше всего, дальше
+// триграммное сходство и ts_rank. Пагинация — по (rank, id).
+const searchQuery = `SELECT ` + userColumns + `, rank FROM (
+	SELECT ` + userColumns + `,
+		(CASE WHEN lower(email) LIKE $2 ESCAPE '\' OR lower(display_name) LIKE $2 ESCAPE '\' THEN 1 ELSE 0 END
+		 + greatest(similarity(lower(email), $1), similarity(lower(display_name), $1))
+		 + ts_rank(search_vector, plainto_tsquery('simple', $1)))::float8 AS rank
+	FROM users
+	WHERE tenant_id = $7 AND (lower(email) LIKE $2 ESCAPE '\'
+		OR lower(display_name) LIKE $2 ESCAPE '\'
+		OR search_vector @@ plainto_tsquery('simple', $1)
+		OR lower(email) % $1
+		OR lower(display_name) % $1)
+) s
+WHERE $3::bool OR (rank, id) < ($4::float8, $5::bigint)
+ORDER BY rank DESC, id DESC
+LIMIT $6`
+
+func (r *postgresRepository) SearchUsers(ctx context.Context, query string, after *model.SearchCursor, limit int, timeout time.Duration) ([]model.SearchResult, error) {
+	// statement_timeout действует только внутри транзакции (SET LOCAL)
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	var cursor model.SearchCursor
+	if after != nil {
+		cursor = *after
+	}
+
+	rows, err := tx.QueryContext(ctx, searchQuery, query, pattern, after == nil, cursor.Rank, cursor.ID, limit, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, searchError(err)
+	}
+	defer rows.Close()
+
+	var results []model.SearchResult
+	for rows.Next() {
+		var rank float64
+		user, err := scanUser(rows, &rank)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, model.SearchResult{User: user, Rank: rank})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, searchError(err)
+	}
+
+	return results, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func searchError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pgQueryCanceled {
+		return ErrQueryTimedOut
+	}
+	logger.Errorf("Repository: Error searching users: %v", err)
+	return err
 }
@@ -0,0 +1,38 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileProvider читает секреты из файлов каталога, например /run/secrets
+// (Docker) или смонтированного Kubernetes Secret. Файл перечитывается при
+// каждом запросе, поэтому ротация подхватывается без перезапуска.
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+func (p *FileProvider) GetSecret(_ context.Context, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(p.dir, name))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return "", fmt.Errorf("read secret %s: %w", name, err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,32 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileProviderGetSecret(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "postgres_dsn"), []byte("postgres://u:p@db/users\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := NewFileProvider(dir)
+
+	got, err := p.GetSecret(context.Background(), "postgres_dsn")
+	if err != nil {
+		t.Fatalf("GetSecret: %v", err)
+	}
+	if got != "postgres://u:p@db/users" {
+		t.Fatalf("GetSecret = %q, want value without trailing newline", got)
+	}
+
+	if _, err := p.GetSecret(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetSecret(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, err := p.GetSecret(context.Background(), "../postgres_dsn"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetSecret(../postgres_dsn) error = %v, want invalid name", err)
+	}
+}
@@ -0,0 +1,63 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
+)
+
+var ErrNotFound = errors.New("secret not found")
+
+// Provider возвращает значение секрета по имени. Формат имени зависит от
+// реализации: для файлов это имя файла в каталоге, для Vault — путь и ключ.
+type Provider interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// New создаёт провайдера по конфигурации; nil означает, что внешние
+// секреты не настроены.
+func New(cfg config.SecretsConfig) (Provider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "file":
+		return NewFileProvider(cfg.Dir), nil
+	case "vault":
+		return NewVaultProvider(cfg.Vault), nil
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q", cfg.Provider)
+	}
+}
+
+// Watch периодически перечитывает секрет и вызывает onChange, когда значение
+// меняется. Ошибки провайдера логируются, последнее значение остаётся в силе.
+// Возвращается при отмене ctx.
+func Watch(ctx context.Context, p Provider, name, current string, interval time.Duration, onChange func(string)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		value, err := p.GetSecret(ctx, name)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Errorf("Secrets: failed to refresh %s: %v", name, err)
+			}
+			continue
+		}
+		if value != current {
+			logger.Infof("Secrets: %s rotated", name)
+			current = value
+			onChange(value)
+		}
+	}
+}
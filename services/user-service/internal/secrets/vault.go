@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+)
+
+const defaultVaultKey = "value"
+
+// VaultProvider читает секреты из KV v2 движка HashiCorp Vault (или
+// совместимого HTTP API). Имя секрета — "path#key", например
+// "user-service/postgres#dsn"; без "#key" берётся ключ "value".
+type VaultProvider struct {
+	addr   string
+	token  string
+	mount  string
+	client *http.Client
+}
+
+func NewVaultProvider(cfg config.VaultConfig) *VaultProvider {
+	return &VaultProvider{
+		addr:   strings.TrimRight(cfg.Addr, "/"),
+		token:  cfg.Token,
+		mount:  strings.Trim(cfg.Mount, "/"),
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+func (p *VaultProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	path, key, ok := strings.Cut(name, "#")
+	if !ok {
+		key = defaultVaultKey
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/data/%s", p.addr, url.PathEscape(p.mount), escapePath(path))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("vault returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var kv vaultKVResponse
+	if err := json.NewDecoder(resp.Body).Decode(&kv); err != nil {
+		return "", fmt.Errorf("decode vault response: %w", err)
+	}
+
+	value, ok := kv.Data.Data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s is not a string", name)
+	}
+
+	return s, nil
+}
+
+func escapePath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,144 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+)
+
+// newVaultStub поднимает заглушку KV v2: data — секреты по пути внутри
+// mount "secret", доступные с токеном "s.token".
+func newVaultStub(t *testing.T, data map[string]map[string]any) (*VaultProvider, chan string) {
+	t.Helper()
+	paths := make(chan string, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case paths <- r.URL.EscapedPath():
+		default:
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		path, ok := strings.CutPrefix(r.URL.Path, "/v1/secret/data/")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		secret, ok := data[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		var resp vaultKVResponse
+		resp.Data.Data = secret
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewVaultProvider(config.VaultConfig{Addr: srv.URL + "/", Token: "s.token", Mount: "/secret/", Timeout: time.Second})
+	return p, paths
+}
+
+func TestVaultProviderGetSecret(t *testing.T) {
+	p, _ := newVaultStub(t, map[string]map[string]any{
+		"user-service/postgres": {"dsn": "postgres://u:p@db/users", "value": "default", "port": 5432},
+	})
+
+	tests := []struct {
+		name     string
+		secret   string
+		want     string
+		notFound bool
+		wantErr  string
+	}{
+		{name: "key", secret: "user-service/postgres#dsn", want: "postgres://u:p@db/users"},
+		{name: "default key", secret: "user-service/postgres", want: "default"},
+		{name: "missing key", secret: "user-service/postgres#password", notFound: true},
+		{name: "missing path", secret: "user-service/redis#dsn", notFound: true},
+		{name: "not a string", secret: "user-service/postgres#port", wantErr: "is not a string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.GetSecret(context.Background(), tt.secret)
+			switch {
+			case tt.notFound:
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("GetSecret(%q) error = %v, want ErrNotFound", tt.secret, err)
+				}
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetSecret(%q) error = %v, want %q", tt.secret, err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("GetSecret(%q): %v", tt.secret, err)
+			case got != tt.want:
+				t.Fatalf("GetSecret(%q) = %q, want %q", tt.secret, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVaultProviderEscapesPath(t *testing.T) {
+	p, paths := newVaultStub(t, map[string]map[string]any{"a b/c?d": {"value": "x"}})
+
+	got, err := p.GetSecret(context.Background(), "/a b/c?d/")
+	if err != nil {
+		t.Fatalf("GetSecret: %v", err)
+	}
+	if got != "x" {
+		t.Fatalf("GetSecret = %q, want x", got)
+	}
+	if got, want := <-paths, "/v1/secret/data/a%20b/c%3Fd"; got != want {
+		t.Fatalf("request path = %s, want %s", got, want)
+	}
+}
+
+func TestVaultProviderBadToken(t *testing.T) {
+	p, _ := newVaultStub(t, nil)
+	p.token = "wrong"
+
+	_, err := p.GetSecret(context.Background(), "user-service/postgres#dsn")
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("GetSecret error = %v, want vault permission error", err)
+	}
+}
+
+func TestWatchPicksUpRotation(t *testing.T) {
+	data := map[string]map[string]any{"db": {"value": "v1"}}
+	p, _ := newVaultStub(t, data)
+	// заглушка читает data без блокировок; меняем значение до первого тика
+	data["db"] = map[string]any{"value": "v2"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan string, 1)
+	go Watch(ctx, p, "db", "v1", 10*time.Millisecond, func(v string) {
+		select {
+		case changed <- v:
+		default:
+		}
+	})
+
+	select {
+	case v := <-changed:
+		if v != "v2" {
+			t.Fatalf("onChange(%q), want v2", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("rotation was not noticed")
+	}
+}
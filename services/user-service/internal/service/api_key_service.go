@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apikey"
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/cache"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"google.golang.org/grpc/codes"
+)
+
+// APIKeyService управляет API ключами машинных клиентов. Доступно
+// администраторам и сервисам (mTLS); сами API ключи ключами не управляют,
+// чтобы ключ не мог выпустить себе более широкий.
+type APIKeyService interface {
+	// Create выпускает ключ и возвращает его вместе с секретом; секрет
+	// больше нигде не сохраняется. expiresAt nil — бессрочный ключ.
+	Create(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error)
+	List(ctx context.Context) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+type apiKeyService struct {
+	keys repository.APIKeyRepository
+	// methods — имена методов UserService, допустимые в scopes.
+	methods []string
+	admins  []string
+	// introspection — кэш ответов Introspect, сбрасывается при отзыве ключа.
+	introspection cache.Tagged
+}
+
+func NewAPIKeyService(keys repository.APIKeyRepository, methods, admins []string, introspection cache.Tagged) APIKeyService {
+	return &apiKeyService{keys: keys, methods: methods, admins: admins, introspection: introspection}
+}
+
+func authorizeKeyManagement(ctx context.Context, admins []string) (*auth.Principal, error) {
+	if err := authorizeAdmin(ctx, admins); err != nil {
+		return nil, err
+	}
+	p, _ := auth.FromContext(ctx)
+	if p.Type == auth.PrincipalAPIKey {
+		return nil, apperr.New(codes.PermissionDenied, apperr.ReasonPermissionDenied, "api keys cannot manage api keys", nil)
+	}
+	return p, nil
+}
+
+func (s *apiKeyService) Create(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error) {
+	p, err := authorizeKeyManagement(ctx, s.admins)
+	if err != nil {
+		return nil, "", err
+	}
+	for i, scope := range scopes {
+		if scope != model.ScopeAll && !slices.Contains(s.methods, scope) {
+			return nil, "", apperr.InvalidFields(apperr.FieldViolation(fmt.Sprintf("scopes[%d]", i),
+				fmt.Sprintf("unknown method %q", scope)))
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", apperr.InvalidFields(apperr.FieldViolation("expires_at", "value must be in the future"))
+	}
+
+	id, secret, token := apikey.Generate()
+	key := &model.APIKey{
+		ID:         id,
+		TenantID:   tenant.FromContext(ctx),
+		Name:       name,
+		SecretHash: apikey.HashSecret(secret),
+		Scopes:     slices.Compact(slices.Sorted(slices.Values(scopes))),
+		CreatedBy:  string(p.Type) + ":" + p.ID,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.keys.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	logger.Infof("apiKeyService - Create: Key %s (%s) created by %s in tenant %s with scopes %v", key.ID, name, key.CreatedBy, key.TenantID, key.Scopes)
+	return key, token, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context) ([]model.APIKey, error) {
+	if _, err := authorizeKeyManagement(ctx, s.admins); err != nil {
+		return nil, err
+	}
+	return s.keys.List(ctx)
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, id string) error {
+	p, err := authorizeKeyManagement(ctx, s.admins)
+	if err != nil {
+		return err
+	}
+	err = s.keys.Revoke(ctx, id)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return apperr.New(codes.NotFound, apperr.ReasonAPIKeyNotFound,
+			fmt.Sprintf("api key %s not found", id), map[string]string{"key_id": id})
+	}
+	if err != nil {
+		return err
+	}
+	invalidateIntrospection(ctx, s.introspection, apiKeyTag(id))
+	logger.Infof("apiKeyService - Revoke: Key %s revoked by %s:%s", id, p.Type, p.ID)
+	return nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apikey"
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"google.golang.org/grpc/codes"
+)
+
+var testMethods = []string{"GetUser", "SearchUsers"}
+
+func TestAPIKeyServiceAuthorization(t *testing.T) {
+	tests := []struct {
+		caller caller
+		want   codes.Code
+	}{
+		{anonymous, codes.Unauthenticated},
+		{asUser(1), codes.PermissionDenied},
+		// ключ не может выпустить себе более широкий
+		{asAPIKey, codes.PermissionDenied},
+		{asAdmin, codes.OK},
+		{asService, codes.OK},
+	}
+	calls := []struct {
+		name string
+		call func(ctx context.Context, svc APIKeyService, existing string) error
+	}{
+		{"Create", func(ctx context.Context, svc APIKeyService, _ string) error {
+			_, _, err := svc.Create(ctx, "ci", []string{"GetUser"}, nil)
+			return err
+		}},
+		{"List", func(ctx context.Context, svc APIKeyService, _ string) error {
+			_, err := svc.List(ctx)
+			return err
+		}},
+		{"Revoke", func(ctx context.Context, svc APIKeyService, existing string) error {
+			return svc.Revoke(ctx, existing)
+		}},
+	}
+	for _, c := range calls {
+		for _, tt := range tests {
+			t.Run(c.name+"/"+tt.caller.name, func(t *testing.T) {
+				repo := newFakeAPIKeys()
+				svc := NewAPIKeyService(repo, testMethods, testAdmins, newMemCache())
+				existing, _, err := svc.Create(asAdmin.ctx(), "existing", []string{model.ScopeAll}, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				wantCode(t, c.call(tt.caller.ctx(), svc, existing.ID), tt.want)
+				if tt.want == codes.OK {
+					return
+				}
+				if keys, _ := repo.List(t.Context()); len(keys) != 1 || keys[0].RevokedAt != nil {
+					t.Fatalf("denied %s changed keys: %+v", c.name, keys)
+				}
+			})
+		}
+	}
+}
+
+func TestAPIKeyServiceCreate(t *testing.T) {
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	tests := []struct {
+		name       string
+		scopes     []string
+		expiresAt  *time.Time
+		want       codes.Code
+		wantScopes []string
+	}{
+		{"scopes are sorted and deduplicated", []string{"SearchUsers", "GetUser", "SearchUsers"}, &future, codes.OK, []string{"GetUser", "SearchUsers"}},
+		{"all methods", []string{model.ScopeAll}, nil, codes.OK, []string{model.ScopeAll}},
+		{"unknown method", []string{"GetUser", "DeleteEverything"}, nil, codes.InvalidArgument, nil},
+		{"expired", []string{"GetUser"}, &past, codes.InvalidArgument, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeAPIKeys()
+			svc := NewAPIKeyService(repo, testMethods, testAdmins, newMemCache())
+
+			key, token, err := svc.Create(asAdmin.ctx(), "ci", tt.scopes, tt.expiresAt)
+			wantCode(t, err, tt.want)
+			if tt.want != codes.OK {
+				if keys, _ := repo.List(t.Context()); len(keys) != 0 {
+					t.Fatalf("invalid key stored: %+v", keys)
+				}
+				return
+			}
+			if !slices.Equal(key.Scopes, tt.wantScopes) || key.CreatedBy != "user:"+testAdminID {
+				t.Fatalf("key = %+v", key)
+			}
+			// секрет хранится только хэшем, а выданный ключ проходит проверку
+			stored, _ := repo.Get(t.Context(), key.ID)
+			if _, secret, _ := apikey.Parse(token); stored.SecretHash != apikey.HashSecret(secret) {
+				t.Fatalf("secret stored as %q", stored.SecretHash)
+			}
+			got, err := apikey.NewAuthenticator(repo).Authenticate(t.Context(), token)
+			if err != nil || got.ID != key.ID {
+				t.Fatalf("Authenticate(issued token) = %+v, %v", got, err)
+			}
+		})
+	}
+}
+
+// Ключ выпускается в тенанте запроса: у администратора это тенант его
+// сессии, сервис выбирает тенант заголовком.
+func TestAPIKeyServiceCreateInRequestTenant(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"without tenant", asService.ctx(), tenant.Default},
+		{"service in tenant", tenant.WithID(asService.ctx(), "acme"), "acme"},
+		{"admin in tenant", tenant.WithID(asAdmin.ctx(), "globex"), "globex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeAPIKeys()
+			svc := NewAPIKeyService(repo, testMethods, testAdmins, newMemCache())
+
+			key, token, err := svc.Create(tt.ctx, "ci", []string{"GetUser"}, nil)
+			wantCode(t, err, codes.OK)
+			got, err := apikey.NewAuthenticator(repo).Authenticate(t.Context(), token)
+			if err != nil || key.TenantID != tt.want || got.TenantID != tt.want {
+				t.Fatalf("key tenant = %q, stored %+v (%v), want %q", key.TenantID, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyServiceRevoke(t *testing.T) {
+	repo := newFakeAPIKeys()
+	introspection := newMemCache()
+	svc := NewAPIKeyService(repo, testMethods, testAdmins, introspection)
+	key, token, err := svc.Create(asAdmin.ctx(), "ci", []string{"GetUser"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	introspection.SetTagged(t.Context(), introspectionKey(token), `{"active":true}`, time.Hour, apiKeyTag(key.ID))
+
+	wantCode(t, svc.Revoke(asService.ctx(), key.ID), codes.OK)
+	if _, err := apikey.NewAuthenticator(repo).Authenticate(t.Context(), token); err != apikey.ErrInvalid {
+		t.Fatalf("Authenticate(revoked) err = %v, want ErrInvalid", err)
+	}
+	if introspection.len() != 0 {
+		t.Fatal("cached introspection of the revoked key was not dropped")
+	}
+	// повторный отзыв не ошибка, неизвестный ключ — NotFound
+	wantCode(t, svc.Revoke(asService.ctx(), key.ID), codes.OK)
+	err = svc.Revoke(asService.ctx(), "missing")
+	wantReason(t, err, codes.NotFound, apperr.ReasonAPIKeyNotFound)
+}
+
+// Администратор видит и отзывает только ключи своего тенанта: ключ
+// другого тенанта для него не существует.
+func TestAPIKeyServiceTenantIsolation(t *testing.T) {
+	repo := newFakeAPIKeys()
+	svc := NewAPIKeyService(repo, testMethods, testAdmins, newMemCache())
+	acme, globex := tenant.WithID(asAdmin.ctx(), "acme"), tenant.WithID(asAdmin.ctx(), "globex")
+	acmeKey, acmeToken, err := svc.Create(acme, "acme ci", []string{"GetUser"}, nil)
+	wantCode(t, err, codes.OK)
+	globexKey, _, err := svc.Create(globex, "globex ci", []string{"GetUser"}, nil)
+	wantCode(t, err, codes.OK)
+
+	keys, err := svc.List(acme)
+	wantCode(t, err, codes.OK)
+	if len(keys) != 1 || keys[0].ID != acmeKey.ID {
+		t.Fatalf("acme keys = %+v, want only %s", keys, acmeKey.ID)
+	}
+
+	err = svc.Revoke(globex, acmeKey.ID)
+	wantReason(t, err, codes.NotFound, apperr.ReasonAPIKeyNotFound)
+	if _, err := apikey.NewAuthenticator(repo).Authenticate(t.Context(), acmeToken); err != nil {
+		t.Fatalf("key revoked from another tenant: %v", err)
+	}
+
+	wantCode(t, svc.Revoke(globex, globexKey.ID), codes.OK)
+	keys, _ = svc.List(globex)
+	if len(keys) != 1 || keys[0].RevokedAt == nil {
+		t.Fatalf("globex keys = %+v", keys)
+	}
+}
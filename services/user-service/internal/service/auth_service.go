@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/cache"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/lockout"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/mfa"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/notify"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/session"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	defaultEventsPageSize = 20
+	maxEventsPageSize     = 100
+)
+
+// Причины отказа во входе (login_events.failure_reason).
+const (
+	FailureInvalidCredentials  = "invalid_credentials"
+	FailureThrottled           = "throttled"
+	FailureLocked              = "locked"
+	FailureInvalidSecondFactor = "invalid_second_factor"
+	FailureInternal            = "internal_error"
+)
+
+type AuthService interface {
+	// Login проверяет email и пароль, записывает попытку в историю входов
+	// и открывает сессию. Если у пользователя включён TOTP, сессии ещё нет:
+	// результат содержит MFAToken для VerifySecondFactor.
+	Login(ctx context.Context, email, password string) (*LoginResult, error)
+	VerifySecondFactor(ctx context.Context, mfaToken, code, recoveryCode string) (*LoginResult, error)
+	// CompleteLogin входит за пользователя, подтвердившего личность другим
+	// способом (method — model.LoginMethod*): как после верного пароля.
+	CompleteLogin(ctx context.Context, user *model.User, method string) (*LoginResult, error)
+	ListSessions(ctx context.Context, userID int64) ([]session.Session, error)
+	RevokeSession(ctx context.Context, userID int64, sessionID string) error
+	// RevokeAllSessions закрывает все сессии пользователя; keepCurrent
+	// оставляет сессию, с которой пришёл запрос.
+	RevokeAllSessions(ctx context.Context, userID int64, keepCurrent bool) (int, error)
+	ListLoginEvents(ctx context.Context, userID int64, pageSize int, pageToken string) ([]model.LoginEvent, string, error)
+	// UnlockUser снимает блокировку входа и сбрасывает счётчик неудач.
+	UnlockUser(ctx context.Context, userID int64) error
+}
+
+// LoginResult — открытая сессия либо, если нужен второй фактор, MFAToken.
+type LoginResult struct {
+	SessionToken string
+	Session      *session.Session
+	MFAToken     string
+}
+
+type authService struct {
+	users    repository.UserRepository
+	events   repository.LoginEventRepository
+	sessions session.Store
+	guard    lockout.Guard
+	notifier notify.Notifier
+	email    EmailNormalizer
+	cfg      config.SessionsConfig
+
+	twoFactor  TwoFactorService
+	challenges mfa.ChallengeStore
+	mfaCfg     config.TwoFactorConfig
+	// admins — ID пользователей из admin.user_ids.
+	admins []string
+	// introspection — кэш ответов Introspect, сбрасывается при отзыве сессий.
+	introspection cache.Tagged
+}
+
+func NewAuthService(users repository.UserRepository, events repository.LoginEventRepository, sessions session.Store,
+	guard lockout.Guard, notifier notify.Notifier, email EmailNormalizer, cfg config.SessionsConfig,
+	twoFactor TwoFactorService, challenges mfa.ChallengeStore, mfaCfg config.TwoFactorConfig, admins []string,
+	introspection cache.Tagged) AuthService {
+	return &authService{users: users, events: events, sessions: sessions, guard: guard, notifier: notifier,
+		email: email, cfg: cfg, twoFactor: twoFactor, challenges: challenges, mfaCfg: mfaCfg, admins: admins,
+		introspection: introspection}
+}
+
+// dummyHash сравнивается с паролем, когда пользователя нет: ответ занимает
+// столько же времени, и по нему не понять, зарегистрирован ли email.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+func (s *authService) Login(ctx context.Context, email, password string) (*LoginResult, error) {
+	email = s.email.Normalize(email)
+	client := auth.ClientInfoFromContext(ctx)
+	event := &model.LoginEvent{Email: email, Method: model.LoginMethodPassword, IP: client.IP, UserAgent: client.UserAgent}
+
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repository.ErrNotFoundUser) {
+		return nil, err
+	}
+
+	hash := dummyHash
+	if user != nil {
+		event.UserID = user.ID
+		hash = []byte(user.PasswordHash)
+	}
+
+	// блокировки ведутся по email, есть такой пользователь или нет: по
+	// ответу нельзя понять, зарегистрирован ли адрес
+	decision, err := s.guard.Check(ctx, email, client.IP)
+	if err != nil {
+		return nil, err
+	}
+	if !decision.Allowed {
+		reason, failure := apperr.ReasonLoginThrottled, FailureThrottled
+		if decision.Locked {
+			reason, failure = apperr.ReasonLoginLocked, FailureLocked
+		}
+		event.FailureReason = failure
+		s.record(ctx, event)
+		logger.Warnf("authService - Login: Attempt for %s from %s rejected (%s) for %v", email, client.IP, failure, decision.RetryAfter)
+		return nil, loginDenied(reason, decision.RetryAfter)
+	}
+
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || user == nil {
+		event.FailureReason = FailureInvalidCredentials
+		s.record(ctx, event)
+		logger.Warnf("authService - Login: Invalid credentials for %s from %s", email, client.IP)
+
+		lockedFor, err := s.guard.Failure(ctx, email, client.IP)
+		if err != nil {
+			logger.Errorf("authService - Login: Failed to count failure for %s: %v", email, err)
+		}
+		if lockedFor > 0 && user != nil {
+			s.notifyLocked(ctx, user, lockedFor)
+		}
+		return nil, apperr.New(codes.Unauthenticated, apperr.ReasonInvalidCredentials, "invalid email or password", nil)
+	}
+	s.release(ctx, email, client.IP)
+
+	return s.completeFirstFactor(ctx, event)
+}
+
+// release освобождает попытку, занятую guard.Check, если она не неудачна.
+func (s *authService) release(ctx context.Context, email, ip string) {
+	if err := s.guard.Release(ctx, email, ip); err != nil {
+		logger.Errorf("authService - Login: Failed to release attempt for %s: %v", email, err)
+	}
+}
+
+func (s *authService) CompleteLogin(ctx context.Context, user *model.User, method string) (*LoginResult, error) {
+	client := auth.ClientInfoFromContext(ctx)
+	return s.completeFirstFactor(ctx, &model.LoginEvent{
+		UserID: user.ID, Email: user.Email, Method: method, IP: client.IP, UserAgent: client.UserAgent,
+	})
+}
+
+// completeFirstFactor открывает сессию пользователю event.UserID либо, если
+// у него включён TOTP, выдаёт токен для VerifySecondFactor.
+func (s *authService) completeFirstFactor(ctx context.Context, event *model.LoginEvent) (*LoginResult, error) {
+	mfaRequired, err := s.twoFactor.Enabled(ctx, event.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if mfaRequired {
+		// счётчик неудач не сбрасываем до второго фактора: иначе знание
+		// пароля давало бы бесконечные попытки подобрать код
+		token, err := s.challenges.Create(ctx, mfa.Challenge{
+			UserID: event.UserID, TenantID: tenant.FromContext(ctx), Email: event.Email, Method: event.Method,
+			IP: event.IP, UserAgent: event.UserAgent,
+		}, s.mfaCfg.ChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		logger.Infof("authService - Login: User %d passed %s check, second factor required", event.UserID, event.Method)
+		return &LoginResult{MFAToken: token}, nil
+	}
+
+	return s.openSession(ctx, event.UserID, event)
+}
+
+// openSession завершает успешный вход: сбрасывает счётчик неудач, открывает
+// сессию и записывает событие.
+func (s *authService) openSession(ctx context.Context, userID int64, event *model.LoginEvent) (*LoginResult, error) {
+	if err := s.guard.Success(ctx, event.Email); err != nil {
+		logger.Errorf("authService - Login: Failed to reset failures for %s: %v", event.Email, err)
+	}
+
+	token, sess, err := s.sessions.Create(ctx, tenant.FromContext(ctx), userID, event.IP, event.UserAgent, s.cfg.TTL)
+	if err != nil {
+		event.FailureReason = FailureInternal
+		s.record(ctx, event)
+		return nil, err
+	}
+
+	event.Success = true
+	s.record(ctx, event)
+	logger.Infof("authService - Login: User %d signed in from %s", userID, event.IP)
+	return &LoginResult{SessionToken: token, Session: sess}, nil
+}
+
+func (s *authService) VerifySecondFactor(ctx context.Context, mfaToken, code, recoveryCode string) (*LoginResult, error) {
+	challenge, err := s.challenges.Get(ctx, mfaToken)
+	if errors.Is(err, mfa.ErrChallengeNotFound) {
+		return nil, apperr.New(codes.Unauthenticated, apperr.ReasonMFAChallengeExpired, "mfa token is invalid or expired", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// код вводится в тенанте, где был введён пароль, какой бы ни пришёл в запросе
+	ctx = tenant.WithID(ctx, challenge.TenantID)
+
+	// событие пишем с адресом того, кто вводит код
+	client := auth.ClientInfoFromContext(ctx)
+	event := &model.LoginEvent{UserID: challenge.UserID, Email: challenge.Email, Method: challenge.Method,
+		IP: client.IP, UserAgent: client.UserAgent}
+
+	decision, err := s.guard.Check(ctx, challenge.Email, client.IP)
+	if err != nil {
+		return nil, err
+	}
+	if !decision.Allowed {
+		reason, failure := apperr.ReasonLoginThrottled, FailureThrottled
+		if decision.Locked {
+			reason, failure = apperr.ReasonLoginLocked, FailureLocked
+		}
+		event.FailureReason = failure
+		s.record(ctx, event)
+		return nil, loginDenied(reason, decision.RetryAfter)
+	}
+
+	ok, err := s.twoFactor.Verify(ctx, challenge.UserID, code, recoveryCode)
+	if err != nil {
+		s.release(ctx, challenge.Email, client.IP)
+		return nil, err
+	}
+	if !ok {
+		event.FailureReason = FailureInvalidSecondFactor
+		s.record(ctx, event)
+		logger.Warnf("authService - VerifySecondFactor: Invalid code for user %d from %s", challenge.UserID, client.IP)
+
+		if _, err := s.challenges.Fail(ctx, mfaToken, s.mfaCfg.MaxAttempts); err != nil {
+			logger.Errorf("authService - VerifySecondFactor: Failed to count attempt: %v", err)
+		}
+		lockedFor, err := s.guard.Failure(ctx, challenge.Email, client.IP)
+		if err != nil {
+			logger.Errorf("authService - VerifySecondFactor: Failed to count failure for %s: %v", challenge.Email, err)
+		}
+		if lockedFor > 0 {
+			s.notifyLocked(ctx, &model.User{ID: challenge.UserID, Email: challenge.Email}, lockedFor)
+		}
+		return nil, errInvalidCode(codes.Unauthenticated)
+	}
+	s.release(ctx, challenge.Email, client.IP)
+
+	if err := s.challenges.Delete(ctx, mfaToken); err != nil {
+		logger.Errorf("authService - VerifySecondFactor: Failed to delete challenge: %v", err)
+	}
+	return s.openSession(ctx, challenge.UserID, event)
+}
+
+func loginDenied(reason string, retryAfter time.Duration) error {
+	seconds := strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds()))))
+	return apperr.New(codes.ResourceExhausted, reason, "too many failed login attempts, retry in "+seconds+"s",
+		map[string]string{"retry_after": seconds},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+}
+
+func (s *authService) notifyLocked(ctx context.Context, user *model.User, lockedFor time.Duration) {
+	logger.Warnf("authService - Login: User %d locked out for %v", user.ID, lockedFor)
+	now := time.Now().UTC()
+	err := s.notifier.Notify(context.WithoutCancel(ctx), notify.Notification{
+		Kind:      notify.KindAccountLocked,
+		UserID:    user.ID,
+		Email:     user.Email,
+		CreatedAt: now,
+		Data:      map[string]string{"locked_until": now.Add(lockedFor).Format(time.RFC3339)},
+	})
+	if err != nil {
+		logger.Errorf("authService - Login: Failed to notify user %d about lockout: %v", user.ID, err)
+	}
+}
+
+// record сохраняет попытку входа. Сбой записи не мешает входу, но
+// попадает в лог.
+func (s *authService) record(ctx context.Context, event *model.LoginEvent) {
+	if err := s.events.Record(context.WithoutCancel(ctx), event); err != nil {
+		logger.Errorf("authService - Login: Failed to record login event for %s: %v", event.Email, err)
+	}
+}
+
+func (s *authService) ListSessions(ctx context.Context, userID int64) ([]session.Session, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.sessions.List(ctx, userID)
+}
+
+func (s *authService) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return err
+	}
+
+	err := s.sessions.Revoke(ctx, userID, sessionID)
+	if errors.Is(err, session.ErrNotFound) {
+		return apperr.New(codes.NotFound, apperr.ReasonSessionNotFound, "session not found",
+			map[string]string{"session_id": sessionID})
+	}
+	if err != nil {
+		return err
+	}
+	invalidateIntrospection(ctx, s.introspection, sessionTag(sessionID))
+
+	logger.Infof("authService - RevokeSession: Session %s of user %d revoked", sessionID, userID)
+	return nil
+}
+
+func (s *authService) RevokeAllSessions(ctx context.Context, userID int64, keepCurrent bool) (int, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return 0, err
+	}
+
+	var except string
+	if p, ok := auth.FromContext(ctx); ok && keepCurrent {
+		except = p.SessionID
+	}
+	n, err := s.sessions.RevokeAll(ctx, userID, except)
+	if err != nil {
+		return 0, err
+	}
+	invalidateIntrospection(ctx, s.introspection, userTag(userID))
+
+	logger.Infof("authService - RevokeAllSessions: %d sessions of user %d revoked", n, userID)
+	return n, nil
+}
+
+func (s *authService) ListLoginEvents(ctx context.Context, userID int64, pageSize int, pageToken string) ([]model.LoginEvent, string, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, "", err
+	}
+	if pageSize <= 0 {
+		pageSize = defaultEventsPageSize
+	}
+	pageSize = min(pageSize, maxEventsPageSize)
+
+	var beforeID int64
+	if pageToken != "" {
+		id, err := decodeEventsToken(pageToken)
+		if err != nil {
+			return nil, "", apperr.InvalidFields(apperr.FieldViolation("page_token", "page token is invalid"))
+		}
+		beforeID = id
+	}
+
+	events, err := s.events.ListByUser(ctx, userID, beforeID, pageSize+1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(events) > pageSize {
+		events = events[:pageSize]
+		next = base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(events[len(events)-1].ID, 10)))
+	}
+	return events, next, nil
+}
+
+func decodeEventsToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid page token")
+	}
+	return id, nil
+}
+
+func (s *authService) UnlockUser(ctx context.Context, userID int64) error {
+	if err := authorizeAdmin(ctx, s.admins); err != nil {
+		return err
+	}
+
+	user, err := s.users.GetUserByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFoundUser) {
+		return apperr.New(codes.NotFound, apperr.ReasonUserNotFound,
+			fmt.Sprintf("user with id %d not found", userID), map[string]string{"id": strconv.FormatInt(userID, 10)})
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.guard.Unlock(ctx, user.Email); err != nil {
+		return err
+	}
+	logger.Infof("authService - UnlockUser: User %d unlocked", userID)
+	return nil
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strconv"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"google.golang.org/grpc/codes"
+)
+
+// authorizeUser пропускает запрос к данным пользователя userID от него
+// самого, от другого сервиса (mTLS) или по API ключу с доступом к методу.
+func authorizeUser(ctx context.Context, userID int64) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return apperr.New(codes.Unauthenticated, apperr.ReasonUnauthenticated, "authentication required", nil)
+	}
+	switch {
+	case p.Type == auth.PrincipalService || p.Type == auth.PrincipalAPIKey:
+		return nil
+	case p.Type == auth.PrincipalUser && p.ID == strconv.FormatInt(userID, 10):
+		return nil
+	}
+	return apperr.New(codes.PermissionDenied, apperr.ReasonPermissionDenied, "access to another user's data is denied", nil)
+}
+
+// authorizeAdmin пропускает администраторов из admin.user_ids, сервисы
+// (mTLS) и API ключи с доступом к методу.
+func authorizeAdmin(ctx context.Context, admins []string) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return apperr.New(codes.Unauthenticated, apperr.ReasonUnauthenticated, "authentication required", nil)
+	}
+	if p.Type == auth.PrincipalService || p.Type == auth.PrincipalAPIKey ||
+		(p.Type == auth.PrincipalUser && slices.Contains(admins, p.ID)) {
+		return nil
+	}
+	return apperr.New(codes.PermissionDenied, apperr.ReasonPermissionDenied, "administrator rights required", nil)
+}
+
+// authorizeUserOrAdmin — authorizeUser, но пропускает и администраторов.
+func authorizeUserOrAdmin(ctx context.Context, userID int64, admins []string) error {
+	err := authorizeUser(ctx, userID)
+	if err != nil && authorizeAdmin(ctx, admins) == nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/avatar"
+	"github.com/DmitriiPro/user-service/internal/blob"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"google.golang.org/grpc/codes"
+)
+
+type AvatarService interface {
+	// Upload сохраняет миниатюры изображения и прописывает avatar_url в
+	// профиль; доступно самому пользователю и администраторам.
+	Upload(ctx context.Context, userID int64, data []byte) (*model.User, error)
+	// Open открывает миниатюру размера size (один из avatar.Sizes).
+	Open(ctx context.Context, userID int64, size int) (*blob.Object, error)
+}
+
+type avatarService struct {
+	users     UserService
+	store     blob.Store
+	maxPixels int
+	admins    []string
+}
+
+func NewAvatarService(users UserService, store blob.Store, maxPixels int, admins []string) AvatarService {
+	return &avatarService{users: users, store: store, maxPixels: maxPixels, admins: admins}
+}
+
+func avatarKey(userID int64, size int) string {
+	return fmt.Sprintf("avatars/%d/%d", userID, size)
+}
+
+func (s *avatarService) Upload(ctx context.Context, userID int64, data []byte) (*model.User, error) {
+	if err := authorizeUserOrAdmin(ctx, userID, s.admins); err != nil {
+		return nil, err
+	}
+	// 404 до того, как тратить CPU на декодирование
+	if _, err := s.users.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	thumbs, err := avatar.Process(data, s.maxPixels)
+	if errors.Is(err, avatar.ErrUnsupportedType) || errors.Is(err, avatar.ErrTooLarge) {
+		logger.Warnf("avatarService - Upload: Rejected image for ID %d: %v", userID, err)
+		return nil, apperr.InvalidFields(apperr.FieldViolation("file", err.Error()))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	for _, t := range thumbs {
+		if err := s.store.Put(ctx, avatarKey(userID, t.Size), t.Data, t.ContentType); err != nil {
+			logger.Errorf("avatarService - Upload: Error storing %dpx avatar for ID %d: %v", t.Size, userID, err)
+			return nil, err
+		}
+		hash.Write(t.Data)
+	}
+
+	// версия в URL меняется вместе с картинкой, поэтому ответ по такому
+	// URL можно кэшировать надолго
+	url := fmt.Sprintf("/v1/users/%d/avatar?v=%s", userID, hex.EncodeToString(hash.Sum(nil))[:16])
+	logger.Infof("avatarService - Upload: Avatar stored for ID %d", userID)
+	return s.users.UpdateProfile(ctx, userID, model.ProfileUpdate{AvatarURL: &url})
+}
+
+func (s *avatarService) Open(ctx context.Context, userID int64, size int) (*blob.Object, error) {
+	if !slices.Contains(avatar.Sizes, size) {
+		return nil, apperr.InvalidFields(apperr.FieldViolation("size",
+			fmt.Sprintf("size must be one of %v", avatar.Sizes)))
+	}
+
+	obj, err := s.store.Get(ctx, avatarKey(userID, size))
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, apperr.New(codes.NotFound, apperr.ReasonAvatarNotFound,
+			fmt.Sprintf("user with id %d has no avatar", userID), map[string]string{"id": strconv.FormatInt(userID, 10)})
+	}
+	return obj, err
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/avatar"
+	"github.com/DmitriiPro/user-service/internal/blob"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"google.golang.org/grpc/codes"
+)
+
+type s3Object struct {
+	data        []byte
+	contentType string
+}
+
+// s3Stub — S3 с path-style адресами: проверяет, что запрос подписан и
+// хэш тела совпадает с x-amz-content-sha256, и хранит объекты в памяти.
+type s3Stub struct {
+	mu      sync.Mutex
+	objects map[string]s3Object
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[r.URL.Path] = s3Object{data: body, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		obj, ok := s.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Write(obj.data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *s3Stub) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func newAvatarTestService(t *testing.T, maxPixels int) (AvatarService, *s3Stub, *fakeUsers) {
+	t.Helper()
+	stub := &s3Stub{objects: make(map[string]s3Object)}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	store := blob.NewS3Store(config.S3Config{Endpoint: srv.URL, Region: "eu-central-1", Bucket: "media",
+		AccessKeyID: "AKID", SecretAccessKey: "secret", PathStyle: true, Timeout: time.Second})
+	repo := newFakeUsers(&model.User{ID: 1, Email: "a@example.com"}, &model.User{ID: 2, Email: "b@example.com"})
+	users := NewUserService(repo, newMemCache(), EmailNormalizer{}, testAdmins)
+	return NewAvatarService(users, store, maxPixels, testAdmins), stub, repo
+}
+
+// testImage — PNG w×h; с transparent часть пикселей полупрозрачна.
+func testImage(t *testing.T, w, h int, transparent bool) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := range w {
+		for y := range h {
+			c := color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255}
+			if transparent && x < w/2 {
+				c.A = 128
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAvatarServiceUploadStoresThumbnails(t *testing.T) {
+	tests := []struct {
+		name        string
+		transparent bool
+		contentType string
+	}{
+		{"opaque", false, "image/jpeg"},
+		{"transparent", true, "image/png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, stub, _ := newAvatarTestService(t, 1_000_000)
+
+			user, err := svc.Upload(asUser(1).ctx(), 1, testImage(t, 300, 200, tt.transparent))
+			wantCode(t, err, codes.OK)
+			if !strings.HasPrefix(user.AvatarURL, "/v1/users/1/avatar?v=") {
+				t.Fatalf("avatar_url = %q", user.AvatarURL)
+			}
+
+			if got := len(stub.keys()); got != len(avatar.Sizes) {
+				t.Fatalf("stored keys = %v, want one per size %v", stub.keys(), avatar.Sizes)
+			}
+			for _, size := range avatar.Sizes {
+				obj, err := svc.Open(t.Context(), 1, size)
+				if err != nil {
+					t.Fatalf("Open(%d): %v", size, err)
+				}
+				if obj.ContentType != tt.contentType {
+					t.Errorf("%dpx content type = %q, want %q", size, obj.ContentType, tt.contentType)
+				}
+				cfg, _, err := image.DecodeConfig(obj.Body)
+				obj.Body.Close()
+				if err != nil {
+					t.Fatalf("decode %dpx thumbnail: %v", size, err)
+				}
+				if cfg.Width != size || cfg.Height != size {
+					t.Errorf("%dpx thumbnail is %dx%d", size, cfg.Width, cfg.Height)
+				}
+			}
+			for _, key := range []string{"/media/avatars/1/64", "/media/avatars/1/256", "/media/avatars/1/512"} {
+				if !slices.Contains(stub.keys(), key) {
+					t.Errorf("object %s is not stored, have %v", key, stub.keys())
+				}
+			}
+		})
+	}
+}
+
+func TestAvatarServiceUploadRejects(t *testing.T) {
+	img := testImage(t, 300, 200, false)
+	tests := []struct {
+		name      string
+		caller    caller
+		userID    int64
+		data      []byte
+		maxPixels int
+		want      codes.Code
+	}{
+		{"anonymous", anonymous, 1, img, 1_000_000, codes.Unauthenticated},
+		{"another user", asUser(2), 1, img, 1_000_000, codes.PermissionDenied},
+		{"missing user", asAdmin, 42, img, 1_000_000, codes.NotFound},
+		{"too many pixels", asUser(1), 1, img, 300*200 - 1, codes.InvalidArgument},
+		{"not an image", asUser(1), 1, []byte("GIF89a but not really an image"), 1_000_000, codes.InvalidArgument},
+		{"admin", asAdmin, 1, img, 1_000_000, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, stub, repo := newAvatarTestService(t, tt.maxPixels)
+
+			_, err := svc.Upload(tt.caller.ctx(), tt.userID, tt.data)
+			wantCode(t, err, tt.want)
+			if tt.want == codes.OK {
+				return
+			}
+			if keys := stub.keys(); len(keys) != 0 {
+				t.Errorf("rejected upload stored %v", keys)
+			}
+			if u, _ := repo.GetUserByID(t.Context(), 1); u.AvatarURL != "" {
+				t.Errorf("rejected upload set avatar_url %q", u.AvatarURL)
+			}
+		})
+	}
+}
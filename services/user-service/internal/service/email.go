@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+)
+
+// EmailNormalizer приводит email к виду, в котором он хранится и
+// сравнивается: без пробелов по краям и с доменом в нижнем регистре.
+// С ProviderRules дополнительно учитываются правила почтовых сервисов,
+// для которых разные написания адреса ведут в один ящик. Сохранённые
+// адреса должны быть нормализованы теми же правилами, что и входящие, —
+// см. ApplyEmailNormalization.
+type EmailNormalizer struct {
+	ProviderRules bool
+}
+
+type providerRule struct {
+	domain     string // канонический домен
+	removeDots bool   // точки в имени не значимы
+	plusTags   bool   // всё после "+" — метка, а не часть имени
+}
+
+var providerRules = map[string]providerRule{
+	"gmail.com":      {domain: "gmail.com", removeDots: true, plusTags: true},
+	"googlemail.com": {domain: "gmail.com", removeDots: true, plusTags: true},
+	"outlook.com":    {domain: "outlook.com", plusTags: true},
+	"hotmail.com":    {domain: "hotmail.com", plusTags: true},
+	"live.com":       {domain: "live.com", plusTags: true},
+	"yandex.ru":      {domain: "yandex.ru", plusTags: true},
+	"yandex.com":     {domain: "yandex.ru", plusTags: true},
+	"yandex.by":      {domain: "yandex.ru", plusTags: true},
+	"yandex.kz":      {domain: "yandex.ru", plusTags: true},
+	"ya.ru":          {domain: "yandex.ru", plusTags: true},
+}
+
+func (n EmailNormalizer) Normalize(email string) string {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+	local, domain := email[:at], strings.ToLower(email[at+1:])
+
+	if n.ProviderRules {
+		if rule, ok := providerRules[domain]; ok {
+			local = strings.ToLower(local)
+			if rule.plusTags {
+				local, _, _ = strings.Cut(local, "+")
+			}
+			if rule.removeDots {
+				local = strings.ReplaceAll(local, ".", "")
+			}
+			domain = rule.domain
+		}
+	}
+
+	return local + "@" + domain
+}
+
+var (
+	ErrEmailCollision         = errors.New("email addresses collide after normalization")
+	ErrEmailRulesIrreversible = errors.New("email provider rules cannot be disabled once users exist")
+)
+
+// ApplyEmailNormalization приводит email в БД к правилам n; вызывается при
+// старте до приёма запросов. Включение ProviderRules пересчитывает
+// сохранённые адреса. Если после пересчёта два пользователя тенанта
+// получают один адрес, ничего не меняется и возвращается ErrEmailCollision
+// со списком таких пользователей — их нужно развести вручную. Выключить
+// правила на базе с пользователями нельзя: исходное написание адресов уже
+// потеряно, и пользователи не смогли бы войти (ErrEmailRulesIrreversible).
+func ApplyEmailNormalization(ctx context.Context, repo repository.EmailNormalizationRepository, n EmailNormalizer) error {
+	return repo.Apply(ctx, n.ProviderRules, n.renormalize)
+}
+
+// renormalize — repository.EmailPlan для правил n.
+func (n EmailNormalizer) renormalize(applied bool, emails []model.UserEmail) ([]model.UserEmail, error) {
+	if applied && !n.ProviderRules {
+		if len(emails) > 0 {
+			return nil, ErrEmailRulesIrreversible
+		}
+		return nil, nil
+	}
+
+	type address struct{ tenant, email string }
+	owners := make(map[address][]int64, len(emails))
+	var changes []model.UserEmail
+	for _, e := range emails {
+		normalized := n.Normalize(e.Email)
+		if normalized != e.Email {
+			changes = append(changes, model.UserEmail{ID: e.ID, TenantID: e.TenantID, Email: normalized})
+		}
+		// уникальность в БД — по lower(email) в пределах тенанта
+		key := address{e.TenantID, strings.ToLower(normalized)}
+		owners[key] = append(owners[key], e.ID)
+	}
+
+	var collisions []string
+	for addr, ids := range owners {
+		if len(ids) > 1 {
+			collisions = append(collisions, fmt.Sprintf("tenant %s: %s (users %v)", addr.tenant, addr.email, ids))
+		}
+	}
+	if len(collisions) > 0 {
+		slices.Sort(collisions)
+		return nil, fmt.Errorf("%w: %s", ErrEmailCollision, strings.Join(collisions, "; "))
+	}
+	return changes, nil
+}
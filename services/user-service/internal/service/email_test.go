@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+)
+
+func TestEmailNormalizer(t *testing.T) {
+	tests := []struct {
+		in               string
+		basic, withRules string
+	}{
+		{" John.Doe@Example.COM ", "John.Doe@example.com", "John.Doe@example.com"},
+		{"J.Doe+news@GMail.com", "J.Doe+news@gmail.com", "jdoe@gmail.com"},
+		{"j.doe@googlemail.com", "j.doe@googlemail.com", "jdoe@gmail.com"},
+		{"first.last+tag@outlook.com", "first.last+tag@outlook.com", "first.last@outlook.com"},
+		{"User+x@ya.ru", "User+x@ya.ru", "user@yandex.ru"},
+		{"not-an-email", "not-an-email", "not-an-email"},
+	}
+	for _, tt := range tests {
+		if got := (EmailNormalizer{}).Normalize(tt.in); got != tt.basic {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.basic)
+		}
+		got := EmailNormalizer{ProviderRules: true}.Normalize(tt.in)
+		if got != tt.withRules {
+			t.Errorf("with provider rules Normalize(%q) = %q, want %q", tt.in, got, tt.withRules)
+		}
+		// нормализованный адрес не меняется — пересчёт можно повторять
+		if again := (EmailNormalizer{ProviderRules: true}).Normalize(got); again != got {
+			t.Errorf("Normalize is not idempotent: %q -> %q", got, again)
+		}
+	}
+}
+
+// emailRules — EmailNormalizationRepository в памяти.
+type emailRules struct {
+	stored  *bool
+	emails  []model.UserEmail
+	applied int
+}
+
+func (r *emailRules) Apply(_ context.Context, providerRules bool, plan repository.EmailPlan) error {
+	if r.stored != nil && *r.stored == providerRules {
+		return nil
+	}
+	changes, err := plan(r.stored != nil && *r.stored, slices.Clone(r.emails))
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		for i := range r.emails {
+			if r.emails[i].ID == c.ID {
+				r.emails[i].Email = c.Email
+			}
+		}
+	}
+	r.stored, r.applied = &providerRules, r.applied+1
+	return nil
+}
+
+func TestApplyEmailNormalization(t *testing.T) {
+	on, off := true, false
+	users := func(emails ...string) []model.UserEmail {
+		out := make([]model.UserEmail, len(emails))
+		for i, e := range emails {
+			tenantID, email, _ := strings.Cut(e, "/")
+			out[i] = model.UserEmail{ID: int64(i + 1), TenantID: tenantID, Email: email}
+		}
+		return out
+	}
+	tests := []struct {
+		name       string
+		stored     *bool
+		rules      bool
+		emails     []model.UserEmail
+		wantErr    error
+		wantErrMsg string
+		want       []string
+	}{
+		{name: "first start without rules", rules: false,
+			emails: users("default/J.Doe@gmail.com"), want: []string{"J.Doe@gmail.com"}},
+		{name: "enable rules", stored: &off, rules: true,
+			emails: users("default/J.Doe+x@gmail.com", "default/alice@example.com"),
+			want:   []string{"jdoe@gmail.com", "alice@example.com"}},
+		{name: "enable on first start", rules: true,
+			emails: users("default/J.Doe@googlemail.com"), want: []string{"jdoe@gmail.com"}},
+		{name: "collision", stored: &off, rules: true,
+			emails:  users("default/jdoe@gmail.com", "default/j.doe+work@gmail.com", "default/alice@example.com"),
+			wantErr: ErrEmailCollision, wantErrMsg: "tenant default: jdoe@gmail.com (users [1 2])",
+			want: []string{"jdoe@gmail.com", "j.doe+work@gmail.com", "alice@example.com"}},
+		{name: "same address in different tenants", stored: &off, rules: true,
+			emails: users("acme/jdoe@gmail.com", "globex/j.doe@gmail.com"),
+			want:   []string{"jdoe@gmail.com", "jdoe@gmail.com"}},
+		{name: "collision differs only in case", stored: &off, rules: true,
+			emails:  users("default/JDoe@example.com", "default/jdoe@EXAMPLE.com "),
+			wantErr: ErrEmailCollision},
+		{name: "disable with users", stored: &on, rules: false,
+			emails: users("default/jdoe@gmail.com"), wantErr: ErrEmailRulesIrreversible, want: []string{"jdoe@gmail.com"}},
+		{name: "disable on empty database", stored: &on, rules: false},
+		{name: "rules unchanged", stored: &on, rules: true,
+			emails: users("default/J.Doe@gmail.com"), want: []string{"J.Doe@gmail.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &emailRules{stored: tt.stored, emails: tt.emails}
+			err := ApplyEmailNormalization(t.Context(), repo, EmailNormalizer{ProviderRules: tt.rules})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErrMsg != "" && !strings.Contains(err.Error(), tt.wantErrMsg) {
+				t.Fatalf("err = %v, want it to name %q", err, tt.wantErrMsg)
+			}
+			if err != nil && repo.applied != 0 {
+				t.Fatal("failed normalization recorded new rules")
+			}
+			if tt.want == nil {
+				return
+			}
+			var got []string
+			for _, e := range repo.emails {
+				got = append(got, e.Email)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("emails = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
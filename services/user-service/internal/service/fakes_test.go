@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Общие заглушки для тестов пакета: контексты вызывающих, кэш в памяти и
+// репозиторий пользователей в памяти.
+
+const testAdminID = "900"
+
+var testAdmins = []string{testAdminID}
+
+// caller — вызывающий в табличных тестах; nil principal — анонимный запрос.
+type caller struct {
+	name      string
+	principal *auth.Principal
+}
+
+func (c caller) ctx() context.Context {
+	if c.principal == nil {
+		return context.Background()
+	}
+	return auth.WithPrincipal(context.Background(), c.principal)
+}
+
+func asUser(id int64) caller {
+	return caller{"user " + strconv.FormatInt(id, 10), &auth.Principal{Type: auth.PrincipalUser, ID: strconv.FormatInt(id, 10)}}
+}
+
+var (
+	anonymous = caller{name: "anonymous"}
+	asAdmin   = caller{"admin", &auth.Principal{Type: auth.PrincipalUser, ID: testAdminID}}
+	asService = caller{"service", &auth.Principal{Type: auth.PrincipalService, ID: "spiffe://test/billing"}}
+	asAPIKey  = caller{"api key", &auth.Principal{Type: auth.PrincipalAPIKey, ID: "key1"}}
+)
+
+// wantCode проверяет gRPC код ошибки; codes.OK — ошибки быть не должно.
+func wantCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("code = %v (%v), want %v", got, err, want)
+	}
+}
+
+// wantReason проверяет код и причину ошибки apperr.
+func wantReason(t *testing.T, err error, code codes.Code, reason string) {
+	t.Helper()
+	wantCode(t, err, code)
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.Reason == reason {
+			return
+		}
+	}
+	t.Fatalf("error %v has no reason %s", err, reason)
+}
+
+type memCache struct {
+	mu   sync.Mutex
+	data map[string]string
+	// tags — ключи записей по тегу SetTagged.
+	tags map[string][]string
+}
+
+func newMemCache() *memCache {
+	return &memCache{data: make(map[string]string), tags: make(map[string][]string)}
+}
+
+func (c *memCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.data[key]
+	if !ok {
+		return "", errors.New("cache miss")
+	}
+	return v, nil
+}
+
+func (c *memCache) Set(_ context.Context, key, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = value
+	return nil
+}
+
+func (c *memCache) Del(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+func (c *memCache) SetTagged(_ context.Context, key, value string, _ time.Duration, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = value
+	for _, tag := range tags {
+		c.tags[tag] = append(c.tags[tag], key)
+	}
+	return nil
+}
+
+func (c *memCache) Invalidate(_ context.Context, tag string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range c.tags[tag] {
+		delete(c.data, key)
+	}
+	delete(c.tags, tag)
+	return nil
+}
+
+func (c *memCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
+}
+
+type fakeUsers struct {
+	mu    sync.Mutex
+	users map[int64]*model.User
+}
+
+func newFakeUsers(users ...*model.User) *fakeUsers {
+	r := &fakeUsers{users: make(map[int64]*model.User)}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *fakeUsers) CreateUser(_ context.Context, email, hash string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if strings.EqualFold(u.Email, email) {
+			return nil, repository.ErrUserExists
+		}
+	}
+	u := &model.User{ID: int64(len(r.users) + 1), Email: email, PasswordHash: hash, CreatedAt: time.Now()}
+	r.users[u.ID] = u
+	return u, nil
+}
+
+func (r *fakeUsers) GetUserByID(_ context.Context, id int64) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFoundUser
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (r *fakeUsers) GetUserByEmail(_ context.Context, email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if strings.EqualFold(u.Email, email) {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, repository.ErrNotFoundUser
+}
+
+func (r *fakeUsers) UpdateProfile(_ context.Context, id int64, upd model.ProfileUpdate) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFoundUser
+	}
+	if upd.DisplayName != nil {
+		u.DisplayName = *upd.DisplayName
+	}
+	if upd.AvatarURL != nil {
+		u.AvatarURL = *upd.AvatarURL
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (r *fakeUsers) SearchUsers(_ context.Context, query string, _ *model.SearchCursor, limit int, _ time.Duration) ([]model.SearchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.SearchResult
+	for _, u := range r.users {
+		if len(out) < limit && strings.Contains(strings.ToLower(u.Email), strings.ToLower(query)) {
+			cp := *u
+			out = append(out, model.SearchResult{User: &cp, Rank: 1})
+		}
+	}
+	return out, nil
+}
+
+// fakeAPIKeys — APIKeyRepository в памяти; как и Postgres, List и Revoke
+// видят только ключи тенанта из контекста.
+type fakeAPIKeys struct {
+	mu   sync.Mutex
+	keys map[string]*model.APIKey
+}
+
+func newFakeAPIKeys() *fakeAPIKeys {
+	return &fakeAPIKeys{keys: make(map[string]*model.APIKey)}
+}
+
+func (r *fakeAPIKeys) Create(_ context.Context, key *model.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *key
+	cp.CreatedAt = time.Now()
+	r.keys[key.ID] = &cp
+	return nil
+}
+
+func (r *fakeAPIKeys) Get(_ context.Context, id string) (*model.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[id]
+	if !ok {
+		return nil, repository.ErrAPIKeyNotFound
+	}
+	cp := *k
+	return &cp, nil
+}
+
+func (r *fakeAPIKeys) List(ctx context.Context) ([]model.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]model.APIKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		if k.TenantID == tenant.FromContext(ctx) {
+			out = append(out, *k)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeAPIKeys) Revoke(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[id]
+	if !ok || k.TenantID != tenant.FromContext(ctx) {
+		return repository.ErrAPIKeyNotFound
+	}
+	if k.RevokedAt == nil {
+		now := time.Now()
+		k.RevokedAt = &now
+	}
+	return nil
+}
+
+func (r *fakeAPIKeys) Touch(context.Context, string, time.Duration) error { return nil }
+
+// fakeOrgs — OrganizationRepository в памяти; как и Postgres, не даёт
+// остаться организации без владельцев.
+type fakeOrgs struct {
+	mu      sync.Mutex
+	orgs    map[int64]*model.Organization
+	members map[int64]map[int64]model.Role
+}
+
+func newFakeOrgs() *fakeOrgs {
+	return &fakeOrgs{orgs: make(map[int64]*model.Organization), members: make(map[int64]map[int64]model.Role)}
+}
+
+// add создаёт организацию id с участниками members.
+func (r *fakeOrgs) add(id int64, name string, members map[int64]model.Role) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orgs[id] = &model.Organization{ID: id, TenantID: "default", Name: name}
+	r.members[id] = members
+}
+
+func (r *fakeOrgs) role(orgID, userID int64) (model.Role, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	role, ok := r.members[orgID][userID]
+	return role, ok
+}
+
+func (r *fakeOrgs) Create(_ context.Context, org *model.Organization, ownerID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	org.ID = int64(len(r.orgs) + 1)
+	org.TenantID = "default"
+	cp := *org
+	r.orgs[org.ID] = &cp
+	r.members[org.ID] = map[int64]model.Role{ownerID: model.RoleOwner}
+	return nil
+}
+
+func (r *fakeOrgs) Get(_ context.Context, id int64) (*model.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	org, ok := r.orgs[id]
+	if !ok {
+		return nil, repository.ErrOrganizationNotFound
+	}
+	cp := *org
+	return &cp, nil
+}
+
+func (r *fakeOrgs) Update(ctx context.Context, id int64, name string) (*model.Organization, error) {
+	r.mu.Lock()
+	if org, ok := r.orgs[id]; ok {
+		org.Name = name
+	}
+	r.mu.Unlock()
+	return r.Get(ctx, id)
+}
+
+func (r *fakeOrgs) Delete(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.orgs[id]; !ok {
+		return repository.ErrOrganizationNotFound
+	}
+	delete(r.orgs, id)
+	delete(r.members, id)
+	return nil
+}
+
+func (r *fakeOrgs) ListByUser(_ context.Context, userID int64) ([]model.UserOrganization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.UserOrganization
+	for id, members := range r.members {
+		if role, ok := members[userID]; ok {
+			out = append(out, model.UserOrganization{Organization: *r.orgs[id], Role: role})
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeOrgs) GetMember(_ context.Context, orgID, userID int64) (*model.Membership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	role, ok := r.members[orgID][userID]
+	if !ok {
+		return nil, repository.ErrMemberNotFound
+	}
+	return &model.Membership{OrganizationID: orgID, UserID: userID, Role: role}, nil
+}
+
+func (r *fakeOrgs) ListMembers(_ context.Context, orgID int64) ([]model.Membership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.Membership
+	for userID, role := range r.members[orgID] {
+		out = append(out, model.Membership{OrganizationID: orgID, UserID: userID, Role: role})
+	}
+	return out, nil
+}
+
+// lastOwner — userID единственный владелец организации.
+func (r *fakeOrgs) lastOwner(orgID, userID int64) bool {
+	if r.members[orgID][userID] != model.RoleOwner {
+		return false
+	}
+	for id, role := range r.members[orgID] {
+		if id != userID && role == model.RoleOwner {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *fakeOrgs) SetMemberRole(_ context.Context, orgID, userID int64, role model.Role) (*model.Membership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.members[orgID][userID]; !ok {
+		return nil, repository.ErrMemberNotFound
+	}
+	if role != model.RoleOwner && r.lastOwner(orgID, userID) {
+		return nil, repository.ErrLastOwner
+	}
+	r.members[orgID][userID] = role
+	return &model.Membership{OrganizationID: orgID, UserID: userID, Role: role}, nil
+}
+
+func (r *fakeOrgs) RemoveMember(_ context.Context, orgID, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.members[orgID][userID]; !ok {
+		return repository.ErrMemberNotFound
+	}
+	if r.lastOwner(orgID, userID) {
+		return repository.ErrLastOwner
+	}
+	delete(r.members[orgID], userID)
+	return nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apikey"
+	"github.com/DmitriiPro/user-service/internal/cache"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/oidc"
+	"github.com/DmitriiPro/user-service/internal/session"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+)
+
+// Типы токенов в ответе Introspect.
+const (
+	TokenTypeSession     = "session"
+	TokenTypeAccessToken = "access_token"
+	TokenTypeAPIKey      = "api_key"
+)
+
+// Introspection — ответ на проверку токена (RFC 7662). У недействительного
+// токена заполнено только Active=false; ExpiresAt пуст у бессрочного ключа.
+type Introspection struct {
+	Active    bool      `json:"active"`
+	TokenType string    `json:"token_type,omitempty"`
+	Subject   string    `json:"sub,omitempty"`
+	Scope     string    `json:"scope,omitempty"`
+	ClientID  string    `json:"client_id,omitempty"`
+	SessionID string    `json:"sid,omitempty"`
+	TenantID  string    `json:"tid,omitempty"`
+	IssuedAt  time.Time `json:"iat,omitzero"`
+	ExpiresAt time.Time `json:"exp,omitzero"`
+}
+
+func (i *Introspection) activeAt(now time.Time) bool {
+	return i.Active && (i.ExpiresAt.IsZero() || now.Before(i.ExpiresAt))
+}
+
+// IntrospectionService отвечает другим сервисам, действителен ли токен
+// сессии, OIDC access токен или API ключ.
+type IntrospectionService interface {
+	Introspect(ctx context.Context, token string) (*Introspection, error)
+}
+
+type introspectionService struct {
+	sessions session.Store
+	apiKeys  apikey.Authenticator
+	oidc     OIDCService
+	// cache хранит только действующие токены; отзыв сессии или ключа
+	// удаляет их по тегу.
+	cache  cache.Tagged
+	admins []string
+}
+
+func NewIntrospectionService(sessions session.Store, apiKeys apikey.Authenticator, oidc OIDCService,
+	cache cache.Tagged, admins []string) IntrospectionService {
+	return &introspectionService{sessions: sessions, apiKeys: apiKeys, oidc: oidc, cache: cache, admins: admins}
+}
+
+// Теги записей кэша Introspect.
+func sessionTag(id string) string { return "session:" + id }
+func userTag(id int64) string     { return "user:" + strconv.FormatInt(id, 10) }
+func apiKeyTag(id string) string  { return "api_key:" + id }
+
+func introspectionKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "introspect:" + hex.EncodeToString(sum[:])
+}
+
+// invalidateIntrospection сбрасывает закэшированные ответы Introspect после
+// отзыва. Ошибка не отменяет отзыв: ответ доживёт не дольше TTL кэша.
+func invalidateIntrospection(ctx context.Context, c cache.Tagged, tag string) {
+	if err := c.Invalidate(ctx, tag); err != nil {
+		logger.Errorf("introspectionService - Invalidate: Failed to drop cached results for %s: %v", tag, err)
+	}
+}
+
+func (s *introspectionService) Introspect(ctx context.Context, token string) (*Introspection, error) {
+	if err := authorizeAdmin(ctx, s.admins); err != nil {
+		return nil, err
+	}
+
+	key := introspectionKey(token)
+	if data, err := s.cache.Get(ctx, key); err == nil {
+		var res Introspection
+		if err := json.Unmarshal([]byte(data), &res); err == nil && res.activeAt(time.Now()) {
+			return &res, nil
+		}
+		_ = s.cache.Del(ctx, key)
+	}
+
+	res, tags, err := s.inspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Active {
+		return res, nil
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	// запись не переживает токен; бессрочный ключ хранится TTL кэша
+	ttl := time.Duration(math.MaxInt64)
+	if !res.ExpiresAt.IsZero() {
+		ttl = time.Until(res.ExpiresAt)
+	}
+	if err := s.cache.SetTagged(ctx, key, string(data), ttl, tags...); err != nil {
+		logger.Errorf("introspectionService - Introspect: Failed to cache result: %v", err)
+	}
+	return res, nil
+}
+
+// inspect проверяет токен и возвращает теги, по которым результат
+// сбрасывается из кэша.
+func (s *introspectionService) inspect(ctx context.Context, token string) (*Introspection, []string, error) {
+	inactive := &Introspection{}
+	switch {
+	case apikey.IsKey(token):
+		key, err := s.apiKeys.Authenticate(ctx, token)
+		if errors.Is(err, apikey.ErrInvalid) {
+			return inactive, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		res := &Introspection{
+			Active:    true,
+			TokenType: TokenTypeAPIKey,
+			Subject:   key.ID,
+			Scope:     strings.Join(key.Scopes, " "),
+			ClientID:  key.ID,
+			TenantID:  tenant.OrDefault(key.TenantID),
+			IssuedAt:  key.CreatedAt,
+		}
+		if key.ExpiresAt != nil {
+			res.ExpiresAt = *key.ExpiresAt
+		}
+		return res, []string{apiKeyTag(key.ID)}, nil
+
+	case strings.Count(token, ".") == 2:
+		claims, err := s.oidc.VerifyAccessToken(ctx, token)
+		var oauthErr *oidc.Error
+		if errors.As(err, &oauthErr) {
+			return inactive, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		userID, _ := strconv.ParseInt(claims.Subject, 10, 64)
+		return &Introspection{
+			Active:    true,
+			TokenType: TokenTypeAccessToken,
+			Subject:   claims.Subject,
+			Scope:     claims.Scope,
+			ClientID:  claims.ClientID,
+			SessionID: claims.SessionID,
+			TenantID:  tenant.OrDefault(claims.TenantID),
+			IssuedAt:  time.Unix(claims.IssuedAt, 0).UTC(),
+			ExpiresAt: time.Unix(claims.ExpiresAt, 0).UTC(),
+		}, []string{sessionTag(claims.SessionID), userTag(userID)}, nil
+
+	default:
+		sess, err := s.sessions.Get(ctx, token)
+		if errors.Is(err, session.ErrNotFound) {
+			return inactive, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return &Introspection{
+			Active:    true,
+			TokenType: TokenTypeSession,
+			Subject:   strconv.FormatInt(sess.UserID, 10),
+			SessionID: sess.ID,
+			TenantID:  tenant.OrDefault(sess.TenantID),
+			IssuedAt:  sess.CreatedAt,
+			ExpiresAt: sess.ExpiresAt,
+		}, []string{sessionTag(sess.ID), userTag(sess.UserID)}, nil
+	}
+}
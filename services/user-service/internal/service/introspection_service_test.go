@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apikey"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/oidc"
+	"github.com/DmitriiPro/user-service/internal/session"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+)
+
+// accessTokens — OIDCService, знающий только выданные тестом access токены.
+type accessTokens struct {
+	OIDCService
+	claims map[string]*oidc.Claims
+}
+
+func (a *accessTokens) VerifyAccessToken(_ context.Context, token string) (*oidc.Claims, error) {
+	c, ok := a.claims[token]
+	if !ok {
+		return nil, &oidc.Error{Code: oidc.ErrorInvalidToken}
+	}
+	return c, nil
+}
+
+type introspectionTest struct {
+	svc      IntrospectionService
+	sessions session.Store
+	keys     APIKeyService
+	tokens   *accessTokens
+	cache    *memCache
+}
+
+func newIntrospectionTest(t *testing.T) *introspectionTest {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	it := &introspectionTest{
+		sessions: session.NewRedisStore(client),
+		tokens:   &accessTokens{claims: make(map[string]*oidc.Claims)},
+		cache:    newMemCache(),
+	}
+	repo := newFakeAPIKeys()
+	it.keys = NewAPIKeyService(repo, testMethods, testAdmins, it.cache)
+	it.svc = NewIntrospectionService(it.sessions, apikey.NewAuthenticator(repo), it.tokens, it.cache, testAdmins)
+	return it
+}
+
+func (it *introspectionTest) session(t *testing.T, tenantID string) (string, *session.Session) {
+	t.Helper()
+	token, sess, err := it.sessions.Create(t.Context(), tenantID, 7, "10.0.0.1", "test", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token, sess
+}
+
+func (it *introspectionTest) apiKey(t *testing.T) (string, *model.APIKey) {
+	t.Helper()
+	key, token, err := it.keys.Create(asAdmin.ctx(), "ci", []string{"SearchUsers", "GetUser"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token, key
+}
+
+func TestIntrospectionAuthorization(t *testing.T) {
+	tests := []struct {
+		caller caller
+		want   codes.Code
+	}{
+		{anonymous, codes.Unauthenticated},
+		// пользователь не может проверять чужие токены
+		{asUser(7), codes.PermissionDenied},
+		{asAdmin, codes.OK},
+		{asService, codes.OK},
+		{asAPIKey, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caller.name, func(t *testing.T) {
+			it := newIntrospectionTest(t)
+			token, _ := it.session(t, "")
+
+			res, err := it.svc.Introspect(tt.caller.ctx(), token)
+			wantCode(t, err, tt.want)
+			if tt.want == codes.OK && !res.Active {
+				t.Fatalf("Introspect = %+v, want active", res)
+			}
+			if tt.want != codes.OK && (res != nil || it.cache.len() != 0) {
+				t.Fatalf("denied Introspect returned %+v, cached %d entries", res, it.cache.len())
+			}
+		})
+	}
+}
+
+func TestIntrospect(t *testing.T) {
+	tests := []struct {
+		name string
+		// token выдаёт проверяемый токен и ожидаемый ответ.
+		token func(t *testing.T, it *introspectionTest) (string, Introspection)
+	}{
+		{"session", func(t *testing.T, it *introspectionTest) (string, Introspection) {
+			token, sess := it.session(t, "acme")
+			return token, Introspection{Active: true, TokenType: TokenTypeSession, Subject: "7", SessionID: sess.ID,
+				TenantID: "acme", IssuedAt: sess.CreatedAt, ExpiresAt: sess.ExpiresAt}
+		}},
+		{"session before tenants", func(t *testing.T, it *introspectionTest) (string, Introspection) {
+			token, sess := it.session(t, "")
+			return token, Introspection{Active: true, TokenType: TokenTypeSession, Subject: "7", SessionID: sess.ID,
+				TenantID: "default", IssuedAt: sess.CreatedAt, ExpiresAt: sess.ExpiresAt}
+		}},
+		{"revoked session", func(t *testing.T, it *introspectionTest) (string, Introspection) {
+			token, sess := it.session(t, "")
+			it.sessions.Revoke(t.Context(), 7, sess.ID)
+			return token, Introspection{}
+		}},
+		{"unknown session", func(*testing.T, *introspectionTest) (string, Introspection) {
+			return "not-a-session", Introspection{}
+		}},
+		{"api key", func(t *testing.T, it *introspectionTest) (string, Introspection) {
+			token, key := it.apiKey(t)
+			return token, Introspection{Active: true, TokenType: TokenTypeAPIKey, Subject: key.ID, ClientID: key.ID,
+				Scope: "GetUser SearchUsers", TenantID: "default", IssuedAt: mustGetKey(t, it, key.ID).CreatedAt}
+		}},
+		{"revoked api key", func(t *testing.T, it *introspectionTest) (string, Introspection) {
+			token, key := it.apiKey(t)
+			it.keys.Revoke(asAdmin.ctx(), key.ID)
+			return token, Introspection{}
+		}},
+		{"forged api key", func(t *testing.T, it *introspectionTest) (string, Introspection) {
+			_, key := it.apiKey(t)
+			return apikey.Prefix + key.ID + "_forged", Introspection{}
+		}},
+		{"access token", func(t *testing.T, it *introspectionTest) (string, Introspection) {
+			now := time.Now().Truncate(time.Second).UTC()
+			it.tokens.claims["h.p.s"] = &oidc.Claims{Subject: "7", ClientID: "web", Scope: "openid email", SessionID: "s1",
+				IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+			return "h.p.s", Introspection{Active: true, TokenType: TokenTypeAccessToken, Subject: "7", ClientID: "web",
+				Scope: "openid email", SessionID: "s1", TenantID: "default", IssuedAt: now, ExpiresAt: now.Add(time.Minute)}
+		}},
+		{"invalid access token", func(*testing.T, *introspectionTest) (string, Introspection) {
+			return "h.p.forged", Introspection{}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := newIntrospectionTest(t)
+			token, want := tt.token(t, it)
+
+			// второй ответ может прийти из кэша и должен совпадать с первым
+			for range 2 {
+				res, err := it.svc.Introspect(asService.ctx(), token)
+				wantCode(t, err, codes.OK)
+				if !res.IssuedAt.Equal(want.IssuedAt) || !res.ExpiresAt.Equal(want.ExpiresAt) {
+					t.Fatalf("Introspect times = %v..%v, want %v..%v", res.IssuedAt, res.ExpiresAt, want.IssuedAt, want.ExpiresAt)
+				}
+				res.IssuedAt, res.ExpiresAt = want.IssuedAt, want.ExpiresAt
+				if *res != want {
+					t.Fatalf("Introspect = %+v, want %+v", *res, want)
+				}
+			}
+			if !want.Active && it.cache.len() != 0 {
+				t.Fatal("inactive result was cached")
+			}
+		})
+	}
+}
+
+// Отзыв сбрасывает закэшированный ответ: отозванный токен не остаётся
+// действующим до истечения TTL кэша.
+func TestIntrospectAfterRevoke(t *testing.T) {
+	tests := []struct {
+		name  string
+		issue func(t *testing.T, it *introspectionTest) (token string, revoke func())
+	}{
+		{"api key", func(t *testing.T, it *introspectionTest) (string, func()) {
+			token, key := it.apiKey(t)
+			return token, func() { wantCode(t, it.keys.Revoke(asAdmin.ctx(), key.ID), codes.OK) }
+		}},
+		{"session", func(t *testing.T, it *introspectionTest) (string, func()) {
+			token, sess := it.session(t, "")
+			return token, func() {
+				it.sessions.Revoke(t.Context(), sess.UserID, sess.ID)
+				invalidateIntrospection(t.Context(), it.cache, sessionTag(sess.ID))
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := newIntrospectionTest(t)
+			token, revoke := tt.issue(t, it)
+			if res, err := it.svc.Introspect(asService.ctx(), token); err != nil || !res.Active {
+				t.Fatalf("Introspect before revoke = %+v, %v", res, err)
+			}
+
+			revoke()
+			res, err := it.svc.Introspect(asService.ctx(), token)
+			wantCode(t, err, codes.OK)
+			if res.Active {
+				t.Fatalf("Introspect after revoke = %+v, want inactive", res)
+			}
+		})
+	}
+}
+
+func mustGetKey(t *testing.T, it *introspectionTest, id string) model.APIKey {
+	t.Helper()
+	keys, err := it.keys.List(asAdmin.ctx())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range keys {
+		if k.ID == id {
+			return k
+		}
+	}
+	t.Fatalf("key %s not found", id)
+	return model.APIKey{}
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/mail"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"google.golang.org/grpc/codes"
+)
+
+// InvitationService приглашает в организации по email. Приглашают
+// владельцы и администраторы организации; принять или отклонить
+// приглашение может любой, у кого есть токен из письма.
+type InvitationService interface {
+	// Create отправляет приглашение; прежнее ожидающее приглашение того же
+	// email в организацию перестаёт действовать.
+	Create(ctx context.Context, orgID int64, email string, role model.Role) (*model.Invitation, error)
+	List(ctx context.Context, orgID int64) ([]model.Invitation, error)
+	Revoke(ctx context.Context, orgID, id int64) error
+	// Accept добавляет в организацию пользователя с email из приглашения.
+	// Если такого пользователя нет, он создаётся с паролем password.
+	Accept(ctx context.Context, token, password string) (*AcceptedInvitation, error)
+	Decline(ctx context.Context, token string) error
+}
+
+type AcceptedInvitation struct {
+	OrganizationID int64
+	UserID         int64
+	// Created — пользователь создан при принятии приглашения.
+	Created bool
+}
+
+type invitationService struct {
+	invitations repository.InvitationRepository
+	orgs        repository.OrganizationRepository
+	users       repository.UserRepository
+	userService UserService
+	mailer      mail.Sender
+	email       EmailNormalizer
+	cfg         config.OrganizationsConfig
+	admins      []string
+}
+
+func NewInvitationService(invitations repository.InvitationRepository, orgs repository.OrganizationRepository,
+	users repository.UserRepository, userService UserService, mailer mail.Sender, email EmailNormalizer,
+	cfg config.OrganizationsConfig, admins []string) InvitationService {
+	return &invitationService{invitations: invitations, orgs: orgs, users: users, userService: userService,
+		mailer: mailer, email: email, cfg: cfg, admins: admins}
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func errInvitationNotFound() error {
+	return apperr.New(codes.NotFound, apperr.ReasonInvitationNotFound, "invitation is invalid, expired or already used", nil)
+}
+
+func (s *invitationService) Create(ctx context.Context, orgID int64, email string, role model.Role) (*model.Invitation, error) {
+	if role != model.RoleAdmin && role != model.RoleMember {
+		return nil, apperr.InvalidFields(apperr.FieldViolation("role", "value must be admin or member"))
+	}
+	org, _, err := authorizeOrganization(ctx, s.orgs, s.admins, orgID, model.RoleOwner, model.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := url.Parse(s.cfg.InvitationURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse organizations.invitation_url: %w", err)
+	}
+	token := rand.Text()
+	q := link.Query()
+	q.Set("token", token)
+	link.RawQuery = q.Encode()
+
+	p, _ := auth.FromContext(ctx)
+	inv := &model.Invitation{
+		OrganizationID: org.ID,
+		TenantID:       org.TenantID,
+		Email:          s.email.Normalize(email),
+		Role:           role,
+		TokenHash:      hashInvitationToken(token),
+		InvitedBy:      string(p.Type) + ":" + p.ID,
+		ExpiresAt:      time.Now().Add(s.cfg.InvitationTTL),
+	}
+	if err := s.invitations.Create(ctx, inv); err != nil {
+		return nil, err
+	}
+	logger.Infof("invitationService - Create: %s invited to organization %d as %s by %s", inv.Email, org.ID, role, inv.InvitedBy)
+
+	go s.send(context.WithoutCancel(ctx), org, inv, link.String())
+	return inv, nil
+}
+
+func (s *invitationService) send(ctx context.Context, org *model.Organization, inv *model.Invitation, link string) {
+	err := s.mailer.Send(ctx, mail.Message{
+		To:      inv.Email,
+		Subject: fmt.Sprintf("You are invited to %s", org.Name),
+		Body: fmt.Sprintf("You are invited to join %s as %s.\n\n"+
+			"Open this link to accept or decline the invitation:\n\n%s\n\n"+
+			"The link is valid for %v. If you do not expect this invitation, ignore this email.\n",
+			org.Name, inv.Role, link, s.cfg.InvitationTTL),
+	})
+	if err != nil {
+		logger.Errorf("invitationService - Create: Failed to send invitation %d: %v", inv.ID, err)
+		return
+	}
+	logger.Infof("invitationService - Create: Invitation %d sent", inv.ID)
+}
+
+func (s *invitationService) List(ctx context.Context, orgID int64) ([]model.Invitation, error) {
+	if _, _, err := authorizeOrganization(ctx, s.orgs, s.admins, orgID, model.RoleOwner, model.RoleAdmin); err != nil {
+		return nil, err
+	}
+	return s.invitations.List(ctx, orgID)
+}
+
+func (s *invitationService) Revoke(ctx context.Context, orgID, id int64) error {
+	if _, _, err := authorizeOrganization(ctx, s.orgs, s.admins, orgID, model.RoleOwner, model.RoleAdmin); err != nil {
+		return err
+	}
+	err := s.invitations.Delete(ctx, orgID, id)
+	if errors.Is(err, repository.ErrInvitationNotFound) {
+		return errInvitationNotFound()
+	}
+	if err != nil {
+		return err
+	}
+	logger.Infof("invitationService - Revoke: Invitation %d to organization %d revoked", id, orgID)
+	return nil
+}
+
+func (s *invitationService) get(ctx context.Context, token string) (*model.Invitation, error) {
+	inv, err := s.invitations.GetByToken(ctx, hashInvitationToken(token))
+	if errors.Is(err, repository.ErrInvitationNotFound) {
+		return nil, errInvitationNotFound()
+	}
+	return inv, err
+}
+
+func (s *invitationService) Accept(ctx context.Context, token, password string) (*AcceptedInvitation, error) {
+	inv, err := s.get(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	// приглашение открывают из письма, без заголовка тенанта
+	ctx = tenant.WithID(ctx, inv.TenantID)
+
+	res := &AcceptedInvitation{OrganizationID: inv.OrganizationID}
+	user, err := s.users.GetUserByEmail(ctx, inv.Email)
+	switch {
+	case err == nil:
+		res.UserID = user.ID
+	case errors.Is(err, repository.ErrNotFoundUser):
+		if password == "" {
+			return nil, apperr.InvalidFields(apperr.FieldViolation("password", "value is required to create an account"))
+		}
+		res.UserID, err = s.userService.CreateUser(ctx, inv.Email, password)
+		if err != nil {
+			return nil, err
+		}
+		res.Created = true
+	default:
+		return nil, err
+	}
+
+	err = s.invitations.Accept(ctx, inv.ID, res.UserID)
+	if errors.Is(err, repository.ErrInvitationNotFound) {
+		return nil, errInvitationNotFound()
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("invitationService - Accept: User %d joined organization %d as %s", res.UserID, inv.OrganizationID, inv.Role)
+	return res, nil
+}
+
+func (s *invitationService) Decline(ctx context.Context, token string) error {
+	inv, err := s.get(ctx, token)
+	if err != nil {
+		return err
+	}
+	err = s.invitations.Decline(ctx, inv.ID)
+	if errors.Is(err, repository.ErrInvitationNotFound) {
+		return errInvitationNotFound()
+	}
+	if err != nil {
+		return err
+	}
+	logger.Infof("invitationService - Decline: Invitation %d to organization %d declined", inv.ID, inv.OrganizationID)
+	return nil
+}
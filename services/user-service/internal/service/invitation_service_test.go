@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/mail"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"google.golang.org/grpc/codes"
+)
+
+// fakeInvitations — InvitationRepository в памяти; Accept добавляет
+// участника в fakeOrgs.
+type fakeInvitations struct {
+	mu   sync.Mutex
+	orgs *fakeOrgs
+	byID map[int64]*model.Invitation
+}
+
+func (r *fakeInvitations) pending(inv *model.Invitation) bool {
+	return inv.AcceptedAt == nil && inv.DeclinedAt == nil && time.Now().Before(inv.ExpiresAt)
+}
+
+func (r *fakeInvitations) Create(_ context.Context, inv *model.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, old := range r.byID {
+		if old.OrganizationID == inv.OrganizationID && old.Email == inv.Email && old.AcceptedAt == nil && old.DeclinedAt == nil {
+			delete(r.byID, id)
+		}
+	}
+	inv.ID = int64(len(r.byID) + 1)
+	inv.CreatedAt = time.Now()
+	cp := *inv
+	r.byID[inv.ID] = &cp
+	return nil
+}
+
+func (r *fakeInvitations) GetByToken(_ context.Context, tokenHash string) (*model.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, inv := range r.byID {
+		if inv.TokenHash == tokenHash && r.pending(inv) {
+			cp := *inv
+			return &cp, nil
+		}
+	}
+	return nil, repository.ErrInvitationNotFound
+}
+
+func (r *fakeInvitations) List(_ context.Context, orgID int64) ([]model.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.Invitation
+	for _, inv := range r.byID {
+		if inv.OrganizationID == orgID && r.pending(inv) {
+			out = append(out, *inv)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeInvitations) Delete(_ context.Context, orgID, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inv, ok := r.byID[id]
+	if !ok || inv.OrganizationID != orgID || !r.pending(inv) {
+		return repository.ErrInvitationNotFound
+	}
+	delete(r.byID, id)
+	return nil
+}
+
+func (r *fakeInvitations) Accept(_ context.Context, id, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inv, ok := r.byID[id]
+	if !ok || !r.pending(inv) {
+		return repository.ErrInvitationNotFound
+	}
+	now := time.Now()
+	inv.AcceptedAt = &now
+	r.orgs.mu.Lock()
+	defer r.orgs.mu.Unlock()
+	if _, member := r.orgs.members[inv.OrganizationID][userID]; !member {
+		r.orgs.members[inv.OrganizationID][userID] = inv.Role
+	}
+	return nil
+}
+
+func (r *fakeInvitations) Decline(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inv, ok := r.byID[id]
+	if !ok || !r.pending(inv) {
+		return repository.ErrInvitationNotFound
+	}
+	now := time.Now()
+	inv.DeclinedAt = &now
+	return nil
+}
+
+// mailbox — mail.Sender, складывающий письма в канал: приглашения
+// отправляются в фоне.
+type mailbox chan mail.Message
+
+func (m mailbox) Send(_ context.Context, msg mail.Message) error {
+	m <- msg
+	return nil
+}
+
+var invitationLink = regexp.MustCompile(`https://app\.example\.com/invitations\S*`)
+
+// token дожидается письма на адрес to и достаёт токен из ссылки.
+func (m mailbox) token(t *testing.T, to string) string {
+	t.Helper()
+	select {
+	case msg := <-m:
+		if msg.To != to {
+			t.Fatalf("invitation sent to %s, want %s", msg.To, to)
+		}
+		link, err := url.Parse(invitationLink.FindString(msg.Body))
+		if err != nil || link.Query().Get("token") == "" {
+			t.Fatalf("no invitation link in %q", msg.Body)
+		}
+		return link.Query().Get("token")
+	case <-time.After(5 * time.Second):
+		t.Fatal("invitation was not sent")
+		return ""
+	}
+}
+
+type invitationTest struct {
+	svc         InvitationService
+	orgs        *fakeOrgs
+	users       *fakeUsers
+	invitations *fakeInvitations
+	mail        mailbox
+}
+
+func newInvitationTest(ttl time.Duration) *invitationTest {
+	orgs, users := newTestOrgs()
+	it := &invitationTest{orgs: orgs, users: users, mail: make(mailbox, 10),
+		invitations: &fakeInvitations{orgs: orgs, byID: make(map[int64]*model.Invitation)}}
+	it.svc = NewInvitationService(it.invitations, orgs, users, NewUserService(users, newMemCache(), EmailNormalizer{}, testAdmins),
+		it.mail, EmailNormalizer{}, config.OrganizationsConfig{InvitationTTL: ttl, InvitationURL: "https://app.example.com/invitations"},
+		testAdmins)
+	return it
+}
+
+func TestInvitationServiceRoles(t *testing.T) {
+	invite := func(role model.Role) func(context.Context, *invitationTest) error {
+		return func(ctx context.Context, it *invitationTest) error {
+			_, err := it.svc.Create(ctx, testOrgID, "new@example.com", role)
+			return err
+		}
+	}
+	list := func(ctx context.Context, it *invitationTest) error {
+		_, err := it.svc.List(ctx, testOrgID)
+		return err
+	}
+	revoke := func(ctx context.Context, it *invitationTest) error {
+		return it.svc.Revoke(ctx, testOrgID, 1)
+	}
+
+	tests := []struct {
+		action string
+		call   func(context.Context, *invitationTest) error
+		caller caller
+		want   codes.Code
+	}{
+		{"invite", invite(model.RoleMember), anonymous, codes.Unauthenticated},
+		{"invite", invite(model.RoleMember), orgOwner, codes.OK},
+		{"invite", invite(model.RoleAdmin), orgAdmin, codes.OK},
+		{"invite", invite(model.RoleMember), orgMember, codes.PermissionDenied},
+		{"invite", invite(model.RoleMember), orgOutsider, codes.NotFound},
+		{"invite", invite(model.RoleMember), asService, codes.OK},
+		// владельцем становятся только через UpdateMemberRole
+		{"invite owner", invite(model.RoleOwner), orgOwner, codes.InvalidArgument},
+
+		{"list", list, orgAdmin, codes.OK},
+		{"list", list, orgMember, codes.PermissionDenied},
+		{"list", list, orgOutsider, codes.NotFound},
+
+		{"revoke", revoke, orgOwner, codes.OK},
+		{"revoke", revoke, orgMember, codes.PermissionDenied},
+		{"revoke", revoke, orgOutsider, codes.NotFound},
+		{"revoke", revoke, anonymous, codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action+"/"+tt.caller.name, func(t *testing.T) {
+			it := newInvitationTest(time.Hour)
+			if _, err := it.svc.Create(orgOwner.ctx(), testOrgID, "pending@example.com", model.RoleMember); err != nil {
+				t.Fatal(err)
+			}
+			it.mail.token(t, "pending@example.com")
+
+			wantCode(t, tt.call(tt.caller.ctx(), it), tt.want)
+			if tt.want == codes.OK {
+				return
+			}
+			if pending, _ := it.invitations.List(t.Context(), testOrgID); len(pending) != 1 || pending[0].Email != "pending@example.com" {
+				t.Fatalf("denied %s changed invitations: %+v", tt.action, pending)
+			}
+			select {
+			case msg := <-it.mail:
+				t.Fatalf("denied %s sent %+v", tt.action, msg)
+			default:
+			}
+		})
+	}
+}
+
+func TestInvitationServiceAccept(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		ttl      time.Duration
+		password string
+		// before выполняется с токеном до Accept.
+		before      func(t *testing.T, it *invitationTest, token string)
+		want        codes.Code
+		wantCreated bool
+	}{
+		{name: "existing user joins", email: "Outsider@Example.com", ttl: time.Hour, want: codes.OK},
+		{name: "new user is created", email: "new@example.com", ttl: time.Hour, password: "s3cret-pass", want: codes.OK, wantCreated: true},
+		{name: "new user needs a password", email: "new@example.com", ttl: time.Hour, want: codes.InvalidArgument},
+		{name: "expired", email: "outsider@example.com", ttl: time.Millisecond, want: codes.NotFound,
+			before: func(*testing.T, *invitationTest, string) { time.Sleep(5 * time.Millisecond) }},
+		{name: "declined", email: "outsider@example.com", ttl: time.Hour, want: codes.NotFound,
+			before: func(t *testing.T, it *invitationTest, token string) {
+				wantCode(t, it.svc.Decline(context.Background(), token), codes.OK)
+			}},
+		{name: "already accepted", email: "outsider@example.com", ttl: time.Hour, want: codes.NotFound,
+			before: func(t *testing.T, it *invitationTest, token string) {
+				_, err := it.svc.Accept(context.Background(), token, "")
+				wantCode(t, err, codes.OK)
+				it.orgs.RemoveMember(t.Context(), testOrgID, 13)
+			}},
+		{name: "revoked", email: "outsider@example.com", ttl: time.Hour, want: codes.NotFound,
+			before: func(t *testing.T, it *invitationTest, _ string) {
+				wantCode(t, it.svc.Revoke(orgAdmin.ctx(), testOrgID, 1), codes.OK)
+			}},
+		{name: "replaced by a new invitation", email: "outsider@example.com", ttl: time.Hour, want: codes.NotFound,
+			before: func(t *testing.T, it *invitationTest, _ string) {
+				if _, err := it.svc.Create(orgAdmin.ctx(), testOrgID, "outsider@example.com", model.RoleMember); err != nil {
+					t.Fatal(err)
+				}
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := newInvitationTest(tt.ttl)
+			inv, err := it.svc.Create(orgAdmin.ctx(), testOrgID, tt.email, model.RoleAdmin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inv.InvitedBy != "user:11" {
+				t.Fatalf("invited_by = %q", inv.InvitedBy)
+			}
+			token := it.mail.token(t, inv.Email)
+			if tt.before != nil {
+				tt.before(t, it, token)
+			}
+
+			// принимает тот, у кого есть ссылка из письма, без сессии
+			res, err := it.svc.Accept(context.Background(), token, tt.password)
+			wantCode(t, err, tt.want)
+			if tt.want != codes.OK {
+				if len(it.orgs.members[testOrgID]) != 3 {
+					t.Fatalf("rejected invitation changed members: %v", it.orgs.members[testOrgID])
+				}
+				return
+			}
+			if res.Created != tt.wantCreated || res.OrganizationID != testOrgID {
+				t.Fatalf("Accept = %+v", res)
+			}
+			user, err := it.users.GetUserByEmail(t.Context(), tt.email)
+			if err != nil || user.ID != res.UserID {
+				t.Fatalf("accepted as user %d, user by email %+v (%v)", res.UserID, user, err)
+			}
+			if role, _ := it.orgs.role(testOrgID, res.UserID); role != model.RoleAdmin {
+				t.Fatalf("joined with role %q, want admin", role)
+			}
+		})
+	}
+}
+
+// Приглашение не меняет роль того, кто уже состоит в организации.
+func TestInvitationServiceAcceptKeepsRole(t *testing.T) {
+	it := newInvitationTest(time.Hour)
+	if _, err := it.svc.Create(orgAdmin.ctx(), testOrgID, "owner@example.com", model.RoleMember); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := it.svc.Accept(context.Background(), it.mail.token(t, "owner@example.com"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if role, _ := it.orgs.role(testOrgID, 10); role != model.RoleOwner {
+		t.Fatalf("owner became %q after accepting an invitation", role)
+	}
+}
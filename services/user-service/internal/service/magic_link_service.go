@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/magiclink"
+	"github.com/DmitriiPro/user-service/internal/mail"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"google.golang.org/grpc/codes"
+)
+
+// Причина отказа во входе по ссылке (login_events.failure_reason).
+const FailureUserAgentMismatch = "user_agent_mismatch"
+
+type MagicLinkService interface {
+	// Request отправляет ссылку для входа, если пользователь с таким email
+	// есть. Ошибку о неизвестном email не возвращает. Повторный запрос на
+	// тот же email раньше cfg.Cooldown тоже молча игнорируется: лимит по IP
+	// не мешает завалить чужой ящик письмами с разных адресов.
+	Request(ctx context.Context, email string) error
+	Consume(ctx context.Context, token string) (*LoginResult, error)
+}
+
+type magicLinkService struct {
+	users  repository.UserRepository
+	events repository.LoginEventRepository
+	links  magiclink.Store
+	mailer mail.Sender
+	auth   AuthService
+	email  EmailNormalizer
+	cfg    config.MagicLinkConfig
+}
+
+func NewMagicLinkService(users repository.UserRepository, events repository.LoginEventRepository, links magiclink.Store,
+	mailer mail.Sender, auth AuthService, email EmailNormalizer, cfg config.MagicLinkConfig) MagicLinkService {
+	return &magicLinkService{users: users, events: events, links: links, mailer: mailer, auth: auth, email: email, cfg: cfg}
+}
+
+func errMagicLinkDisabled() error {
+	return apperr.New(codes.FailedPrecondition, apperr.ReasonMagicLinkDisabled, "magic link sign-in is disabled", nil)
+}
+
+func (s *magicLinkService) Request(ctx context.Context, email string) error {
+	if !s.cfg.Enabled {
+		return errMagicLinkDisabled()
+	}
+	email = s.email.Normalize(email)
+
+	// cooldown занимается и для неизвестных email — иначе ответ выдавал бы,
+	// есть ли пользователь; регистр не важен, как и при поиске пользователя
+	if s.cfg.Cooldown > 0 {
+		ok, err := s.links.Cooldown(ctx, tenant.FromContext(ctx), strings.ToLower(email), s.cfg.Cooldown)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logger.Infof("magicLinkService - Request: Link for %s was requested less than %v ago", email, s.cfg.Cooldown)
+			return nil
+		}
+	}
+
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFoundUser) {
+		logger.Infof("magicLinkService - Request: No user with email %s", email)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	client := auth.ClientInfoFromContext(ctx)
+	token, err := s.links.Create(ctx, user.TenantID, user.ID, user.Email, client.UserAgent, s.cfg.TTL)
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(s.cfg.URL)
+	if err != nil {
+		return fmt.Errorf("parse magic_link.url: %w", err)
+	}
+	q := link.Query()
+	q.Set("token", token)
+	link.RawQuery = q.Encode()
+
+	// письмо уходит в фоне: по времени ответа нельзя понять, есть ли
+	// пользователь с таким email
+	go s.send(context.WithoutCancel(ctx), user, link.String())
+	return nil
+}
+
+func (s *magicLinkService) send(ctx context.Context, user *model.User, link string) {
+	err := s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Use this link to sign in:\n\n%s\n\n"+
+			"The link works once, for %v, and only in the browser you requested it from.\n"+
+			"If you did not request it, ignore this email.\n", link, s.cfg.TTL),
+	})
+	if err != nil {
+		logger.Errorf("magicLinkService - Request: Failed to send link to user %d: %v", user.ID, err)
+		return
+	}
+	logger.Infof("magicLinkService - Request: Link sent to user %d", user.ID)
+}
+
+func (s *magicLinkService) Consume(ctx context.Context, token string) (*LoginResult, error) {
+	if !s.cfg.Enabled {
+		return nil, errMagicLinkDisabled()
+	}
+	client := auth.ClientInfoFromContext(ctx)
+
+	link, err := s.links.Consume(ctx, token, client.UserAgent)
+	if errors.Is(err, magiclink.ErrUserAgentMismatch) {
+		logger.Warnf("magicLinkService - Consume: Link for user %d used from another user agent", link.UserID)
+		s.recordFailure(ctx, link, FailureUserAgentMismatch)
+	}
+	if errors.Is(err, magiclink.ErrNotFound) || errors.Is(err, magiclink.ErrUserAgentMismatch) {
+		return nil, apperr.New(codes.Unauthenticated, apperr.ReasonMagicLinkInvalid, "magic link is invalid or expired", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// ссылку открывают из письма, без заголовка тенанта
+	ctx = tenant.WithID(ctx, link.TenantID)
+	user, err := s.users.GetUserByID(ctx, link.UserID)
+	if errors.Is(err, repository.ErrNotFoundUser) {
+		return nil, apperr.New(codes.Unauthenticated, apperr.ReasonMagicLinkInvalid, "magic link is invalid or expired", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.auth.CompleteLogin(ctx, user, model.LoginMethodMagicLink)
+}
+
+func (s *magicLinkService) recordFailure(ctx context.Context, link *magiclink.Link, reason string) {
+	client := auth.ClientInfoFromContext(ctx)
+	// событие относится к тенанту ссылки, а не к заголовку запроса
+	ctx = tenant.WithID(ctx, link.TenantID)
+	err := s.events.Record(context.WithoutCancel(ctx), &model.LoginEvent{
+		UserID:        link.UserID,
+		Email:         link.Email,
+		Method:        model.LoginMethodMagicLink,
+		IP:            client.IP,
+		UserAgent:     client.UserAgent,
+		FailureReason: reason,
+	})
+	if err != nil {
+		logger.Errorf("magicLinkService - Consume: Failed to record login event for %s: %v", link.Email, err)
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/magiclink"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+)
+
+var magicLink = regexp.MustCompile(`https://app\.example\.com/magic-link\S*`)
+
+type magicLinkTest struct {
+	svc    MagicLinkService
+	mr     *miniredis.Miniredis
+	mail   mailbox
+	events *fakeEvents
+	logins *loginRecorder
+}
+
+func newMagicLinkTest(t *testing.T) *magicLinkTest {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	users := newFakeUsers(&model.User{ID: 1, Email: "alice@example.com"}, &model.User{ID: 2, Email: "bob@example.com"})
+	mt := &magicLinkTest{mr: mr, mail: make(mailbox, 10), events: &fakeEvents{}, logins: &loginRecorder{}}
+	cfg := config.MagicLinkConfig{Enabled: true, TTL: 15 * time.Minute, URL: "https://app.example.com/magic-link",
+		Cooldown: time.Minute}
+	mt.svc = NewMagicLinkService(users, mt.events, magiclink.NewRedisStore(client), mt.mail, mt.logins, EmailNormalizer{}, cfg)
+	return mt
+}
+
+// token дожидается письма на адрес to и достаёт токен из ссылки.
+func (mt *magicLinkTest) token(t *testing.T, to string) string {
+	t.Helper()
+	select {
+	case msg := <-mt.mail:
+		if msg.To != to {
+			t.Fatalf("link sent to %s, want %s", msg.To, to)
+		}
+		link, err := url.Parse(magicLink.FindString(msg.Body))
+		if err != nil || link.Query().Get("token") == "" {
+			t.Fatalf("no magic link in %q", msg.Body)
+		}
+		return link.Query().Get("token")
+	case <-time.After(5 * time.Second):
+		t.Fatal("magic link was not sent")
+		return ""
+	}
+}
+
+func (mt *magicLinkTest) noMail(t *testing.T) {
+	t.Helper()
+	select {
+	case msg := <-mt.mail:
+		t.Fatalf("unexpected email to %s", msg.To)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMagicLinkConsume(t *testing.T) {
+	mt := newMagicLinkTest(t)
+	if err := mt.svc.Request(browser("Firefox"), " Alice@Example.com "); err != nil {
+		t.Fatal(err)
+	}
+	token := mt.token(t, "alice@example.com")
+
+	// перехваченная ссылка из другого браузера не входит и не сгорает
+	_, err := mt.svc.Consume(browser("curl/8.0"), token)
+	wantReason(t, err, codes.Unauthenticated, apperr.ReasonMagicLinkInvalid)
+	if len(mt.events.events) != 1 || mt.events.events[0].FailureReason != FailureUserAgentMismatch {
+		t.Fatalf("login events = %+v", mt.events.events)
+	}
+
+	res, err := mt.svc.Consume(browser("Firefox"), token)
+	wantCode(t, err, codes.OK)
+	if res.SessionToken != "session-for-"+model.LoginMethodMagicLink || len(mt.logins.userIDs) != 1 || mt.logins.userIDs[0] != 1 {
+		t.Fatalf("login = %+v, users %v", res, mt.logins.userIDs)
+	}
+
+	_, err = mt.svc.Consume(browser("Firefox"), token)
+	wantReason(t, err, codes.Unauthenticated, apperr.ReasonMagicLinkInvalid)
+	if len(mt.logins.userIDs) != 1 {
+		t.Fatal("magic link worked twice")
+	}
+}
+
+func TestMagicLinkExpires(t *testing.T) {
+	mt := newMagicLinkTest(t)
+	mt.svc.Request(browser("Firefox"), "alice@example.com")
+	token := mt.token(t, "alice@example.com")
+	mt.mr.FastForward(15 * time.Minute)
+
+	_, err := mt.svc.Consume(browser("Firefox"), token)
+	wantReason(t, err, codes.Unauthenticated, apperr.ReasonMagicLinkInvalid)
+}
+
+// На один email письмо уходит не чаще раза в cooldown, с каких бы IP его
+// ни запрашивали; ответ при этом тот же, что и на первый запрос.
+func TestMagicLinkRequestCooldown(t *testing.T) {
+	mt := newMagicLinkTest(t)
+	if err := mt.svc.Request(browser("Firefox"), "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	first := mt.token(t, "alice@example.com")
+
+	other := auth.WithClientInfo(context.Background(), auth.ClientInfo{IP: "192.0.2.7", UserAgent: "curl/8.0"})
+	if err := mt.svc.Request(other, "ALICE@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	mt.noMail(t)
+
+	// cooldown одного адреса не мешает другим
+	mt.svc.Request(browser("Firefox"), "bob@example.com")
+	mt.token(t, "bob@example.com")
+
+	mt.mr.FastForward(time.Minute)
+	mt.svc.Request(browser("Firefox"), "alice@example.com")
+	if second := mt.token(t, "alice@example.com"); second == first {
+		t.Fatal("same token issued twice")
+	}
+}
+
+func TestMagicLinkDisabled(t *testing.T) {
+	svc := NewMagicLinkService(newFakeUsers(), &fakeEvents{}, nil, nil, nil, EmailNormalizer{}, config.MagicLinkConfig{})
+	wantReason(t, svc.Request(browser("Firefox"), "alice@example.com"), codes.FailedPrecondition, apperr.ReasonMagicLinkDisabled)
+	_, err := svc.Consume(browser("Firefox"), "token")
+	wantReason(t, err, codes.FailedPrecondition, apperr.ReasonMagicLinkDisabled)
+}
@@ -0,0 +1,479 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/oidc"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/session"
+	"github.com/DmitriiPro/user-service/internal/signing"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"google.golang.org/grpc/codes"
+)
+
+// ErrLoginRequired — у пользователя нет сессии: его нужно отправить на
+// страницу входа, а затем повторить запрос авторизации.
+var ErrLoginRequired = errors.New("login required")
+
+const (
+	ScopeOpenID  = "openid"
+	ScopeEmail   = "email"
+	ScopeProfile = "profile"
+)
+
+var supportedScopes = []string{ScopeOpenID, ScopeEmail, ScopeProfile}
+
+// AuthorizeRequest — параметры /oauth2/authorize и токен сессии
+// пользователя (из cookie или заголовка Authorization).
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Prompt              string
+	SessionToken        string
+}
+
+// TokenRequest — параметры /oauth2/token; секрет клиента уже извлечён из
+// заголовка Authorization или тела запроса.
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	ClientID     string
+	ClientSecret string
+	CodeVerifier string
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+// Discovery — документ /.well-known/openid-configuration.
+type Discovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// OIDCService — провайдер OpenID Connect (authorization code flow с PKCE)
+// и управление клиентами. Методы протокола возвращают *oidc.Error для
+// ошибок, которые показываются клиенту.
+type OIDCService interface {
+	Discovery() *Discovery
+	// Authorize выдаёт код и возвращает адрес возврата к клиенту.
+	Authorize(ctx context.Context, req AuthorizeRequest) (string, error)
+	Token(ctx context.Context, req TokenRequest) (*TokenResponse, error)
+	// UserInfo возвращает claims пользователя по access токену.
+	UserInfo(ctx context.Context, accessToken string) (map[string]any, error)
+	// VerifyAccessToken проверяет подпись, срок и издателя access токена,
+	// то, что он выпущен зарегистрированному клиенту (aud), и то, что
+	// сессия, для которой он выпущен, ещё открыта.
+	VerifyAccessToken(ctx context.Context, accessToken string) (*oidc.Claims, error)
+
+	// CreateClient регистрирует клиента и возвращает его секрет (пусто у
+	// публичного клиента); секрет больше нигде не показывается.
+	CreateClient(ctx context.Context, name string, redirectURIs []string, public bool) (*model.OIDCClient, string, error)
+	ListClients(ctx context.Context) ([]model.OIDCClient, error)
+	DeleteClient(ctx context.Context, clientID string) error
+}
+
+type oidcService struct {
+	users    repository.UserRepository
+	clients  repository.OIDCClientRepository
+	sessions session.Store
+	codes    oidc.CodeStore
+	keys     *signing.KeyManager
+	cfg      config.OIDCConfig
+	admins   []string
+}
+
+// NewOIDCService: keys равен nil, если провайдер выключен — тогда
+// работает только управление клиентами.
+func NewOIDCService(users repository.UserRepository, clients repository.OIDCClientRepository, sessions session.Store,
+	codes oidc.CodeStore, keys *signing.KeyManager, cfg config.OIDCConfig, admins []string) OIDCService {
+	return &oidcService{users: users, clients: clients, sessions: sessions, codes: codes, keys: keys, cfg: cfg, admins: admins}
+}
+
+func (s *oidcService) Discovery() *Discovery {
+	return &Discovery{
+		Issuer:                            s.cfg.Issuer,
+		AuthorizationEndpoint:             s.cfg.Issuer + "/oauth2/authorize",
+		TokenEndpoint:                     s.cfg.Issuer + "/oauth2/token",
+		UserInfoEndpoint:                  s.cfg.Issuer + "/oauth2/userinfo",
+		JWKSURI:                           s.cfg.Issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   supportedScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  s.keys.Algorithms(),
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{oidc.PKCEMethod},
+		ClaimsSupported: []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "sid",
+			"email", "name", "locale", "zoneinfo", "picture", "updated_at"},
+	}
+}
+
+func (s *oidcService) Authorize(ctx context.Context, req AuthorizeRequest) (string, error) {
+	// пока redirect_uri не сверен с клиентом, ошибки туда не отправляются:
+	// иначе провайдер стал бы открытым редиректом
+	client, err := s.clients.Get(ctx, req.ClientID)
+	if errors.Is(err, repository.ErrOIDCClientNotFound) {
+		return "", &oidc.Error{Code: oidc.ErrorInvalidRequest, Description: "unknown client_id"}
+	}
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return "", &oidc.Error{Code: oidc.ErrorInvalidRequest, Description: "redirect_uri is not registered for the client"}
+	}
+
+	fail := func(code, description string) error {
+		return &oidc.Error{Code: code, Description: description, RedirectURI: req.RedirectURI, State: req.State}
+	}
+	if req.ResponseType != "code" {
+		return "", fail(oidc.ErrorUnsupportedResponseType, "only response_type=code is supported")
+	}
+	scopes := strings.Fields(req.Scope)
+	if !slices.Contains(scopes, ScopeOpenID) {
+		return "", fail(oidc.ErrorInvalidScope, "scope must include openid")
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != oidc.PKCEMethod {
+		return "", fail(oidc.ErrorInvalidRequest, "PKCE with code_challenge_method=S256 is required")
+	}
+
+	var sess *session.Session
+	if req.SessionToken != "" {
+		sess, err = s.sessions.Get(ctx, req.SessionToken)
+		if err != nil && !errors.Is(err, session.ErrNotFound) {
+			return "", err
+		}
+	}
+	if sess == nil {
+		if req.Prompt == "none" {
+			return "", fail(oidc.ErrorLoginRequired, "the user is not signed in")
+		}
+		return "", ErrLoginRequired
+	}
+
+	// неизвестные scope отбрасываются (RFC 6749, раздел 3.3)
+	scopes = slices.DeleteFunc(scopes, func(scope string) bool { return !slices.Contains(supportedScopes, scope) })
+	code, err := s.codes.Create(ctx, oidc.AuthorizationCode{
+		ClientID:      client.ID,
+		RedirectURI:   req.RedirectURI,
+		UserID:        sess.UserID,
+		SessionID:     sess.ID,
+		TenantID:      sess.TenantID,
+		Scope:         strings.Join(scopes, " "),
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      sess.CreatedAt,
+	}, s.cfg.CodeTTL)
+	if err != nil {
+		return "", err
+	}
+
+	redirect, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		return "", fmt.Errorf("parse redirect_uri: %w", err)
+	}
+	q := redirect.Query()
+	q.Set("code", code)
+	if req.State != "" {
+		q.Set("state", req.State)
+	}
+	// RFC 9207: клиент сверяет, от какого провайдера пришёл ответ
+	q.Set("iss", s.cfg.Issuer)
+	redirect.RawQuery = q.Encode()
+
+	logger.Infof("oidcService - Authorize: Code issued to client %s for user %d", client.ID, sess.UserID)
+	return redirect.String(), nil
+}
+
+func (s *oidcService) Token(ctx context.Context, req TokenRequest) (*TokenResponse, error) {
+	if req.GrantType != "authorization_code" {
+		return nil, &oidc.Error{Code: oidc.ErrorUnsupportedGrantType, Description: "only authorization_code is supported"}
+	}
+
+	// клиент проверяется до кода, чтобы чужой код нельзя было сжечь
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidGrant := &oidc.Error{Code: oidc.ErrorInvalidGrant, Description: "authorization code is invalid, expired or already used"}
+	code, err := s.codes.Consume(ctx, req.Code, func(code *oidc.AuthorizationCode) error {
+		if code.ClientID != client.ID || code.RedirectURI != req.RedirectURI {
+			logger.Debugf("oidcService - Token: Code of client %s presented by %s", code.ClientID, client.ID)
+			return invalidGrant
+		}
+		if !oidc.VerifyPKCE(req.CodeVerifier, code.CodeChallenge) {
+			return &oidc.Error{Code: oidc.ErrorInvalidGrant, Description: "code_verifier does not match code_challenge"}
+		}
+		return nil
+	})
+	if errors.Is(err, oidc.ErrCodeNotFound) {
+		return nil, invalidGrant
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// пользователь ищется в тенанте сессии, а не в заголовке запроса клиента
+	ctx = tenant.WithID(ctx, code.TenantID)
+	// сессия могла быть закрыта, пока клиент обменивал код
+	if ok, err := s.sessionActive(ctx, code.UserID, code.SessionID); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, invalidGrant
+	}
+	user, err := s.users.GetUserByID(ctx, code.UserID)
+	if errors.Is(err, repository.ErrNotFoundUser) {
+		return nil, invalidGrant
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	key, err := s.keys.Signer(now)
+	if err != nil {
+		return nil, err
+	}
+	scopes := strings.Fields(code.Scope)
+	base := oidc.Claims{
+		Issuer:    s.cfg.Issuer,
+		Subject:   strconv.FormatInt(user.ID, 10),
+		Audience:  oidc.Audience{client.ID},
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.cfg.TokenTTL).Unix(),
+		SessionID: code.SessionID,
+		TenantID:  code.TenantID,
+	}
+
+	access := base
+	access.ID = rand.Text()
+	access.ClientID = client.ID
+	access.Scope = code.Scope
+	accessToken, err := oidc.Sign(key, oidc.TypeAccessToken, &access)
+	if err != nil {
+		return nil, err
+	}
+
+	id := base
+	id.AuthTime = code.AuthTime.Unix()
+	id.Nonce = code.Nonce
+	if slices.Contains(scopes, ScopeEmail) {
+		id.Email = user.Email
+	}
+	if slices.Contains(scopes, ScopeProfile) {
+		id.Name, id.Locale, id.Zoneinfo = user.DisplayName, user.Locale, user.Timezone
+	}
+	idToken, err := oidc.Sign(key, oidc.TypeIDToken, &id)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("oidcService - Token: Tokens issued to client %s for user %d", client.ID, user.ID)
+	return &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.cfg.TokenTTL.Seconds()),
+		IDToken:     idToken,
+		Scope:       code.Scope,
+	}, nil
+}
+
+// authenticateClient: конфиденциальный клиент предъявляет секрет,
+// публичный — только client_id (его защищает PKCE).
+func (s *oidcService) authenticateClient(ctx context.Context, clientID, secret string) (*model.OIDCClient, error) {
+	invalidClient := &oidc.Error{Code: oidc.ErrorInvalidClient, Description: "client authentication failed"}
+	if clientID == "" {
+		return nil, invalidClient
+	}
+	client, err := s.clients.Get(ctx, clientID)
+	if errors.Is(err, repository.ErrOIDCClientNotFound) {
+		return nil, invalidClient
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Public() {
+		if secret != "" {
+			return nil, invalidClient
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(hashClientSecret(secret)), []byte(client.SecretHash)) != 1 {
+		logger.Warnf("oidcService - Token: Invalid secret for client %s", clientID)
+		return nil, invalidClient
+	}
+	return client, nil
+}
+
+func (s *oidcService) sessionActive(ctx context.Context, userID int64, sessionID string) (bool, error) {
+	sessions, err := s.sessions.List(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(sessions, func(sess session.Session) bool { return sess.ID == sessionID }), nil
+}
+
+func (s *oidcService) VerifyAccessToken(ctx context.Context, accessToken string) (*oidc.Claims, error) {
+	invalidToken := &oidc.Error{Code: oidc.ErrorInvalidToken, Description: "access token is invalid or expired"}
+	if s.keys == nil {
+		return nil, invalidToken
+	}
+	claims, err := oidc.Verify(accessToken, oidc.TypeAccessToken, s.keys.Lookup, time.Now())
+	if err != nil || claims.Issuer != s.cfg.Issuer {
+		return nil, invalidToken
+	}
+	// токен удалённого клиента больше не принимается
+	if claims.ClientID == "" || !slices.Contains(claims.Audience, claims.ClientID) {
+		return nil, invalidToken
+	}
+	if _, err := s.clients.Get(ctx, claims.ClientID); errors.Is(err, repository.ErrOIDCClientNotFound) {
+		return nil, invalidToken
+	} else if err != nil {
+		return nil, err
+	}
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, invalidToken
+	}
+	// токен живёт не дольше сессии: после выхода он недействителен
+	if ok, err := s.sessionActive(ctx, userID, claims.SessionID); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, invalidToken
+	}
+	return claims, nil
+}
+
+func (s *oidcService) UserInfo(ctx context.Context, accessToken string) (map[string]any, error) {
+	invalidToken := &oidc.Error{Code: oidc.ErrorInvalidToken, Description: "access token is invalid or expired"}
+	claims, err := s.VerifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	ctx = tenant.WithID(ctx, claims.TenantID)
+	scopes := strings.Fields(claims.Scope)
+	if !slices.Contains(scopes, ScopeOpenID) {
+		return nil, invalidToken
+	}
+
+	userID, _ := strconv.ParseInt(claims.Subject, 10, 64)
+	user, err := s.users.GetUserByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFoundUser) {
+		return nil, invalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info := map[string]any{"sub": claims.Subject}
+	if slices.Contains(scopes, ScopeEmail) {
+		info["email"] = user.Email
+	}
+	if slices.Contains(scopes, ScopeProfile) {
+		info["name"] = user.DisplayName
+		info["locale"] = user.Locale
+		info["zoneinfo"] = user.Timezone
+		info["picture"] = user.AvatarURL
+		info["updated_at"] = user.UpdatedAt.Unix()
+	}
+	return info, nil
+}
+
+func hashClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *oidcService) CreateClient(ctx context.Context, name string, redirectURIs []string, public bool) (*model.OIDCClient, string, error) {
+	if err := authorizeAdmin(ctx, s.admins); err != nil {
+		return nil, "", err
+	}
+	for i, raw := range redirectURIs {
+		// RFC 6749, раздел 3.1.2: абсолютный URI без фрагмента
+		u, err := url.Parse(raw)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			return nil, "", apperr.InvalidFields(apperr.FieldViolation(fmt.Sprintf("redirect_uris[%d]", i),
+				"value must be an absolute URI without fragment"))
+		}
+	}
+
+	client := &model.OIDCClient{
+		ID:           strings.ToLower(rand.Text()),
+		Name:         name,
+		RedirectURIs: redirectURIs,
+	}
+	var secret string
+	if !public {
+		secret = rand.Text() + rand.Text()
+		client.SecretHash = hashClientSecret(secret)
+	}
+	if err := s.clients.Create(ctx, client); err != nil {
+		return nil, "", err
+	}
+
+	logger.Infof("oidcService - CreateClient: Client %s (%s) registered", client.ID, name)
+	return client, secret, nil
+}
+
+func (s *oidcService) ListClients(ctx context.Context) ([]model.OIDCClient, error) {
+	if err := authorizeAdmin(ctx, s.admins); err != nil {
+		return nil, err
+	}
+	return s.clients.List(ctx)
+}
+
+func (s *oidcService) DeleteClient(ctx context.Context, clientID string) error {
+	if err := authorizeAdmin(ctx, s.admins); err != nil {
+		return err
+	}
+	err := s.clients.Delete(ctx, clientID)
+	if errors.Is(err, repository.ErrOIDCClientNotFound) {
+		return apperr.New(codes.NotFound, apperr.ReasonOIDCClientNotFound,
+			fmt.Sprintf("OIDC client %s not found", clientID), map[string]string{"client_id": clientID})
+	}
+	if err != nil {
+		return err
+	}
+	logger.Infof("oidcService - DeleteClient: Client %s deleted", clientID)
+	return nil
+}
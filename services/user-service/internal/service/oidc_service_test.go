@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/oidc"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/session"
+	"github.com/DmitriiPro/user-service/internal/signing"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type fakeOIDCClients struct {
+	clients map[string]*model.OIDCClient
+}
+
+func (r *fakeOIDCClients) Create(_ context.Context, c *model.OIDCClient) error {
+	r.clients[c.ID] = c
+	return nil
+}
+
+func (r *fakeOIDCClients) Get(_ context.Context, id string) (*model.OIDCClient, error) {
+	c, ok := r.clients[id]
+	if !ok {
+		return nil, repository.ErrOIDCClientNotFound
+	}
+	return c, nil
+}
+
+func (r *fakeOIDCClients) List(context.Context) ([]model.OIDCClient, error) { return nil, nil }
+
+func (r *fakeOIDCClients) Delete(_ context.Context, id string) error {
+	if _, ok := r.clients[id]; !ok {
+		return repository.ErrOIDCClientNotFound
+	}
+	delete(r.clients, id)
+	return nil
+}
+
+// memSigningKeys — SigningKeyRepository в памяти.
+type memSigningKeys struct {
+	keys []model.SigningKey
+}
+
+func (r *memSigningKeys) Create(_ context.Context, key *model.SigningKey) error {
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *memSigningKeys) ListActive(_ context.Context, now time.Time) ([]model.SigningKey, error) {
+	var out []model.SigningKey
+	for _, k := range r.keys {
+		if k.ExpiresAt.After(now) {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func (r *memSigningKeys) DeleteExpired(context.Context, time.Time) (int64, error) { return 0, nil }
+
+// plainSealer не шифрует: тесту не нужна защита ключей в БД.
+type plainSealer struct{}
+
+func (plainSealer) Seal(plaintext, _ []byte) []byte       { return plaintext }
+func (plainSealer) Open(sealed, _ []byte) ([]byte, error) { return sealed, nil }
+
+const (
+	testIssuer      = "https://id.example.com"
+	testRedirectURI = "https://app.example.com/callback"
+	testVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+type oidcTest struct {
+	svc          OIDCService
+	keys         *signing.KeyManager
+	clients      *fakeOIDCClients
+	sessions     session.Store
+	sessionToken string
+}
+
+func newOIDCTest(t *testing.T) *oidcTest {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	keys := signing.NewKeyManager(&memSigningKeys{}, plainSealer{}, config.SigningKeysConfig{
+		Algorithm: signing.AlgorithmEdDSA, Rotation: time.Hour, PublishAhead: time.Minute, Retention: time.Hour})
+	if err := keys.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	ot := &oidcTest{keys: keys, sessions: session.NewRedisStore(client), clients: &fakeOIDCClients{clients: map[string]*model.OIDCClient{
+		"app":   {ID: "app", RedirectURIs: []string{testRedirectURI}},
+		"other": {ID: "other", RedirectURIs: []string{"https://other.example.com/callback"}},
+	}}}
+	token, _, err := ot.sessions.Create(t.Context(), "", 1, "10.0.0.1", "Firefox", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ot.sessionToken = token
+	users := newFakeUsers(&model.User{ID: 1, Email: "alice@example.com", DisplayName: "Alice"})
+	ot.svc = NewOIDCService(users, ot.clients, ot.sessions, oidc.NewRedisCodeStore(client), keys,
+		config.OIDCConfig{Issuer: testIssuer, CodeTTL: time.Minute, TokenTTL: 15 * time.Minute}, testAdmins)
+	return ot
+}
+
+// authorize выдаёт клиенту app код для пользователя 1.
+func (ot *oidcTest) authorize(t *testing.T) string {
+	t.Helper()
+	redirect, err := ot.svc.Authorize(t.Context(), AuthorizeRequest{ResponseType: "code", ClientID: "app",
+		RedirectURI: testRedirectURI, Scope: "openid email", CodeChallenge: oidc.PKCEChallenge(testVerifier),
+		CodeChallengeMethod: oidc.PKCEMethod, SessionToken: ot.sessionToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(redirect)
+	if u.Query().Get("iss") != testIssuer || u.Query().Get("code") == "" {
+		t.Fatalf("redirect = %s", redirect)
+	}
+	return u.Query().Get("code")
+}
+
+func (ot *oidcTest) token(code string) (*TokenResponse, error) {
+	return ot.svc.Token(context.Background(), TokenRequest{GrantType: "authorization_code", Code: code,
+		RedirectURI: testRedirectURI, ClientID: "app", CodeVerifier: testVerifier})
+}
+
+func wantOAuthError(t *testing.T, err error, code string) {
+	t.Helper()
+	var oauthErr *oidc.Error
+	if !errors.As(err, &oauthErr) || oauthErr.Code != code {
+		t.Fatalf("err = %v, want %s", err, code)
+	}
+}
+
+func TestOIDCAuthorizeRequiresPKCE(t *testing.T) {
+	ot := newOIDCTest(t)
+	for _, method := range []string{"", "plain"} {
+		_, err := ot.svc.Authorize(t.Context(), AuthorizeRequest{ResponseType: "code", ClientID: "app",
+			RedirectURI: testRedirectURI, Scope: "openid", CodeChallenge: testVerifier, CodeChallengeMethod: method,
+			SessionToken: ot.sessionToken})
+		wantOAuthError(t, err, oidc.ErrorInvalidRequest)
+	}
+}
+
+func TestOIDCTokenExchange(t *testing.T) {
+	ot := newOIDCTest(t)
+	code := ot.authorize(t)
+
+	res, err := ot.token(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TokenType != "Bearer" || res.Scope != "openid email" {
+		t.Fatalf("token response = %+v", res)
+	}
+	claims, err := ot.svc.VerifyAccessToken(t.Context(), res.AccessToken)
+	if err != nil || claims.Subject != "1" || claims.ClientID != "app" {
+		t.Fatalf("VerifyAccessToken = %+v, %v", claims, err)
+	}
+	// ID токен не годится как access токен
+	_, err = ot.svc.VerifyAccessToken(t.Context(), res.IDToken)
+	wantOAuthError(t, err, oidc.ErrorInvalidToken)
+
+	// код одноразовый
+	_, err = ot.token(code)
+	wantOAuthError(t, err, oidc.ErrorInvalidGrant)
+}
+
+// Код, предъявленный с чужим client_id, redirect_uri или code_verifier,
+// отклоняется и не сгорает: законный клиент обменивает его после.
+func TestOIDCTokenCodeBinding(t *testing.T) {
+	tests := []struct {
+		name string
+		req  TokenRequest
+	}{
+		{"other client", TokenRequest{ClientID: "other", RedirectURI: testRedirectURI, CodeVerifier: testVerifier}},
+		{"other redirect_uri", TokenRequest{ClientID: "app", RedirectURI: "https://evil.example.com/cb", CodeVerifier: testVerifier}},
+		{"wrong code_verifier", TokenRequest{ClientID: "app", RedirectURI: testRedirectURI, CodeVerifier: strings.Repeat("a", 43)}},
+		{"no code_verifier", TokenRequest{ClientID: "app", RedirectURI: testRedirectURI}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ot := newOIDCTest(t)
+			code := ot.authorize(t)
+			tt.req.GrantType, tt.req.Code = "authorization_code", code
+			_, err := ot.svc.Token(t.Context(), tt.req)
+			wantOAuthError(t, err, oidc.ErrorInvalidGrant)
+
+			if _, err := ot.token(code); err != nil {
+				t.Fatalf("owner's exchange after %s: %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestOIDCVerifyAccessToken(t *testing.T) {
+	ot := newOIDCTest(t)
+	res, err := ot.token(ot.authorize(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(change func(c *oidc.Claims)) string {
+		now := time.Now()
+		c := oidc.Claims{Issuer: testIssuer, Subject: "1", Audience: oidc.Audience{"app"}, ClientID: "app",
+			IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix(), Scope: "openid"}
+		sess, _ := ot.sessions.Get(t.Context(), ot.sessionToken)
+		c.SessionID = sess.ID
+		change(&c)
+		key, _ := ot.keys.Signer(now)
+		token, err := oidc.Sign(key, oidc.TypeAccessToken, &c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	if _, err := ot.svc.VerifyAccessToken(t.Context(), sign(func(*oidc.Claims) {})); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"other issuer", sign(func(c *oidc.Claims) { c.Issuer = "https://evil.example.com" })},
+		{"aud without client", sign(func(c *oidc.Claims) { c.Audience = oidc.Audience{"other"} })},
+		{"no aud", sign(func(c *oidc.Claims) { c.Audience = nil })},
+		{"no client_id", sign(func(c *oidc.Claims) { c.ClientID, c.Audience = "", oidc.Audience{""} })},
+		{"unknown client", sign(func(c *oidc.Claims) { c.ClientID, c.Audience = "gone", oidc.Audience{"gone"} })},
+		{"closed session", sign(func(c *oidc.Claims) { c.SessionID = "closed" })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ot.svc.VerifyAccessToken(t.Context(), tt.token)
+			wantOAuthError(t, err, oidc.ErrorInvalidToken)
+		})
+	}
+
+	// токены удалённого клиента перестают действовать
+	delete(ot.clients.clients, "app")
+	_, err = ot.svc.VerifyAccessToken(t.Context(), res.AccessToken)
+	wantOAuthError(t, err, oidc.ErrorInvalidToken)
+}
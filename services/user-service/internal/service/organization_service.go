@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"google.golang.org/grpc/codes"
+)
+
+// OrganizationService управляет организациями и их участниками.
+// Владельцы управляют всем, администраторы — участниками без роли
+// владельца, участники только видят организацию. Сервисы, API ключи и
+// администраторы из admin.user_ids проходят любые проверки ролей.
+type OrganizationService interface {
+	// Create создаёт организацию; ownerID 0 — владельцем становится
+	// вызывающий пользователь.
+	Create(ctx context.Context, name string, ownerID int64) (*model.Organization, error)
+	Get(ctx context.Context, id int64) (*model.Organization, error)
+	ListForUser(ctx context.Context, userID int64) ([]model.UserOrganization, error)
+	Update(ctx context.Context, id int64, name string) (*model.Organization, error)
+	Delete(ctx context.Context, id int64) error
+
+	ListMembers(ctx context.Context, orgID int64) ([]model.Membership, error)
+	UpdateMemberRole(ctx context.Context, orgID, userID int64, role model.Role) (*model.Membership, error)
+	// RemoveMember исключает участника; участник может выйти сам.
+	RemoveMember(ctx context.Context, orgID, userID int64) error
+}
+
+type organizationService struct {
+	orgs   repository.OrganizationRepository
+	users  repository.UserRepository
+	admins []string
+}
+
+func NewOrganizationService(orgs repository.OrganizationRepository, users repository.UserRepository, admins []string) OrganizationService {
+	return &organizationService{orgs: orgs, users: users, admins: admins}
+}
+
+func errOrganizationNotFound(id int64) error {
+	return apperr.New(codes.NotFound, apperr.ReasonOrganizationNotFound,
+		fmt.Sprintf("organization %d not found", id), map[string]string{"organization_id": strconv.FormatInt(id, 10)})
+}
+
+func errMemberNotFound(orgID, userID int64) error {
+	return apperr.New(codes.NotFound, apperr.ReasonMemberNotFound,
+		fmt.Sprintf("user %d is not a member of organization %d", userID, orgID), nil)
+}
+
+// organizationError переводит ошибки OrganizationRepository в ответы API.
+func organizationError(err error, orgID, userID int64) error {
+	switch {
+	case errors.Is(err, repository.ErrOrganizationNotFound):
+		return errOrganizationNotFound(orgID)
+	case errors.Is(err, repository.ErrMemberNotFound):
+		return errMemberNotFound(orgID, userID)
+	case errors.Is(err, repository.ErrLastOwner):
+		return apperr.New(codes.FailedPrecondition, apperr.ReasonLastOwner, "organization must keep at least one owner", nil)
+	}
+	return err
+}
+
+// authorizeOrganization возвращает организацию и участие в ней
+// вызывающего пользователя с одной из ролей roles (без roles — с любой).
+// Для сервисов, API ключей и администраторов участие nil. Не участнику
+// организация не видна — NotFound, как и несуществующая.
+func authorizeOrganization(ctx context.Context, orgs repository.OrganizationRepository, admins []string,
+	orgID int64, roles ...model.Role) (*model.Organization, *model.Membership, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, nil, apperr.New(codes.Unauthenticated, apperr.ReasonUnauthenticated, "authentication required", nil)
+	}
+	org, err := orgs.Get(ctx, orgID)
+	if err != nil {
+		return nil, nil, organizationError(err, orgID, 0)
+	}
+	if authorizeAdmin(ctx, admins) == nil {
+		return org, nil, nil
+	}
+
+	userID, _ := strconv.ParseInt(p.ID, 10, 64)
+	m, err := orgs.GetMember(ctx, orgID, userID)
+	if errors.Is(err, repository.ErrMemberNotFound) {
+		return nil, nil, errOrganizationNotFound(orgID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(roles) > 0 && !slices.Contains(roles, m.Role) {
+		return nil, nil, apperr.New(codes.PermissionDenied, apperr.ReasonPermissionDenied,
+			fmt.Sprintf("organization role %s is not allowed to do this", m.Role), nil)
+	}
+	return org, m, nil
+}
+
+func (s *organizationService) Create(ctx context.Context, name string, ownerID int64) (*model.Organization, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, apperr.New(codes.Unauthenticated, apperr.ReasonUnauthenticated, "authentication required", nil)
+	}
+	if ownerID == 0 {
+		if p.Type != auth.PrincipalUser {
+			return nil, apperr.InvalidFields(apperr.FieldViolation("owner_id", "value is required for non-user callers"))
+		}
+		ownerID, _ = strconv.ParseInt(p.ID, 10, 64)
+	}
+	if err := authorizeUser(ctx, ownerID); err != nil {
+		return nil, err
+	}
+
+	// владелец должен быть в том же тенанте, что и организация
+	if _, err := s.users.GetUserByID(ctx, ownerID); err != nil {
+		if errors.Is(err, repository.ErrNotFoundUser) {
+			return nil, apperr.New(codes.NotFound, apperr.ReasonUserNotFound,
+				fmt.Sprintf("user with id %d not found", ownerID), map[string]string{"id": strconv.FormatInt(ownerID, 10)})
+		}
+		return nil, err
+	}
+
+	org := &model.Organization{Name: name}
+	if err := s.orgs.Create(ctx, org, ownerID); err != nil {
+		return nil, err
+	}
+	logger.Infof("organizationService - Create: Organization %d (%s) created with owner %d", org.ID, org.Name, ownerID)
+	return org, nil
+}
+
+func (s *organizationService) Get(ctx context.Context, id int64) (*model.Organization, error) {
+	org, _, err := authorizeOrganization(ctx, s.orgs, s.admins, id)
+	return org, err
+}
+
+func (s *organizationService) ListForUser(ctx context.Context, userID int64) ([]model.UserOrganization, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.orgs.ListByUser(ctx, userID)
+}
+
+func (s *organizationService) Update(ctx context.Context, id int64, name string) (*model.Organization, error) {
+	if _, _, err := authorizeOrganization(ctx, s.orgs, s.admins, id, model.RoleOwner, model.RoleAdmin); err != nil {
+		return nil, err
+	}
+	org, err := s.orgs.Update(ctx, id, name)
+	if err != nil {
+		return nil, organizationError(err, id, 0)
+	}
+	return org, nil
+}
+
+func (s *organizationService) Delete(ctx context.Context, id int64) error {
+	if _, _, err := authorizeOrganization(ctx, s.orgs, s.admins, id, model.RoleOwner); err != nil {
+		return err
+	}
+	if err := s.orgs.Delete(ctx, id); err != nil {
+		return organizationError(err, id, 0)
+	}
+	logger.Infof("organizationService - Delete: Organization %d deleted", id)
+	return nil
+}
+
+func (s *organizationService) ListMembers(ctx context.Context, orgID int64) ([]model.Membership, error) {
+	if _, _, err := authorizeOrganization(ctx, s.orgs, s.admins, orgID); err != nil {
+		return nil, err
+	}
+	return s.orgs.ListMembers(ctx, orgID)
+}
+
+// authorizeMemberChange проверяет, что вызывающий может менять участника
+// userID: роль владельца выдают и снимают только владельцы.
+func (s *organizationService) authorizeMemberChange(ctx context.Context, orgID, userID int64, role model.Role) error {
+	_, caller, err := authorizeOrganization(ctx, s.orgs, s.admins, orgID, model.RoleOwner, model.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	target, err := s.orgs.GetMember(ctx, orgID, userID)
+	if err != nil {
+		return organizationError(err, orgID, userID)
+	}
+	if caller != nil && caller.Role != model.RoleOwner && (target.Role == model.RoleOwner || role == model.RoleOwner) {
+		return apperr.New(codes.PermissionDenied, apperr.ReasonPermissionDenied, "only owners can manage owners", nil)
+	}
+	return nil
+}
+
+func (s *organizationService) UpdateMemberRole(ctx context.Context, orgID, userID int64, role model.Role) (*model.Membership, error) {
+	if !role.Valid() {
+		return nil, apperr.InvalidFields(apperr.FieldViolation("role", fmt.Sprintf("unknown role %q", role)))
+	}
+	if err := s.authorizeMemberChange(ctx, orgID, userID, role); err != nil {
+		return nil, err
+	}
+	m, err := s.orgs.SetMemberRole(ctx, orgID, userID, role)
+	if err != nil {
+		return nil, organizationError(err, orgID, userID)
+	}
+	logger.Infof("organizationService - UpdateMemberRole: User %d is now %s in organization %d", userID, role, orgID)
+	return m, nil
+}
+
+func (s *organizationService) RemoveMember(ctx context.Context, orgID, userID int64) error {
+	p, _ := auth.FromContext(ctx)
+	self := p != nil && p.Type == auth.PrincipalUser && p.ID == strconv.FormatInt(userID, 10)
+	if self {
+		_, _, err := authorizeOrganization(ctx, s.orgs, s.admins, orgID)
+		if err != nil {
+			return err
+		}
+	} else if err := s.authorizeMemberChange(ctx, orgID, userID, ""); err != nil {
+		return err
+	}
+
+	if err := s.orgs.RemoveMember(ctx, orgID, userID); err != nil {
+		return organizationError(err, orgID, userID)
+	}
+	logger.Infof("organizationService - RemoveMember: User %d removed from organization %d", userID, orgID)
+	return nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"google.golang.org/grpc/codes"
+)
+
+const testOrgID = 1
+
+// Участники организации testOrgID в newTestOrgs и посторонний пользователь.
+var (
+	orgOwner    = named("owner", asUser(10))
+	orgAdmin    = named("org admin", asUser(11))
+	orgMember   = named("member", asUser(12))
+	orgOutsider = named("outsider", asUser(13))
+)
+
+func named(name string, c caller) caller {
+	c.name = name
+	return c
+}
+
+func newTestOrgs() (*fakeOrgs, *fakeUsers) {
+	orgs := newFakeOrgs()
+	orgs.add(testOrgID, "Acme", map[int64]model.Role{10: model.RoleOwner, 11: model.RoleAdmin, 12: model.RoleMember})
+	users := newFakeUsers(
+		&model.User{ID: 10, Email: "owner@example.com"}, &model.User{ID: 11, Email: "admin@example.com"},
+		&model.User{ID: 12, Email: "member@example.com"}, &model.User{ID: 13, Email: "outsider@example.com"},
+	)
+	return orgs, users
+}
+
+func TestOrganizationServiceRoles(t *testing.T) {
+	get := func(ctx context.Context, s OrganizationService) error {
+		_, err := s.Get(ctx, testOrgID)
+		return err
+	}
+	listMembers := func(ctx context.Context, s OrganizationService) error {
+		_, err := s.ListMembers(ctx, testOrgID)
+		return err
+	}
+	rename := func(ctx context.Context, s OrganizationService) error {
+		_, err := s.Update(ctx, testOrgID, "Acme Inc")
+		return err
+	}
+	remove := func(ctx context.Context, s OrganizationService) error {
+		return s.Delete(ctx, testOrgID)
+	}
+	setRole := func(userID int64, role model.Role) func(context.Context, OrganizationService) error {
+		return func(ctx context.Context, s OrganizationService) error {
+			_, err := s.UpdateMemberRole(ctx, testOrgID, userID, role)
+			return err
+		}
+	}
+	removeMember := func(userID int64) func(context.Context, OrganizationService) error {
+		return func(ctx context.Context, s OrganizationService) error {
+			return s.RemoveMember(ctx, testOrgID, userID)
+		}
+	}
+	listForUser := func(userID int64) func(context.Context, OrganizationService) error {
+		return func(ctx context.Context, s OrganizationService) error {
+			_, err := s.ListForUser(ctx, userID)
+			return err
+		}
+	}
+
+	tests := []struct {
+		action string
+		call   func(context.Context, OrganizationService) error
+		caller caller
+		want   codes.Code
+	}{
+		{"get", get, anonymous, codes.Unauthenticated},
+		{"get", get, orgMember, codes.OK},
+		// посторонний не узнаёт, что организация существует
+		{"get", get, orgOutsider, codes.NotFound},
+		{"get", get, asAdmin, codes.OK},
+		{"get", get, asService, codes.OK},
+		{"list members", listMembers, orgMember, codes.OK},
+		{"list members", listMembers, orgOutsider, codes.NotFound},
+
+		{"rename", rename, orgOwner, codes.OK},
+		{"rename", rename, orgAdmin, codes.OK},
+		{"rename", rename, orgMember, codes.PermissionDenied},
+		{"rename", rename, orgOutsider, codes.NotFound},
+		{"rename", rename, asAPIKey, codes.OK},
+
+		{"delete", remove, orgOwner, codes.OK},
+		{"delete", remove, orgAdmin, codes.PermissionDenied},
+		{"delete", remove, orgMember, codes.PermissionDenied},
+		{"delete", remove, orgOutsider, codes.NotFound},
+		{"delete", remove, asAdmin, codes.OK},
+
+		{"promote member to admin", setRole(12, model.RoleAdmin), orgOwner, codes.OK},
+		{"promote member to admin", setRole(12, model.RoleAdmin), orgAdmin, codes.OK},
+		{"promote member to admin", setRole(12, model.RoleAdmin), orgMember, codes.PermissionDenied},
+		{"promote member to admin", setRole(12, model.RoleAdmin), orgOutsider, codes.NotFound},
+		// роль владельца выдают и снимают только владельцы
+		{"promote member to owner", setRole(12, model.RoleOwner), orgOwner, codes.OK},
+		{"promote member to owner", setRole(12, model.RoleOwner), orgAdmin, codes.PermissionDenied},
+		{"promote self to owner", setRole(11, model.RoleOwner), orgAdmin, codes.PermissionDenied},
+		{"demote owner", setRole(10, model.RoleMember), orgAdmin, codes.PermissionDenied},
+		{"demote owner", setRole(10, model.RoleMember), asService, codes.FailedPrecondition},
+		{"set unknown role", setRole(12, "root"), orgOwner, codes.InvalidArgument},
+		{"set role of non-member", setRole(13, model.RoleAdmin), orgOwner, codes.NotFound},
+
+		{"remove member", removeMember(12), orgAdmin, codes.OK},
+		{"remove member", removeMember(12), orgMember, codes.OK},
+		{"remove admin", removeMember(11), orgMember, codes.PermissionDenied},
+		{"remove owner", removeMember(10), orgAdmin, codes.PermissionDenied},
+		{"remove owner", removeMember(10), orgOwner, codes.FailedPrecondition},
+		{"leave", removeMember(13), orgOutsider, codes.NotFound},
+
+		{"list own organizations", listForUser(12), orgMember, codes.OK},
+		{"list organizations of another user", listForUser(12), orgAdmin, codes.PermissionDenied},
+		{"list organizations of another user", listForUser(12), asService, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action+"/"+tt.caller.name, func(t *testing.T) {
+			orgs, users := newTestOrgs()
+			svc := NewOrganizationService(orgs, users, testAdmins)
+			before := snapshotOrg(orgs)
+
+			wantCode(t, tt.call(tt.caller.ctx(), svc), tt.want)
+			if tt.want != codes.OK && snapshotOrg(orgs) != before {
+				t.Fatalf("denied %s changed the organization: %s -> %s", tt.action, before, snapshotOrg(orgs))
+			}
+		})
+	}
+}
+
+// snapshotOrg — имя и состав организации testOrgID для сравнения.
+func snapshotOrg(orgs *fakeOrgs) string {
+	orgs.mu.Lock()
+	defer orgs.mu.Unlock()
+	org, ok := orgs.orgs[testOrgID]
+	if !ok {
+		return "deleted"
+	}
+	s := org.Name
+	for _, id := range []int64{10, 11, 12, 13} {
+		s += " " + string(orgs.members[testOrgID][id])
+	}
+	return s
+}
+
+func TestOrganizationServiceKeepsAnOwner(t *testing.T) {
+	orgs, users := newTestOrgs()
+	svc := NewOrganizationService(orgs, users, testAdmins)
+
+	// единственный владелец не может ни уйти, ни сложить роль
+	err := svc.RemoveMember(orgOwner.ctx(), testOrgID, 10)
+	wantReason(t, err, codes.FailedPrecondition, apperr.ReasonLastOwner)
+	_, err = svc.UpdateMemberRole(orgOwner.ctx(), testOrgID, 10, model.RoleAdmin)
+	wantReason(t, err, codes.FailedPrecondition, apperr.ReasonLastOwner)
+
+	// назначив второго владельца — может
+	if _, err := svc.UpdateMemberRole(orgOwner.ctx(), testOrgID, 11, model.RoleOwner); err != nil {
+		t.Fatal(err)
+	}
+	wantCode(t, svc.RemoveMember(orgOwner.ctx(), testOrgID, 10), codes.OK)
+	if role, _ := orgs.role(testOrgID, 11); role != model.RoleOwner {
+		t.Fatalf("remaining owner has role %q", role)
+	}
+	_, err = svc.UpdateMemberRole(orgAdmin.ctx(), testOrgID, 11, model.RoleMember)
+	wantReason(t, err, codes.FailedPrecondition, apperr.ReasonLastOwner)
+}
+
+func TestOrganizationServiceCreate(t *testing.T) {
+	tests := []struct {
+		name      string
+		caller    caller
+		ownerID   int64
+		want      codes.Code
+		wantOwner int64
+	}{
+		{"anonymous", anonymous, 0, codes.Unauthenticated, 0},
+		{"caller becomes owner", orgOutsider, 0, codes.OK, 13},
+		{"user names self", orgOutsider, 13, codes.OK, 13},
+		{"user for another user", orgOutsider, 12, codes.PermissionDenied, 0},
+		{"service must name owner", asService, 0, codes.InvalidArgument, 0},
+		{"service for user", asService, 12, codes.OK, 12},
+		{"service for missing user", asService, 99, codes.NotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs, users := newTestOrgs()
+			svc := NewOrganizationService(orgs, users, testAdmins)
+
+			org, err := svc.Create(tt.caller.ctx(), "Globex", tt.ownerID)
+			wantCode(t, err, tt.want)
+			if tt.want != codes.OK {
+				if len(orgs.orgs) != 1 {
+					t.Fatalf("denied create stored an organization")
+				}
+				return
+			}
+			if role, _ := orgs.role(org.ID, tt.wantOwner); role != model.RoleOwner {
+				t.Fatalf("user %d has role %q in the new organization, want owner", tt.wantOwner, role)
+			}
+		})
+	}
+}
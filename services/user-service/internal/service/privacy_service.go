@@ -0,0 +1,350 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/avatar"
+	"github.com/DmitriiPro/user-service/internal/blob"
+	"github.com/DmitriiPro/user-service/internal/cache"
+	"github.com/DmitriiPro/user-service/internal/lockout"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/session"
+	"google.golang.org/grpc/codes"
+)
+
+// PrivacyService выгружает и удаляет персональные данные пользователя по
+// его запросу (GDPR). Доступно самому пользователю, администраторам и
+// сервисам.
+type PrivacyService interface {
+	// Export собирает всё, что хранится о пользователе, в ZIP архив с JSON
+	// файлами. Секреты (хэш пароля, ключ TOTP, коды восстановления) не
+	// выгружаются.
+	Export(ctx context.Context, userID int64) (*DataExport, error)
+	// Erase безвозвратно удаляет пользователя: данные в БД удаляются или
+	// обезличиваются, сессии, кэш и аватар удаляются. Остаётся запись
+	// model.Erasure; email в ней — только HMAC с ключом сервиса.
+	Erase(ctx context.Context, userID int64, reason string) (*model.Erasure, error)
+}
+
+type DataExport struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+type privacyService struct {
+	users      repository.UserRepository
+	events     repository.LoginEventRepository
+	identities repository.FederatedIdentityRepository
+	totp       repository.TOTPRepository
+	orgs       repository.OrganizationRepository
+	erasures   repository.ErasureRepository
+	sessions   session.Store
+	guard      lockout.Guard
+	avatars    blob.Store
+	userCache  cache.Cache
+	// introspection — кэш ответов Introspect, сбрасывается при удалении.
+	introspection cache.Tagged
+	// emailHashKey — ключ HMAC model.Erasure.EmailHash; nil — email в
+	// запись не попадает.
+	emailHashKey []byte
+	admins       []string
+}
+
+func NewPrivacyService(users repository.UserRepository, events repository.LoginEventRepository,
+	identities repository.FederatedIdentityRepository, totp repository.TOTPRepository,
+	orgs repository.OrganizationRepository, erasures repository.ErasureRepository, sessions session.Store,
+	guard lockout.Guard, avatars blob.Store, userCache cache.Cache, introspection cache.Tagged, emailHashKey []byte,
+	admins []string) PrivacyService {
+	return &privacyService{users: users, events: events, identities: identities, totp: totp, orgs: orgs,
+		erasures: erasures, sessions: sessions, guard: guard, avatars: avatars, userCache: userCache,
+		introspection: introspection, emailHashKey: emailHashKey, admins: admins}
+}
+
+// exportEventsPageSize — по сколько событий входа читать за запрос.
+const exportEventsPageSize = 1000
+
+func (s *privacyService) getUser(ctx context.Context, userID int64) (*model.User, error) {
+	if err := authorizeUserOrAdmin(ctx, userID, s.admins); err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetUserByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFoundUser) {
+		return nil, apperr.New(codes.NotFound, apperr.ReasonUserNotFound,
+			fmt.Sprintf("user with id %d not found", userID), map[string]string{"id": strconv.FormatInt(userID, 10)})
+	}
+	return user, err
+}
+
+// Файлы архива; поля в snake_case, время в RFC 3339.
+
+type exportUser struct {
+	ID          int64             `json:"id"`
+	TenantID    string            `json:"tenant_id"`
+	Email       string            `json:"email"`
+	DisplayName string            `json:"display_name"`
+	Phone       string            `json:"phone"`
+	Locale      string            `json:"locale"`
+	Timezone    string            `json:"timezone"`
+	AvatarURL   string            `json:"avatar_url"`
+	Metadata    map[string]string `json:"metadata"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+type exportLoginEvent struct {
+	ID            int64     `json:"id"`
+	Email         string    `json:"email"`
+	Method        string    `json:"method"`
+	IP            string    `json:"ip"`
+	UserAgent     string    `json:"user_agent"`
+	Success       bool      `json:"success"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type exportIdentity struct {
+	Provider    string    `json:"provider"`
+	Subject     string    `json:"subject"`
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+type exportTwoFactor struct {
+	Enabled     bool       `json:"enabled"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+}
+
+type exportOrganization struct {
+	OrganizationID int64     `json:"organization_id"`
+	Name           string    `json:"name"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type exportManifest struct {
+	UserID      int64     `json:"user_id"`
+	TenantID    string    `json:"tenant_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+func (s *privacyService) Export(ctx context.Context, userID int64) (*DataExport, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// пустые списки выгружаются как [], а не null
+	events := []exportLoginEvent{}
+	for beforeID := int64(0); ; {
+		page, err := s.events.ListByUser(ctx, userID, beforeID, exportEventsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page {
+			events = append(events, exportLoginEvent{ID: e.ID, Email: e.Email, Method: e.Method, IP: e.IP,
+				UserAgent: e.UserAgent, Success: e.Success, FailureReason: e.FailureReason, CreatedAt: e.CreatedAt})
+		}
+		if len(page) < exportEventsPageSize {
+			break
+		}
+		beforeID = page[len(page)-1].ID
+	}
+
+	sessions, err := s.sessions.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions = append([]session.Session{}, sessions...)
+
+	fedIdentities, err := s.identities.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	identities := make([]exportIdentity, 0, len(fedIdentities))
+	for _, i := range fedIdentities {
+		identities = append(identities, exportIdentity{Provider: i.Provider, Subject: i.Subject, Email: i.Email,
+			CreatedAt: i.CreatedAt, LastLoginAt: i.LastLoginAt})
+	}
+
+	var twoFactor exportTwoFactor
+	totp, err := s.totp.Get(ctx, userID)
+	switch {
+	case err == nil:
+		twoFactor = exportTwoFactor{Enabled: totp.Confirmed(), CreatedAt: &totp.CreatedAt, ConfirmedAt: totp.ConfirmedAt}
+	case !errors.Is(err, repository.ErrTOTPNotFound):
+		return nil, err
+	}
+
+	userOrgs, err := s.orgs.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	orgs := make([]exportOrganization, 0, len(userOrgs))
+	for _, o := range userOrgs {
+		orgs = append(orgs, exportOrganization{OrganizationID: o.Organization.ID, Name: o.Organization.Name,
+			Role: string(o.Role), CreatedAt: o.Organization.CreatedAt})
+	}
+
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"user.json", exportUser{ID: user.ID, TenantID: user.TenantID, Email: user.Email, DisplayName: user.DisplayName,
+			Phone: user.Phone, Locale: user.Locale, Timezone: user.Timezone, AvatarURL: user.AvatarURL,
+			Metadata: user.Metadata, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt}},
+		{"sessions.json", sessions},
+		{"login_events.json", events},
+		{"federated_identities.json", identities},
+		{"two_factor.json", twoFactor},
+		{"organizations.json", orgs},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	manifest := exportManifest{UserID: user.ID, TenantID: user.TenantID, GeneratedAt: time.Now().UTC()}
+	for _, f := range files {
+		if err := writeJSON(zw, f.name, f.v); err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, f.name)
+	}
+
+	name, err := s.exportAvatar(ctx, zw, userID)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		manifest.Files = append(manifest.Files, name)
+	}
+
+	if err := writeJSON(zw, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("privacyService - Export: Archive for user %d built, %d bytes", userID, buf.Len())
+	return &DataExport{
+		Filename:    fmt.Sprintf("user-%d-%s.zip", userID, manifest.GeneratedAt.Format("20060102")),
+		ContentType: "application/zip",
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// exportAvatar кладёт в архив исходный по размеру аватар (остальные —
+// его уменьшенные копии) и возвращает имя файла; "" — аватара нет.
+func (s *privacyService) exportAvatar(ctx context.Context, zw *zip.Writer, userID int64) (string, error) {
+	obj, err := s.avatars.Get(ctx, avatarKey(userID, slices.Max(avatar.Sizes)))
+	if errors.Is(err, blob.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer obj.Body.Close()
+
+	name := "avatar.png"
+	if obj.ContentType == "image/jpeg" {
+		name = "avatar.jpg"
+	}
+	w, err := zw.Create(name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(w, obj.Body); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// hashEmail — model.Erasure.EmailHash. Простой sha256 email подбирается
+// по списку адресов, поэтому хэш ключевой.
+func hashEmail(key []byte, email string) string {
+	if key == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.ToLower(email)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *privacyService) Erase(ctx context.Context, userID int64, reason string) (*model.Erasure, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	p, _ := auth.FromContext(ctx)
+	rec := &model.Erasure{
+		EmailHash:   hashEmail(s.emailHashKey, user.Email),
+		RequestedBy: string(p.Type) + ":" + p.ID,
+		Reason:      reason,
+	}
+	err = s.erasures.Erase(ctx, user, rec)
+	if errors.Is(err, repository.ErrLastOwner) {
+		return nil, apperr.New(codes.FailedPrecondition, apperr.ReasonLastOwner,
+			"user is the last owner of an organization with other members; transfer ownership first", nil)
+	}
+	if errors.Is(err, repository.ErrNotFoundUser) {
+		return nil, apperr.New(codes.NotFound, apperr.ReasonUserNotFound,
+			fmt.Sprintf("user with id %d not found", userID), map[string]string{"id": strconv.FormatInt(userID, 10)})
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("privacyService - Erase: User %d erased by %s (erasure %d): %v", userID, rec.RequestedBy, rec.ID, rec.Summary)
+
+	// пользователя в БД уже нет, поэтому ошибки ниже запрос не проваливают:
+	// повторить удаление будет нельзя. Одноразовые токены (magic link,
+	// MFA, OIDC коды) живут минуты и без пользователя не сработают
+	ctx = context.WithoutCancel(ctx)
+	if _, err := s.sessions.RevokeAll(ctx, userID, ""); err != nil {
+		logger.Errorf("privacyService - Erase: Failed to revoke sessions of user %d (erasure %d): %v", userID, rec.ID, err)
+	}
+	invalidateIntrospection(ctx, s.introspection, userTag(userID))
+	if err := s.userCache.Del(ctx, userCacheKey(ctx, userID)); err != nil {
+		logger.Errorf("privacyService - Erase: Failed to delete cache of user %d (erasure %d): %v", userID, rec.ID, err)
+	}
+	if err := s.guard.Unlock(ctx, user.Email); err != nil {
+		logger.Errorf("privacyService - Erase: Failed to delete lockout state of user %d (erasure %d): %v", userID, rec.ID, err)
+	}
+	for _, size := range avatar.Sizes {
+		err := s.avatars.Delete(ctx, avatarKey(userID, size))
+		if err != nil && !errors.Is(err, blob.ErrNotFound) {
+			logger.Errorf("privacyService - Erase: Failed to delete %dpx avatar of user %d (erasure %d): %v", size, userID, rec.ID, err)
+		}
+	}
+	return rec, nil
+}
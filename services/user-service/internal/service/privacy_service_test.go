@@ -0,0 +1,308 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/avatar"
+	"github.com/DmitriiPro/user-service/internal/blob"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/lockout"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/session"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+)
+
+var testErasureKey = bytes.Repeat([]byte{7}, 32)
+
+type fakeEvents struct {
+	repository.LoginEventRepository
+	events []model.LoginEvent // новые первыми
+}
+
+func (r *fakeEvents) Record(_ context.Context, e *model.LoginEvent) error {
+	e.ID = int64(len(r.events) + 1)
+	r.events = append([]model.LoginEvent{*e}, r.events...)
+	return nil
+}
+
+func (r *fakeEvents) ListByUser(_ context.Context, userID, beforeID int64, limit int) ([]model.LoginEvent, error) {
+	var out []model.LoginEvent
+	for _, e := range r.events {
+		if e.UserID == userID && (beforeID == 0 || e.ID < beforeID) && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// fakeErasures удаляет пользователя из fakeUsers и его участие в
+// организациях; как и Postgres, не удаляет последнего владельца
+// организации с другими участниками.
+type fakeErasures struct {
+	users *fakeUsers
+	orgs  *fakeOrgs
+}
+
+func (r *fakeErasures) Erase(_ context.Context, user *model.User, rec *model.Erasure) error {
+	r.orgs.mu.Lock()
+	defer r.orgs.mu.Unlock()
+	for orgID, members := range r.orgs.members {
+		if len(members) > 1 && r.orgs.lastOwner(orgID, user.ID) {
+			return repository.ErrLastOwner
+		}
+	}
+	r.users.mu.Lock()
+	defer r.users.mu.Unlock()
+	if _, ok := r.users.users[user.ID]; !ok {
+		return repository.ErrNotFoundUser
+	}
+	delete(r.users.users, user.ID)
+	for _, members := range r.orgs.members {
+		delete(members, user.ID)
+	}
+	rec.ID, rec.UserID, rec.Summary = 1, user.ID, map[string]int64{"users": 1}
+	return nil
+}
+
+type privacyTest struct {
+	svc           PrivacyService
+	users         *fakeUsers
+	orgs          *fakeOrgs
+	sessions      session.Store
+	guard         lockout.Guard
+	avatars       blob.Store
+	userCache     *memCache
+	introspection *memCache
+}
+
+var testLockout = config.LockoutConfig{MaxFailures: 3, LockDuration: time.Hour, Window: time.Hour, DelayAfter: 10,
+	IPMaxFailures: 100, IPLockDuration: time.Hour}
+
+func newPrivacyTest(t *testing.T) *privacyTest {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	avatars, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orgs, users := newTestOrgs()
+	users.users[12].PasswordHash = "$2a$10$secret-password-hash"
+	events := &fakeEvents{}
+	for id := int64(exportEventsPageSize + 1); id > 0; id-- {
+		events.events = append(events.events, model.LoginEvent{ID: id, UserID: 12, Email: "member@example.com", Success: true})
+	}
+	confirmed := time.Now()
+	totp := &fakeTOTP{byUser: map[int64]*model.TOTP{12: {UserID: 12, Secret: []byte("totp-secret-bytes"), ConfirmedAt: &confirmed}}}
+	identities := &fakeIdentities{links: map[string]model.FederatedIdentity{
+		"github/4242": {UserID: 12, Provider: "github", Subject: "4242", Email: "member@example.com"},
+	}}
+
+	pt := &privacyTest{users: users, orgs: orgs, sessions: session.NewRedisStore(client),
+		guard: lockout.NewRedisGuard(client, testLockout), avatars: avatars, userCache: newMemCache(), introspection: newMemCache()}
+	pt.svc = NewPrivacyService(users, events, identities, totp, orgs, &fakeErasures{users: users, orgs: orgs},
+		pt.sessions, pt.guard, avatars, pt.userCache, pt.introspection, testErasureKey, testAdmins)
+	return pt
+}
+
+func TestPrivacyServiceAuthorization(t *testing.T) {
+	tests := []struct {
+		caller caller
+		want   codes.Code
+	}{
+		{anonymous, codes.Unauthenticated},
+		{orgMember, codes.OK},
+		{orgOwner, codes.PermissionDenied},
+		{asAdmin, codes.OK},
+		{asService, codes.OK},
+		{asAPIKey, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run("export/"+tt.caller.name, func(t *testing.T) {
+			pt := newPrivacyTest(t)
+			export, err := pt.svc.Export(tt.caller.ctx(), 12)
+			wantCode(t, err, tt.want)
+			if tt.want != codes.OK && export != nil {
+				t.Fatal("denied export returned an archive")
+			}
+		})
+		t.Run("erase/"+tt.caller.name, func(t *testing.T) {
+			pt := newPrivacyTest(t)
+			_, err := pt.svc.Erase(tt.caller.ctx(), 12, "")
+			wantCode(t, err, tt.want)
+			_, getErr := pt.users.GetUserByID(t.Context(), 12)
+			if erased := getErr != nil; erased != (tt.want == codes.OK) {
+				t.Fatalf("user erased = %v, want %v", erased, tt.want == codes.OK)
+			}
+		})
+	}
+}
+
+func TestPrivacyServiceExport(t *testing.T) {
+	pt := newPrivacyTest(t)
+	if _, _, err := pt.sessions.Create(t.Context(), "default", 12, "10.0.0.1", "test", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	pt.avatars.Put(t.Context(), avatarKey(12, slices.Max(avatar.Sizes)), []byte("jpeg bytes"), "image/jpeg")
+
+	export, err := pt.svc.Export(orgMember.ctx(), 12)
+	wantCode(t, err, codes.OK)
+	if export.ContentType != "application/zip" || !strings.HasPrefix(export.Filename, "user-12-") {
+		t.Fatalf("export = %s %s", export.Filename, export.ContentType)
+	}
+	files := unzip(t, export.Data)
+
+	var manifest exportManifest
+	json.Unmarshal(files["manifest.json"], &manifest)
+	want := []string{"user.json", "sessions.json", "login_events.json", "federated_identities.json",
+		"two_factor.json", "organizations.json", "avatar.jpg"}
+	if !slices.Equal(manifest.Files, want) || manifest.UserID != 12 {
+		t.Fatalf("manifest = %+v, want files %v", manifest, want)
+	}
+	for _, name := range want {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive has no %s", name)
+		}
+	}
+
+	counts := map[string]int{"sessions.json": 1, "login_events.json": exportEventsPageSize + 1,
+		"federated_identities.json": 1, "organizations.json": 1}
+	for name, n := range counts {
+		var items []json.RawMessage
+		if err := json.Unmarshal(files[name], &items); err != nil || len(items) != n {
+			t.Errorf("%s has %d items (%v), want %d", name, len(items), err, n)
+		}
+	}
+	var twoFactor exportTwoFactor
+	if json.Unmarshal(files["two_factor.json"], &twoFactor); !twoFactor.Enabled {
+		t.Error("two_factor.json does not report enabled TOTP")
+	}
+
+	// секреты в архив не попадают
+	for name, data := range files {
+		for _, secret := range []string{"secret-password-hash", "totp-secret-bytes", "dG90cC1zZWNyZXQtYnl0ZXM"} {
+			if bytes.Contains(data, []byte(secret)) {
+				t.Errorf("%s contains secret %q", name, secret)
+			}
+		}
+	}
+}
+
+func unzip(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	return files
+}
+
+func TestPrivacyServiceErase(t *testing.T) {
+	pt := newPrivacyTest(t)
+	ctx := t.Context()
+	token, _, err := pt.sessions.Create(ctx, "default", 12, "10.0.0.1", "test", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range testLockout.MaxFailures {
+		pt.guard.Check(ctx, "member@example.com", "10.0.0.1")
+		pt.guard.Failure(ctx, "member@example.com", "10.0.0.1")
+	}
+	if d, _ := pt.guard.Check(ctx, "member@example.com", "10.0.0.1"); !d.Locked {
+		t.Fatalf("account is not locked before erase: %+v", d)
+	}
+	pt.userCache.Set(ctx, userCacheKey(ctx, 12), `{"id":12}`)
+	pt.introspection.SetTagged(ctx, introspectionKey(token), `{"active":true}`, time.Hour, userTag(12))
+	for _, size := range avatar.Sizes {
+		pt.avatars.Put(ctx, avatarKey(12, size), []byte("png"), "image/png")
+	}
+
+	rec, err := pt.svc.Erase(asAdmin.ctx(), 12, "user request")
+	wantCode(t, err, codes.OK)
+	if rec.RequestedBy != "user:"+testAdminID || rec.EmailHash != hashEmail(testErasureKey, "member@example.com") || rec.Reason != "user request" {
+		t.Fatalf("erasure = %+v", rec)
+	}
+
+	if _, err := pt.users.GetUserByID(ctx, 12); err == nil {
+		t.Error("user is still stored")
+	}
+	if _, member := pt.orgs.role(testOrgID, 12); member {
+		t.Error("user is still a member of the organization")
+	}
+	if _, err := pt.sessions.Get(ctx, token); err != session.ErrNotFound {
+		t.Errorf("session after erase: %v", err)
+	}
+	if d, _ := pt.guard.Check(ctx, "member@example.com", "10.0.0.1"); !d.Allowed {
+		t.Errorf("lockout state survived erase: %+v", d)
+	}
+	if pt.userCache.len() != 0 || pt.introspection.len() != 0 {
+		t.Errorf("cached entries survived erase: user %d, introspection %d", pt.userCache.len(), pt.introspection.len())
+	}
+	for _, size := range avatar.Sizes {
+		if _, err := pt.avatars.Get(ctx, avatarKey(12, size)); err != blob.ErrNotFound {
+			t.Errorf("%dpx avatar after erase: %v", size, err)
+		}
+	}
+
+	// повторное удаление — пользователя уже нет
+	_, err = pt.svc.Erase(asAdmin.ctx(), 12, "")
+	wantReason(t, err, codes.NotFound, apperr.ReasonUserNotFound)
+}
+
+func TestHashEmail(t *testing.T) {
+	got := hashEmail(testErasureKey, "Member@Example.com")
+	if got != hashEmail(testErasureKey, "member@example.com") {
+		t.Error("hash depends on email case")
+	}
+	plain := sha256.Sum256([]byte("member@example.com"))
+	if got == "" || got == hex.EncodeToString(plain[:]) {
+		t.Errorf("hash = %q, want a keyed hash", got)
+	}
+	if other := hashEmail(bytes.Repeat([]byte{8}, 32), "member@example.com"); other == got {
+		t.Error("hash does not depend on the key")
+	}
+	if h := hashEmail(nil, "member@example.com"); h != "" {
+		t.Errorf("hash without key = %q, want empty", h)
+	}
+}
+
+func TestPrivacyServiceEraseLastOwner(t *testing.T) {
+	pt := newPrivacyTest(t)
+
+	// владелец сам не может удалить себя, пока в организации есть другие
+	_, err := pt.svc.Erase(orgOwner.ctx(), 10, "")
+	wantReason(t, err, codes.FailedPrecondition, apperr.ReasonLastOwner)
+	if _, err := pt.users.GetUserByID(t.Context(), 10); err != nil {
+		t.Fatalf("last owner was erased: %v", err)
+	}
+
+	// передав владение — может
+	pt.orgs.SetMemberRole(t.Context(), testOrgID, 11, model.RoleOwner)
+	_, err = pt.svc.Erase(orgOwner.ctx(), 10, "")
+	wantCode(t, err, codes.OK)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"golang.org/x/sync/semaphore"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+)
+
+type SearchService interface {
+	// SearchUsers возвращает страницу результатов и токен следующей
+	// страницы (пустой, если это последняя). Искать могут администраторы
+	// из admin.user_ids, сервисы и API ключи.
+	SearchUsers(ctx context.Context, query string, pageSize int, pageToken string) ([]model.SearchResult, string, error)
+}
+
+type searchService struct {
+	repo   repository.UserRepository
+	cfg    config.SearchConfig
+	sem    *semaphore.Weighted
+	admins []string
+}
+
+func NewSearchService(repo repository.UserRepository, cfg config.SearchConfig, admins []string) SearchService {
+	return &searchService{repo: repo, cfg: cfg, sem: semaphore.NewWeighted(int64(cfg.MaxConcurrent)), admins: admins}
+}
+
+// pageToken — содержимое next_page_token. Query — хэш запроса: токен
+// нельзя применить к другому запросу.
+type pageToken struct {
+	Rank  float64 `json:"r"`
+	ID    int64   `json:"i"`
+	Query string  `json:"q"`
+}
+
+func (s *searchService) SearchUsers(ctx context.Context, query string, pageSize int, token string) ([]model.SearchResult, string, error) {
+	if err := authorizeAdmin(ctx, s.admins); err != nil {
+		return nil, "", err
+	}
+
+	query = strings.TrimSpace(query)
+	if pageSize <= 0 {
+		pageSize = defaultSearchPageSize
+	}
+	pageSize = min(pageSize, maxSearchPageSize)
+
+	var after *model.SearchCursor
+	if token != "" {
+		cursor, err := decodePageToken(token, query)
+		if err != nil {
+			return nil, "", apperr.InvalidFields(apperr.FieldViolation("page_token", "page token is invalid or belongs to another query"))
+		}
+		after = cursor
+	}
+
+	if err := s.acquire(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil, "", status.FromContextError(ctx.Err()).Err()
+		}
+		logger.Warnf("searchService - SearchUsers: Throttled, %d searches already running", s.cfg.MaxConcurrent)
+		return nil, "", apperr.New(codes.ResourceExhausted, apperr.ReasonSearchThrottled, "too many concurrent searches", nil,
+			&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Second)})
+	}
+	defer s.sem.Release(1)
+
+	// берём на одну запись больше, чтобы знать, есть ли следующая страница
+	results, err := s.repo.SearchUsers(ctx, query, after, pageSize+1, s.cfg.StatementTimeout)
+	if errors.Is(err, repository.ErrQueryTimedOut) {
+		logger.Warnf("searchService - SearchUsers: Query %q timed out", query)
+		return nil, "", apperr.New(codes.DeadlineExceeded, apperr.ReasonSearchTimeout, "search query timed out", nil)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(results) > pageSize {
+		results = results[:pageSize]
+		last := results[len(results)-1]
+		next = encodePageToken(pageToken{Rank: last.Rank, ID: last.User.ID, Query: queryHash(query)})
+	}
+
+	for i := range results {
+		results[i].Highlights = highlight(results[i].User, query)
+	}
+
+	return results, next, nil
+}
+
+// acquire занимает слот поиска. Очередь ограничена queue_timeout: лучше
+// быстро отказать, чем держать запрос, пока поиск занимает соединения.
+func (s *searchService) acquire(ctx context.Context) error {
+	if s.sem.TryAcquire(1) {
+		return nil
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, s.cfg.QueueTimeout)
+	defer cancel()
+	return s.sem.Acquire(waitCtx, 1)
+}
+
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(query)))
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodePageToken(t pageToken) string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(token, query string) (*model.SearchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	var t pageToken
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	if t.Query != queryHash(query) {
+		return nil, errors.New("page token belongs to another query")
+	}
+	return &model.SearchCursor{Rank: t.Rank, ID: t.ID}, nil
+}
+
+// highlight отмечает вхождения слов запроса в email и display_name без
+// учёта регистра. Нечёткие совпадения (опечатки) не подсвечиваются.
+func highlight(user *model.User, query string) []model.Highlight {
+	words := strings.Fields(strings.ToLower(query))
+	var out []model.Highlight
+	for _, f := range []struct{ name, value string }{
+		{"email", user.Email},
+		{"display_name", user.DisplayName},
+	} {
+		out = append(out, fieldHighlights(f.name, f.value, words)...)
+	}
+	return out
+}
+
+func fieldHighlights(field, value string, words []string) []model.Highlight {
+	lower := strings.ToLower(value)
+	// смещения считаем в рунах; strings.ToLower может поменять длину в
+	// байтах, поэтому ищем по рунам нижнего регистра
+	runes := []rune(lower)
+	var ranges []model.Highlight
+	for _, w := range words {
+		wr := []rune(w)
+		for i := 0; i+len(wr) <= len(runes); i++ {
+			if string(runes[i:i+len(wr)]) == w {
+				ranges = append(ranges, model.Highlight{Field: field, Start: i, End: i + len(wr)})
+			}
+		}
+	}
+	if len(ranges) == 0 || utf8.RuneCountInString(value) != len(runes) {
+		return nil
+	}
+
+	// объединяем пересекающиеся фрагменты
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End {
+			last.End = max(last.End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"google.golang.org/grpc/codes"
+)
+
+func TestSearchServiceAuthorization(t *testing.T) {
+	tests := []struct {
+		caller caller
+		want   codes.Code
+	}{
+		{anonymous, codes.Unauthenticated},
+		{asUser(1), codes.PermissionDenied},
+		{asAdmin, codes.OK},
+		{asService, codes.OK},
+		{asAPIKey, codes.OK},
+	}
+	repo := newFakeUsers(&model.User{ID: 1, Email: "alice@example.com"}, &model.User{ID: 2, Email: "bob@example.com"})
+	svc := NewSearchService(repo, config.SearchConfig{MaxConcurrent: 1, QueueTimeout: time.Second}, testAdmins)
+
+	for _, tt := range tests {
+		t.Run(tt.caller.name, func(t *testing.T) {
+			results, _, err := svc.SearchUsers(tt.caller.ctx(), "example", 10, "")
+			wantCode(t, err, tt.want)
+			if tt.want == codes.OK && len(results) != 2 {
+				t.Fatalf("got %d results, want 2", len(results))
+			}
+			if tt.want != codes.OK && results != nil {
+				t.Fatalf("denied search returned %v", results)
+			}
+		})
+	}
+}
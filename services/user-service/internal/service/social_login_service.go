@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/oidc"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/social"
+	"github.com/DmitriiPro/user-service/internal/tenant"
+	"google.golang.org/grpc/codes"
+)
+
+type SocialLoginService interface {
+	// Start начинает вход через провайдера и возвращает адрес, на который
+	// нужно отправить пользователя.
+	Start(ctx context.Context, provider string) (string, error)
+	// Complete принимает code и state, с которыми провайдер вернул
+	// пользователя на redirect_url, и входит за привязанного пользователя.
+	// Пользователь без привязки находится или создаётся по подтверждённому
+	// провайдером email.
+	Complete(ctx context.Context, provider, code, state string) (*LoginResult, error)
+}
+
+type socialLoginService struct {
+	providers  map[string]social.Provider
+	states     social.StateStore
+	identities repository.FederatedIdentityRepository
+	users      repository.UserRepository
+	auth       AuthService
+	email      EmailNormalizer
+	cfg        config.SocialConfig
+}
+
+func NewSocialLoginService(providers map[string]social.Provider, states social.StateStore, identities repository.FederatedIdentityRepository,
+	users repository.UserRepository, auth AuthService, email EmailNormalizer, cfg config.SocialConfig) SocialLoginService {
+	return &socialLoginService{providers: providers, states: states, identities: identities, users: users, auth: auth, email: email, cfg: cfg}
+}
+
+func errSocialLoginFailed() error {
+	return apperr.New(codes.Unauthenticated, apperr.ReasonSocialLoginFailed, "social login failed", nil)
+}
+
+func (s *socialLoginService) provider(name string) (social.Provider, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, apperr.New(codes.NotFound, apperr.ReasonSocialProviderNotFound,
+			fmt.Sprintf("social provider %s is not configured", name), map[string]string{"provider": name})
+	}
+	return p, nil
+}
+
+// newCodeVerifier — 32 случайных байта, 43 символа base64url (RFC 7636).
+func newCodeVerifier() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (s *socialLoginService) Start(ctx context.Context, name string) (string, error) {
+	p, err := s.provider(name)
+	if err != nil {
+		return "", err
+	}
+
+	client := auth.ClientInfoFromContext(ctx)
+	st := social.State{
+		Provider:      name,
+		Nonce:         rand.Text(),
+		CodeVerifier:  newCodeVerifier(),
+		UserAgentHash: social.Hash(client.UserAgent),
+		TenantID:      tenant.FromContext(ctx),
+	}
+	state, err := s.states.Create(ctx, st, s.cfg.StateTTL)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, st.Nonce, oidc.PKCEChallenge(st.CodeVerifier))
+	if err != nil {
+		logger.Warnf("socialLoginService - Start: Provider %s is unavailable: %v", name, err)
+		return "", apperr.New(codes.Unavailable, apperr.ReasonSocialLoginFailed, "social provider is unavailable", nil)
+	}
+	return authURL, nil
+}
+
+func (s *socialLoginService) Complete(ctx context.Context, name, code, state string) (*LoginResult, error) {
+	p, err := s.provider(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// state гасится до обмена кода: повтор того же ответа провайдера не
+	// пройдёт, даже если первый обмен не удался
+	st, err := s.states.Consume(ctx, state)
+	if errors.Is(err, social.ErrStateNotFound) {
+		return nil, errSocialLoginFailed()
+	}
+	if err != nil {
+		return nil, err
+	}
+	client := auth.ClientInfoFromContext(ctx)
+	if st.Provider != name || subtle.ConstantTimeCompare([]byte(st.UserAgentHash), []byte(social.Hash(client.UserAgent))) != 1 {
+		logger.Warnf("socialLoginService - Complete: State for %s used with provider %s or from another user agent", st.Provider, name)
+		return nil, errSocialLoginFailed()
+	}
+	// провайдер возвращает пользователя без заголовка тенанта: вход
+	// завершается в тенанте, где он начат
+	ctx = tenant.WithID(ctx, st.TenantID)
+
+	identity, err := p.Exchange(ctx, code, st.CodeVerifier, st.Nonce)
+	if err != nil {
+		logger.Warnf("socialLoginService - Complete: Exchange with %s failed: %v", name, err)
+		return nil, errSocialLoginFailed()
+	}
+
+	user, err := s.resolveUser(ctx, name, identity)
+	if err != nil {
+		return nil, err
+	}
+	return s.auth.CompleteLogin(ctx, user, model.LoginMethodSocialPrefix+name)
+}
+
+// resolveUser находит пользователя по привязке или привязывает учётную
+// запись провайдера к пользователю с тем же email, создавая его при
+// необходимости.
+func (s *socialLoginService) resolveUser(ctx context.Context, provider string, identity *social.Identity) (*model.User, error) {
+	linked, err := s.identities.Get(ctx, provider, identity.Subject)
+	if err == nil {
+		return s.users.GetUserByID(ctx, linked.UserID)
+	}
+	if !errors.Is(err, repository.ErrFederatedIdentityNotFound) {
+		return nil, err
+	}
+
+	// неподтверждённый email мог указать кто угодно: привязка по нему
+	// отдала бы чужую учётную запись
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, apperr.New(codes.FailedPrecondition, apperr.ReasonSocialEmailUnverified,
+			"external account has no verified email", nil)
+	}
+	email := s.email.Normalize(identity.Email)
+
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFoundUser) {
+		// пустой хэш пароля: войти паролем нельзя, пока пользователь его не задаст
+		user, err = s.users.CreateUser(ctx, email, "")
+		if errors.Is(err, repository.ErrUserExists) {
+			user, err = s.users.GetUserByEmail(ctx, email)
+		} else if err == nil {
+			logger.Infof("socialLoginService - Complete: User %d created via %s", user.ID, provider)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.identities.Create(ctx, &model.FederatedIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    email,
+	})
+	if errors.Is(err, repository.ErrFederatedIdentityExists) {
+		// параллельный вход уже привязал эту учётную запись
+		linked, err := s.identities.Get(ctx, provider, identity.Subject)
+		if err != nil {
+			return nil, err
+		}
+		return s.users.GetUserByID(ctx, linked.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("socialLoginService - Complete: %s account %s linked to user %d", provider, identity.Subject, user.ID)
+	return user, nil
+}
@@ -0,0 +1,491 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/oidc"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/social"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+)
+
+const testUserAgent = "test-browser/1.0"
+
+// fakeAccount — пользователь внешнего провайдера.
+type fakeAccount struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type fakeGrant struct {
+	account       fakeAccount
+	nonce         string
+	codeChallenge string
+}
+
+// fakeIdP — провайдер OpenID Connect на httptest: discovery, token
+// endpoint с PKCE и JWKS. Пользователь «входит» через authorize, который
+// тест вызывает напрямую, разобрав адрес из Start.
+type fakeIdP struct {
+	srv      *httptest.Server
+	clientID string
+	key      *rsa.PrivateKey
+	kid      string
+
+	mu     sync.Mutex
+	grants map[string]fakeGrant
+	// tamper меняет claims ID токена перед подписью.
+	tamper func(*oidc.Claims)
+	// signer — ключ подписи вместо key (подделка с тем же kid).
+	signer *rsa.PrivateKey
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdP{clientID: "user-service", key: key, kid: "idp-key-1", grants: make(map[string]fakeGrant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.srv.URL,
+			"authorization_endpoint": idp.srv.URL + "/authorize",
+			"token_endpoint":         idp.srv.URL + "/token",
+			"jwks_uri":               idp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		pub := idp.key.PublicKey
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "use": "sig", "alg": "RS256", "kid": idp.kid,
+			"n": b64url(pub.N.Bytes()), "e": b64url(big.NewInt(int64(pub.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", idp.token)
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+func b64url(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (idp *fakeIdP) config() config.SocialProviderConfig {
+	return config.SocialProviderConfig{Enabled: true, Issuer: idp.srv.URL, ClientID: idp.clientID, ClientSecret: "secret",
+		Scopes: []string{"openid", "email"}, RedirectURL: "https://app.example.com/callback"}
+}
+
+// authorize — пользователь account вошёл у провайдера по адресу authURL;
+// возвращает code для redirect_url.
+func (idp *fakeIdP) authorize(t *testing.T, authURL string, account fakeAccount) string {
+	t.Helper()
+	q := mustQuery(t, authURL)
+	if q.Get("client_id") != idp.clientID || q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization request %s", authURL)
+	}
+	code := rand.Text()
+	idp.mu.Lock()
+	idp.grants[code] = fakeGrant{account: account, nonce: q.Get("nonce"), codeChallenge: q.Get("code_challenge")}
+	idp.mu.Unlock()
+	return code
+}
+
+func (idp *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, _ := r.BasicAuth()
+	idp.mu.Lock()
+	grant, ok := idp.grants[r.FormValue("code")]
+	delete(idp.grants, r.FormValue("code"))
+	idp.mu.Unlock()
+	if id != idp.clientID || secret != "secret" || !ok || !oidc.VerifyPKCE(r.FormValue("code_verifier"), grant.codeChallenge) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := &oidc.Claims{
+		Issuer: idp.srv.URL, Subject: grant.account.Subject, Audience: oidc.Audience{idp.clientID},
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix(), Nonce: grant.nonce,
+		Email: grant.account.Email, EmailVerified: grant.account.EmailVerified,
+	}
+	if idp.tamper != nil {
+		idp.tamper(claims)
+	}
+	signer := idp.key
+	if idp.signer != nil {
+		signer = idp.signer
+	}
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "at-" + rand.Text(), "token_type": "Bearer", "id_token": signRS256(signer, idp.kid, claims),
+	})
+}
+
+// signRS256 подписывает ID токен независимо от пакета signing — так, как
+// это сделал бы сторонний провайдер.
+func signRS256(key *rsa.PrivateKey, kid string, claims *oidc.Claims) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64url(header) + "." + b64url(payload)
+	sum := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		panic(err)
+	}
+	return input + "." + b64url(sig)
+}
+
+// fakeGitHub — OAuth App GitHub: token endpoint и REST API /user, /user/emails.
+type fakeGitHub struct {
+	srv *httptest.Server
+
+	mu     sync.Mutex
+	grants map[string]fakeAccount
+	tokens map[string]fakeAccount
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	t.Helper()
+	gh := &fakeGitHub{grants: make(map[string]fakeAccount), tokens: make(map[string]fakeAccount)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		gh.mu.Lock()
+		defer gh.mu.Unlock()
+		account, ok := gh.grants[r.FormValue("code")]
+		delete(gh.grants, r.FormValue("code"))
+		if !ok {
+			// GitHub отвечает об ошибке с кодом 200
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		token := rand.Text()
+		gh.tokens[token] = account
+		json.NewEncoder(w).Encode(map[string]string{"access_token": token})
+	})
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		account, ok := gh.account(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// публичный email профиля не обязательно подтверждён
+		id, _ := strconv.ParseInt(account.Subject, 10, 64)
+		json.NewEncoder(w).Encode(map[string]any{"id": id, "login": "octocat", "email": account.Email})
+	})
+	mux.HandleFunc("GET /user/emails", func(w http.ResponseWriter, r *http.Request) {
+		account, ok := gh.account(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]any{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": account.Email, "primary": true, "verified": account.EmailVerified},
+		})
+	})
+	gh.srv = httptest.NewServer(mux)
+	t.Cleanup(gh.srv.Close)
+	return gh
+}
+
+func (gh *fakeGitHub) account(r *http.Request) (fakeAccount, bool) {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	token, ok := bearerToken(r)
+	account, found := gh.tokens[token]
+	return account, ok && found
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if len(h) <= len(prefix) || h[:len(prefix)] != prefix {
+		return "", false
+	}
+	return h[len(prefix):], true
+}
+
+func (gh *fakeGitHub) config() config.SocialProviderConfig {
+	return config.SocialProviderConfig{Enabled: true, Issuer: gh.srv.URL, APIURL: gh.srv.URL, ClientID: "gh-client",
+		ClientSecret: "secret", Scopes: []string{"user:email"}, RedirectURL: "https://app.example.com/callback"}
+}
+
+func (gh *fakeGitHub) authorize(_ *testing.T, _ string, account fakeAccount) string {
+	code := rand.Text()
+	gh.mu.Lock()
+	gh.grants[code] = account
+	gh.mu.Unlock()
+	return code
+}
+
+// fakeIdentities — FederatedIdentityRepository в памяти.
+type fakeIdentities struct {
+	mu    sync.Mutex
+	links map[string]model.FederatedIdentity
+}
+
+func (r *fakeIdentities) Get(_ context.Context, provider, subject string) (*model.FederatedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, ok := r.links[provider+"/"+subject]
+	if !ok {
+		return nil, repository.ErrFederatedIdentityNotFound
+	}
+	return &i, nil
+}
+
+func (r *fakeIdentities) Create(_ context.Context, i *model.FederatedIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.links[i.Provider+"/"+i.Subject]; ok {
+		return repository.ErrFederatedIdentityExists
+	}
+	r.links[i.Provider+"/"+i.Subject] = *i
+	return nil
+}
+
+func (r *fakeIdentities) ListByUser(_ context.Context, userID int64) ([]model.FederatedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.FederatedIdentity
+	for _, i := range r.links {
+		if i.UserID == userID {
+			out = append(out, i)
+		}
+	}
+	return out, nil
+}
+
+// loginRecorder — AuthService, который только запоминает, кто вошёл.
+type loginRecorder struct {
+	AuthService
+	userIDs []int64
+}
+
+func (a *loginRecorder) CompleteLogin(_ context.Context, user *model.User, method string) (*LoginResult, error) {
+	a.userIDs = append(a.userIDs, user.ID)
+	return &LoginResult{SessionToken: "session-for-" + method}, nil
+}
+
+type socialTest struct {
+	svc        SocialLoginService
+	idp        *fakeIdP
+	github     *fakeGitHub
+	users      *fakeUsers
+	identities *fakeIdentities
+	logins     *loginRecorder
+}
+
+func newSocialTest(t *testing.T) *socialTest {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	st := &socialTest{
+		idp:        newFakeIdP(t),
+		github:     newFakeGitHub(t),
+		users:      newFakeUsers(&model.User{ID: 1, Email: "alice@example.com"}, &model.User{ID: 2, Email: "bob@example.com"}),
+		identities: &fakeIdentities{links: make(map[string]model.FederatedIdentity)},
+		logins:     &loginRecorder{},
+	}
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+	providers := map[string]social.Provider{
+		"oidc":   social.NewOIDCProvider(st.idp.config(), httpClient),
+		"github": social.NewGitHubProvider(st.github.config(), httpClient),
+	}
+	st.svc = NewSocialLoginService(providers, social.NewRedisStateStore(client), st.identities, st.users, st.logins,
+		EmailNormalizer{}, config.SocialConfig{StateTTL: time.Minute})
+	return st
+}
+
+func browser(userAgent string) context.Context {
+	return auth.WithClientInfo(context.Background(), auth.ClientInfo{IP: "10.0.0.1", UserAgent: userAgent})
+}
+
+func mustQuery(t *testing.T, rawURL string) url.Values {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
+
+// login проходит весь вход через провайдера за account и возвращает
+// результат Complete.
+func (st *socialTest) login(t *testing.T, provider string, account fakeAccount) (*LoginResult, error) {
+	t.Helper()
+	authURL, err := st.svc.Start(browser(testUserAgent), provider)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	var code string
+	if provider == "github" {
+		code = st.github.authorize(t, authURL, account)
+	} else {
+		code = st.idp.authorize(t, authURL, account)
+	}
+	return st.svc.Complete(browser(testUserAgent), provider, code, mustQuery(t, authURL).Get("state"))
+}
+
+func TestSocialLoginLinksAccounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		account  fakeAccount
+		// linked — привязка, существующая до входа (user ID по subject).
+		linked   map[string]int64
+		wantUser int64
+	}{
+		{"new user is created", "oidc", fakeAccount{"sub-new", "carol@example.com", true}, nil, 3},
+		{"existing user is linked by verified email", "oidc", fakeAccount{"sub-alice", "Alice@Example.com", true}, nil, 1},
+		{"linked account wins over email", "oidc", fakeAccount{"sub-bob", "alice@example.com", true}, map[string]int64{"sub-bob": 2}, 2},
+		{"github verified primary email", "github", fakeAccount{"4242", "bob@example.com", true}, nil, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newSocialTest(t)
+			for sub, userID := range tt.linked {
+				st.identities.Create(t.Context(), &model.FederatedIdentity{UserID: userID, Provider: tt.provider, Subject: sub})
+			}
+
+			res, err := st.login(t, tt.provider, tt.account)
+			wantCode(t, err, codes.OK)
+			if res.SessionToken != "session-for-"+model.LoginMethodSocialPrefix+tt.provider {
+				t.Fatalf("session token = %q", res.SessionToken)
+			}
+			if len(st.logins.userIDs) != 1 || st.logins.userIDs[0] != tt.wantUser {
+				t.Fatalf("logged in users %v, want [%d]", st.logins.userIDs, tt.wantUser)
+			}
+			link, err := st.identities.Get(t.Context(), tt.provider, tt.account.Subject)
+			if err != nil || link.UserID != tt.wantUser {
+				t.Fatalf("link = %+v, %v; want user %d", link, err, tt.wantUser)
+			}
+
+			// повторный вход идёт по привязке, новых пользователей нет
+			before := len(st.users.users)
+			if _, err := st.login(t, tt.provider, tt.account); err != nil {
+				t.Fatalf("second login: %v", err)
+			}
+			if len(st.users.users) != before || st.logins.userIDs[1] != tt.wantUser {
+				t.Fatalf("second login: users %d -> %d, logged in %v", before, len(st.users.users), st.logins.userIDs)
+			}
+		})
+	}
+}
+
+func TestSocialLoginRejectsUnverifiedEmail(t *testing.T) {
+	for _, provider := range []string{"oidc", "github"} {
+		t.Run(provider, func(t *testing.T) {
+			st := newSocialTest(t)
+
+			// адрес существующего пользователя, но не подтверждённый провайдером
+			_, err := st.login(t, provider, fakeAccount{"777", "alice@example.com", false})
+			wantReason(t, err, codes.FailedPrecondition, apperr.ReasonSocialEmailUnverified)
+			if len(st.logins.userIDs) != 0 || len(st.identities.links) != 0 || len(st.users.users) != 2 {
+				t.Fatalf("unverified email: logins %v, links %v, users %d", st.logins.userIDs, st.identities.links, len(st.users.users))
+			}
+		})
+	}
+}
+
+func TestSocialLoginRejectsBadIDToken(t *testing.T) {
+	forged, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		tamper func(*oidc.Claims)
+		signer *rsa.PrivateKey
+	}{
+		{name: "nonce mismatch", tamper: func(c *oidc.Claims) { c.Nonce = "attacker-nonce" }},
+		{name: "missing nonce", tamper: func(c *oidc.Claims) { c.Nonce = "" }},
+		{name: "signature by another key", signer: forged},
+		{name: "another audience", tamper: func(c *oidc.Claims) { c.Audience = oidc.Audience{"other-client"} }},
+		{name: "another issuer", tamper: func(c *oidc.Claims) { c.Issuer = "https://evil.example.com" }},
+		{name: "expired", tamper: func(c *oidc.Claims) { c.ExpiresAt = time.Now().Add(-time.Minute).Unix() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newSocialTest(t)
+			st.idp.tamper, st.idp.signer = tt.tamper, tt.signer
+
+			_, err := st.login(t, "oidc", fakeAccount{"sub-alice", "alice@example.com", true})
+			wantReason(t, err, codes.Unauthenticated, apperr.ReasonSocialLoginFailed)
+			if len(st.logins.userIDs) != 0 || len(st.identities.links) != 0 {
+				t.Fatalf("bad id_token: logins %v, links %v", st.logins.userIDs, st.identities.links)
+			}
+		})
+	}
+}
+
+func TestSocialLoginRejectsBadState(t *testing.T) {
+	account := fakeAccount{"sub-alice", "alice@example.com", true}
+	tests := []struct {
+		name string
+		// complete завершает вход, начатый на authURL, неправильно.
+		complete func(st *socialTest, code, state string) error
+	}{
+		{"unknown state", func(st *socialTest, code, _ string) error {
+			_, err := st.svc.Complete(browser(testUserAgent), "oidc", code, "forged-state")
+			return err
+		}},
+		{"state of another provider", func(st *socialTest, code, state string) error {
+			_, err := st.svc.Complete(browser(testUserAgent), "github", code, state)
+			return err
+		}},
+		{"another user agent", func(st *socialTest, code, state string) error {
+			_, err := st.svc.Complete(browser("attacker/1.0"), "oidc", code, state)
+			return err
+		}},
+		{"replayed state", func(st *socialTest, code, state string) error {
+			if _, err := st.svc.Complete(browser(testUserAgent), "oidc", code, state); err != nil {
+				return err
+			}
+			_, err := st.svc.Complete(browser(testUserAgent), "oidc", code, state)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newSocialTest(t)
+			authURL, err := st.svc.Start(browser(testUserAgent), "oidc")
+			if err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			code := st.idp.authorize(t, authURL, account)
+
+			err = tt.complete(st, code, mustQuery(t, authURL).Get("state"))
+			wantReason(t, err, codes.Unauthenticated, apperr.ReasonSocialLoginFailed)
+			if len(st.logins.userIDs) > 1 {
+				t.Fatalf("logged in %v", st.logins.userIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/lockout"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/mfa"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"google.golang.org/grpc/codes"
+	"rsc.io/qr"
+)
+
+// TOTPEnrollment — данные для подключения приложения-аутентификатора.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+	QRPNG  []byte
+}
+
+type TwoFactorService interface {
+	Enroll(ctx context.Context, userID int64) (*TOTPEnrollment, error)
+	// Confirm включает TOTP и возвращает новые коды восстановления.
+	// Неверные коды Confirm и Disable учитываются в lockout.Guard, как
+	// неудачные попытки входа.
+	Confirm(ctx context.Context, userID int64, code string) ([]string, error)
+	// Disable требует код TOTP или код восстановления.
+	Disable(ctx context.Context, userID int64, code, recoveryCode string) error
+	// Enabled сообщает, нужен ли пользователю второй фактор при входе.
+	Enabled(ctx context.Context, userID int64) (bool, error)
+	// Verify проверяет код TOTP или код восстановления и гасит его: код
+	// TOTP нельзя предъявить повторно, код восстановления — одноразовый.
+	Verify(ctx context.Context, userID int64, code, recoveryCode string) (bool, error)
+}
+
+type twoFactorService struct {
+	users  repository.UserRepository
+	totp   repository.TOTPRepository
+	cipher *mfa.Cipher
+	guard  lockout.Guard
+	cfg    config.TwoFactorConfig
+}
+
+// NewTwoFactorService: cipher равен nil, если ключ шифрования не задан —
+// тогда TOTP подключить нельзя.
+func NewTwoFactorService(users repository.UserRepository, totp repository.TOTPRepository, cipher *mfa.Cipher,
+	guard lockout.Guard, cfg config.TwoFactorConfig) TwoFactorService {
+	return &twoFactorService{users: users, totp: totp, cipher: cipher, guard: guard, cfg: cfg}
+}
+
+// secretAD — associated data секрета: шифротекст привязан к пользователю.
+func secretAD(userID int64) []byte {
+	return []byte("totp:" + strconv.FormatInt(userID, 10))
+}
+
+func errTOTPNotEnabled() error {
+	return apperr.New(codes.FailedPrecondition, apperr.ReasonTOTPNotEnabled, "two-factor authentication is not enabled", nil)
+}
+
+func errInvalidCode(code codes.Code) error {
+	return apperr.New(code, apperr.ReasonInvalidSecondFactor, "verification code is invalid or already used", nil)
+}
+
+func (s *twoFactorService) Enroll(ctx context.Context, userID int64) (*TOTPEnrollment, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if s.cipher == nil {
+		logger.Warnf("twoFactorService - Enroll: two_factor.encryption_key is not configured")
+		return nil, apperr.New(codes.FailedPrecondition, apperr.ReasonTwoFactorUnavailable, "two-factor authentication is not configured", nil)
+	}
+
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := mfa.GenerateSecret()
+	err = s.totp.SavePending(ctx, userID, s.cipher.Seal(secret, secretAD(userID)))
+	if errors.Is(err, repository.ErrTOTPEnabled) {
+		return nil, apperr.New(codes.FailedPrecondition, apperr.ReasonTOTPAlreadyEnabled, "two-factor authentication is already enabled", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	uri := mfa.URI(s.cfg.Issuer, user.Email, secret)
+	code, err := qr.Encode(uri, qr.M)
+	if err != nil {
+		return nil, fmt.Errorf("encode QR: %w", err)
+	}
+
+	logger.Infof("twoFactorService - Enroll: TOTP enrollment started for ID %d", userID)
+	return &TOTPEnrollment{Secret: mfa.EncodeSecret(secret), URI: uri, QRPNG: code.PNG()}, nil
+}
+
+func (s *twoFactorService) Confirm(ctx context.Context, userID int64, code string) ([]string, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	t, err := s.totp.Get(ctx, userID)
+	if errors.Is(err, repository.ErrTOTPNotFound) {
+		return nil, errTOTPNotEnabled()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Confirmed() {
+		return nil, apperr.New(codes.FailedPrecondition, apperr.ReasonTOTPAlreadyEnabled, "two-factor authentication is already enabled", nil)
+	}
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.open(userID, t.Secret)
+	if err != nil {
+		return nil, err
+	}
+	var step int64
+	err = s.guarded(ctx, user, func() (ok bool, err error) {
+		step, ok = mfa.Verify(secret, code, time.Now())
+		return ok, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recovery := mfa.GenerateRecoveryCodes(s.cfg.RecoveryCodes)
+	hashes := make([]string, len(recovery))
+	for i, c := range recovery {
+		hashes[i] = mfa.HashRecoveryCode(c)
+	}
+	err = s.totp.Confirm(ctx, userID, step, hashes)
+	if errors.Is(err, repository.ErrTOTPNotFound) {
+		// подключение уже подтверждено параллельным запросом или код повторный
+		return nil, errInvalidCode(codes.InvalidArgument)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("twoFactorService - Confirm: TOTP enabled for ID %d", userID)
+	return recovery, nil
+}
+
+func (s *twoFactorService) Disable(ctx context.Context, userID int64, code, recoveryCode string) error {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return err
+	}
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.guarded(ctx, user, func() (bool, error) {
+		return s.Verify(ctx, userID, code, recoveryCode)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.totp.Delete(ctx, userID); err != nil && !errors.Is(err, repository.ErrTOTPNotFound) {
+		return err
+	}
+	logger.Infof("twoFactorService - Disable: TOTP disabled for ID %d", userID)
+	return nil
+}
+
+func (s *twoFactorService) Enabled(ctx context.Context, userID int64) (bool, error) {
+	t, err := s.totp.Get(ctx, userID)
+	if errors.Is(err, repository.ErrTOTPNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return t.Confirmed(), nil
+}
+
+func (s *twoFactorService) Verify(ctx context.Context, userID int64, code, recoveryCode string) (bool, error) {
+	if (code == "") == (recoveryCode == "") {
+		return false, apperr.InvalidFields(apperr.FieldViolation("code", "exactly one of code and recovery_code is required"))
+	}
+
+	t, err := s.totp.Get(ctx, userID)
+	if errors.Is(err, repository.ErrTOTPNotFound) {
+		return false, errTOTPNotEnabled()
+	}
+	if err != nil {
+		return false, err
+	}
+	if !t.Confirmed() {
+		return false, errTOTPNotEnabled()
+	}
+
+	if recoveryCode != "" {
+		ok, err := s.totp.UseRecoveryCode(ctx, userID, mfa.HashRecoveryCode(recoveryCode))
+		if ok {
+			logger.Infof("twoFactorService - Verify: Recovery code used for ID %d", userID)
+		}
+		return ok, err
+	}
+
+	secret, err := s.open(userID, t.Secret)
+	if err != nil {
+		return false, err
+	}
+	step, ok := mfa.Verify(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	// код, уже принятый в этом окне, повторно не принимается
+	return s.totp.UseStep(ctx, userID, step)
+}
+
+// guarded проверяет код через verify так же, как Login проверяет пароль:
+// попытка занимается в lockout.Guard, неверный код засчитывается как
+// неудача. Иначе шестизначный код можно было бы подбирать через Confirm и
+// Disable, минуя блокировку входа.
+func (s *twoFactorService) guarded(ctx context.Context, user *model.User, verify func() (bool, error)) error {
+	ip := auth.ClientInfoFromContext(ctx).IP
+	decision, err := s.guard.Check(ctx, user.Email, ip)
+	if err != nil {
+		return err
+	}
+	if !decision.Allowed {
+		reason := apperr.ReasonLoginThrottled
+		if decision.Locked {
+			reason = apperr.ReasonLoginLocked
+		}
+		logger.Warnf("twoFactorService: Code check for ID %d from %s rejected for %v", user.ID, ip, decision.RetryAfter)
+		return loginDenied(reason, decision.RetryAfter)
+	}
+
+	ok, err := verify()
+	if err != nil || ok {
+		if relErr := s.guard.Release(ctx, user.Email, ip); relErr != nil {
+			logger.Errorf("twoFactorService: Failed to release attempt for ID %d: %v", user.ID, relErr)
+		}
+		return err
+	}
+
+	logger.Warnf("twoFactorService: Invalid code for ID %d from %s", user.ID, ip)
+	lockedFor, err := s.guard.Failure(ctx, user.Email, ip)
+	if err != nil {
+		logger.Errorf("twoFactorService: Failed to count failure for ID %d: %v", user.ID, err)
+	}
+	if lockedFor > 0 {
+		logger.Warnf("twoFactorService: User %d locked out for %v", user.ID, lockedFor)
+	}
+	return errInvalidCode(codes.InvalidArgument)
+}
+
+func (s *twoFactorService) user(ctx context.Context, userID int64) (*model.User, error) {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFoundUser) {
+		return nil, apperr.New(codes.NotFound, apperr.ReasonUserNotFound,
+			fmt.Sprintf("user with id %d not found", userID), map[string]string{"id": strconv.FormatInt(userID, 10)})
+	}
+	return user, err
+}
+
+func (s *twoFactorService) open(userID int64, sealed []byte) ([]byte, error) {
+	if s.cipher == nil {
+		logger.Warnf("twoFactorService: two_factor.encryption_key is not configured, cannot check TOTP for ID %d", userID)
+		return nil, apperr.New(codes.FailedPrecondition, apperr.ReasonTwoFactorUnavailable, "two-factor authentication is not configured", nil)
+	}
+	secret, err := s.cipher.Open(sealed, secretAD(userID))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt TOTP secret for ID %d: %w", userID, err)
+	}
+	return secret, nil
+}
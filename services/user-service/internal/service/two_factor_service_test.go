@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"encoding/base32"
+	"encoding/base64"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/auth"
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/lockout"
+	"github.com/DmitriiPro/user-service/internal/mfa"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+)
+
+// fakeTOTP — TOTPRepository в памяти с теми же условиями, что в Postgres:
+// шаг принимается, только если он больше last_used_step, код
+// восстановления гасится один раз.
+type fakeTOTP struct {
+	byUser   map[int64]*model.TOTP
+	recovery map[int64]map[string]bool // хэш -> использован
+}
+
+func (r *fakeTOTP) SavePending(_ context.Context, userID int64, secret []byte) error {
+	if t, ok := r.byUser[userID]; ok && t.Confirmed() {
+		return repository.ErrTOTPEnabled
+	}
+	r.byUser[userID] = &model.TOTP{UserID: userID, Secret: secret, CreatedAt: time.Now()}
+	return nil
+}
+
+func (r *fakeTOTP) Get(_ context.Context, userID int64) (*model.TOTP, error) {
+	t, ok := r.byUser[userID]
+	if !ok {
+		return nil, repository.ErrTOTPNotFound
+	}
+	return t, nil
+}
+
+func (r *fakeTOTP) Confirm(_ context.Context, userID, step int64, recoveryHashes []string) error {
+	t, ok := r.byUser[userID]
+	if !ok || t.Confirmed() || t.LastUsedStep >= step {
+		return repository.ErrTOTPNotFound
+	}
+	now := time.Now()
+	t.ConfirmedAt, t.LastUsedStep = &now, step
+	r.recovery[userID] = map[string]bool{}
+	for _, h := range recoveryHashes {
+		r.recovery[userID][h] = false
+	}
+	return nil
+}
+
+func (r *fakeTOTP) UseStep(_ context.Context, userID, step int64) (bool, error) {
+	t, ok := r.byUser[userID]
+	if !ok || t.LastUsedStep >= step {
+		return false, nil
+	}
+	t.LastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeTOTP) UseRecoveryCode(_ context.Context, userID int64, hash string) (bool, error) {
+	used, ok := r.recovery[userID][hash]
+	if !ok || used {
+		return false, nil
+	}
+	r.recovery[userID][hash] = true
+	return true, nil
+}
+
+func (r *fakeTOTP) Delete(_ context.Context, userID int64) error {
+	if _, ok := r.byUser[userID]; !ok {
+		return repository.ErrTOTPNotFound
+	}
+	delete(r.byUser, userID)
+	delete(r.recovery, userID)
+	return nil
+}
+
+type twoFactorTest struct {
+	svc  TwoFactorService
+	totp *fakeTOTP
+}
+
+func newTwoFactorTest(t *testing.T) *twoFactorTest {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	cipher, err := mfa.NewCipher(base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := newFakeUsers(&model.User{ID: 1, Email: "alice@example.com"})
+	totp := &fakeTOTP{byUser: map[int64]*model.TOTP{}, recovery: map[int64]map[string]bool{}}
+	cfg := config.TwoFactorConfig{Issuer: "Test", RecoveryCodes: 4}
+	return &twoFactorTest{
+		svc:  NewTwoFactorService(users, totp, cipher, lockout.NewRedisGuard(client, testLockout), cfg),
+		totp: totp,
+	}
+}
+
+// enroll подключает TOTP пользователю 1 и возвращает секрет и коды
+// восстановления. Подтверждающий код взят из прошлого шага, чтобы тест мог
+// предъявить текущий.
+func (tt *twoFactorTest) enroll(t *testing.T) ([]byte, []string) {
+	t.Helper()
+	ctx := asUser(1).ctx()
+	enrollment, err := tt.svc.Enroll(ctx, 1)
+	wantCode(t, err, codes.OK)
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(enrollment.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovery, err := tt.svc.Confirm(ctx, 1, mfa.Code(secret, mfa.Step(time.Now())-1))
+	wantCode(t, err, codes.OK)
+	if len(recovery) != 4 {
+		t.Fatalf("got %d recovery codes, want 4", len(recovery))
+	}
+	return secret, recovery
+}
+
+// Код TOTP принимается один раз: повтор в том же шаге и код из уже
+// использованного шага отклоняются.
+func TestTwoFactorVerifyStepOnce(t *testing.T) {
+	tt := newTwoFactorTest(t)
+	secret, _ := tt.enroll(t)
+	ctx := asUser(1).ctx()
+	current := mfa.Step(time.Now())
+
+	// код, которым подтверждено подключение, уже использован
+	ok, err := tt.svc.Verify(ctx, 1, mfa.Code(secret, current-1), "")
+	if err != nil || ok {
+		t.Fatalf("confirmation code accepted again: %v, %v", ok, err)
+	}
+	ok, err = tt.svc.Verify(ctx, 1, mfa.Code(secret, current), "")
+	if err != nil || !ok {
+		t.Fatalf("current code rejected: %v, %v", ok, err)
+	}
+	ok, err = tt.svc.Verify(ctx, 1, mfa.Code(secret, current), "")
+	if err != nil || ok {
+		t.Fatalf("current code accepted twice: %v, %v", ok, err)
+	}
+	if got := tt.totp.byUser[1].LastUsedStep; got != current {
+		t.Fatalf("last_used_step = %d, want %d", got, current)
+	}
+}
+
+func TestTwoFactorVerifyRecoveryCodeOnce(t *testing.T) {
+	tt := newTwoFactorTest(t)
+	_, recovery := tt.enroll(t)
+	ctx := asUser(1).ctx()
+
+	ok, err := tt.svc.Verify(ctx, 1, "", recovery[0])
+	if err != nil || !ok {
+		t.Fatalf("recovery code rejected: %v, %v", ok, err)
+	}
+	ok, err = tt.svc.Verify(ctx, 1, "", recovery[0])
+	if err != nil || ok {
+		t.Fatalf("recovery code accepted twice: %v, %v", ok, err)
+	}
+	// остальные коды не затронуты
+	ok, err = tt.svc.Verify(ctx, 1, "", recovery[1])
+	if err != nil || !ok {
+		t.Fatalf("second recovery code rejected: %v, %v", ok, err)
+	}
+}
+
+// Confirm и Disable засчитывают неверные коды в lockout.Guard: после
+// max_failures учётная запись блокируется и верный код уже не помогает.
+func TestTwoFactorLockout(t *testing.T) {
+	t.Run("confirm", func(t *testing.T) {
+		tt := newTwoFactorTest(t)
+		ctx := asUser(1).ctx()
+		enrollment, err := tt.svc.Enroll(ctx, 1)
+		wantCode(t, err, codes.OK)
+		for range testLockout.MaxFailures {
+			_, err := tt.svc.Confirm(ctx, 1, "000000")
+			wantReason(t, err, codes.InvalidArgument, apperr.ReasonInvalidSecondFactor)
+		}
+		secret, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(enrollment.Secret)
+		_, err = tt.svc.Confirm(ctx, 1, mfa.Code(secret, mfa.Step(time.Now())))
+		wantReason(t, err, codes.ResourceExhausted, apperr.ReasonLoginLocked)
+		if tt.totp.byUser[1].Confirmed() {
+			t.Fatal("TOTP confirmed while locked out")
+		}
+	})
+	t.Run("disable", func(t *testing.T) {
+		tt := newTwoFactorTest(t)
+		_, recovery := tt.enroll(t)
+		ctx := auth.WithClientInfo(asUser(1).ctx(), auth.ClientInfo{IP: "10.0.0.1"})
+		for range testLockout.MaxFailures {
+			err := tt.svc.Disable(ctx, 1, "", "AAAAA-AAAAA")
+			wantReason(t, err, codes.InvalidArgument, apperr.ReasonInvalidSecondFactor)
+		}
+		err := tt.svc.Disable(ctx, 1, "", recovery[0])
+		wantReason(t, err, codes.ResourceExhausted, apperr.ReasonLoginLocked)
+		if _, ok := tt.totp.byUser[1]; !ok {
+			t.Fatal("TOTP disabled while locked out")
+		}
+		if slices.Contains(slices.Collect(maps.Values(tt.totp.recovery[1])), true) {
+			t.Fatal("recovery code spent while locked out")
+		}
+	})
+}
+
+// Верный код не засчитывается как неудача: успешные проверки не
+// приближают блокировку.
+func TestTwoFactorSuccessReleasesAttempt(t *testing.T) {
+	tt := newTwoFactorTest(t)
+	_, recovery := tt.enroll(t)
+	ctx := asUser(1).ctx()
+	for i := range testLockout.MaxFailures - 1 {
+		ok, err := tt.svc.Verify(ctx, 1, "", recovery[i])
+		if err != nil || !ok {
+			t.Fatalf("recovery code %d rejected: %v, %v", i, ok, err)
+		}
+	}
+	err := tt.svc.Disable(ctx, 1, "", recovery[testLockout.MaxFailures-1])
+	wantCode(t, err, codes.OK)
+	if _, ok := tt.totp.byUser[1]; ok {
+		t.Fatal("TOTP still enabled")
+	}
+}
+
+func TestTwoFactorAuthorization(t *testing.T) {
+	tests := []struct {
+		caller caller
+		want   codes.Code
+	}{
+		{anonymous, codes.Unauthenticated},
+		{asUser(2), codes.PermissionDenied},
+		{asAdmin, codes.PermissionDenied},
+	}
+	for _, tc := range tests {
+		t.Run(tc.caller.name, func(t *testing.T) {
+			tt := newTwoFactorTest(t)
+			_, recovery := tt.enroll(t)
+			err := tt.svc.Disable(tc.caller.ctx(), 1, "", recovery[0])
+			wantCode(t, err, tc.want)
+			if _, ok := tt.totp.byUser[1]; !ok {
+				t.Fatal("TOTP disabled by another caller")
+			}
+		})
+	}
+}
@@ -3,76 +3,93 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"strconv"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
 	"github.com/DmitriiPro/user-service/internal/cache"
+	"github.com/DmitriiPro/user-service/internal/logger"
 	"github.com/DmitriiPro/user-service/internal/model"
 	"github.com/DmitriiPro/user-service/internal/repository"
+	"github.com/DmitriiPro/user-service/internal/tenant"
 	"github.com/redis/go-redis/v9"
 	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type UserService interface {
+	// GetUserByID возвращает пользователя без проверки прав — для других
+	// сервисов пакета, которые проверяют права сами.
 	GetUserByID(ctx context.Context, id int64) (*model.User, error)
+	// GetProfile — профиль для RPC GetUserByID: самому пользователю,
+	// администраторам, сервисам и API ключам целиком, остальным — только
+	// публичные поля (id, имя, аватар).
+	GetProfile(ctx context.Context, id int64) (*model.User, error)
 	CreateUser(ctx context.Context, email, password string) (int64, error)
+	// UpdateProfile меняет профиль; доступно самому пользователю,
+	// администраторам из admin.user_ids, сервисам и API ключам.
+	UpdateProfile(ctx context.Context, id int64, upd model.ProfileUpdate) (*model.User, error)
 }
 
 type userService struct {
-	repo  repository.UserRepository
-	cache cache.Cache
+	repo   repository.UserRepository
+	cache  cache.Cache
+	email  EmailNormalizer
+	admins []string
 }
 
 type ClientWrapper struct {
 	Client *redis.Client
 }
 
-func NewUserService(repo repository.UserRepository, cache cache.Cache) UserService {
-	return &userService{repo: repo, cache: cache}
+func NewUserService(repo repository.UserRepository, cache cache.Cache, email EmailNormalizer, admins []string) UserService {
+	return &userService{repo: repo, cache: cache, email: email, admins: admins}
 }
 
-func (s *userService) CreateUser(ctx context.Context, email, password string) (int64, error) {
-	log.Printf("Service: CreateUser called for email: %s", email)
-	// Проверка, есть ли пользователь с таким email
-	existing, err := s.repo.GetUserByEmail(ctx, email)
-	if err != nil && err != repository.ErrNotFoundUser {
-		log.Printf("Service: Error checking existing user: %v", err)
-		return 0, err
-	}
+// userCacheKey: ключ включает тенант, чтобы запись одного тенанта не
+// отдавалась в запросе другого.
+func userCacheKey(ctx context.Context, id int64) string {
+	return fmt.Sprintf("user:%s:%d", tenant.FromContext(ctx), id)
+}
 
-	if existing != nil {
-		log.Printf("Service: User with email %s already exists", email)
-		return 0, status.Errorf(codes.AlreadyExists, "user with email %s already exists", email)
-	}
+func (s *userService) CreateUser(ctx context.Context, email, password string) (int64, error) {
+	email = s.email.Normalize(email)
+	logger.Debugf("Service: CreateUser called for email: %s", email)
 
-	log.Printf("Service: Hashing password...")
+	logger.Debugf("Service: Hashing password...")
 	// hashed password
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("Service: Error hashing password: %v", err)
+		logger.Errorf("Service: Error hashing password: %v", err)
 		return 0, fmt.Errorf("error generate password %v ", err)
 	}
 
-	log.Printf("Service: Creating user in repository...")
+	logger.Debugf("Service: Creating user in repository...")
 	user, err := s.repo.CreateUser(ctx, email, string(hash))
 
+	// уникальность email проверяет индекс в БД, без гонки между проверкой и вставкой
+	if errors.Is(err, repository.ErrUserExists) {
+		logger.Infof("Service: User with email %s already exists", email)
+		return 0, apperr.New(codes.AlreadyExists, apperr.ReasonUserAlreadyExists,
+			fmt.Sprintf("user with email %s already exists", email), map[string]string{"email": email})
+	}
 	if err != nil {
-		log.Printf("Service: Repository error: %v", err)
+		logger.Errorf("Service: Repository error: %v", err)
 		return 0, err
 	}
 
-	key := fmt.Sprintf("user:%d", user.ID)
+	key := userCacheKey(ctx, user.ID)
 
 	data, _ := json.Marshal(user)
 	s.cache.Set(ctx, key, string(data))
-	log.Printf("Service: User created with ID: %d", user.ID)
+	logger.Infof("Service: User created with ID: %d", user.ID)
 
 	return user.ID, nil
 }
 
 func (s *userService) GetUserByID(ctx context.Context, id int64) (*model.User, error) {
-	key := fmt.Sprintf("user:%d", id)
+	key := userCacheKey(ctx, id)
 
 	// redis cache
 	valueRedis, err := s.cache.Get(ctx, key)
@@ -80,10 +97,10 @@ func (s *userService) GetUserByID(ctx context.Context, id int64) (*model.User, e
 	if err == nil {
 		var user model.User
 		if json.Unmarshal([]byte(valueRedis), &user) == nil {
-			log.Printf("userService - GetUserByID: Cache hit for ID %d", id)
+			logger.Debugf("userService - GetUserByID: Cache hit for ID %d", id)
 			return &user, nil
 		}
-		log.Printf("userService - GetUserByID: Stale cache for ID %d, deleting", id)
+		logger.Warnf("userService - GetUserByID: Stale cache for ID %d, deleting", id)
 		_ = s.cache.Del(ctx, key) // delete stale cache
 	}
 
@@ -91,26 +108,69 @@ func (s *userService) GetUserByID(ctx context.Context, id int64) (*model.User, e
 	user, err := s.repo.GetUserByID(ctx, id)
 
 	if err != nil {
-		log.Printf("userService - GetUserByID: Error from repository for ID %d: %v", id, err)
+		logger.Errorf("userService - GetUserByID: Error from repository for ID %d: %v", id, err)
 		if err == repository.ErrNotFoundUser {
-			log.Printf("userService - GetUserByID: User with id %d not found", id)
+			logger.Infof("userService - GetUserByID: User with id %d not found", id)
 			_ = s.cache.Del(ctx, key) // delete stale cache
-			return nil, status.Errorf(codes.NotFound, "user with id %d not found", id)
+			return nil, apperr.New(codes.NotFound, apperr.ReasonUserNotFound,
+				fmt.Sprintf("user with id %d not found", id), map[string]string{"id": strconv.FormatInt(id, 10)})
 		}
 		return nil, err
 	}
-	log.Printf("userService - GetUserByID: %v, CreatedAt: %v, Type: %T",
+	logger.Debugf("userService - GetUserByID: %v, CreatedAt: %v, Type: %T",
 		user, user.CreatedAt, user.CreatedAt)
 
 	// save to redis
 	data, err := json.Marshal(user)
 	if err != nil {
-		log.Printf("userService - GetUserByID: Error marshalling user for cache: %v", err)
+		logger.Errorf("userService - GetUserByID: Error marshalling user for cache: %v", err)
 	} else {
 		if err := s.cache.Set(ctx, key, string(data)); err != nil {
-			log.Printf("userService - GetUserByID: Error saving to cache: %v", err)
+			logger.Errorf("userService - GetUserByID: Error saving to cache: %v", err)
+		}
+	}
+
+	return user, nil
+}
+
+func (s *userService) GetProfile(ctx context.Context, id int64) (*model.User, error) {
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if authorizeUserOrAdmin(ctx, id, s.admins) == nil {
+		return user, nil
+	}
+	logger.Debugf("userService - GetProfile: Returning public profile of ID %d", id)
+	return &model.User{ID: user.ID, DisplayName: user.DisplayName, AvatarURL: user.AvatarURL}, nil
+}
+
+func (s *userService) UpdateProfile(ctx context.Context, id int64, upd model.ProfileUpdate) (*model.User, error) {
+	if err := authorizeUserOrAdmin(ctx, id, s.admins); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.UpdateProfile(ctx, id, upd)
+	if err != nil {
+		if err == repository.ErrNotFoundUser {
+			logger.Infof("userService - UpdateProfile: User with id %d not found", id)
+			return nil, apperr.New(codes.NotFound, apperr.ReasonUserNotFound,
+				fmt.Sprintf("user with id %d not found", id), map[string]string{"id": strconv.FormatInt(id, 10)})
 		}
+		return nil, err
+	}
+
+	// кладём в кэш свежий профиль вместо устаревшего
+	key := userCacheKey(ctx, id)
+	data, err := json.Marshal(user)
+	if err != nil {
+		logger.Errorf("userService - UpdateProfile: Error marshalling user for cache: %v", err)
+		_ = s.cache.Del(ctx, key)
+	} else if err := s.cache.Set(ctx, key, string(data)); err != nil {
+		logger.Errorf("userService - UpdateProfile: Error saving to cache: %v", err)
+		_ = s.cache.Del(ctx, key)
 	}
 
+	logger.Infof("userService - UpdateProfile: Profile updated for ID %d", id)
 	return user, nil
 }
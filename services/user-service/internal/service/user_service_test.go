@@ -0,0 +1,90 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/apperr"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"google.golang.org/grpc/codes"
+)
+
+func TestUserServiceUpdateProfileAuthorization(t *testing.T) {
+	tests := []struct {
+		caller caller
+		want   codes.Code
+	}{
+		{anonymous, codes.Unauthenticated},
+		{asUser(2), codes.PermissionDenied},
+		{asUser(1), codes.OK},
+		{asAdmin, codes.OK},
+		{asService, codes.OK},
+		{asAPIKey, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caller.name, func(t *testing.T) {
+			repo := newFakeUsers(&model.User{ID: 1, Email: "a@example.com", DisplayName: "Alice"},
+				&model.User{ID: 2, Email: "b@example.com"})
+			svc := NewUserService(repo, newMemCache(), EmailNormalizer{}, testAdmins)
+
+			name := "Mallory"
+			_, err := svc.UpdateProfile(tt.caller.ctx(), 1, model.ProfileUpdate{DisplayName: &name})
+			wantCode(t, err, tt.want)
+
+			user, _ := repo.GetUserByID(t.Context(), 1)
+			if changed := user.DisplayName == name; changed != (tt.want == codes.OK) {
+				t.Fatalf("display_name = %q after %v", user.DisplayName, err)
+			}
+		})
+	}
+}
+
+func TestUserServiceUpdateProfileNotFound(t *testing.T) {
+	svc := NewUserService(newFakeUsers(), newMemCache(), EmailNormalizer{}, testAdmins)
+	name := "Bob"
+	_, err := svc.UpdateProfile(asAdmin.ctx(), 42, model.ProfileUpdate{DisplayName: &name})
+	wantCode(t, err, codes.NotFound)
+}
+
+func TestUserServiceGetProfile(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	full := &model.User{ID: 1, Email: "a@example.com", CreatedAt: created, UpdatedAt: created, DisplayName: "Alice",
+		Phone: "+79991234567", Locale: "ru-RU", Timezone: "Europe/Moscow", AvatarURL: "https://cdn.example.com/a.png",
+		Metadata: map[string]string{"plan": "pro"}}
+	public := &model.User{ID: 1, DisplayName: "Alice", AvatarURL: "https://cdn.example.com/a.png"}
+	tests := []struct {
+		caller caller
+		want   *model.User
+	}{
+		{asUser(1), full},
+		{asAdmin, full},
+		{asService, full},
+		{asAPIKey, full},
+		{asUser(2), public},
+		{anonymous, public},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caller.name, func(t *testing.T) {
+			cp := *full
+			svc := NewUserService(newFakeUsers(&cp), newMemCache(), EmailNormalizer{}, testAdmins)
+
+			// из кэша — то же, что из БД
+			for _, source := range []string{"repository", "cache"} {
+				got, err := svc.GetProfile(tt.caller.ctx(), 1)
+				wantCode(t, err, codes.OK)
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("%s: profile = %+v, want %+v", source, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUserServiceGetProfileNotFound(t *testing.T) {
+	svc := NewUserService(newFakeUsers(), newMemCache(), EmailNormalizer{}, testAdmins)
+	for _, c := range []caller{anonymous, asAdmin} {
+		_, err := svc.GetProfile(c.ctx(), 42)
+		wantReason(t, err, codes.NotFound, apperr.ReasonUserNotFound)
+	}
+}
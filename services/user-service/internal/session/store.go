@@ -0,0 +1,209 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrNotFound = errors.New("session not found")
+
+// Session — открытая сессия пользователя. Сам токен не хранится: ID —
+// хэш токена, по нему токен не восстановить.
+type Session struct {
+	ID        string    `json:"id"`
+	UserID    int64     `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	// TenantID — тенант пользователя; пуст у сессий, открытых до
+	// разделения на тенанты (это тенант по умолчанию).
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+type Store interface {
+	// Create открывает сессию и возвращает её токен; токен показывается один раз.
+	Create(ctx context.Context, tenantID string, userID int64, ip, userAgent string, ttl time.Duration) (string, *Session, error)
+	// Get находит действующую сессию по токену.
+	Get(ctx context.Context, token string) (*Session, error)
+	List(ctx context.Context, userID int64) ([]Session, error)
+	Revoke(ctx context.Context, userID int64, sessionID string) error
+	// RevokeAll закрывает все сессии пользователя, кроме except, и
+	// возвращает их число.
+	RevokeAll(ctx context.Context, userID int64, except string) (int, error)
+}
+
+// redisStore хранит сессию в "session:<id>" с TTL до истечения и индекс
+// "user_sessions:<userID>" — множество ID сессий пользователя. ID
+// истёкших сессий вычищаются из индекса при List; сам индекс живёт до
+// истечения самой долгой сессии и не остаётся навсегда после последней.
+type redisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) Store {
+	return &redisStore{client: client}
+}
+
+// ID возвращает идентификатор сессии для токена.
+func ID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:16])
+}
+
+func sessionKey(id string) string {
+	return "session:" + id
+}
+
+func userKey(userID int64) string {
+	return "user_sessions:" + strconv.FormatInt(userID, 10)
+}
+
+func (s *redisStore) Create(ctx context.Context, tenantID string, userID int64, ip, userAgent string, ttl time.Duration) (string, *Session, error) {
+	token := rand.Text()
+	now := time.Now().UTC()
+	sess := &Session{
+		ID:        ID(token),
+		UserID:    userID,
+		TenantID:  tenantID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		IP:        ip,
+		UserAgent: userAgent,
+	}
+
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return "", nil, err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, sessionKey(sess.ID), data, ttl)
+		p.SAdd(ctx, userKey(userID), sess.ID)
+		// NX ставит TTL новому индексу, GT продлевает его, если эта
+		// сессия переживёт все прежние (Redis 7+)
+		p.ExpireNX(ctx, userKey(userID), ttl)
+		p.ExpireGT(ctx, userKey(userID), ttl)
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return token, sess, nil
+}
+
+func (s *redisStore) Get(ctx context.Context, token string) (*Session, error) {
+	return s.get(ctx, ID(token))
+}
+
+func (s *redisStore) get(ctx context.Context, id string) (*Session, error) {
+	raw, err := s.client.Get(ctx, sessionKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sess Session
+	if err := json.Unmarshal(raw, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+func (s *redisStore) List(ctx context.Context, userID int64) ([]Session, error) {
+	ids, err := s.client.SMembers(ctx, userKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = sessionKey(id)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	var expired []any
+	for i, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			expired = append(expired, ids[i])
+			continue
+		}
+		var sess Session
+		if err := json.Unmarshal([]byte(raw), &sess); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sess)
+	}
+
+	if len(expired) > 0 {
+		_ = s.client.SRem(ctx, userKey(userID), expired...).Err()
+	}
+	return sessions, nil
+}
+
+func (s *redisStore) Revoke(ctx context.Context, userID int64, sessionID string) error {
+	sess, err := s.get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	// чужую сессию не раскрываем: для вызывающего её просто нет
+	if sess.UserID != userID {
+		return ErrNotFound
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, sessionKey(sessionID))
+		p.SRem(ctx, userKey(userID), sessionID)
+		return nil
+	})
+	return err
+}
+
+func (s *redisStore) RevokeAll(ctx context.Context, userID int64, except string) (int, error) {
+	ids, err := s.client.SMembers(ctx, userKey(userID)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	var keys []string
+	var members []any
+	for _, id := range ids {
+		if id == except {
+			continue
+		}
+		keys = append(keys, sessionKey(id))
+		members = append(members, id)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	var deleted *redis.IntCmd
+	_, err = s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		deleted = p.Del(ctx, keys...)
+		p.SRem(ctx, userKey(userID), members...)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted.Val()), nil
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestStore(t *testing.T) (Store, *redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisStore(client), client, mr
+}
+
+func TestCreateExpiresUserIndex(t *testing.T) {
+	store, client, mr := newTestStore(t)
+	ctx := context.Background()
+	create := func(ttl time.Duration) {
+		t.Helper()
+		if _, _, err := store.Create(ctx, "default", 1, "10.0.0.1", "test", ttl); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	create(time.Hour)
+	if got := mr.TTL(userKey(1)); got != time.Hour {
+		t.Fatalf("index TTL after first session = %v, want 1h", got)
+	}
+
+	// более короткая сессия не укорачивает индекс
+	create(time.Minute)
+	if got := mr.TTL(userKey(1)); got != time.Hour {
+		t.Fatalf("index TTL after shorter session = %v, want 1h", got)
+	}
+
+	// более долгая — продлевает
+	create(24 * time.Hour)
+	if got := mr.TTL(userKey(1)); got != 24*time.Hour {
+		t.Fatalf("index TTL after longer session = %v, want 24h", got)
+	}
+
+	// индекс без TTL, оставшийся от прежних версий, получает его
+	if err := client.Persist(ctx, userKey(1)).Err(); err != nil {
+		t.Fatal(err)
+	}
+	create(time.Hour)
+	if got := mr.TTL(userKey(1)); got != time.Hour {
+		t.Fatalf("index TTL after persisted index = %v, want 1h", got)
+	}
+
+	sessions, err := store.List(ctx, 1)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(sessions) != 4 {
+		t.Fatalf("List returned %d sessions, want 4", len(sessions))
+	}
+
+	mr.FastForward(25 * time.Hour)
+	if mr.Exists(userKey(1)) {
+		t.Fatal("index outlived all sessions")
+	}
+}
@@ -0,0 +1,90 @@
+package signing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// Sign подписывает payload ключом key (JWS compact serialization). typ
+// попадает в заголовок и отличает виды токенов друг от друга.
+func Sign(key *Key, typ string, payload []byte) (string, error) {
+	h, err := json.Marshal(header{Algorithm: key.Algorithm, KeyID: key.ID, Type: typ})
+	if err != nil {
+		return "", err
+	}
+	signingInput := b64(h) + "." + b64(payload)
+	sig, err := key.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + b64(sig), nil
+}
+
+// Verify проверяет подпись и возвращает payload. lookup находит ключ по
+// kid. Пустой typ не проверяется — для токенов внешних провайдеров,
+// которые заголовок typ не ставят.
+func Verify(token, typ string, lookup func(kid string) (*Key, bool)) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if typ != "" && h.Type != typ {
+		return nil, ErrInvalidToken
+	}
+	key, ok := lookup(h.KeyID)
+	// алгоритм берётся из ключа, а не из заголовка: иначе токен мог бы
+	// выбрать проверку сам (alg=none и т.п.)
+	if !ok || h.Algorithm != key.Algorithm {
+		return nil, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := key.verify([]byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return payload, nil
+}
+
+// KeyID возвращает kid из заголовка токена без проверки подписи — чтобы
+// найти ключ заранее.
+func KeyID(token string) (string, error) {
+	segment, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	var h header
+	if err := decodeSegment(segment, &h); err != nil {
+		return "", ErrInvalidToken
+	}
+	return h.KeyID, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
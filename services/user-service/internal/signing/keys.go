@@ -0,0 +1,153 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Алгоритмы подписи (JWS alg).
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// rsaKeyBits — размер создаваемых ключей RSA и минимальный размер ключей
+// внешних провайдеров.
+const rsaKeyBits = 2048
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Key — расшифрованный ключ подписи либо, без закрытой части, ключ
+// внешнего провайдера из его JWKS.
+type Key struct {
+	ID        string
+	Algorithm string
+	NotBefore time.Time
+	NotAfter  time.Time
+	ExpiresAt time.Time
+	private   crypto.Signer
+	public    crypto.PublicKey
+}
+
+func generate(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		return private, err
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+}
+
+// newKey проверяет, что закрытый ключ подходит алгоритму.
+func newKey(algorithm string, private crypto.Signer) (*Key, error) {
+	switch private.(type) {
+	case *rsa.PrivateKey:
+		if algorithm == AlgorithmRS256 {
+			return &Key{Algorithm: algorithm, private: private, public: private.Public()}, nil
+		}
+	case ed25519.PrivateKey:
+		if algorithm == AlgorithmEdDSA {
+			return &Key{Algorithm: algorithm, private: private, public: private.Public()}, nil
+		}
+	}
+	return nil, fmt.Errorf("%T key does not match algorithm %q", private, algorithm)
+}
+
+func (k *Key) sign(input []byte) ([]byte, error) {
+	if k.private == nil {
+		return nil, fmt.Errorf("key %s has no private part", k.ID)
+	}
+	if k.Algorithm == AlgorithmRS256 {
+		sum := sha256.Sum256(input)
+		return k.private.Sign(rand.Reader, sum[:], crypto.SHA256)
+	}
+	// Ed25519 подписывает само сообщение, без предварительного хэша
+	return k.private.Sign(rand.Reader, input, crypto.Hash(0))
+}
+
+func (k *Key) verify(input, sig []byte) error {
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		sum := sha256.Sum256(input)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig)
+	case ed25519.PublicKey:
+		if ed25519.Verify(pub, input, sig) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// JWK — открытый ключ в формате RFC 7517: n и e у RSA, crv и x у Ed25519
+// (RFC 8037).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+func (k *Key) JWK() JWK {
+	jwk := JWK{Use: "sig", Algorithm: k.Algorithm, KeyID: k.ID}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = b64(pub.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = b64(pub)
+	}
+	return jwk
+}
+
+// PublicKey разбирает JWK внешнего провайдера: RSA (RS256) не короче
+// 2048 бит или Ed25519 (EdDSA).
+func (j JWK) PublicKey() (*Key, error) {
+	if j.Use != "" && j.Use != "sig" {
+		return nil, fmt.Errorf("key %s is not a signing key", j.KeyID)
+	}
+	switch {
+	case j.KeyType == "RSA" && (j.Algorithm == "" || j.Algorithm == AlgorithmRS256):
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: decode n: %w", j.KeyID, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("key %s: invalid e", j.KeyID)
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < rsaKeyBits {
+			return nil, fmt.Errorf("key %s: RSA key is shorter than %d bits", j.KeyID, rsaKeyBits)
+		}
+		return &Key{ID: j.KeyID, Algorithm: AlgorithmRS256, public: pub}, nil
+
+	case j.KeyType == "OKP" && j.Curve == "Ed25519" && (j.Algorithm == "" || j.Algorithm == AlgorithmEdDSA):
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %s: invalid x", j.KeyID)
+		}
+		return &Key{ID: j.KeyID, Algorithm: AlgorithmEdDSA, public: ed25519.PublicKey(x)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key %s (kty %s, alg %s)", j.KeyID, j.KeyType, j.Algorithm)
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
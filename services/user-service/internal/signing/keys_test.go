@@ -0,0 +1,124 @@
+package signing
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func newTestKey(t *testing.T, algorithm string) *Key {
+	t.Helper()
+	private, err := generate(algorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := newKey(algorithm, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.ID = "kid-" + algorithm
+	return k
+}
+
+// JWKS публикует только открытую часть, и по ней проверяются токены.
+func TestJWK(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		want      map[string]string
+	}{
+		{AlgorithmRS256, map[string]string{"kty": "RSA", "use": "sig", "alg": "RS256", "kid": "kid-RS256", "e": "AQAB"}},
+		{AlgorithmEdDSA, map[string]string{"kty": "OKP", "use": "sig", "alg": "EdDSA", "kid": "kid-EdDSA", "crv": "Ed25519"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			key := newTestKey(t, tt.algorithm)
+			data, err := json.Marshal(key.JWK())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]string
+			json.Unmarshal(data, &got)
+			for field, want := range tt.want {
+				if got[field] != want {
+					t.Errorf("%s = %q, want %q", field, got[field], want)
+				}
+			}
+			for _, private := range []string{"d", "p", "q", "dp", "dq", "qi"} {
+				if _, ok := got[private]; ok {
+					t.Errorf("JWK publishes private field %s", private)
+				}
+			}
+
+			public, err := key.JWK().PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := Sign(key, "JWT", []byte(`{"sub":"1"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lookup := func(kid string) (*Key, bool) { return public, kid == public.ID }
+			payload, err := Verify(token, "JWT", lookup)
+			if err != nil || string(payload) != `{"sub":"1"}` {
+				t.Fatalf("Verify with the published key = %s, %v", payload, err)
+			}
+			if _, err := Sign(public, "JWT", nil); err == nil {
+				t.Fatal("public key signed a token")
+			}
+		})
+	}
+}
+
+func TestJWKPublicKeyRejects(t *testing.T) {
+	short, _ := rsa.GenerateKey(rand.Reader, 1024)
+	rsaJWK := newTestKey(t, AlgorithmRS256).JWK()
+	edJWK := newTestKey(t, AlgorithmEdDSA).JWK()
+	tests := []struct {
+		name string
+		jwk  JWK
+	}{
+		{"encryption key", func() JWK { j := rsaJWK; j.Use = "enc"; return j }()},
+		{"short RSA", JWK{KeyType: "RSA", KeyID: "short", N: b64(short.N.Bytes()), E: "AQAB"}},
+		{"RSA with EdDSA alg", func() JWK { j := rsaJWK; j.Algorithm = AlgorithmEdDSA; return j }()},
+		{"Ed25519 with RS256 alg", func() JWK { j := edJWK; j.Algorithm = AlgorithmRS256; return j }()},
+		{"truncated x", func() JWK { j := edJWK; j.X = j.X[:10]; return j }()},
+		{"HMAC", JWK{KeyType: "oct", KeyID: "hs", Algorithm: "HS256"}},
+		{"EC", JWK{KeyType: "EC", KeyID: "es", Algorithm: "ES256", Curve: "P-256"}},
+	}
+	for _, tt := range tests {
+		if _, err := tt.jwk.PublicKey(); err == nil {
+			t.Errorf("%s: PublicKey accepted the key", tt.name)
+		}
+	}
+}
+
+func TestNewKeyRejectsMismatchedAlgorithm(t *testing.T) {
+	private, _ := generate(AlgorithmEdDSA)
+	if _, err := newKey(AlgorithmRS256, private); err == nil {
+		t.Fatal("Ed25519 key accepted as RS256")
+	}
+	if _, err := generate("HS256"); err == nil {
+		t.Fatal("HS256 key generated")
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	key := newTestKey(t, AlgorithmEdDSA)
+	token, _ := Sign(key, "JWT", []byte(`{}`))
+	if kid, err := KeyID(token); err != nil || kid != key.ID {
+		t.Fatalf("KeyID = %q, %v", kid, err)
+	}
+	header, _, _ := strings.Cut(token, ".")
+	raw, _ := base64.RawURLEncoding.DecodeString(header)
+	var h map[string]string
+	json.Unmarshal(raw, &h)
+	if h["alg"] != AlgorithmEdDSA || h["typ"] != "JWT" || h["kid"] != key.ID {
+		t.Fatalf("header = %v", h)
+	}
+	if _, err := KeyID("garbage"); err == nil {
+		t.Fatal("KeyID accepted garbage")
+	}
+}
@@ -0,0 +1,216 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/logger"
+	"github.com/DmitriiPro/user-service/internal/model"
+	"github.com/DmitriiPro/user-service/internal/repository"
+)
+
+var ErrNoSigningKey = errors.New("no signing key available")
+
+// Sealer шифрует закрытые ключи перед записью в БД (mfa.Cipher).
+type Sealer interface {
+	Seal(plaintext, associated []byte) []byte
+	Open(sealed, associated []byte) ([]byte, error)
+}
+
+// KeyManager хранит ключи подписи в БД и держит их копию в памяти.
+// Refresh заранее создаёт следующий ключ, так что в JWKS он появляется
+// раньше, чем начинает подписывать, и удаляет ключи с истёкшей
+// публикацией. Реплики могут одновременно создать по ключу на один срок —
+// подписывает тогда созданный последним, а опубликованы оба.
+type KeyManager struct {
+	repo   repository.SigningKeyRepository
+	sealer Sealer
+	cfg    config.SigningKeysConfig
+	now    func() time.Time
+
+	mu   sync.RWMutex
+	keys []*Key // по возрастанию NotBefore
+}
+
+func NewKeyManager(repo repository.SigningKeyRepository, sealer Sealer, cfg config.SigningKeysConfig) *KeyManager {
+	return &KeyManager{repo: repo, sealer: sealer, cfg: cfg, now: time.Now}
+}
+
+// keyAD — associated data закрытого ключа: шифротекст привязан к kid.
+// Префикс остался с тех пор, как ключи были только у OIDC: с ним
+// зашифрованы уже сохранённые ключи.
+func keyAD(id string) []byte {
+	return []byte("oidc_signing_key:" + id)
+}
+
+// Run вызывает Refresh каждые interval до отмены ctx.
+func (m *KeyManager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Refresh(ctx); err != nil {
+				logger.Errorf("KeyManager - Refresh: %v", err)
+			}
+		}
+	}
+}
+
+// Refresh при необходимости создаёт ключ на текущий или следующий срок,
+// удаляет истёкшие и перечитывает ключи из БД.
+func (m *KeyManager) Refresh(ctx context.Context) error {
+	now := m.now()
+	stored, err := m.repo.ListActive(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var next time.Time
+	if len(stored) == 0 || !stored[len(stored)-1].NotAfter.After(now) {
+		next = now
+	} else if latest := stored[len(stored)-1]; !latest.NotAfter.Add(-m.cfg.PublishAhead).After(now) {
+		next = latest.NotAfter
+	}
+	if !next.IsZero() {
+		created, err := m.create(ctx, next)
+		if err != nil {
+			return fmt.Errorf("create signing key: %w", err)
+		}
+		stored = append(stored, *created)
+		logger.Infof("KeyManager - Refresh: Created signing key %s, signs from %s", created.ID, created.NotBefore.Format(time.RFC3339))
+	}
+
+	if n, err := m.repo.DeleteExpired(ctx, now); err != nil {
+		logger.Errorf("KeyManager - Refresh: Failed to delete expired keys: %v", err)
+	} else if n > 0 {
+		logger.Infof("KeyManager - Refresh: Deleted %d expired signing keys", n)
+	}
+
+	keys := make([]*Key, 0, len(stored))
+	for _, s := range stored {
+		k, err := m.open(s)
+		if err != nil {
+			// ключ, зашифрованный другим encryption_key, пропускаем:
+			// остальные продолжают работать
+			logger.Warnf("KeyManager - Refresh: Skipping key %s: %v", s.ID, err)
+			continue
+		}
+		keys = append(keys, k)
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *KeyManager) create(ctx context.Context, notBefore time.Time) (*model.SigningKey, error) {
+	private, err := generate(m.cfg.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	id := rand.Text()
+	key := &model.SigningKey{
+		ID:         id,
+		Algorithm:  m.cfg.Algorithm,
+		PrivateKey: m.sealer.Seal(der, keyAD(id)),
+		NotBefore:  notBefore,
+		NotAfter:   notBefore.Add(m.cfg.Rotation),
+		ExpiresAt:  notBefore.Add(m.cfg.Rotation + m.cfg.Retention),
+	}
+	if err := m.repo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (m *KeyManager) open(s model.SigningKey) (*Key, error) {
+	der, err := m.sealer.Open(s.PrivateKey, keyAD(s.ID))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("key is %T, not a signer", parsed)
+	}
+	k, err := newKey(s.Algorithm, private)
+	if err != nil {
+		return nil, err
+	}
+	k.ID, k.NotBefore, k.NotAfter, k.ExpiresAt = s.ID, s.NotBefore, s.NotAfter, s.ExpiresAt
+	return k, nil
+}
+
+// Signer возвращает ключ, которым сейчас подписываются токены.
+func (m *KeyManager) Signer(now time.Time) (*Key, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for i := len(m.keys) - 1; i >= 0; i-- {
+		k := m.keys[i]
+		if !k.NotBefore.After(now) && k.NotAfter.After(now) {
+			return k, nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+// Lookup находит опубликованный ключ по kid для проверки подписи.
+func (m *KeyManager) Lookup(kid string) (*Key, bool) {
+	now := m.now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, k := range m.keys {
+		if k.ID == kid && k.ExpiresAt.After(now) {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// JWKS возвращает открытые части опубликованных ключей.
+func (m *KeyManager) JWKS() []JWK {
+	now := m.now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	jwks := make([]JWK, 0, len(m.keys))
+	for _, k := range m.keys {
+		if k.ExpiresAt.After(now) {
+			jwks = append(jwks, k.JWK())
+		}
+	}
+	return jwks
+}
+
+// Algorithms — алгоритмы опубликованных ключей: после смены алгоритма
+// какое-то время действуют ключи обоих.
+func (m *KeyManager) Algorithms() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var algs []string
+	for _, k := range m.keys {
+		if !slices.Contains(algs, k.Algorithm) {
+			algs = append(algs, k.Algorithm)
+		}
+	}
+	return algs
+}
@@ -0,0 +1,242 @@
+package signing
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/mfa"
+	"github.com/DmitriiPro/user-service/internal/model"
+)
+
+// memKeys — SigningKeyRepository в памяти с теми же условиями, что в
+// Postgres.
+type memKeys struct {
+	keys []model.SigningKey
+}
+
+func (r *memKeys) Create(_ context.Context, key *model.SigningKey) error {
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *memKeys) ListActive(_ context.Context, now time.Time) ([]model.SigningKey, error) {
+	var out []model.SigningKey
+	for _, k := range r.keys {
+		if k.ExpiresAt.After(now) {
+			out = append(out, k)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b model.SigningKey) int { return a.NotBefore.Compare(b.NotBefore) })
+	return out, nil
+}
+
+func (r *memKeys) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	n := len(r.keys)
+	r.keys = slices.DeleteFunc(r.keys, func(k model.SigningKey) bool { return !k.ExpiresAt.After(now) })
+	return int64(n - len(r.keys)), nil
+}
+
+var testKeysConfig = config.SigningKeysConfig{
+	Algorithm:    AlgorithmEdDSA,
+	Rotation:     24 * time.Hour,
+	PublishAhead: time.Hour,
+	Retention:    48 * time.Hour,
+}
+
+func newTestSealer(t *testing.T, fill byte) Sealer {
+	t.Helper()
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = fill
+	}
+	c, err := mfa.NewCipher(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// testClock — управляемое время KeyManager.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestManager(t *testing.T, repo *memKeys, cfg config.SigningKeysConfig, clock *testClock) *KeyManager {
+	t.Helper()
+	m := NewKeyManager(repo, newTestSealer(t, 1), cfg)
+	m.now = func() time.Time { return clock.now }
+	return m
+}
+
+func refresh(t *testing.T, m *KeyManager) {
+	t.Helper()
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func kids(jwks []JWK) []string {
+	out := make([]string, len(jwks))
+	for i, j := range jwks {
+		out[i] = j.KeyID
+	}
+	return out
+}
+
+func mustSign(t *testing.T, m *KeyManager, now time.Time) (string, *Key) {
+	t.Helper()
+	key, err := m.Signer(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := Sign(key, "JWT", []byte(`{"sub":"1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token, key
+}
+
+// Полный цикл ключа: новый ключ публикуется за publish_ahead до начала
+// подписи, токены старого проверяются ещё retention после его замены,
+// затем ключ исчезает из JWKS и из БД.
+func TestKeyManagerRotation(t *testing.T) {
+	repo := &memKeys{}
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	start := clock.now
+	m := newTestManager(t, repo, testKeysConfig, clock)
+
+	if _, err := m.Signer(clock.now); !errors.Is(err, ErrNoSigningKey) {
+		t.Fatalf("Signer before Refresh err = %v", err)
+	}
+	refresh(t, m)
+	oldToken, first := mustSign(t, m, clock.now)
+	if !first.NotBefore.Equal(start) || !first.NotAfter.Equal(start.Add(24*time.Hour)) ||
+		!first.ExpiresAt.Equal(start.Add(72*time.Hour)) {
+		t.Fatalf("first key period %v – %v, published until %v", first.NotBefore, first.NotAfter, first.ExpiresAt)
+	}
+
+	// раньше publish_ahead до конца срока новый ключ не создаётся
+	clock.advance(22 * time.Hour)
+	refresh(t, m)
+	if got := kids(m.JWKS()); !slices.Equal(got, []string{first.ID}) {
+		t.Fatalf("JWKS at 22h = %v", got)
+	}
+
+	// за publish_ahead ключ уже опубликован, но ещё не подписывает
+	clock.advance(time.Hour)
+	refresh(t, m)
+	jwks := kids(m.JWKS())
+	if len(jwks) != 2 || jwks[0] != first.ID {
+		t.Fatalf("JWKS at 23h = %v, want the current and the next key", jwks)
+	}
+	next := jwks[1]
+	if _, key := mustSign(t, m, clock.now); key.ID != first.ID {
+		t.Fatalf("signer at 23h = %s, want %s", key.ID, first.ID)
+	}
+	refresh(t, m)
+	if len(repo.keys) != 2 {
+		t.Fatalf("repeated Refresh created a key: %d keys", len(repo.keys))
+	}
+
+	// с начала срока подписывает новый ключ, токены старого проверяются
+	clock.advance(time.Hour)
+	if _, key := mustSign(t, m, clock.now); key.ID != next {
+		t.Fatalf("signer at 24h = %s, want %s", key.ID, next)
+	}
+	if _, err := Verify(oldToken, "JWT", m.Lookup); err != nil {
+		t.Fatalf("token of the retired key at 24h: %v", err)
+	}
+
+	// старый ключ публикуется до конца retention
+	clock.now = first.ExpiresAt.Add(-time.Second)
+	refresh(t, m)
+	if _, err := Verify(oldToken, "JWT", m.Lookup); err != nil {
+		t.Fatalf("token of the retired key before expiry: %v", err)
+	}
+	if !slices.Contains(kids(m.JWKS()), first.ID) {
+		t.Fatal("retired key left JWKS before expiry")
+	}
+
+	clock.advance(time.Second)
+	if _, err := Verify(oldToken, "JWT", m.Lookup); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("token of an expired key err = %v", err)
+	}
+	if slices.Contains(kids(m.JWKS()), first.ID) {
+		t.Fatal("expired key is still published")
+	}
+	refresh(t, m)
+	if slices.ContainsFunc(repo.keys, func(k model.SigningKey) bool { return k.ID == first.ID }) {
+		t.Fatal("expired key is still stored")
+	}
+}
+
+// После простоя дольше срока ключа новый ключ начинает подписывать сразу.
+func TestKeyManagerRefreshAfterDowntime(t *testing.T) {
+	repo := &memKeys{}
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	refresh(t, newTestManager(t, repo, testKeysConfig, clock))
+
+	clock.advance(30 * time.Hour)
+	m := newTestManager(t, repo, testKeysConfig, clock)
+	refresh(t, m)
+	_, key := mustSign(t, m, clock.now)
+	if !key.NotBefore.Equal(clock.now) {
+		t.Fatalf("new key signs from %v, want %v", key.NotBefore, clock.now)
+	}
+	if len(m.JWKS()) != 2 {
+		t.Fatalf("JWKS has %d keys, want the retired and the new one", len(m.JWKS()))
+	}
+}
+
+// Новый алгоритм действует со следующего ключа; пока старый опубликован,
+// Algorithms возвращает оба.
+func TestKeyManagerAlgorithmChange(t *testing.T) {
+	repo := &memKeys{}
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	refresh(t, newTestManager(t, repo, testKeysConfig, clock))
+
+	cfg := testKeysConfig
+	cfg.Algorithm = AlgorithmRS256
+	m := newTestManager(t, repo, cfg, clock)
+	refresh(t, m)
+	if _, key := mustSign(t, m, clock.now); key.Algorithm != AlgorithmEdDSA {
+		t.Fatalf("current key switched to %s before rotation", key.Algorithm)
+	}
+
+	clock.advance(24 * time.Hour)
+	refresh(t, m)
+	token, key := mustSign(t, m, clock.now)
+	if key.Algorithm != AlgorithmRS256 {
+		t.Fatalf("next key algorithm = %s", key.Algorithm)
+	}
+	if _, err := Verify(token, "JWT", m.Lookup); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Algorithms(); !slices.Equal(got, []string{AlgorithmEdDSA, AlgorithmRS256}) {
+		t.Fatalf("Algorithms = %v", got)
+	}
+}
+
+// Ключ, зашифрованный другим encryption_key, пропускается, остальные
+// работают.
+func TestKeyManagerSkipsUndecryptableKeys(t *testing.T) {
+	repo := &memKeys{}
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	other := NewKeyManager(repo, newTestSealer(t, 2), testKeysConfig)
+	other.now = func() time.Time { return clock.now }
+	refresh(t, other)
+
+	clock.advance(23 * time.Hour)
+	m := newTestManager(t, repo, testKeysConfig, clock)
+	refresh(t, m)
+	if len(repo.keys) != 2 || len(m.JWKS()) != 1 || m.JWKS()[0].KeyID != repo.keys[1].ID {
+		t.Fatalf("stored %d keys, published %v", len(repo.keys), kids(m.JWKS()))
+	}
+}
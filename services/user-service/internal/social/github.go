@@ -0,0 +1,77 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+)
+
+// githubProvider — GitHub OAuth App: ID токена нет, личность берётся из
+// REST API (/user и /user/emails).
+type githubProvider struct {
+	cfg    config.SocialProviderConfig
+	client *http.Client
+}
+
+func NewGitHubProvider(cfg config.SocialProviderConfig, client *http.Client) Provider {
+	return &githubProvider{cfg: cfg, client: client}
+}
+
+func (p *githubProvider) endpoint(path string) string {
+	return strings.TrimSuffix(p.cfg.Issuer, "/") + path
+}
+
+func (p *githubProvider) api(path string) string {
+	return strings.TrimSuffix(p.cfg.APIURL, "/") + path
+}
+
+// nonce у GitHub не поддерживается; от подмены ответа защищают state и PKCE.
+func (p *githubProvider) AuthCodeURL(_ context.Context, state, _, codeChallenge string) (string, error) {
+	return authCodeURL(p.endpoint("/login/oauth/authorize"), p.cfg, state, codeChallenge, nil)
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code, codeVerifier, _ string) (*Identity, error) {
+	token, err := exchangeCode(ctx, p.client, p.endpoint("/login/oauth/access_token"), p.cfg, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, p.client, p.api("/user"), token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errors.New("github: user has no id")
+	}
+
+	// email из /user — публичный адрес профиля, он не обязательно
+	// подтверждён; подтверждённость есть только в /user/emails
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, p.client, p.api("/user/emails"), token.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{Subject: strconv.FormatInt(user.ID, 10), Name: user.Name}
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+	for _, e := range emails {
+		if e.Primary {
+			identity.Email, identity.EmailVerified = e.Email, e.Verified
+			break
+		}
+	}
+	return identity, nil
+}
@@ -0,0 +1,158 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+	"github.com/DmitriiPro/user-service/internal/oidc"
+	"github.com/DmitriiPro/user-service/internal/signing"
+)
+
+// jwksMinRefresh — JWKS перечитывается на неизвестный kid не чаще: иначе
+// поддельные токены заставляли бы ходить к провайдеру на каждый запрос.
+const jwksMinRefresh = time.Minute
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcProvider — провайдер OpenID Connect: эндпоинты берутся из discovery,
+// личность — из подписанного ID токена.
+type oidcProvider struct {
+	cfg    config.SocialProviderConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	meta        *discovery
+	keys        map[string]*signing.Key
+	keysFetched time.Time
+}
+
+func NewOIDCProvider(cfg config.SocialProviderConfig, client *http.Client) Provider {
+	return &oidcProvider{cfg: cfg, client: client}
+}
+
+// discover загружает документ discovery один раз; при ошибке следующий
+// вызов попробует снова.
+func (p *oidcProvider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta discovery
+	endpoint := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, p.client, endpoint, "", &meta); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	// OpenID Connect Discovery, раздел 4.3
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery: issuer %q does not match configured %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("discovery: required endpoints are missing")
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return authCodeURL(meta.AuthorizationEndpoint, p.cfg, state, codeChallenge, url.Values{"nonce": {nonce}})
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := exchangeCode(ctx, p.client, meta.TokenEndpoint, p.cfg, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	// ключ ищем до Verify: lookup вызывается под её контролем и не должен
+	// ходить в сеть
+	if err := p.ensureKey(ctx, meta, token.IDToken); err != nil {
+		return nil, err
+	}
+	claims, err := oidc.Verify(token.IDToken, "", p.lookup, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("id_token: %w", err)
+	}
+	switch {
+	case claims.Issuer != meta.Issuer:
+		return nil, fmt.Errorf("id_token: unexpected issuer %q", claims.Issuer)
+	case !slices.Contains(claims.Audience, p.cfg.ClientID):
+		return nil, errors.New("id_token: client is not in audience")
+	case claims.Nonce != nonce:
+		return nil, errors.New("id_token: nonce mismatch")
+	case claims.Subject == "":
+		return nil, errors.New("id_token: empty subject")
+	}
+
+	return &Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
+
+func (p *oidcProvider) lookup(kid string) (*signing.Key, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k, ok := p.keys[kid]
+	return k, ok
+}
+
+// ensureKey перечитывает JWKS, если ключа из заголовка токена ещё нет:
+// провайдер мог сменить ключи.
+func (p *oidcProvider) ensureKey(ctx context.Context, meta *discovery, idToken string) error {
+	kid, err := signing.KeyID(idToken)
+	if err != nil {
+		return fmt.Errorf("id_token: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.keys[kid]; ok || time.Since(p.keysFetched) < jwksMinRefresh {
+		return nil
+	}
+
+	var set struct {
+		Keys []signing.JWK `json:"keys"`
+	}
+	if err := getJSON(ctx, p.client, meta.JWKSURI, "", &set); err != nil {
+		return fmt.Errorf("jwks: %w", err)
+	}
+	keys := make(map[string]*signing.Key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		// ключи шифрования и других алгоритмов провайдер публикует рядом —
+		// они просто не используются
+		if k, err := jwk.PublicKey(); err == nil {
+			keys[k.ID] = k
+		}
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+	return nil
+}
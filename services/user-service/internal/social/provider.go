@@ -0,0 +1,152 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/config"
+)
+
+// Identity — пользователь, как его описал внешний провайдер. Subject
+// постоянен в пределах провайдера, в отличие от email.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider — клиент authorization code flow одного провайдера.
+type Provider interface {
+	// AuthCodeURL — адрес провайдера, на который отправляется пользователь.
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange обменивает code на личность пользователя. nonce сверяется с
+	// ID токеном, если провайдер его выдаёт.
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
+
+const httpTimeout = 10 * time.Second
+
+// New создаёт включённых в конфигурации провайдеров по имени.
+func New(cfg config.SocialConfig) map[string]Provider {
+	client := &http.Client{Timeout: httpTimeout}
+	providers := make(map[string]Provider)
+	for name, p := range cfg.Providers() {
+		if !p.Enabled {
+			continue
+		}
+		if name == "github" {
+			providers[name] = NewGitHubProvider(p, client)
+		} else {
+			providers[name] = NewOIDCProvider(p, client)
+		}
+	}
+	return providers
+}
+
+// authCodeURL добавляет к endpoint параметры запроса авторизации (RFC 6749,
+// раздел 4.1.1) с PKCE.
+func authCodeURL(endpoint string, cfg config.SocialProviderConfig, state, codeChallenge string, extra url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("parse authorization endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", cfg.ClientID)
+	q.Set("redirect_uri", cfg.RedirectURL)
+	q.Set("scope", strings.Join(cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	for k, v := range extra {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// exchangeCode выполняет запрос к token endpoint (client_secret_basic).
+func exchangeCode(ctx context.Context, client *http.Client, endpoint string, cfg config.SocialProviderConfig, code, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+
+	var token tokenResponse
+	status, err := doJSON(client, req, &token)
+	if err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	// GitHub сообщает об ошибке с кодом 200
+	if token.Error != "" {
+		return nil, fmt.Errorf("token request: %s: %s", token.Error, token.Description)
+	}
+	if status != http.StatusOK || token.AccessToken == "" {
+		return nil, fmt.Errorf("token request: unexpected status %d", status)
+	}
+	return &token, nil
+}
+
+// getJSON — GET с access токеном провайдера.
+func getJSON(ctx context.Context, client *http.Client, endpoint, accessToken string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	status, err := doJSON(client, req, v)
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", endpoint, err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %d", endpoint, status)
+	}
+	return nil
+}
+
+// ответы провайдеров небольшие; предел защищает от бесконечного тела
+const maxResponseBytes = 1 << 20
+
+func doJSON(client *http.Client, req *http.Request, v any) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return resp.StatusCode, errors.Join(fmt.Errorf("decode response (status %d)", resp.StatusCode), err)
+	}
+	return resp.StatusCode, nil
+}
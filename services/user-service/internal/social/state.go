@@ -0,0 +1,78 @@
+package social
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrStateNotFound = errors.New("social login state not found")
+
+// State — начатый вход через провайдера. Параметр state из ответа
+// провайдера — ключ к нему; nonce и code_verifier наружу не отдаются.
+type State struct {
+	Provider      string `json:"provider"`
+	Nonce         string `json:"nonce"`
+	CodeVerifier  string `json:"code_verifier"`
+	UserAgentHash string `json:"user_agent_hash"`
+	TenantID      string `json:"tenant_id,omitempty"`
+}
+
+type StateStore interface {
+	Create(ctx context.Context, s State, ttl time.Duration) (string, error)
+	// Consume возвращает state и гасит его: повтор ответа провайдера
+	// получит ErrStateNotFound.
+	Consume(ctx context.Context, state string) (*State, error)
+}
+
+// redisStateStore хранит state в "social_state:<sha256(state)>".
+type redisStateStore struct {
+	client *redis.Client
+}
+
+func NewRedisStateStore(client *redis.Client) StateStore {
+	return &redisStateStore{client: client}
+}
+
+// Hash — sha256 в hex; им же хэшируется User-Agent для State.
+func Hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func stateKey(state string) string {
+	return "social_state:" + Hash(state)
+}
+
+func (s *redisStateStore) Create(ctx context.Context, st State, ttl time.Duration) (string, error) {
+	token := rand.Text()
+	data, err := json.Marshal(st)
+	if err != nil {
+		return "", err
+	}
+	if err := s.client.Set(ctx, stateKey(token), data, ttl).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *redisStateStore) Consume(ctx context.Context, state string) (*State, error) {
+	raw, err := s.client.GetDel(ctx, stateKey(state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st State
+	if err := json.Unmarshal(raw, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
@@ -0,0 +1,100 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Default — тенант запросов, в которых тенант не указан, и всех
+// пользователей, созданных до разделения на тенанты.
+const Default = "default"
+
+// Header — HTTP заголовок с тенантом; в gRPC — метаданные MetadataKey.
+const (
+	Header      = "X-Tenant-ID"
+	MetadataKey = "x-tenant-id"
+)
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Valid проверяет формат идентификатора тенанта.
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// OrDefault: у сессий и токенов, выпущенных до разделения на тенанты,
+// тенант не записан — это тенант по умолчанию.
+func OrDefault(id string) string {
+	if id == "" {
+		return Default
+	}
+	return id
+}
+
+type tenantKey struct{}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext возвращает тенант запроса; Default, если он не задан
+// (фоновые задачи, запросы до TenantInterceptor).
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return OrDefault(id)
+}
+
+// Store — источник тенантов (таблица tenants).
+type Store interface {
+	Exists(ctx context.Context, id string) (bool, error)
+}
+
+// Directory проверяет существование тенантов и помнит ответ ttl: тенант
+// нужен на каждый запрос, а заводятся они редко.
+type Directory struct {
+	store Store
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	known map[string]entry
+}
+
+type entry struct {
+	exists  bool
+	expires time.Time
+}
+
+func NewDirectory(store Store, ttl time.Duration) *Directory {
+	return &Directory{store: store, ttl: ttl, known: map[string]entry{}}
+}
+
+func (d *Directory) Exists(ctx context.Context, id string) (bool, error) {
+	if !Valid(id) {
+		return false, nil
+	}
+	now := time.Now()
+	d.mu.Lock()
+	e, ok := d.known[id]
+	d.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.exists, nil
+	}
+
+	exists, err := d.store.Exists(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	d.mu.Lock()
+	// несуществующие идентификаторы тоже запоминаются, но карта не растёт
+	// бесконечно: при переполнении она просто сбрасывается
+	if len(d.known) >= maxKnown {
+		clear(d.known)
+	}
+	d.known[id] = entry{exists: exists, expires: now.Add(d.ttl)}
+	d.mu.Unlock()
+	return exists, nil
+}
+
+const maxKnown = 10000
@@ -0,0 +1,41 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+)
+
+// ServerConfig собирает tls.Config для сервера. Если clientCAs не nil,
+// клиентские сертификаты проверяются по актуальному пулу CA на каждом
+// рукопожатии.
+func ServerConfig(cert *CertReloader, clientCAs *CAReloader, clientAuth tls.ClientAuthType) *tls.Config {
+	base := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: cert.GetCertificate,
+	}
+	if clientCAs == nil {
+		return base
+	}
+
+	base.ClientAuth = clientAuth
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.ClientCAs = clientCAs.Pool()
+		return cfg, nil
+	}
+	return base
+}
+
+// ParseClientAuth переводит значение из конфигурации в tls.ClientAuthType.
+func ParseClientAuth(mode string) (tls.ClientAuthType, bool) {
+	switch mode {
+	case "", "none":
+		return tls.NoClientCert, true
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, true
+	case "require":
+		return tls.RequireAndVerifyClientCert, true
+	default:
+		return tls.NoClientCert, false
+	}
+}
@@ -0,0 +1,153 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/DmitriiPro/user-service/internal/logger"
+)
+
+// CertReloader держит пару сертификат/ключ и перечитывает её, когда файлы
+// на диске меняются (например, cert-manager или Vault agent обновили
+// секрет). Новые TLS рукопожатия получают свежий сертификат, открытые
+// соединения не разрываются.
+type CertReloader struct {
+	certFile, keyFile string
+	cert              atomic.Pointer[tls.Certificate]
+}
+
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair %s: %w", r.certFile, err)
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Watch проверяет файлы каждые interval до отмены ctx.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	watch(ctx, interval, r.reload, r.certFile, r.keyFile)
+}
+
+// CAReloader держит пул доверенных CA из PEM файла.
+type CAReloader struct {
+	file string
+	pool atomic.Pointer[x509.CertPool]
+}
+
+func NewCAReloader(file string) (*CAReloader, error) {
+	r := &CAReloader{file: file}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CAReloader) reload() error {
+	pool, err := LoadCertPool(r.file)
+	if err != nil {
+		return err
+	}
+	r.pool.Store(pool)
+	return nil
+}
+
+func (r *CAReloader) Pool() *x509.CertPool {
+	return r.pool.Load()
+}
+
+func (r *CAReloader) Watch(ctx context.Context, interval time.Duration) {
+	watch(ctx, interval, r.reload, r.file)
+}
+
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in " + file)
+	}
+	return pool, nil
+}
+
+// fileState — время изменения и размер файлов; os.Stat идёт по симлинкам,
+// поэтому подмена ..data в смонтированном Kubernetes Secret тоже видна.
+type fileState []string
+
+func stat(files ...string) fileState {
+	state := make(fileState, 0, len(files))
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			state = append(state, "")
+			continue
+		}
+		state = append(state, fmt.Sprintf("%d/%d", info.ModTime().UnixNano(), info.Size()))
+	}
+	return state
+}
+
+func (s fileState) equal(other fileState) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for i := range s {
+		if s[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func watch(ctx context.Context, interval time.Duration, reload func() error, files ...string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := stat(files...)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := stat(files...)
+		if current.equal(last) {
+			continue
+		}
+		// При ошибке (файлы записаны наполовину) остаётся прежний
+		// сертификат; следующая проверка попробует снова.
+		if err := reload(); err != nil {
+			logger.Errorf("TLS: failed to reload %s: %v", files[0], err)
+			continue
+		}
+		last = current
+		logger.Infof("TLS: reloaded %s", files[0])
+	}
+}
@@ -0,0 +1,233 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA выпускает сертификаты для тестов.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue выпускает сертификат с серийным номером serial; PEM сертификата и ключа.
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage, dnsNames ...string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writePair записывает пару и сдвигает время изменения, чтобы Watch
+// заметил замену даже в пределах точности mtime файловой системы.
+func writePair(t *testing.T, dir string, certPEM, keyPEM []byte, mtime time.Time) (certFile, keyFile string) {
+	t.Helper()
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	for file, data := range map[string][]byte{certFile: certPEM, keyFile: keyPEM} {
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return certFile, keyFile
+}
+
+// servedSerial подключается к addr и возвращает серийный номер
+// сертификата сервера.
+func servedSerial(t *testing.T, addr string, roots *x509.CertPool, client *tls.Certificate) (int64, error) {
+	t.Helper()
+	cfg := &tls.Config{RootCAs: roots, ServerName: "127.0.0.1"}
+	if client != nil {
+		cfg.Certificates = []tls.Certificate{*client}
+	}
+	conn, err := tls.Dial("tcp", addr, cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	// на TLS 1.3 отказ в клиентском сертификате приходит после рукопожатия
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !isTimeout(err) {
+		return 0, err
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// serve принимает TLS соединения с cfg и держит их открытыми.
+func serve(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if err := conn.(*tls.Conn).Handshake(); err != nil {
+					return
+				}
+				conn.Read(make([]byte, 1))
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestCertReloaderServesRotatedCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Minute)
+
+	certPEM, keyPEM := ca.issue(t, 100, x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := writePair(t, dir, certPEM, keyPEM, start)
+	reloader, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 10*time.Millisecond)
+	addr := serve(t, ServerConfig(reloader, nil, tls.NoClientCert))
+
+	if serial, err := servedSerial(t, addr, roots, nil); err != nil || serial != 100 {
+		t.Fatalf("served serial %d (%v), want 100", serial, err)
+	}
+
+	// записанный наполовину файл не подменяет рабочий сертификат
+	writePair(t, dir, certPEM[:len(certPEM)/2], keyPEM, start.Add(time.Second))
+	time.Sleep(50 * time.Millisecond)
+	if serial, err := servedSerial(t, addr, roots, nil); err != nil || serial != 100 {
+		t.Fatalf("after broken write: served serial %d (%v), want 100", serial, err)
+	}
+
+	certPEM, keyPEM = ca.issue(t, 200, x509.ExtKeyUsageServerAuth)
+	writePair(t, dir, certPEM, keyPEM, start.Add(2*time.Second))
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		serial, err := servedSerial(t, addr, roots, nil)
+		if err == nil && serial == 200 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("rotated certificate not served: serial %d (%v)", serial, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerConfigVerifiesClientCertificates(t *testing.T) {
+	ca, other := newTestCA(t), newTestCA(t)
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.issue(t, 1, x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := writePair(t, dir, certPEM, keyPEM, time.Now())
+	caFile := filepath.Join(dir, "ca.crt")
+	os.WriteFile(caFile, ca.pem, 0o600)
+
+	serverCert, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs, err := NewCAReloader(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := serve(t, ServerConfig(serverCert, clientCAs, tls.RequireAndVerifyClientCert))
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	clientPair := func(ca *testCA) *tls.Certificate {
+		certPEM, keyPEM := ca.issue(t, 2, x509.ExtKeyUsageClientAuth, "billing.internal")
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &pair
+	}
+	tests := []struct {
+		name    string
+		client  *tls.Certificate
+		wantErr bool
+	}{
+		{"trusted client", clientPair(ca), false},
+		{"client of another CA", clientPair(other), true},
+		{"no client certificate", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := servedSerial(t, addr, roots, tt.client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handshake err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
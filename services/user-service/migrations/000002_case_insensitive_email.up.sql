@@ -0,0 +1,6 @@
+-- email уникален без учёта регистра. Если в таблице уже есть адреса,
+-- отличающиеся только регистром, миграция упадёт — их нужно объединить вручную.
+UPDATE users SET email = btrim(email) WHERE email <> btrim(email);
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (lower(email));
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS users_display_name_trgm_idx;
+DROP INDEX IF EXISTS users_email_trgm_idx;
+DROP INDEX IF EXISTS users_search_vector_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS search_vector;
+-- расширение pg_trgm не удаляем: им могут пользоваться другие объекты базы
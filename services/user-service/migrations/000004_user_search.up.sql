@@ -0,0 +1,13 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- полнотекстовый поиск по имени и локальной части email ("ivan.petrov" → "ivan petrov")
+ALTER TABLE users ADD COLUMN IF NOT EXISTS search_vector tsvector
+  GENERATED ALWAYS AS (
+    to_tsvector('simple', display_name || ' ' || translate(split_part(email, '@', 1), '._-+', '    '))
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS users_search_vector_idx ON users USING GIN (search_vector);
+
+-- подстроки и нечёткое совпадение (LIKE '%...%', %, similarity)
+CREATE INDEX IF NOT EXISTS users_email_trgm_idx ON users USING GIN (lower(email) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS users_display_name_trgm_idx ON users USING GIN (lower(display_name) gin_trgm_ops);
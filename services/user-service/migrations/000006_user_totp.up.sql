@@ -0,0 +1,19 @@
+-- секрет TOTP хранится зашифрованным (AES-GCM, ключ two_factor.encryption_key);
+-- confirmed_at пуст, пока пользователь не подтвердил подключение кодом
+CREATE TABLE IF NOT EXISTS user_totp (
+  user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  secret_encrypted BYTEA NOT NULL,
+  confirmed_at TIMESTAMP WITH TIME ZONE,
+  -- последний принятый шаг времени: код нельзя предъявить повторно
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash TEXT NOT NULL,
+  used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS user_recovery_codes_user_id_idx ON user_recovery_codes (user_id);
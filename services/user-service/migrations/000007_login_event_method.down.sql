@@ -0,0 +1 @@
+ALTER TABLE login_events DROP COLUMN IF EXISTS method;
@@ -0,0 +1,2 @@
+-- способ входа: password, magic_link и т.д.
+ALTER TABLE login_events ADD COLUMN IF NOT EXISTS method TEXT NOT NULL DEFAULT 'password';
@@ -0,0 +1,22 @@
+-- клиенты OIDC (приложения, которые входят через user-service); секрет
+-- хранится хэшем, у публичных клиентов (SPA, мобильные) его нет
+CREATE TABLE IF NOT EXISTS oidc_clients (
+  id TEXT PRIMARY KEY,
+  secret_hash TEXT,
+  name TEXT NOT NULL,
+  redirect_uris TEXT[] NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- ключи подписи токенов; закрытый ключ зашифрован (AES-GCM, ключ
+-- oidc.key_encryption_key). Ключ подписывает с not_before до not_after и
+-- публикуется в JWKS до expires_at
+CREATE TABLE IF NOT EXISTS oidc_signing_keys (
+  id TEXT PRIMARY KEY,
+  algorithm TEXT NOT NULL,
+  private_key_encrypted BYTEA NOT NULL,
+  not_before TIMESTAMP WITH TIME ZONE NOT NULL,
+  not_after TIMESTAMP WITH TIME ZONE NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
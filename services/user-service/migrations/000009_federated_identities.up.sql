@@ -0,0 +1,14 @@
+-- учётные записи внешних провайдеров (Google, GitHub, OIDC), привязанные
+-- к пользователю; subject постоянен в пределах провайдера
+CREATE TABLE IF NOT EXISTS federated_identities (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  provider TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  email TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  last_login_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS federated_identities_user_id_idx ON federated_identities (user_id);
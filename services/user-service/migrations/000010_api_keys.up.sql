@@ -0,0 +1,14 @@
+-- API ключи сервисов и фоновых задач. Ключ "usk_<id>_<секрет>" показывается
+-- один раз; хранится только id и хэш секрета. scopes — методы
+-- UserService, которые ключ может вызывать ("*" — все)
+CREATE TABLE IF NOT EXISTS api_keys (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  secret_hash TEXT NOT NULL,
+  scopes TEXT[] NOT NULL,
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  expires_at TIMESTAMP WITH TIME ZONE,
+  last_used_at TIMESTAMP WITH TIME ZONE,
+  revoked_at TIMESTAMP WITH TIME ZONE
+);
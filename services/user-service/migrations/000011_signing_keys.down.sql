@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS signing_keys RENAME TO oidc_signing_keys;
@@ -0,0 +1,2 @@
+-- ключи подписи больше не только у OIDC: ими подписываются любые токены
+ALTER TABLE IF EXISTS oidc_signing_keys RENAME TO signing_keys;
@@ -0,0 +1,10 @@
+-- упадёт, если одна внешняя учётная запись или email есть в нескольких тенантах
+ALTER TABLE federated_identities DROP CONSTRAINT IF EXISTS federated_identities_tenant_provider_subject_key;
+ALTER TABLE federated_identities ADD CONSTRAINT federated_identities_provider_subject_key UNIQUE (provider, subject);
+ALTER TABLE federated_identities DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS users_tenant_email_lower_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (lower(email));
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,20 @@
+-- тенанты заводятся вручную: INSERT INTO tenants (id, name) VALUES (...)
+CREATE TABLE IF NOT EXISTS tenants (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+INSERT INTO tenants (id, name) VALUES ('default', 'Default') ON CONFLICT (id) DO NOTHING;
+
+-- существующие пользователи и привязки попадают в тенант default
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+
+-- email уникален в пределах тенанта
+DROP INDEX IF EXISTS users_email_lower_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_email_lower_key ON users (tenant_id, lower(email));
+
+ALTER TABLE federated_identities ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE federated_identities DROP CONSTRAINT IF EXISTS federated_identities_provider_subject_key;
+ALTER TABLE federated_identities ADD CONSTRAINT federated_identities_tenant_provider_subject_key
+  UNIQUE (tenant_id, provider, subject);
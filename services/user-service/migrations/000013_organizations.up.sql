@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS organizations (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id TEXT NOT NULL REFERENCES tenants (id),
+  name TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS organizations_tenant_id_idx ON organizations (tenant_id);
+
+-- роли: owner управляет всем, включая других владельцев; admin — участниками
+-- и приглашениями; member — только чтение
+CREATE TABLE IF NOT EXISTS organization_members (
+  organization_id BIGINT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  role TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS organization_members_user_id_idx ON organization_members (user_id);
+
+-- токен приглашения хранится только хэшем; на email в организации не больше
+-- одного ожидающего приглашения — повторное обновляет его
+CREATE TABLE IF NOT EXISTS organization_invitations (
+  id BIGSERIAL PRIMARY KEY,
+  organization_id BIGINT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  email TEXT NOT NULL,
+  role TEXT NOT NULL CHECK (role IN ('admin', 'member')),
+  token_hash TEXT NOT NULL UNIQUE,
+  invited_by TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  accepted_at TIMESTAMP WITH TIME ZONE,
+  declined_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS organization_invitations_pending_key
+  ON organization_invitations (organization_id, lower(email))
+  WHERE accepted_at IS NULL AND declined_at IS NULL;
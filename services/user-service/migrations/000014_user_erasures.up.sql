@@ -0,0 +1,16 @@
+-- журнал удалений персональных данных по запросу (право на забвение).
+-- email хранится только хэшем, чтобы по обращению подтвердить, что данные
+-- этого адреса удалены. summary — сколько строк удалено и обезличено в
+-- каждой таблице
+CREATE TABLE IF NOT EXISTS user_erasures (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  user_id BIGINT NOT NULL,
+  email_hash TEXT NOT NULL,
+  requested_by TEXT NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  summary JSONB NOT NULL DEFAULT '{}'::jsonb,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS user_erasures_email_hash_idx ON user_erasures (email_hash);
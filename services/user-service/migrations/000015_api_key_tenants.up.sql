@@ -0,0 +1,3 @@
+-- API ключ действует в тенанте, в котором выпущен; существующие ключи
+-- попадают в тенант default
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default' REFERENCES tenants (id);
@@ -0,0 +1,7 @@
+-- с какими правилами нормализованы email в users (service.EmailNormalizer);
+-- единственную строку пишет сервис при старте, пересчитав адреса
+CREATE TABLE IF NOT EXISTS email_normalization (
+  id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+  provider_rules BOOLEAN NOT NULL,
+  applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
@@ -0,0 +1,6 @@
+-- событие входа относится к тенанту, в котором был вход; тенант старых
+-- событий берётся у пользователя, события без пользователя (неизвестный
+-- тогда email) попадают в тенант default
+ALTER TABLE login_events ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+
+UPDATE login_events e SET tenant_id = u.tenant_id FROM users u WHERE u.id = e.user_id;